@@ -0,0 +1,218 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// Alerter is a measure handler which evaluates alerting rules over the measures
+// aggregated in each window, and calls the callbacks of the rules when their
+// state changes. It lets programs alert on their own metrics without an
+// external monitoring system.
+//
+// A window ends each time the alerter is flushed. In each window, the values of
+// counters are summed, the last values of gauges and histograms are retained.
+// Alerting on percentiles of histograms can be done by enabling the summaries
+// of the engine, which reports quantiles as gauges when it is flushed:
+//
+//	eng.Summaries = &stats.Summaries{}
+//	eng.Handler = &stats.Alerter{
+//		Handler: eng.Handler,
+//		Rules: []stats.AlertRule{{
+//			Name:      "slow requests",
+//			Measure:   "myapp.http",
+//			Field:     "rtt.p99",
+//			Predicate: func(v float64) bool { return v > 0.5 },
+//			Windows:   3,
+//			Callback:  func(a stats.Alert) { log.Print(a) },
+//		}},
+//	}
+//
+// Measures are forwarded unchanged to the base handler, which may be nil.
+type Alerter struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// The alerting rules, the list must not be modified after the alerter was
+	// first used.
+	Rules []AlertRule
+
+	mutex  sync.Mutex
+	series map[string]*alertSeries
+	keys   []byte
+}
+
+// AlertRule is a predicate over the series of a field of a measure.
+type AlertRule struct {
+	// Name of the rule, reported in the alerts.
+	Name string
+
+	// Names of the measure and field that the rule applies to.
+	Measure string
+	Field   string
+
+	// Tags that the measures must have for the rule to apply, each series
+	// (combination of tags) is evaluated independently.
+	Tags []Tag
+
+	// Predicate returns true if the value aggregated in a window breaches the
+	// threshold of the rule.
+	Predicate func(float64) bool
+
+	// Number of consecutive windows in which the predicate must be true for
+	// the alert to fire. Defaults to 1.
+	Windows int
+
+	// Callback is called when the alert fires, and when it resolves after the
+	// predicate became false. The callback is called by the goroutine flushing
+	// the alerter.
+	Callback func(Alert)
+}
+
+// Alert carries the state of an alerting rule for a series.
+type Alert struct {
+	Rule    string
+	Measure string
+	Field   string
+	Tags    []Tag
+	Value   float64 // value aggregated in the last window
+	Firing  bool    // false when the alert resolved
+	Time    time.Time
+}
+
+type alertSeries struct {
+	rule     *AlertRule
+	tags     []Tag
+	value    float64
+	updated  bool // the series received measures in the current window
+	breaches int  // number of consecutive windows breaching the threshold
+	firing   bool
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (a *Alerter) HandleMeasures(t time.Time, measures ...Measure) {
+	a.mutex.Lock()
+
+	for _, m := range measures {
+		for i := range a.Rules {
+			if rule := &a.Rules[i]; rule.Measure == m.Name && hasTags(m.Tags, rule.Tags) {
+				for _, f := range m.Fields {
+					if f.Name == rule.Field {
+						a.update(i, m.Tags, f, m.SampleRate)
+					}
+				}
+			}
+		}
+	}
+
+	a.mutex.Unlock()
+
+	if a.Handler != nil {
+		a.Handler.HandleMeasures(t, measures...)
+	}
+}
+
+// Flush satisfies the Flusher interface, it ends the current window and
+// evaluates the rules, then flushes the base handler.
+func (a *Alerter) Flush() {
+	now := time.Now()
+	alerts := []Alert{}
+	callbacks := []func(Alert){}
+
+	a.mutex.Lock()
+
+	for _, s := range a.series {
+		if !s.updated {
+			continue
+		}
+
+		fire, resolve := s.evaluate()
+
+		if fire || resolve {
+			alerts = append(alerts, Alert{
+				Rule:    s.rule.Name,
+				Measure: s.rule.Measure,
+				Field:   s.rule.Field,
+				Tags:    s.tags,
+				Value:   s.value,
+				Firing:  fire,
+				Time:    now,
+			})
+			callbacks = append(callbacks, s.rule.Callback)
+		}
+
+		s.value, s.updated = 0, false
+	}
+
+	a.mutex.Unlock()
+
+	for i, alert := range alerts {
+		if callbacks[i] != nil {
+			callbacks[i](alert)
+		}
+	}
+
+	if a.Handler != nil {
+		flush(a.Handler)
+	}
+}
+
+func (a *Alerter) update(rule int, tags []Tag, f Field, rate float64) {
+	a.keys = appendSeriesKey(a.keys[:0], "", "", tags)
+	a.keys = append(a.keys, byte(rule), byte(rule>>8), byte(rule>>16), byte(rule>>24))
+	s := a.series[string(a.keys)]
+
+	if s == nil {
+		if a.series == nil {
+			a.series = make(map[string]*alertSeries)
+		}
+		s = &alertSeries{rule: &a.Rules[rule], tags: copyTags(tags)}
+		a.series[string(a.keys)] = s
+	}
+
+	if f.Type() == Counter {
+		s.value += valueToFloat(unsample(f.Value, rate))
+	} else {
+		s.value = valueToFloat(f.Value)
+	}
+
+	s.updated = true
+}
+
+// evaluate applies the rule to the value of the window that ended, it returns
+// whether the alert started firing or resolved.
+func (s *alertSeries) evaluate() (fire bool, resolve bool) {
+	if s.rule.Predicate != nil && s.rule.Predicate(s.value) {
+		s.breaches++
+	} else {
+		s.breaches = 0
+	}
+
+	windows := s.rule.Windows
+	if windows < 1 {
+		windows = 1
+	}
+
+	switch {
+	case !s.firing && s.breaches >= windows:
+		s.firing, fire = true, true
+	case s.firing && s.breaches == 0:
+		s.firing, resolve = false, true
+	}
+
+	return
+}
+
+// hasTags returns true if tags contains all the tags of subset.
+func hasTags(tags []Tag, subset []Tag) bool {
+search:
+	for _, t := range subset {
+		for _, x := range tags {
+			if x == t {
+				continue search
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestAlerter(t *testing.T) {
+	var alerts []stats.Alert
+
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", &stats.Alerter{
+		Handler: h,
+		Rules: []stats.AlertRule{{
+			Name:      "errors",
+			Measure:   "test.http",
+			Field:     "errors",
+			Tags:      []stats.Tag{stats.T("service", "api")},
+			Predicate: func(v float64) bool { return v > 10 },
+			Windows:   2,
+			Callback:  func(a stats.Alert) { alerts = append(alerts, a) },
+		}},
+	})
+
+	api := eng.WithTags(stats.T("host", "a"), stats.T("service", "api"))
+	web := eng.WithTags(stats.T("service", "web"))
+
+	for _, errors := range []int{20, 5, 20, 20, 20, 0} {
+		// The errors are reported in two increments to verify that counters
+		// are summed over each window.
+		api.Add("http:errors", errors/2)
+		api.Add("http:errors", errors/2)
+		web.Add("http:errors", 100)
+		eng.Flush()
+	}
+
+	if len(alerts) != 2 {
+		t.Fatal("bad number of alerts:", alerts)
+	}
+
+	if a := alerts[0]; !a.Firing || a.Rule != "errors" || a.Value != 20 || len(a.Tags) != 2 {
+		t.Errorf("bad firing alert: %+v", a)
+	}
+
+	if a := alerts[1]; a.Firing || a.Value != 0 {
+		t.Errorf("bad resolved alert: %+v", a)
+	}
+
+	if n := len(h.Measures()); n != 18 {
+		t.Error("bad number of measures forwarded to the base handler:", n)
+	}
+}
+
+func TestAlerterSummaries(t *testing.T) {
+	var alerts []stats.Alert
+
+	eng := stats.NewEngine("test", &stats.Alerter{
+		Rules: []stats.AlertRule{{
+			Measure:   "test.http",
+			Field:     "rtt.p99",
+			Predicate: func(v float64) bool { return v > 0.5 },
+			Callback:  func(a stats.Alert) { alerts = append(alerts, a) },
+		}},
+	})
+	eng.Summaries = &stats.Summaries{}
+
+	for i := 0; i != 100; i++ {
+		eng.Observe("http:rtt", time.Second)
+	}
+
+	eng.Flush()
+
+	if len(alerts) != 1 || alerts[0].Value != 1 {
+		t.Error("bad alerts:", alerts)
+	}
+}
@@ -0,0 +1,151 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TagAllowlist restricts the names of the tags that measures may carry, which
+// protects metric collection systems from instrumentation accidentally setting
+// tags with free-form values (full URLs or error messages for example).
+//
+// Allowlists are enabled by setting the TagAllowlist field of an engine, the
+// engines derived from it with WithPrefix and WithTags share the allowlist.
+// Tags which are not allowed are removed from the measures, except the ones
+// set on the engine.
+//
+// The number of measures which had tags removed is reported when the engine is
+// flushed, as a counter named "tags.dropped" on the measure that carried them.
+type TagAllowlist struct {
+	// Names of the tags allowed on the measures whose names start with the
+	// keys of the map, the longest matching prefix is used. Measures that
+	// match none of the prefixes keep all their tags.
+	//
+	// The prefixes are matched against the full measure names, which include
+	// the prefix of the engine that produced them. The map must not be
+	// modified after the allowlist was first used.
+	Tags map[string][]string
+
+	once    sync.Once
+	allowed map[string]map[string]struct{}
+
+	mutex   sync.Mutex
+	dropped map[string]*allowlistDrops
+}
+
+type allowlistDrops struct {
+	count uint64 // since the last flush
+	total uint64
+}
+
+// Dropped returns the number of measures named name which had tags removed
+// because they were not allowed.
+func (a *TagAllowlist) Dropped(name string) uint64 {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if d := a.dropped[name]; d != nil {
+		return d.total
+	}
+	return 0
+}
+
+// filter returns the tags of m, or a copy without the tags which are not
+// allowed. Tags found in base are always allowed.
+func (a *TagAllowlist) filter(m Measure, base []Tag) []Tag {
+	a.once.Do(a.init)
+
+	allowed := a.lookup(m.Name)
+	if allowed == nil {
+		return m.Tags
+	}
+
+	var tags []Tag
+
+	for i, t := range m.Tags {
+		_, ok := allowed[t.Name]
+
+		if !ok && !hasTags(base, []Tag{t}) {
+			if tags == nil {
+				tags = append(make([]Tag, 0, len(m.Tags)), m.Tags[:i]...)
+			}
+			continue
+		}
+
+		if tags != nil {
+			tags = append(tags, t)
+		}
+	}
+
+	if tags == nil {
+		return m.Tags
+	}
+
+	a.mutex.Lock()
+
+	d := a.dropped[m.Name]
+	if d == nil {
+		if a.dropped == nil {
+			a.dropped = make(map[string]*allowlistDrops)
+		}
+		d = &allowlistDrops{}
+		a.dropped[m.Name] = d
+	}
+	d.count++
+	d.total++
+
+	a.mutex.Unlock()
+	return tags
+}
+
+// measures returns the counters of measures which had tags removed since the
+// last call.
+func (a *TagAllowlist) measures(tags []Tag) []Measure {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	var measures []Measure
+
+	for name, d := range a.dropped {
+		if d.count != 0 {
+			measures = append(measures, Measure{
+				Name:   name,
+				Fields: []Field{MakeField("tags.dropped", d.count, Counter)},
+				Tags:   tags,
+			})
+			d.count = 0
+		}
+	}
+
+	sort.Slice(measures, func(i, j int) bool {
+		return measures[i].Name < measures[j].Name
+	})
+
+	return measures
+}
+
+func (a *TagAllowlist) init() {
+	a.allowed = make(map[string]map[string]struct{}, len(a.Tags))
+
+	for prefix, names := range a.Tags {
+		set := make(map[string]struct{}, len(names))
+		for _, name := range names {
+			set[name] = struct{}{}
+		}
+		a.allowed[prefix] = set
+	}
+}
+
+func (a *TagAllowlist) lookup(name string) map[string]struct{} {
+	prefix, found := "", false
+	var allowed map[string]struct{}
+
+	for p, set := range a.allowed {
+		if (!found || len(p) > len(prefix)) && strings.HasPrefix(name, p) {
+			prefix, allowed, found = p, set, true
+		}
+	}
+
+	return allowed
+}
@@ -0,0 +1,81 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestEngineTagAllowlist(t *testing.T) {
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", h, stats.T("service", "api"))
+	eng.TagAllowlist = &stats.TagAllowlist{
+		Tags: map[string][]string{
+			"test.http":      {"method", "status"},
+			"test.http.conn": {},
+		},
+	}
+
+	eng.Incr("http.requests", stats.T("method", "GET"), stats.T("url", "/users/42"), stats.T("status", "200"))
+	eng.Incr("http.conn.open", stats.T("remote", "10.0.0.1"))
+	eng.Incr("rpc.requests", stats.T("url", "/users/42"))
+	eng.WithPrefix("http").Report(struct {
+		Size int `metric:"size" type:"gauge"`
+	}{42}, stats.T("error", "EOF"))
+
+	found := [][]stats.Tag{}
+	for _, m := range h.Measures() {
+		found = append(found, m.Tags)
+	}
+
+	expected := [][]stats.Tag{
+		{stats.T("method", "GET"), stats.T("service", "api"), stats.T("status", "200")},
+		{stats.T("service", "api")},
+		{stats.T("service", "api"), stats.T("url", "/users/42")},
+		{stats.T("service", "api")},
+	}
+
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("bad tags:\nexpected: %v\nfound:    %v", expected, found)
+	}
+
+	if n := eng.TagAllowlist.Dropped("test.http.requests"); n != 1 {
+		t.Error("bad number of measures with dropped tags:", n)
+	}
+
+	h.Clear()
+	eng.Flush()
+
+	names := []string{}
+	for _, m := range h.Measures() {
+		if m.Fields[0].Name != "tags.dropped" || m.Fields[0].Value.Uint() != 1 {
+			t.Errorf("bad measure: %v", m)
+		}
+		names = append(names, m.Name)
+	}
+
+	if expected := []string{"test.http", "test.http.conn.open", "test.http.requests"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("bad measures reported on flush: %v", names)
+	}
+}
+
+func TestEngineTagAllowlistHandle(t *testing.T) {
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", h)
+	eng.TagAllowlist = &stats.TagAllowlist{Tags: map[string][]string{"test.http": {"method"}}}
+
+	eng.Handle("http:requests", stats.Counter, stats.T("method", "GET"), stats.T("url", "/users/42")).Measure(1)
+
+	m := h.Measures()
+	if len(m) != 1 || !reflect.DeepEqual(m[0].Tags, []stats.Tag{stats.T("method", "GET")}) {
+		t.Fatal("bad measures:", m)
+	}
+
+	if m[0].Const != nil {
+		t.Error("the constant tags of the handle were not dropped:", m[0].Const.Tags())
+	}
+}
@@ -0,0 +1,205 @@
+package stats
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AnomalyDetector is a measure handler which flags the values that deviate
+// from the recent baseline of their series.
+//
+// The baseline of each series (measure name, field name, and tags) is an
+// exponentially weighted moving average (EWMA) of its values, and the spread
+// of the series is the EWMA of the absolute deviations from the baseline. A
+// value is anomalous when its deviation exceeds Threshold times the spread.
+//
+// Measures are forwarded unchanged to the base handler. For each anomalous
+// value the detector also forwards a counter increment, with the name of the
+// measure suffixed with ".anomaly", the name and tags of the field. Programs
+// which need the details of anomalies (to compare canaries for example) can
+// set a callback.
+type AnomalyDetector struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// Names of the measures which are monitored are prefixed with one of the
+	// values of the list. All measures are monitored when the list is empty.
+	Prefixes []string
+
+	// Smoothing factor of the moving averages, between 0 and 1. Higher values
+	// give more weight to recent values. Defaults to 0.1.
+	Alpha float64
+
+	// Number of spreads that a value must deviate from the baseline to be
+	// anomalous. Defaults to 4.
+	Threshold float64
+
+	// Number of values used to establish the baseline of a series before the
+	// detector flags anomalies. Defaults to 10.
+	MinSamples int
+
+	// Callback is called for each anomalous value, after the measures were
+	// forwarded to the base handler.
+	Callback func(Anomaly)
+
+	mutex  sync.Mutex
+	series map[string]*anomalySeries
+	keys   []byte
+}
+
+// Anomaly describes a value which deviated from the baseline of its series.
+type Anomaly struct {
+	Measure  string
+	Field    string
+	Tags     []Tag
+	Value    float64
+	Baseline float64 // moving average of the series before the value
+	Spread   float64 // moving average of the absolute deviations
+	Score    float64 // deviation of the value, in number of spreads
+	Time     time.Time
+}
+
+type anomalySeries struct {
+	mean    float64
+	spread  float64
+	samples int
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (d *AnomalyDetector) HandleMeasures(t time.Time, measures ...Measure) {
+	var anomalies []Anomaly
+
+	d.mutex.Lock()
+
+	for _, m := range measures {
+		if !d.monitored(m.Name) {
+			continue
+		}
+		for _, f := range m.Fields {
+			if a, ok := d.observe(m, f); ok {
+				a.Time = t
+				anomalies = append(anomalies, a)
+			}
+		}
+	}
+
+	d.mutex.Unlock()
+
+	d.Handler.HandleMeasures(t, measures...)
+
+	if len(anomalies) == 0 {
+		return
+	}
+
+	events := make([]Measure, len(anomalies))
+
+	for i, a := range anomalies {
+		events[i] = Measure{
+			Name:   a.Measure + ".anomaly",
+			Fields: []Field{MakeField(a.Field, 1, Counter)},
+			Tags:   a.Tags,
+		}
+	}
+
+	d.Handler.HandleMeasures(t, events...)
+
+	if d.Callback != nil {
+		for _, a := range anomalies {
+			d.Callback(a)
+		}
+	}
+}
+
+// Flush satisfies the Flusher interface.
+func (d *AnomalyDetector) Flush() {
+	flush(d.Handler)
+}
+
+func (d *AnomalyDetector) monitored(name string) bool {
+	if len(d.Prefixes) == 0 {
+		return true
+	}
+	for _, p := range d.Prefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// observe updates the baseline of the series of f with its value, it returns
+// an anomaly and true if the value deviated from the previous baseline.
+func (d *AnomalyDetector) observe(m Measure, f Field) (Anomaly, bool) {
+	d.keys = appendSeriesKey(d.keys[:0], m.Name, f.Name, m.Tags)
+	s := d.series[string(d.keys)]
+
+	if s == nil {
+		if d.series == nil {
+			d.series = make(map[string]*anomalySeries)
+		}
+		s = &anomalySeries{}
+		d.series[string(d.keys)] = s
+	}
+
+	v := valueToFloat(f.Value)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return Anomaly{}, false
+	}
+
+	deviation := math.Abs(v - s.mean)
+	anomaly := Anomaly{}
+	found := false
+
+	if s.samples >= d.minSamples() && deviation != 0 {
+		score := math.Inf(1)
+		if s.spread != 0 {
+			score = deviation / s.spread
+		}
+		if score > d.threshold() {
+			anomaly = Anomaly{
+				Measure:  m.Name,
+				Field:    f.Name,
+				Tags:     copyTags(m.Tags),
+				Value:    v,
+				Baseline: s.mean,
+				Spread:   s.spread,
+				Score:    score,
+			}
+			found = true
+		}
+	}
+
+	if s.samples == 0 {
+		s.mean = v
+	} else {
+		alpha := d.alpha()
+		s.mean += alpha * (v - s.mean)
+		s.spread += alpha * (deviation - s.spread)
+	}
+
+	s.samples++
+	return anomaly, found
+}
+
+func (d *AnomalyDetector) alpha() float64 {
+	if d.Alpha > 0 && d.Alpha <= 1 {
+		return d.Alpha
+	}
+	return 0.1
+}
+
+func (d *AnomalyDetector) threshold() float64 {
+	if d.Threshold > 0 {
+		return d.Threshold
+	}
+	return 4
+}
+
+func (d *AnomalyDetector) minSamples() int {
+	if d.MinSamples > 0 {
+		return d.MinSamples
+	}
+	return 10
+}
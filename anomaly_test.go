@@ -0,0 +1,53 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	var anomalies []stats.Anomaly
+
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", &stats.AnomalyDetector{
+		Handler:  h,
+		Prefixes: []string{"test.http"},
+		Callback: func(a stats.Anomaly) { anomalies = append(anomalies, a) },
+	})
+
+	for i := 0; i != 50; i++ {
+		eng.Set("http:rtt", 100+i%3, stats.T("host", "a"))
+		eng.Set("queue:size", i%2)
+	}
+
+	eng.Set("http:rtt", 101, stats.T("host", "a"))
+	eng.Set("queue:size", 1000)
+
+	if len(anomalies) != 0 {
+		t.Fatal("unexpected anomalies:", anomalies)
+	}
+
+	h.Clear()
+	eng.Set("http:rtt", 500, stats.T("host", "a"))
+
+	if len(anomalies) != 1 {
+		t.Fatal("bad number of anomalies:", anomalies)
+	}
+
+	if a := anomalies[0]; a.Measure != "test.http" || a.Field != "rtt" || a.Value != 500 || a.Score <= 4 {
+		t.Errorf("bad anomaly: %+v", a)
+	}
+
+	found := h.Measures()
+	if len(found) != 2 {
+		t.Fatal("bad number of measures:", found)
+	}
+
+	if m := found[1]; m.Name != "test.http.anomaly" || m.Fields[0].Name != "rtt" || m.Fields[0].Type() != stats.Counter {
+		t.Error("bad anomaly event:", m)
+	}
+}
@@ -0,0 +1,131 @@
+package stats
+
+import "time"
+
+// Batch is a builder of measures which are committed together to the handler
+// of the engine it was created from. Batches are useful to report multiple
+// related metrics, like the count, size, and duration of a request, the
+// measures of a batch are passed to the handler in a single call with the same
+// time, so they always land in the same flush window.
+//
+// Metrics recorded with the same name and tags are merged into a single measure
+// with multiple fields, for example:
+//
+//	b := eng.Batch()
+//	b.Incr("request:count")
+//	b.Observe("request:size", size)
+//	b.Observe("request:rtt", rtt)
+//	b.Commit()
+//
+// produces a single "request" measure with the "count", "size", and "rtt"
+// fields.
+//
+// Batch values are not safe to use concurrently from multiple goroutines.
+type Batch struct {
+	eng      *Engine
+	measures []Measure
+	sample   []bool // whether the measures at the same index are sampled
+}
+
+// Batch returns a new, empty, batch of measures for eng.
+func (eng *Engine) Batch() *Batch {
+	return &Batch{eng: eng}
+}
+
+// Len returns the number of measures recorded in b.
+func (b *Batch) Len() int {
+	return len(b.measures)
+}
+
+// Incr increments by one the counter identified by name and tags.
+func (b *Batch) Incr(name string, tags ...Tag) {
+	b.add(name, intValue(1), Counter, tags)
+}
+
+// Add increments by value the counter identified by name and tags.
+func (b *Batch) Add(name string, value interface{}, tags ...Tag) {
+	b.add(name, ValueOf(value), Counter, tags)
+}
+
+// AddInt increments by value the counter identified by name and tags.
+func (b *Batch) AddInt(name string, value int64, tags ...Tag) {
+	b.add(name, int64Value(value), Counter, tags)
+}
+
+// Set sets to value the gauge identified by name and tags.
+func (b *Batch) Set(name string, value interface{}, tags ...Tag) {
+	b.add(name, ValueOf(value), Gauge, tags)
+}
+
+// Observe reports value for the histogram identified by name and tags.
+func (b *Batch) Observe(name string, value interface{}, tags ...Tag) {
+	b.add(name, ValueOf(value), Histogram, tags)
+}
+
+// Commit calls CommitAt with the current time of the engine's clock source.
+func (b *Batch) Commit() {
+	b.CommitAt(b.eng.Now())
+}
+
+// CommitAt passes the measures recorded in b to the handler of the engine at
+// time t, then resets the batch so it can be reused.
+func (b *Batch) CommitAt(t time.Time) {
+	defer b.reset()
+
+	if !Enabled || len(b.measures) == 0 {
+		return
+	}
+
+	eng := b.eng
+	ms := b.measures
+
+	state := eng.state()
+	if !state.acquire(len(ms)) {
+		return
+	}
+
+	for i := range ms {
+		eng.prepare(t, &ms[i], b.sample[i])
+	}
+
+	eng.handle(state, t, ms)
+	state.release(len(ms))
+}
+
+func (b *Batch) add(name string, value Value, ftype FieldType, tags []Tag) {
+	if !Enabled {
+		return
+	}
+
+	eng := b.eng
+	sample := ftype != Gauge && sampling(eng.SampleRate)
+	if sample && !sampled(eng.SampleRate) {
+		return
+	}
+
+	name, field := splitMeasureField(name)
+	name = eng.makeName(name)
+
+	f := Field{Name: field, Value: value}
+	f.setType(ftype)
+
+	t := eng.makeTags(tags)
+
+	for i := range b.measures {
+		if m := &b.measures[i]; b.sample[i] == sample && m.Name == name && tagsEqual(m.Tags, t) {
+			m.Fields = append(m.Fields, f)
+			return
+		}
+	}
+
+	b.measures = append(b.measures, Measure{Name: name, Fields: []Field{f}, Tags: t})
+	b.sample = append(b.sample, sample)
+}
+
+func (b *Batch) reset() {
+	for i := range b.measures {
+		b.measures[i] = Measure{}
+	}
+	b.measures = b.measures[:0]
+	b.sample = b.sample[:0]
+}
@@ -0,0 +1,63 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestBatch(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("test", h, stats.T("service", "api"))
+
+	b := e.Batch()
+	b.Incr("request:count", stats.T("path", "/"))
+	b.Observe("request:rtt", time.Second, stats.T("path", "/"))
+	b.Set("connections", 10)
+
+	if n := b.Len(); n != 2 {
+		t.Errorf("bad number of measures in the batch: %d", n)
+	}
+
+	if measures := h.Measures(); len(measures) != 0 {
+		t.Fatalf("measures were reported before the batch was committed: %v", measures)
+	}
+
+	now := time.Date(2017, 6, 4, 22, 12, 0, 0, time.UTC)
+	b.CommitAt(now)
+
+	measures := h.Measures()
+
+	if len(measures) != 2 {
+		t.Fatalf("bad measures: %v", measures)
+	}
+
+	m := measures[0]
+
+	if m.Name != "test.request" || len(m.Fields) != 2 || m.Fields[0].Name != "count" || m.Fields[1].Name != "rtt" {
+		t.Errorf("bad first measure: %v", m)
+	}
+
+	if len(m.Tags) != 2 || m.Tags[0] != stats.T("path", "/") || m.Tags[1] != stats.T("service", "api") {
+		t.Errorf("bad tags of the first measure: %v", m.Tags)
+	}
+
+	if m := measures[1]; m.Name != "test.connections" || len(m.Fields) != 1 || m.Fields[0].Type() != stats.Gauge {
+		t.Errorf("bad second measure: %v", m)
+	}
+
+	if n := b.Len(); n != 0 {
+		t.Errorf("the batch was not reset after being committed: %d measures", n)
+	}
+
+	h.Clear()
+	b.Commit()
+
+	if measures := h.Measures(); len(measures) != 0 {
+		t.Errorf("committing an empty batch produced measures: %v", measures)
+	}
+}
@@ -0,0 +1,186 @@
+package stats
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Batcher is a measure handler which accumulates the measures it receives and
+// forwards them to its base handler in batches, which amortizes the cost of
+// calling handlers that do expensive work on every call (writing to files or
+// network connections for example).
+//
+// A batch is forwarded when it reaches MaxBatch measures, at each interval of
+// MaxDelay, or when the handler is flushed or closed. The measures of a batch are coalesced by windows of MaxDelay
+// (aligned on the times of the calls that added them), each window of the
+// batch is forwarded in a single call to the base handler, with the time of
+// the most recent call that added measures to it. The times of the measures
+// are therefore preserved with the precision of MaxDelay.
+type Batcher struct {
+	// The handler that batches of measures are forwarded to.
+	Handler Handler
+
+	// Maximum number of measures in a batch, defaults to 1000.
+	MaxBatch int
+
+	// Maximum delay between the time a measure is received and the time it is
+	// forwarded, defaults to one second.
+	MaxDelay time.Duration
+
+	// The source of the time used to schedule the forwarding of batches after
+	// MaxDelay. Defaults to SystemClock.
+	ClockSource ClockSource
+
+	mutex    sync.Mutex
+	measures []Measure
+	spans    []batchSpan
+	ticker   Ticker
+	done     chan struct{}
+	closed   bool
+
+	// Serializes the calls to the base handler, so batches are forwarded in
+	// the order they were built.
+	send sync.Mutex
+}
+
+// BufferedHandler returns a Batcher which forwards batches of at most maxBatch
+// measures to handler, at most maxDelay after receiving them.
+func BufferedHandler(handler Handler, maxBatch int, maxDelay time.Duration) *Batcher {
+	return &Batcher{Handler: handler, MaxBatch: maxBatch, MaxDelay: maxDelay}
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (b *Batcher) HandleMeasures(t time.Time, measures ...Measure) {
+	if len(measures) == 0 {
+		return
+	}
+
+	b.mutex.Lock()
+
+	if b.closed {
+		b.mutex.Unlock()
+		b.Handler.HandleMeasures(t, measures...)
+		return
+	}
+
+	for _, m := range measures {
+		b.measures = append(b.measures, m.Clone())
+	}
+
+	window := t.Truncate(b.maxDelay())
+
+	if n := len(b.spans); n != 0 && b.spans[n-1].window.Equal(window) {
+		s := &b.spans[n-1]
+		s.end = len(b.measures)
+		if t.After(s.time) {
+			s.time = t
+		}
+	} else {
+		b.spans = append(b.spans, batchSpan{window: window, time: t, end: len(b.measures)})
+	}
+
+	full := len(b.measures) >= b.maxBatch()
+
+	if b.ticker == nil {
+		b.start()
+	}
+
+	b.mutex.Unlock()
+
+	if full {
+		b.forward()
+	}
+}
+
+// Flush satisfies the Flusher interface, it forwards the pending measures then
+// flushes the base handler.
+func (b *Batcher) Flush() {
+	b.forward()
+	flush(b.Handler)
+}
+
+// Close satisfies the io.Closer interface, it forwards the pending measures,
+// flushes the base handler, and closes it if it implements io.Closer. Measures
+// received after the batcher was closed are forwarded immediately.
+func (b *Batcher) Close() error {
+	b.mutex.Lock()
+	if !b.closed {
+		b.closed = true
+		if b.ticker != nil {
+			b.ticker.Stop()
+			close(b.done)
+		}
+	}
+	b.mutex.Unlock()
+
+	b.Flush()
+
+	if c, ok := b.Handler.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+func (b *Batcher) forward() {
+	b.send.Lock()
+	defer b.send.Unlock()
+
+	b.mutex.Lock()
+	measures, spans := b.measures, b.spans
+	b.measures, b.spans = nil, nil
+	b.mutex.Unlock()
+
+	start := 0
+	for _, span := range spans {
+		b.Handler.HandleMeasures(span.time, measures[start:span.end]...)
+		start = span.end
+	}
+}
+
+// start starts the goroutine which forwards the batches at the interval of
+// MaxDelay, it must be called with the mutex locked.
+func (b *Batcher) start() {
+	clock := b.ClockSource
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	b.ticker = clock.NewTicker(b.maxDelay())
+	b.done = make(chan struct{})
+
+	go func(ticks <-chan time.Time, done <-chan struct{}) {
+		for {
+			select {
+			case <-ticks:
+				b.forward()
+			case <-done:
+				return
+			}
+		}
+	}(b.ticker.C(), b.done)
+}
+
+// batchSpan is the range of measures of a batch which were received in the
+// same window, end is the index following the last measure of the span and
+// time the most recent time of the calls that added measures to it.
+type batchSpan struct {
+	window time.Time
+	time   time.Time
+	end    int
+}
+
+func (b *Batcher) maxBatch() int {
+	if b.MaxBatch > 0 {
+		return b.MaxBatch
+	}
+	return 1000
+}
+
+func (b *Batcher) maxDelay() time.Duration {
+	if b.MaxDelay > 0 {
+		return b.MaxDelay
+	}
+	return time.Second
+}
@@ -0,0 +1,163 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+// callsHandler records the number of measures of each call it receives.
+type callsHandler struct {
+	statstest.Handler
+	mutex  sync.Mutex
+	calls  []int
+	closed bool
+}
+
+func (h *callsHandler) HandleMeasures(t time.Time, measures ...stats.Measure) {
+	h.mutex.Lock()
+	h.calls = append(h.calls, len(measures))
+	h.mutex.Unlock()
+	h.Handler.HandleMeasures(t, measures...)
+}
+
+func (h *callsHandler) Calls() []int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]int(nil), h.calls...)
+}
+
+func (h *callsHandler) Close() error {
+	h.closed = true
+	return errors.New("closed")
+}
+
+func TestBatcher(t *testing.T) {
+	h := &callsHandler{}
+	b := stats.BufferedHandler(h, 3, time.Hour)
+	eng := stats.NewEngine("test", b)
+	eng.ClockSource = newManualClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	for i := 0; i != 7; i++ {
+		eng.Incr("A")
+	}
+
+	if calls := h.Calls(); len(calls) != 2 || calls[0] != 3 || calls[1] != 3 {
+		t.Errorf("bad calls: %v", calls)
+	}
+
+	eng.Flush()
+
+	if calls := h.Calls(); len(calls) != 3 || calls[2] != 1 {
+		t.Errorf("bad calls after flush: %v", calls)
+	}
+
+	if n := h.FlushCalls(); n != 1 {
+		t.Errorf("bad number of flushes: %d", n)
+	}
+
+	if n := len(h.Measures()); n != 7 {
+		t.Errorf("bad number of measures: %d", n)
+	}
+}
+
+func TestBatcherMaxDelay(t *testing.T) {
+	h := &callsHandler{}
+	eng := stats.NewEngine("test", stats.BufferedHandler(h, 100, 10*time.Millisecond))
+	eng.ClockSource = newManualClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	eng.Incr("A")
+	eng.Incr("B")
+
+	if !h.WaitForMetric("test.B", time.Second) {
+		t.Fatal("the batch was not forwarded after the maximum delay")
+	}
+
+	if calls := h.Calls(); len(calls) != 1 || calls[0] != 2 {
+		t.Errorf("bad calls: %v", calls)
+	}
+}
+
+func TestBatcherTimes(t *testing.T) {
+	var times []time.Time
+	var calls []int
+
+	b := stats.BufferedHandler(stats.HandlerFunc(func(t time.Time, measures ...stats.Measure) {
+		times = append(times, t)
+		calls = append(calls, len(measures))
+	}), 100, 10*time.Second)
+	b.ClockSource = newManualClock(time.Time{})
+
+	t0 := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Nanosecond)
+	t2 := t0.Add(15 * time.Second)
+	m := stats.Measure{Name: "test", Fields: []stats.Field{stats.MakeField("value", 1, stats.Counter)}}
+
+	b.HandleMeasures(t1, m)
+	b.HandleMeasures(t0, m, m)
+	b.HandleMeasures(t2, m)
+	b.Flush()
+
+	if len(times) != 2 || !times[0].Equal(t1) || !times[1].Equal(t2) {
+		t.Errorf("bad times: %v", times)
+	}
+
+	if len(calls) != 2 || calls[0] != 3 || calls[1] != 1 {
+		t.Errorf("bad calls: %v", calls)
+	}
+}
+
+func TestBatcherClockSource(t *testing.T) {
+	h := &callsHandler{}
+	clock := newManualClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := &stats.Batcher{Handler: h, MaxDelay: time.Second, ClockSource: clock}
+	defer b.Close()
+
+	eng := stats.NewEngine("test", b)
+	eng.ClockSource = clock
+
+	eng.Incr("A")
+	eng.Incr("B")
+
+	if calls := h.Calls(); len(calls) != 0 {
+		t.Fatalf("the batch was forwarded before the clock ticked: %v", calls)
+	}
+
+	clock.tick(time.Second)
+
+	if !h.WaitForMetric("test.B", time.Second) {
+		t.Fatal("the batch was not forwarded when the clock ticked")
+	}
+
+	if calls := h.Calls(); len(calls) != 1 || calls[0] != 2 {
+		t.Errorf("bad calls: %v", calls)
+	}
+}
+
+func TestBatcherClose(t *testing.T) {
+	h := &callsHandler{}
+	b := stats.BufferedHandler(h, 100, time.Hour)
+	eng := stats.NewEngine("test", b)
+
+	eng.Incr("A")
+
+	if err := b.Close(); err == nil || err.Error() != "closed" {
+		t.Error("bad error:", err)
+	}
+
+	if !h.closed {
+		t.Error("the base handler was not closed")
+	}
+
+	eng.Incr("B")
+
+	if calls := h.Calls(); len(calls) != 2 || calls[0] != 1 || calls[1] != 1 {
+		t.Errorf("bad calls: %v", calls)
+	}
+}
@@ -0,0 +1,330 @@
+// Package bench implements a harness which drives configurable workloads
+// against stats handlers, so programs can compare the performance of protocols
+// and backends on their own hardware.
+//
+// A benchmark runs goroutines producing measures on a set of series, while
+// another goroutine flushes the engine at a regular interval:
+//
+//	r := bench.Run(datadog.NewClient("localhost:8125"), bench.Config{
+//		Goroutines: 32,
+//		Series:     10000,
+//		Duration:   10 * time.Second,
+//	})
+//	fmt.Println(r)
+package bench
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// Config carries the configuration of a benchmark. The zero value is a valid
+// configuration.
+type Config struct {
+	// Number of goroutines producing measures. Defaults to GOMAXPROCS.
+	Goroutines int
+
+	// Number of distinct series (combinations of metric names and tags) that
+	// the goroutines produce measures on. Defaults to 100.
+	Series int
+
+	// Number of tags set on each measure, in addition to the tag identifying
+	// the series. Defaults to 2, set to a negative value for none.
+	Tags int
+
+	// Relative weights of counter increments, gauge updates, and histogram
+	// observations in the workload. Defaults to an even mix.
+	Mix Mix
+
+	// Duration of the benchmark. Defaults to 1s.
+	Duration time.Duration
+
+	// Interval at which the engine is flushed during the benchmark, flushes
+	// are disabled when negative. Defaults to 100ms.
+	FlushInterval time.Duration
+
+	// When true, the measures are produced with metric handles created before
+	// the benchmark starts instead of engine methods.
+	Handles bool
+}
+
+// Mix represents the relative weights of the types of operations of a
+// workload.
+type Mix struct {
+	Counters   int
+	Gauges     int
+	Histograms int
+}
+
+// Result carries the results of a benchmark.
+type Result struct {
+	// Number of measures produced by the goroutines, and time it took.
+	Operations int64
+	Elapsed    time.Duration
+
+	// Number of memory allocations and bytes allocated during the benchmark.
+	Allocs uint64
+	Bytes  uint64
+
+	// Number of measures (or batches of measures) that the handler reported
+	// dropping during the benchmark, only set when the handler has a method
+	// with the signature Dropped() uint64.
+	Dropped uint64
+
+	// Durations of the flushes of the engine, sorted in ascending order. The
+	// last flush happens after the goroutines completed.
+	Flushes []time.Duration
+}
+
+// NsPerOp returns the average wall time per operation, in nanoseconds.
+func (r Result) NsPerOp() float64 {
+	if r.Operations == 0 {
+		return 0
+	}
+	return float64(r.Elapsed.Nanoseconds()) / float64(r.Operations)
+}
+
+// AllocsPerOp returns the average number of memory allocations per operation.
+func (r Result) AllocsPerOp() float64 {
+	if r.Operations == 0 {
+		return 0
+	}
+	return float64(r.Allocs) / float64(r.Operations)
+}
+
+// BytesPerOp returns the average number of bytes allocated per operation.
+func (r Result) BytesPerOp() float64 {
+	if r.Operations == 0 {
+		return 0
+	}
+	return float64(r.Bytes) / float64(r.Operations)
+}
+
+// DropRate returns the number of drops reported by the handler per operation.
+func (r Result) DropRate() float64 {
+	if r.Operations == 0 {
+		return 0
+	}
+	return float64(r.Dropped) / float64(r.Operations)
+}
+
+// FlushLatency returns the flush latency at the quantile q (between 0 and 1),
+// or zero if the engine was never flushed.
+func (r Result) FlushLatency(q float64) time.Duration {
+	if len(r.Flushes) == 0 {
+		return 0
+	}
+	i := int(q * float64(len(r.Flushes)-1))
+	if i < 0 {
+		i = 0
+	}
+	return r.Flushes[i]
+}
+
+// String satisfies the fmt.Stringer interface, the result is formatted like
+// the results of the benchmarks of the testing package.
+func (r Result) String() string {
+	return fmt.Sprintf("%d\t%.1f ns/op\t%.1f B/op\t%.2f allocs/op\t%.4f drops/op\t%d flushes\t%v p50\t%v p99",
+		r.Operations,
+		r.NsPerOp(),
+		r.BytesPerOp(),
+		r.AllocsPerOp(),
+		r.DropRate(),
+		len(r.Flushes),
+		r.FlushLatency(0.5),
+		r.FlushLatency(0.99),
+	)
+}
+
+// Run runs a benchmark of handler configured by config, and returns the
+// results.
+func Run(handler stats.Handler, config Config) Result {
+	config = config.defaults()
+
+	eng := stats.NewEngine("bench", handler)
+	series := makeSeries(eng, config)
+
+	var (
+		ops     int64
+		stop    int32
+		flushes []time.Duration
+		workers sync.WaitGroup
+		flusher sync.WaitGroup
+		done    = make(chan struct{})
+	)
+
+	flushEngine := func() {
+		start := time.Now()
+		eng.Flush()
+		flushes = append(flushes, time.Since(start))
+	}
+
+	dropped0 := dropped(handler)
+
+	var mem0, mem1 runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&mem0)
+
+	start := time.Now()
+
+	for g := 0; g != config.Goroutines; g++ {
+		workers.Add(1)
+		go func(offset int) {
+			defer workers.Done()
+			n := int64(0)
+
+			for i := offset; atomic.LoadInt32(&stop) == 0; i++ {
+				series[i%len(series)].produce(float64(i))
+				n++
+			}
+
+			atomic.AddInt64(&ops, n)
+		}(g * len(series) / config.Goroutines)
+	}
+
+	if config.FlushInterval > 0 {
+		flusher.Add(1)
+		go func() {
+			defer flusher.Done()
+
+			ticker := time.NewTicker(config.FlushInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					flushEngine()
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	time.Sleep(config.Duration)
+	atomic.StoreInt32(&stop, 1)
+	workers.Wait()
+	elapsed := time.Since(start)
+
+	close(done)
+	flusher.Wait()
+	flushEngine()
+
+	runtime.ReadMemStats(&mem1)
+
+	sort.Slice(flushes, func(i, j int) bool { return flushes[i] < flushes[j] })
+
+	return Result{
+		Operations: ops,
+		Elapsed:    elapsed,
+		Allocs:     mem1.Mallocs - mem0.Mallocs,
+		Bytes:      mem1.TotalAlloc - mem0.TotalAlloc,
+		Dropped:    dropped(handler) - dropped0,
+		Flushes:    flushes,
+	}
+}
+
+func (c Config) defaults() Config {
+	if c.Goroutines <= 0 {
+		c.Goroutines = runtime.GOMAXPROCS(0)
+	}
+	if c.Series <= 0 {
+		c.Series = 100
+	}
+	if c.Tags < 0 {
+		c.Tags = 0
+	} else if c.Tags == 0 {
+		c.Tags = 2
+	}
+	if c.Mix.Counters <= 0 && c.Mix.Gauges <= 0 && c.Mix.Histograms <= 0 {
+		c.Mix = Mix{Counters: 1, Gauges: 1, Histograms: 1}
+	}
+	if c.Duration <= 0 {
+		c.Duration = time.Second
+	}
+	if c.FlushInterval == 0 {
+		c.FlushInterval = 100 * time.Millisecond
+	}
+	return c
+}
+
+// series is a metric name and set of tags that the benchmark produces
+// measures on.
+type series struct {
+	eng    *stats.Engine
+	handle *stats.Handle
+	name   string
+	ftype  stats.FieldType
+	tags   []stats.Tag
+}
+
+func makeSeries(eng *stats.Engine, config Config) []series {
+	list := make([]series, config.Series)
+	mix := config.Mix
+	total := positive(mix.Counters) + positive(mix.Gauges) + positive(mix.Histograms)
+
+	for i := range list {
+		s := &list[i]
+		s.eng = eng
+
+		// The types of operations are spread across the series according to
+		// their weights.
+		switch k := i % total; {
+		case k < positive(mix.Counters):
+			s.ftype = stats.Counter
+		case k < positive(mix.Counters)+positive(mix.Gauges):
+			s.ftype = stats.Gauge
+		default:
+			s.ftype = stats.Histogram
+		}
+
+		// Series of the same type are spread across 10 metric names, the
+		// names never mix types since some backends do not support it.
+		s.name = s.ftype.String() + strconv.Itoa(i%10)
+
+		s.tags = append(s.tags, stats.T("series", strconv.Itoa(i)))
+		for j := 0; j != config.Tags; j++ {
+			s.tags = append(s.tags, stats.T("tag"+strconv.Itoa(j), "value"+strconv.Itoa(j)))
+		}
+
+		if config.Handles {
+			s.handle = eng.Handle(s.name, s.ftype, s.tags...)
+		}
+	}
+
+	return list
+}
+
+func (s *series) produce(value float64) {
+	switch {
+	case s.handle != nil:
+		s.handle.Measure(value)
+	case s.ftype == stats.Counter:
+		s.eng.Add(s.name, value, s.tags...)
+	case s.ftype == stats.Gauge:
+		s.eng.Set(s.name, value, s.tags...)
+	default:
+		s.eng.Observe(s.name, value, s.tags...)
+	}
+}
+
+func positive(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+func dropped(handler stats.Handler) uint64 {
+	if d, ok := handler.(interface{ Dropped() uint64 }); ok {
+		return d.Dropped()
+	}
+	return 0
+}
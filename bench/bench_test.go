@@ -0,0 +1,74 @@
+//go:build !statsoff
+
+package bench
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestRun(t *testing.T) {
+	for _, handles := range []bool{false, true} {
+		h := &statstest.Handler{}
+
+		r := Run(h, Config{
+			Goroutines:    2,
+			Series:        10,
+			Mix:           Mix{Counters: 1, Histograms: 1},
+			Duration:      50 * time.Millisecond,
+			FlushInterval: 10 * time.Millisecond,
+			Handles:       handles,
+		})
+
+		if r.Operations == 0 || r.NsPerOp() == 0 {
+			t.Errorf("handles=%t: no operations were run: %v", handles, r)
+		}
+
+		if n := int64(len(h.Measures())); n != r.Operations {
+			t.Errorf("handles=%t: bad number of measures: %d != %d", handles, n, r.Operations)
+		}
+
+		if len(r.Flushes) < 2 || h.FlushCalls() != len(r.Flushes) {
+			t.Errorf("handles=%t: bad number of flushes: %d", handles, len(r.Flushes))
+		}
+
+		for _, m := range h.Measures()[:10] {
+			if f := m.Fields[0]; f.Type() == stats.Gauge {
+				t.Errorf("handles=%t: unexpected gauge: %v", handles, m)
+			}
+		}
+
+		if !strings.Contains(r.String(), "ns/op") {
+			t.Errorf("handles=%t: bad string representation: %s", handles, r)
+		}
+	}
+}
+
+type droppingHandler struct {
+	dropped uint64
+}
+
+func (h *droppingHandler) HandleMeasures(time time.Time, measures ...stats.Measure) {
+	atomic.AddUint64(&h.dropped, uint64(len(measures)))
+}
+
+func (h *droppingHandler) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+func TestRunDropped(t *testing.T) {
+	r := Run(&droppingHandler{}, Config{Duration: 10 * time.Millisecond, FlushInterval: -1})
+
+	if r.DropRate() != 1 {
+		t.Errorf("bad drop rate: %g", r.DropRate())
+	}
+
+	if len(r.Flushes) != 1 {
+		t.Errorf("bad number of flushes: %d", len(r.Flushes))
+	}
+}
@@ -0,0 +1,269 @@
+package stats
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bucketer is a measure handler which aggregates the values of histograms into
+// buckets before forwarding them to its base handler, so backends receive
+// histogram series instead of every observed value.
+//
+// The values of a histogram are aggregated if buckets were configured for it,
+// either in Buckets for the measure and field name of the histogram, or in
+// Prefixes for the measure name. Other histograms, counters, and gauges are
+// forwarded unchanged.
+//
+// For each histogram series (measure name, field name, and tags), the bucketer
+// forwards the following counters, which are prefixed with the field name:
+//
+//	bucket: number of values lower or equal to the bound in the "le" tag
+//	sum:    sum of the values
+//	count:  number of values
+//
+// The counts are not cumulative over time, each set of counters describes the
+// values observed in one aggregation window. Aggregated measures are forwarded
+// when a measure that falls in a new window is received, or when the bucketer
+// is flushed.
+//
+// When the base handler implements BucketedHandler, the distributions of the
+// series are passed to its ObserveBucketed method instead of being converted
+// to counters.
+type Bucketer struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// Registry of histogram buckets, if nil the global Buckets registry is
+	// used instead.
+	Buckets HistogramBuckets
+
+	// Upper bounds of the buckets of histograms whose measure names start
+	// with the keys of the map, the longest matching prefix is used. Buckets
+	// configured for a measure and field name have precedence over prefixes.
+	//
+	// The map must not be modified after the bucketer was first used.
+	Prefixes map[string][]float64
+
+	// Duration of the aggregation windows. Defaults to 10s.
+	Interval time.Duration
+
+	mutex  sync.Mutex
+	start  time.Time
+	last   time.Time
+	series map[string]*bucketedSeries
+	keys   []byte
+}
+
+type bucketedSeries struct {
+	name  string
+	field string
+	tags  []Tag
+	dist  *Distribution
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (b *Bucketer) HandleMeasures(t time.Time, measures ...Measure) {
+	var expired map[string]*bucketedSeries
+	var expiredTime time.Time
+
+	passthrough := measurePool.Get().(*measuresBuffer)
+	ms := passthrough.measures[:0]
+
+	b.mutex.Lock()
+
+	if b.start.IsZero() {
+		b.start = t
+	}
+
+	if t.Sub(b.start) >= b.interval() {
+		expired, expiredTime = b.series, b.last
+		b.series, b.start = nil, t
+	}
+
+	for _, m := range measures {
+		// The list of fields is only copied when some were aggregated.
+		var fields []Field
+
+		for i, f := range m.Fields {
+			if f.Type() == Histogram && b.aggregate(m, f) {
+				if fields == nil {
+					fields = append(make([]Field, 0, len(m.Fields)), m.Fields[:i]...)
+				}
+			} else if fields != nil {
+				fields = append(fields, f)
+			}
+		}
+
+		switch {
+		case fields == nil:
+			ms = append(ms, m)
+		case len(fields) != 0:
+			ms = append(ms, Measure{Name: m.Name, Fields: fields, Tags: m.Tags, Const: m.Const, SampleRate: m.SampleRate})
+		}
+	}
+
+	if t.After(b.last) {
+		b.last = t
+	}
+
+	b.mutex.Unlock()
+
+	if len(expired) != 0 {
+		b.emit(expiredTime, expired)
+	}
+
+	if len(ms) != 0 {
+		b.Handler.HandleMeasures(t, ms...)
+	}
+
+	for i := range ms {
+		ms[i] = Measure{}
+	}
+
+	passthrough.measures = ms[:0]
+	measurePool.Put(passthrough)
+}
+
+// Flush satisfies the Flusher interface, it forwards the buckets aggregated in
+// the current window to the base handler, then flushes it.
+func (b *Bucketer) Flush() {
+	b.mutex.Lock()
+	series, last := b.series, b.last
+	b.series, b.start = nil, time.Time{}
+	b.mutex.Unlock()
+
+	if len(series) != 0 {
+		b.emit(last, series)
+	}
+
+	flush(b.Handler)
+}
+
+// aggregate adds the value of f to the distribution of its series, it returns
+// false if no buckets were configured for the histogram.
+func (b *Bucketer) aggregate(m Measure, f Field) bool {
+	b.keys = appendSeriesKey(b.keys[:0], m.Name, f.Name, m.Tags)
+	s := b.series[string(b.keys)]
+
+	if s == nil {
+		bounds, ok := b.bounds(m.Name, f.Name)
+		if !ok {
+			return false
+		}
+		if b.series == nil {
+			b.series = make(map[string]*bucketedSeries)
+		}
+		s = &bucketedSeries{
+			name:  m.Name,
+			field: f.Name,
+			tags:  copyTags(m.Tags),
+			dist:  NewDistribution(bounds...),
+		}
+		b.series[string(b.keys)] = s
+	}
+
+	// Sampled observations stand for several values, like in summaries.
+	s.dist.ObserveN(valueToFloat(f.Value), uint64(math.Round(sampleWeight(m.SampleRate))))
+	return true
+}
+
+func (b *Bucketer) bounds(measure string, field string) ([]float64, bool) {
+	buckets := b.Buckets
+	if buckets == nil {
+		buckets = Buckets
+	}
+
+	if values, ok := buckets[Key{Measure: measure, Field: field}]; ok {
+		bounds := make([]float64, len(values))
+		for i, v := range values {
+			bounds[i] = valueToFloat(v)
+		}
+		return bounds, true
+	}
+
+	prefix, found := "", false
+	for p := range b.Prefixes {
+		if (!found || len(p) > len(prefix)) && strings.HasPrefix(measure, p) {
+			prefix, found = p, true
+		}
+	}
+
+	return b.Prefixes[prefix], found
+}
+
+func (b *Bucketer) emit(t time.Time, series map[string]*bucketedSeries) {
+	if h, ok := b.Handler.(BucketedHandler); ok {
+		for _, s := range series {
+			h.ObserveBucketed(t, s.name, s.field, s.tags, s.dist)
+		}
+		return
+	}
+
+	measures := make([]Measure, 0, len(series))
+
+	for _, s := range series {
+		measures = appendBucketMeasures(measures, s.name, s.field, s.tags, s.dist)
+	}
+
+	b.Handler.HandleMeasures(t, measures...)
+}
+
+func (b *Bucketer) interval() time.Duration {
+	if b.Interval != 0 {
+		return b.Interval
+	}
+	return 10 * time.Second
+}
+
+// BucketedHandler is an optional interface implemented by measure handlers
+// which accept histograms aggregated into buckets, usually because their
+// backends support histograms natively. Passing the distributions of the
+// values instead of every observation dramatically reduces the traffic to the
+// backends for histograms produced on hot code paths.
+type BucketedHandler interface {
+	// ObserveBucketed is called with the distribution of the values observed
+	// for the histogram series identified by the measure name, field name,
+	// and tags, since the last call for the series.
+	//
+	// The method must treat the tags and the distribution as read-only
+	// values, and must not retain them after returning.
+	ObserveBucketed(t time.Time, name string, field string, tags []Tag, dist *Distribution)
+}
+
+// appendBucketMeasures appends to measures the counters describing dist, see
+// Bucketer for details.
+func appendBucketMeasures(measures []Measure, name string, field string, tags []Tag, dist *Distribution) []Measure {
+	count := uint64(0)
+
+	for i, n := range dist.Counts {
+		le := "+Inf"
+		if i < len(dist.Bounds) {
+			le = strconv.FormatFloat(dist.Bounds[i], 'g', -1, 64)
+		}
+		count += n
+		measures = append(measures, Measure{
+			Name:   name,
+			Fields: []Field{MakeField(bucketFieldName(field, "bucket"), count, Counter)},
+			Tags:   SortTags(append(copyTags(tags), T("le", le))),
+		})
+	}
+
+	return append(measures, Measure{
+		Name: name,
+		Fields: []Field{
+			MakeField(bucketFieldName(field, "sum"), dist.Sum, Counter),
+			MakeField(bucketFieldName(field, "count"), dist.Count, Counter),
+		},
+		Tags: tags,
+	})
+}
+
+func bucketFieldName(field string, suffix string) string {
+	if len(field) == 0 {
+		return suffix
+	}
+	return field + "." + suffix
+}
@@ -0,0 +1,173 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestBucketer(t *testing.T) {
+	now := time.Now()
+	h := &statstest.Handler{}
+	b := &stats.Bucketer{
+		Handler:  h,
+		Buckets:  stats.HistogramBuckets{},
+		Prefixes: map[string][]float64{"test.http": {0.1, 1}},
+	}
+	b.Buckets.Set("test.rpc:size", 10, 100)
+	eng := stats.NewEngine("test", b)
+
+	eng.ObserveAt(now, "rpc:size", 5)
+	eng.ObserveAt(now, "rpc:size", 50)
+	eng.ObserveAt(now, "rpc:size", 500)
+	eng.ObserveAt(now, "http.rtt", 0.5)
+	eng.ObserveAt(now, "other", 42)
+	eng.AddAt(now, "calls", 1)
+
+	found := h.Measures()
+	if len(found) != 2 || found[0].Name != "test.other" || found[1].Name != "test.calls" {
+		t.Fatal("only measures without buckets should have been forwarded:", found)
+	}
+
+	b.Flush()
+
+	values := formatMeasures(h.Measures()[2:])
+	sort.Strings(values)
+
+	expect := []string{
+		"test.http.rtt:bucket=0 le=0.1",
+		"test.http.rtt:bucket=1 le=+Inf",
+		"test.http.rtt:bucket=1 le=1",
+		"test.http.rtt:count=1",
+		"test.http.rtt:sum=0.5",
+		"test.rpc:size.bucket=1 le=10",
+		"test.rpc:size.bucket=2 le=100",
+		"test.rpc:size.bucket=3 le=+Inf",
+		"test.rpc:size.count=3",
+		"test.rpc:size.sum=555",
+	}
+
+	if len(values) != len(expect) {
+		t.Fatalf("bad measures:\n%q", values)
+	}
+
+	for i := range expect {
+		if values[i] != expect[i] {
+			t.Errorf("bad measure at index %d:\n- expected: %s\n- found:    %s", i, expect[i], values[i])
+		}
+	}
+}
+
+func TestBucketerSampleRate(t *testing.T) {
+	h := &statstest.Handler{}
+	b := &stats.Bucketer{Handler: h, Prefixes: map[string][]float64{"test": {1}}}
+
+	b.HandleMeasures(time.Now(), stats.Measure{
+		Name:       "test",
+		Fields:     []stats.Field{stats.MakeField("rtt", 0.5, stats.Histogram)},
+		SampleRate: 0.1,
+	})
+	b.Flush()
+
+	values := formatMeasures(h.Measures())
+	sort.Strings(values)
+
+	expect := []string{
+		"test:rtt.bucket=10 le=+Inf",
+		"test:rtt.bucket=10 le=1",
+		"test:rtt.count=10",
+		"test:rtt.sum=5",
+	}
+
+	if len(values) != len(expect) {
+		t.Fatalf("bad measures:\n%q", values)
+	}
+
+	for i := range expect {
+		if values[i] != expect[i] {
+			t.Errorf("bad measure at index %d:\n- expected: %s\n- found:    %s", i, expect[i], values[i])
+		}
+	}
+}
+
+func TestBucketerBucketedHandler(t *testing.T) {
+	h := &bucketedHandler{}
+	b := &stats.Bucketer{
+		Handler:  stats.MultiHandler(h, &statstest.Handler{}),
+		Prefixes: map[string][]float64{"test.http": {0.1, 1}},
+	}
+	eng := stats.NewEngine("test", b)
+
+	eng.Observe("http.rtt", 0.05, stats.T("a", "1"))
+	eng.Observe("http.rtt", 0.5, stats.T("a", "1"))
+	eng.Observe("http.rtt", 5, stats.T("a", "1"))
+	b.Flush()
+
+	if len(h.measures) != 0 {
+		t.Error("histograms were passed to HandleMeasures:", h.measures)
+	}
+
+	if len(h.calls) != 1 {
+		t.Fatal("bad number of calls to ObserveBucketed:", len(h.calls))
+	}
+
+	c := h.calls[0]
+
+	if c.name != "test.http.rtt" || c.field != "" || len(c.tags) != 1 || c.tags[0] != stats.T("a", "1") {
+		t.Error("bad histogram series:", c.name, c.field, c.tags)
+	}
+
+	if c.count != 3 || c.sum != 5.55 || len(c.counts) != 3 || c.counts[0] != 1 || c.counts[1] != 1 || c.counts[2] != 1 {
+		t.Errorf("bad distribution: count=%d sum=%g counts=%v", c.count, c.sum, c.counts)
+	}
+}
+
+type bucketedCall struct {
+	name   string
+	field  string
+	tags   []stats.Tag
+	count  uint64
+	sum    float64
+	counts []uint64
+}
+
+type bucketedHandler struct {
+	measures []stats.Measure
+	calls    []bucketedCall
+}
+
+func (h *bucketedHandler) HandleMeasures(_ time.Time, measures ...stats.Measure) {
+	h.measures = append(h.measures, measures...)
+}
+
+func (h *bucketedHandler) ObserveBucketed(_ time.Time, name string, field string, tags []stats.Tag, dist *stats.Distribution) {
+	h.calls = append(h.calls, bucketedCall{
+		name:   name,
+		field:  field,
+		tags:   append([]stats.Tag(nil), tags...),
+		count:  dist.Count,
+		sum:    dist.Sum,
+		counts: append([]uint64(nil), dist.Counts...),
+	})
+}
+
+func formatMeasures(measures []stats.Measure) []string {
+	var values []string
+
+	for _, m := range measures {
+		for _, f := range m.Fields {
+			s := m.Name + ":" + f.Name + "=" + f.Value.String()
+			for _, tag := range m.Tags {
+				s += " " + tag.Name + "=" + tag.Value
+			}
+			values = append(values, s)
+		}
+	}
+
+	return values
+}
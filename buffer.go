@@ -1,6 +1,7 @@
 package stats
 
 import (
+	"fmt"
 	"io"
 	"runtime"
 	"sync"
@@ -33,9 +34,20 @@ type Buffer struct {
 	// This field cannot be nil.
 	Serializer Serializer
 
+	// Handler that the measures which could not be serialized or written are
+	// forwarded to, with an "error" tag set to the error that occurred. When
+	// nil, these measures are dropped.
+	//
+	// Setting a dead letter handler makes the buffer retain a copy of the
+	// measures until they are written, and recover from panics of the
+	// serializer. Measures of a write that failed may have been partially
+	// written.
+	DeadLetter Handler
+
 	once    sync.Once
 	offset  uint64
 	buffers []buffer
+	errors  ErrorChannel
 }
 
 // HandleMeasures satisfies the Handler interface.
@@ -49,7 +61,15 @@ func (b *Buffer) HandleMeasures(time time.Time, measures ...Measure) {
 
 	buffer := b.acquireBuffer()
 	length := buffer.len()
-	buffer.append(b.Serializer, time, measures...)
+
+	if b.DeadLetter == nil {
+		buffer.append(b.Serializer, time, measures...)
+	} else if err := buffer.appendPending(b.Serializer, time, measures...); err != nil {
+		buffer.release()
+		b.errors.Report(err)
+		b.deadLetter(err, time, measures)
+		return
+	}
 
 	if buffer.len() >= size {
 		if length == 0 {
@@ -59,7 +79,7 @@ func (b *Buffer) HandleMeasures(time time.Time, measures ...Measure) {
 			// case has to be handled.
 			length = buffer.len()
 		}
-		buffer.flush(b.Serializer, length)
+		b.flush(buffer, length)
 	}
 
 	buffer.release()
@@ -71,12 +91,53 @@ func (b *Buffer) Flush() {
 
 	for i := range b.buffers {
 		if buffer := &b.buffers[i]; buffer.acquire() {
-			buffer.flush(b.Serializer, buffer.len())
+			b.flush(buffer, buffer.len())
 			buffer.release()
 		}
 	}
 }
 
+// Errors returns a channel receiving the errors returned by the serializer
+// when writing measures, see ErrorChannel for details.
+func (b *Buffer) Errors() <-chan error {
+	return b.errors.C()
+}
+
+func (b *Buffer) flush(buffer *buffer, n int) {
+	err := buffer.flush(b.Serializer, n)
+
+	if err != nil {
+		b.errors.Report(err)
+	}
+
+	if b.DeadLetter != nil {
+		flushed := buffer.shift(n)
+
+		if err != nil {
+			for _, p := range flushed {
+				b.deadLetter(err, p.time, p.measures)
+			}
+		}
+	}
+}
+
+func (b *Buffer) deadLetter(err error, t time.Time, measures []Measure) {
+	tag := T("error", err.Error())
+	failed := make([]Measure, len(measures))
+
+	for i, m := range measures {
+		failed[i] = Measure{
+			Name:       m.Name,
+			Fields:     m.Fields,
+			Tags:       SortTags(append(copyTags(m.Tags), tag)),
+			Const:      m.Const,
+			SampleRate: m.SampleRate,
+		}
+	}
+
+	b.DeadLetter.HandleMeasures(t, failed...)
+}
+
 func (b *Buffer) prepare(bufferSize int) {
 	b.once.Do(func() {
 		b.buffers = make([]buffer, b.bufferPoolSize())
@@ -130,9 +191,18 @@ type Serializer interface {
 }
 
 type buffer struct {
-	lock uint64
-	data []byte
-	pad  [32]byte // padding to avoid false sharing between threads
+	lock    uint64
+	data    []byte
+	pending []pendingMeasures // only used when the buffer has a dead letter handler
+	pad     [32]byte          // padding to avoid false sharing between threads
+}
+
+// pendingMeasures is a copy of measures serialized in a buffer, end is the
+// offset in the buffer data where their serialized representation ends.
+type pendingMeasures struct {
+	time     time.Time
+	measures []Measure
+	end      int
 }
 
 func (b *buffer) acquire() bool {
@@ -151,6 +221,57 @@ func (b *buffer) append(s Serializer, t time.Time, m ...Measure) {
 	b.data = s.AppendMeasures(b.data, t, m...)
 }
 
+// appendPending is like append but retains a copy of the measures and
+// converts panics of the serializer to errors, in which case the buffer data is
+// left unchanged.
+func (b *buffer) appendPending(s Serializer, t time.Time, m ...Measure) (err error) {
+	n := len(b.data)
+
+	defer func() {
+		if x := recover(); x != nil {
+			if err, _ = x.(error); err == nil {
+				err = fmt.Errorf("%v", x)
+			}
+			b.data = b.data[:n]
+		}
+	}()
+
+	b.append(s, t, m...)
+
+	measures := make([]Measure, len(m))
+	for i := range m {
+		measures[i] = m[i].Clone()
+	}
+
+	b.pending = append(b.pending, pendingMeasures{
+		time:     t,
+		measures: measures,
+		end:      len(b.data),
+	})
+	return
+}
+
+// shift removes and returns the pending measures which were serialized in the
+// first n bytes of the buffer, after they were flushed.
+func (b *buffer) shift(n int) []pendingMeasures {
+	i := 0
+
+	for i < len(b.pending) && b.pending[i].end <= n {
+		i++
+	}
+
+	flushed := b.pending[:i]
+	pending := make([]pendingMeasures, 0, len(b.pending)-i)
+
+	for _, p := range b.pending[i:] {
+		p.end -= n
+		pending = append(pending, p)
+	}
+
+	b.pending = pending
+	return flushed
+}
+
 func (b *buffer) len() int {
 	return len(b.data)
 }
@@ -159,8 +280,9 @@ func (b *buffer) cap() int {
 	return cap(b.data)
 }
 
-func (b *buffer) flush(w io.Writer, n int) {
-	w.Write(b.data[:n])
+func (b *buffer) flush(w io.Writer, n int) error {
+	_, err := w.Write(b.data[:n])
 	n = copy(b.data, b.data[n:])
 	b.data = b.data[:n]
+	return err
 }
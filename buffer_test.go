@@ -0,0 +1,102 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+type testSerializer struct {
+	err    error
+	writes []string
+}
+
+func (s *testSerializer) AppendMeasures(b []byte, _ time.Time, measures ...stats.Measure) []byte {
+	for _, m := range measures {
+		if strings.Contains(m.Name, "\n") {
+			panic(errors.New("invalid measure name"))
+		}
+		b = append(b, m.Name...)
+		b = append(b, '\n')
+	}
+	return b
+}
+
+func (s *testSerializer) Write(b []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	s.writes = append(s.writes, string(b))
+	return len(b), nil
+}
+
+func TestBufferDeadLetter(t *testing.T) {
+	t.Run("write errors", func(t *testing.T) {
+		h := &statstest.Handler{}
+		s := &testSerializer{err: errors.New("connection refused")}
+		b := &stats.Buffer{BufferSize: 1024, BufferPoolSize: 1, Serializer: s, DeadLetter: h}
+		e := stats.NewEngine("", b)
+
+		e.Incr("a", stats.T("x", "y"))
+		e.Incr("b")
+		b.Flush()
+
+		found := h.Measures()
+		if len(found) != 2 {
+			t.Fatal("bad number of measures forwarded to the dead letter handler:", found)
+		}
+
+		for i, name := range []string{"a", "b"} {
+			if m := found[i]; m.Name != name || !hasTag(m.Tags, "error", "connection refused") {
+				t.Errorf("bad measure at index %d: %v", i, m)
+			}
+		}
+
+		h.Clear()
+		s.err = nil
+		e.Incr("c")
+		b.Flush()
+
+		if found := h.Measures(); len(found) != 0 {
+			t.Error("measures written successfully were forwarded to the dead letter handler:", found)
+		}
+
+		if len(s.writes) != 1 || s.writes[0] != "c\n" {
+			t.Error("bad writes:", s.writes)
+		}
+	})
+
+	t.Run("serialization errors", func(t *testing.T) {
+		h := &statstest.Handler{}
+		s := &testSerializer{}
+		b := &stats.Buffer{BufferSize: 1024, BufferPoolSize: 1, Serializer: s, DeadLetter: h}
+		e := stats.NewEngine("", b)
+
+		e.Incr("a")
+		e.Incr("b\n")
+		b.Flush()
+
+		if found := h.Measures(); len(found) != 1 || !hasTag(found[0].Tags, "error", "invalid measure name") {
+			t.Error("bad measures forwarded to the dead letter handler:", found)
+		}
+
+		if len(s.writes) != 1 || s.writes[0] != "a\n" {
+			t.Error("bad writes:", s.writes)
+		}
+	})
+}
+
+func hasTag(tags []stats.Tag, name string, value string) bool {
+	for _, tag := range tags {
+		if tag.Name == name && tag.Value == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,273 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CanaryComparator is a measure handler which compares the measures of two
+// versions of a program, distinguished by the value of a tag, over a sliding
+// window. The comparisons are meant to drive automated rollback decisions.
+//
+// The measures carrying the canary or baseline value of the tag are
+// aggregated across their other tags. For each histogram and gauge field,
+// the comparison is made between the means of the values of both versions. A
+// counter field is compared when a rate is configured for it, the comparison is
+// then made between the rates of both versions (the error rates for example).
+//
+// Measures are forwarded unchanged to the base handler. When the comparator is
+// flushed, it forwards the comparisons as gauges on a measure named after the
+// compared measure with a ".canary" suffix, with the following fields:
+//
+//	<field>.delta: difference between the canary and baseline values
+//	<field>.ratio: ratio of the canary value over the baseline value
+//
+// Ratios are not reported when the baseline value is zero.
+type CanaryComparator struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// Name of the tag which distinguishes the versions, and values of the tag
+	// on the canary and baseline measures (for example "version", "canary",
+	// and "stable").
+	Tag      string
+	Canary   string
+	Baseline string
+
+	// Rates maps the names of counter fields to the names of the counter fields
+	// of the same measure that they are divided by, for example "errors" to
+	// "requests" to compare error rates.
+	Rates map[string]string
+
+	// Duration of the sliding window. Defaults to 1 minute.
+	Window time.Duration
+
+	mutex sync.Mutex
+	slots [canarySlots]canarySlot
+}
+
+// CanaryComparison is the comparison of a field between the canary and the
+// baseline over the sliding window.
+type CanaryComparison struct {
+	Measure  string
+	Field    string
+	Canary   float64
+	Baseline float64
+}
+
+// Delta returns the difference between the canary and baseline values.
+func (c CanaryComparison) Delta() float64 {
+	return c.Canary - c.Baseline
+}
+
+// Ratio returns the ratio of the canary value over the baseline value, which
+// is NaN if the baseline value is zero.
+func (c CanaryComparison) Ratio() float64 {
+	if c.Baseline == 0 {
+		return math.NaN()
+	}
+	return c.Canary / c.Baseline
+}
+
+// The sliding window is made of slots which each aggregate the measures of a
+// fraction of the window.
+const canarySlots = 6
+
+type canarySlot struct {
+	epoch  int64 // index of the time slot since the unix epoch
+	fields map[canaryKey]*canaryStats
+}
+
+type canaryKey struct {
+	measure string
+	field   string
+}
+
+type canaryStats struct {
+	counter bool
+	sum     [2]float64 // sum of the values of the canary and the baseline
+	count   [2]float64 // number of values of the canary and the baseline
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (c *CanaryComparator) HandleMeasures(t time.Time, measures ...Measure) {
+	c.mutex.Lock()
+
+	for _, m := range measures {
+		if version, ok := c.version(m.Tags); ok {
+			slot := c.slot(t)
+			if slot == nil {
+				continue
+			}
+
+			for _, f := range m.Fields {
+				k := canaryKey{measure: m.Name, field: f.Name}
+				s := slot.fields[k]
+
+				if s == nil {
+					s = &canaryStats{counter: f.Type() == Counter}
+					slot.fields[k] = s
+				}
+
+				// Sampled values stand for more than one value.
+				w := sampleWeight(m.SampleRate)
+				s.sum[version] += valueToFloat(f.Value) * w
+				s.count[version] += w
+			}
+		}
+	}
+
+	c.mutex.Unlock()
+
+	c.Handler.HandleMeasures(t, measures...)
+}
+
+// Flush satisfies the Flusher interface, it forwards the comparisons of the
+// measures in the sliding window to the base handler, then flushes it.
+func (c *CanaryComparator) Flush() {
+	now := time.Now()
+	comparisons := c.compare(now)
+
+	if len(comparisons) != 0 {
+		measures := make([]Measure, 0, len(comparisons))
+		tags := []Tag{{Name: c.Tag, Value: c.Canary}}
+
+		for _, cmp := range comparisons {
+			fields := []Field{MakeField(cmp.Field+".delta", cmp.Delta(), Gauge)}
+
+			if ratio := cmp.Ratio(); !math.IsNaN(ratio) {
+				fields = append(fields, MakeField(cmp.Field+".ratio", ratio, Gauge))
+			}
+
+			measures = append(measures, Measure{
+				Name:   cmp.Measure + ".canary",
+				Fields: fields,
+				Tags:   tags,
+			})
+		}
+
+		c.Handler.HandleMeasures(now, measures...)
+	}
+
+	flush(c.Handler)
+}
+
+// Comparisons returns the comparisons of the measures of the canary and the
+// baseline in the sliding window that ends now, sorted by measure and field
+// names. Fields which were not seen on both versions are not compared.
+func (c *CanaryComparator) Comparisons() []CanaryComparison {
+	return c.compare(time.Now())
+}
+
+func (c *CanaryComparator) compare(now time.Time) []CanaryComparison {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	epoch := c.epoch(now)
+	total := make(map[canaryKey]*canaryStats)
+
+	for i := range c.slots {
+		slot := &c.slots[i]
+
+		if slot.fields == nil || epoch-slot.epoch >= canarySlots || slot.epoch > epoch {
+			continue
+		}
+
+		for k, s := range slot.fields {
+			t := total[k]
+			if t == nil {
+				t = &canaryStats{counter: s.counter}
+				total[k] = t
+			}
+			for v := range s.sum {
+				t.sum[v] += s.sum[v]
+				t.count[v] += s.count[v]
+			}
+		}
+	}
+
+	comparisons := make([]CanaryComparison, 0, len(total))
+
+	for k, s := range total {
+		cmp := CanaryComparison{Measure: k.measure, Field: k.field}
+
+		if s.counter {
+			// Counters are only compared as rates, divided by the counter
+			// configured in Rates. A version may not have incremented the
+			// counter (no errors), but must have incremented the divisor.
+			rate, ok := c.Rates[k.field]
+			d := total[canaryKey{measure: k.measure, field: rate}]
+			if !ok || d == nil || d.sum[0] == 0 || d.sum[1] == 0 {
+				continue
+			}
+			cmp.Canary = s.sum[0] / d.sum[0]
+			cmp.Baseline = s.sum[1] / d.sum[1]
+		} else {
+			if s.count[0] == 0 || s.count[1] == 0 {
+				continue
+			}
+			cmp.Canary = s.sum[0] / s.count[0]
+			cmp.Baseline = s.sum[1] / s.count[1]
+		}
+
+		comparisons = append(comparisons, cmp)
+	}
+
+	sort.Slice(comparisons, func(i, j int) bool {
+		if comparisons[i].Measure != comparisons[j].Measure {
+			return comparisons[i].Measure < comparisons[j].Measure
+		}
+		return comparisons[i].Field < comparisons[j].Field
+	})
+
+	return comparisons
+}
+
+// version returns 0 for the canary and 1 for the baseline, and false if the
+// tags carry neither value.
+func (c *CanaryComparator) version(tags []Tag) (int, bool) {
+	for _, t := range tags {
+		if t.Name == c.Tag {
+			switch t.Value {
+			case c.Canary:
+				return 0, true
+			case c.Baseline:
+				return 1, true
+			}
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// slot returns the slot of the sliding window that t falls in, resetting it
+// if it was last used by an expired time slot. The method returns nil if t is
+// older than the time slots of the window.
+func (c *CanaryComparator) slot(t time.Time) *canarySlot {
+	epoch := c.epoch(t)
+	slot := &c.slots[epoch%canarySlots]
+
+	if slot.fields != nil && slot.epoch > epoch {
+		return nil
+	}
+
+	if slot.fields == nil || slot.epoch != epoch {
+		slot.epoch = epoch
+		slot.fields = make(map[canaryKey]*canaryStats)
+	}
+
+	return slot
+}
+
+func (c *CanaryComparator) epoch(t time.Time) int64 {
+	return t.UnixNano() / int64(c.window()/canarySlots)
+}
+
+func (c *CanaryComparator) window() time.Duration {
+	if c.Window > 0 {
+		return c.Window
+	}
+	return time.Minute
+}
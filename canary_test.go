@@ -0,0 +1,65 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestCanaryComparator(t *testing.T) {
+	h := &statstest.Handler{}
+	c := &stats.CanaryComparator{
+		Handler:  h,
+		Tag:      "version",
+		Canary:   "canary",
+		Baseline: "stable",
+		Rates:    map[string]string{"errors": "requests"},
+	}
+
+	eng := stats.NewEngine("test", c)
+	canary := eng.WithTags(stats.T("version", "canary"))
+	stable := eng.WithTags(stats.T("version", "stable"))
+
+	for i := 0; i != 10; i++ {
+		canary.Observe("http:rtt", 0.25, stats.T("host", "a"))
+		stable.Observe("http:rtt", 0.125, stats.T("host", "b"))
+		canary.Incr("http:requests")
+		stable.Incr("http:requests")
+		stable.Incr("http:requests")
+	}
+
+	canary.Add("http:errors", 2)
+	stable.Add("http:errors", 1)
+
+	// Measures of other versions and measures older than the window are
+	// ignored.
+	eng.Observe("http:rtt", 10, stats.T("version", "other"))
+	canary.ObserveAt(time.Now().Add(-time.Hour), "http:rtt", 10)
+
+	expected := []stats.CanaryComparison{
+		{Measure: "test.http", Field: "errors", Canary: 0.2, Baseline: 0.05},
+		{Measure: "test.http", Field: "rtt", Canary: 0.25, Baseline: 0.125},
+	}
+
+	if found := c.Comparisons(); !reflect.DeepEqual(found, expected) {
+		t.Errorf("bad comparisons:\nexpected: %+v\nfound:    %+v", expected, found)
+	}
+
+	h.Clear()
+	eng.Flush()
+
+	found := h.Measures()
+	if len(found) != 2 {
+		t.Fatal("bad number of measures:", found)
+	}
+
+	m := found[1]
+	if m.Name != "test.http.canary" || len(m.Fields) != 2 || m.Fields[0].Name != "rtt.delta" || m.Fields[1].Value.Float() != 2 {
+		t.Error("bad comparison measure:", m)
+	}
+}
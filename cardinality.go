@@ -0,0 +1,148 @@
+package stats
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// OtherTagValue is the value set on the tags of measures collapsed by a
+// cardinality limit.
+const OtherTagValue = "other"
+
+// CardinalityLimit bounds the number of distinct combinations of tags that an
+// engine produces for each measure name, which protects the program and the
+// metric collection systems from tags with unbounded values (user IDs or URLs
+// for example).
+//
+// Cardinality limits are enabled by setting the CardinalityLimit field of an
+// engine, the engines derived from it with WithPrefix and WithTags share the
+// limit. Once a measure reached the limit, the measures carrying new
+// combinations of tags are collapsed: the values of their tags, except the
+// ones set on the engine, are replaced with OtherTagValue.
+//
+// The number of collapsed measures is reported when the engine is flushed, as
+// a counter named "cardinality.dropped" on the measure that reached the limit.
+//
+// Combinations of tags are retained forever unless TTL is set, in which case
+// the combinations which were not seen for longer than TTL are forgotten when
+// the engine is flushed, making room for new ones.
+type CardinalityLimit struct {
+	// Maximum number of combinations of tags for each measure name, the
+	// limit is disabled when zero or negative.
+	Max int
+
+	// Time to live of the combinations of tags which were not seen. The
+	// combinations never expire when zero.
+	TTL time.Duration
+
+	mutex sync.Mutex
+	names map[string]*cardinality
+	keys  []byte
+}
+
+type cardinality struct {
+	combinations map[string]time.Time // time each combination was last seen
+	dropped      uint64               // since the last flush
+	total        uint64
+}
+
+// Dropped returns the number of measures named name which were collapsed
+// because they had new combinations of tags after the limit was reached.
+func (c *CardinalityLimit) Dropped(name string) uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if m := c.names[name]; m != nil {
+		return m.total
+	}
+	return 0
+}
+
+// limit returns the tags of m, or a copy with the values collapsed if m has a
+// new combination of tags and its name reached the limit. Tags found in base
+// are never collapsed.
+func (c *CardinalityLimit) limit(t time.Time, m Measure, base []Tag) []Tag {
+	if c.Max <= 0 {
+		return m.Tags
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := c.names[m.Name]
+
+	if entry == nil {
+		if c.names == nil {
+			c.names = make(map[string]*cardinality)
+		}
+		entry = &cardinality{combinations: make(map[string]time.Time)}
+		c.names[m.Name] = entry
+	}
+
+	c.keys = appendSeriesKey(c.keys[:0], "", "", m.Tags)
+
+	if last, ok := entry.combinations[string(c.keys)]; ok {
+		if t.After(last) {
+			entry.combinations[string(c.keys)] = t
+		}
+		return m.Tags
+	}
+
+	if len(entry.combinations) < c.Max {
+		entry.combinations[string(c.keys)] = t
+		return m.Tags
+	}
+
+	tags := make([]Tag, len(m.Tags))
+	collapsed := false
+
+	for i, t := range m.Tags {
+		if !hasTags(base, []Tag{t}) {
+			t.Value, collapsed = OtherTagValue, true
+		}
+		tags[i] = t
+	}
+
+	if !collapsed {
+		return m.Tags
+	}
+
+	entry.dropped++
+	entry.total++
+	return tags
+}
+
+// measures returns the counters of measures dropped since the last call, and
+// forgets the combinations of tags which expired at now.
+func (c *CardinalityLimit) measures(now time.Time, tags []Tag) []Measure {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var measures []Measure
+
+	for name, entry := range c.names {
+		if c.TTL > 0 {
+			for key, last := range entry.combinations {
+				if now.Sub(last) > c.TTL {
+					delete(entry.combinations, key)
+				}
+			}
+		}
+
+		if entry.dropped != 0 {
+			measures = append(measures, Measure{
+				Name:   name,
+				Fields: []Field{MakeField("cardinality.dropped", entry.dropped, Counter)},
+				Tags:   tags,
+			})
+			entry.dropped = 0
+		}
+	}
+
+	sort.Slice(measures, func(i, j int) bool {
+		return measures[i].Name < measures[j].Name
+	})
+
+	return measures
+}
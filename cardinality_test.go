@@ -0,0 +1,73 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestEngineCardinalityLimit(t *testing.T) {
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", h, stats.T("service", "api"))
+	eng.CardinalityLimit = &stats.CardinalityLimit{Max: 2}
+
+	for _, user := range []string{"a", "b", "a", "c", "d"} {
+		eng.Incr("requests", stats.T("user", user))
+	}
+
+	eng.Report(struct {
+		Size int `metric:"size" type:"gauge"`
+	}{42}, stats.T("user", "e"))
+
+	users := []string{}
+	for _, m := range h.Measures() {
+		users = append(users, m.Tags[1].Value)
+
+		if m.Tags[0] != stats.T("service", "api") {
+			t.Error("the engine tags were collapsed:", m.Tags)
+		}
+	}
+
+	if expected := []string{"a", "b", "a", "other", "other", "e"}; !reflect.DeepEqual(users, expected) {
+		t.Errorf("bad tag values: %v != %v", users, expected)
+	}
+
+	if n := eng.CardinalityLimit.Dropped("test.requests"); n != 2 {
+		t.Error("bad number of dropped measures:", n)
+	}
+
+	h.Clear()
+	eng.Flush()
+
+	expected := []stats.Measure{{
+		Name:   "test.requests",
+		Fields: []stats.Field{stats.MakeField("cardinality.dropped", uint64(2), stats.Counter)},
+		Tags:   []stats.Tag{stats.T("service", "api")},
+	}}
+
+	if found := h.Measures(); !reflect.DeepEqual(found, expected) {
+		t.Errorf("bad measures reported on flush:\nexpected: %v\nfound:    %v", expected, found)
+	}
+}
+
+func TestEngineCardinalityLimitTTL(t *testing.T) {
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", h)
+	eng.CardinalityLimit = &stats.CardinalityLimit{Max: 1, TTL: time.Minute}
+
+	eng.IncrAt(time.Now().Add(-time.Hour), "requests", stats.T("user", "a"))
+	eng.Incr("requests", stats.T("user", "b"))
+	eng.Flush()
+
+	h.Clear()
+	eng.Incr("requests", stats.T("user", "b"))
+
+	if m := h.Measures()[0]; m.Tags[0].Value != "b" {
+		t.Error("the expired combination of tags was not forgotten:", m.Tags)
+	}
+}
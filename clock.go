@@ -19,7 +19,7 @@ type Clock struct {
 //
 // The metric produced by this method call will have a "stamp" tag set to name.
 func (c *Clock) Stamp(name string) {
-	c.StampAt(name, time.Now())
+	c.StampAt(name, c.eng.Now())
 }
 
 // StampAt reports the time difference between now and the last time the method
@@ -31,12 +31,23 @@ func (c *Clock) StampAt(name string, now time.Time) {
 	c.last = now
 }
 
+// Lap is an alias of Stamp, it reports the duration of the segment of the
+// operation that ended now, with a "stamp" tag set to name.
+func (c *Clock) Lap(name string) {
+	c.StampAt(name, c.eng.Now())
+}
+
+// LapAt is an alias of StampAt.
+func (c *Clock) LapAt(name string, now time.Time) {
+	c.StampAt(name, now)
+}
+
 // Stop reports the time difference between now and the time the clock was created at.
 //
 // The metric produced by this method call will have a "stamp" tag set to
 // "total".
 func (c *Clock) Stop() {
-	c.StopAt(time.Now())
+	c.StopAt(c.eng.Now())
 }
 
 // StopAt reports the time difference between now and the time the clock was created at.
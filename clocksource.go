@@ -0,0 +1,64 @@
+package stats
+
+import "time"
+
+// ClockSource is the interface implemented by the sources of time used by
+// engines to timestamp measures and schedule flushes, and by retry policies to
+// wait between attempts.
+//
+// Programs use the system clock by default, tests can set a clock source that
+// they control on engines and retry policies to drive the flush timers and
+// backoffs deterministically.
+type ClockSource interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTicker returns a ticker which delivers ticks at the interval d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker is the interface of the tickers created by clock sources.
+type Ticker interface {
+	// C returns the channel receiving the ticks.
+	C() <-chan time.Time
+
+	// Stop turns off the ticker.
+	Stop()
+}
+
+// SystemClock is the clock source reading the time of the system, which is
+// used when no clock source was configured.
+var SystemClock ClockSource = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct{ *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// Now returns the current time of the clock source of eng.
+func (eng *Engine) Now() time.Time {
+	return eng.clock().Now()
+}
+
+// clockNow returns the current time of c, or of the system clock if c is nil.
+// It is used by the handlers which have a ClockSource field.
+func clockNow(c ClockSource) time.Time {
+	if c != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func (eng *Engine) clock() ClockSource {
+	if eng.ClockSource != nil {
+		return eng.ClockSource
+	}
+	return SystemClock
+}
@@ -0,0 +1,149 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+// manualClock is a clock source whose time and ticks are controlled by tests.
+type manualClock struct {
+	mutex sync.Mutex
+	now   time.Time
+	ticks chan time.Time
+}
+
+func newManualClock(now time.Time) *manualClock {
+	return &manualClock{now: now, ticks: make(chan time.Time)}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *manualClock) NewTicker(time.Duration) stats.Ticker {
+	return manualTicker{c.ticks}
+}
+
+// tick advances the time of the clock by d and delivers a tick to the ticker
+// created by the clock, blocking until it is received.
+func (c *manualClock) tick(d time.Duration) {
+	c.mutex.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	c.mutex.Unlock()
+	c.ticks <- now
+}
+
+// advance advances the time of the clock by d without delivering a tick.
+func (c *manualClock) advance(d time.Duration) {
+	c.mutex.Lock()
+	c.now = c.now.Add(d)
+	c.mutex.Unlock()
+}
+
+type manualTicker struct{ ticks chan time.Time }
+
+func (t manualTicker) C() <-chan time.Time { return t.ticks }
+
+func (t manualTicker) Stop() {}
+
+func TestEngineClockSource(t *testing.T) {
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := newManualClock(now)
+
+	var times []time.Time
+	eng := stats.NewEngine("test", stats.HandlerFunc(func(t time.Time, _ ...stats.Measure) {
+		times = append(times, t)
+	}))
+	eng.ClockSource = clock
+
+	eng.Incr("A")
+	eng.WithTags(stats.T("a", "1")).Set("B", 1)
+	eng.Handle("C", stats.Histogram).Measure(1)
+
+	for i, ts := range times {
+		if !ts.Equal(now) {
+			t.Errorf("measure #%d: bad time: %s != %s", i, ts, now)
+		}
+	}
+
+	if len(times) != 3 {
+		t.Error("bad number of measures:", len(times))
+	}
+}
+
+func TestEngineFlushEveryClockSource(t *testing.T) {
+	h := &statstest.Handler{}
+	clock := newManualClock(time.Now())
+
+	eng := stats.NewEngine("test", h)
+	eng.ClockSource = clock
+
+	stop := eng.FlushEvery(time.Hour)
+	clock.tick(time.Hour)
+	clock.tick(time.Hour) // the first flush completed when this is received
+	stop()
+
+	if n := h.FlushCalls(); n < 1 || n > 2 {
+		t.Error("bad number of flushes:", n)
+	}
+}
+
+func TestRetryPolicyClockSource(t *testing.T) {
+	clock := newManualClock(time.Now())
+	attempts := 0
+
+	p := stats.RetryPolicy{MaxAttempts: 3, ClockSource: clock}
+	done := make(chan error)
+
+	go func() {
+		done <- p.Do(nil, func() error {
+			attempts++
+			return errors.New("failed")
+		})
+	}()
+
+	// The backoffs last for as long as the test does not tick the clock.
+	clock.tick(time.Second)
+	clock.tick(time.Second)
+
+	if err := <-done; err == nil {
+		t.Error("expected an error")
+	}
+
+	if attempts != 3 {
+		t.Error("bad number of attempts:", attempts)
+	}
+}
+
+func TestCounterRatesClockSource(t *testing.T) {
+	clock := newManualClock(time.Now())
+	h := &statstest.Handler{}
+	r := &stats.CounterRates{Handler: h, Replace: true, ClockSource: clock}
+
+	r.HandleMeasures(clock.Now(), stats.Measure{
+		Name:   "test.http",
+		Fields: []stats.Field{stats.MakeField("requests", 100, stats.Counter)},
+	})
+
+	clock.advance(10 * time.Second)
+	r.Flush()
+
+	found := h.Measures()
+	if len(found) != 1 {
+		t.Fatal("bad number of rates:", found)
+	}
+
+	if f := found[0].Fields[0]; f.Name != "requests.rate" || f.Value.Float() != 10 {
+		t.Error("bad rate:", f)
+	}
+}
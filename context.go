@@ -0,0 +1,37 @@
+package stats
+
+import "context"
+
+type contextTagsKey struct{}
+
+// ContextWithTags returns a copy of ctx carrying tags, in addition to the tags
+// that ctx already carried. Tags replace the ones with the same names in ctx.
+//
+// Contextual tags let request-scoped dimensions (like a tenant or an endpoint)
+// flow into the metrics produced deep in the call stack, the instrumentation
+// packages (httpstats for example) merge them into the metrics that they
+// produce.
+func ContextWithTags(ctx context.Context, tags ...Tag) context.Context {
+	parent := TagsFromContext(ctx)
+	merged := make([]Tag, 0, len(parent)+len(tags))
+
+search:
+	for _, t := range parent {
+		for _, x := range tags {
+			if x.Name == t.Name {
+				continue search
+			}
+		}
+		merged = append(merged, t)
+	}
+
+	merged = append(merged, tags...)
+	return context.WithValue(ctx, contextTagsKey{}, SortTags(merged))
+}
+
+// TagsFromContext returns the tags carried by ctx, sorted by name. The program
+// must not modify the returned slice.
+func TagsFromContext(ctx context.Context) []Tag {
+	tags, _ := ctx.Value(contextTagsKey{}).([]Tag)
+	return tags
+}
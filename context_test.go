@@ -0,0 +1,30 @@
+package stats_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/stats"
+)
+
+func TestContextWithTags(t *testing.T) {
+	ctx := context.Background()
+
+	if tags := stats.TagsFromContext(ctx); tags != nil {
+		t.Error("unexpected tags in the background context:", tags)
+	}
+
+	ctx = stats.ContextWithTags(ctx, stats.T("tenant", "acme"), stats.T("endpoint", "/a"))
+	ctx = stats.ContextWithTags(ctx, stats.T("endpoint", "/b"), stats.T("a", "b"))
+
+	expected := []stats.Tag{
+		stats.T("a", "b"),
+		stats.T("endpoint", "/b"),
+		stats.T("tenant", "acme"),
+	}
+
+	if tags := stats.TagsFromContext(ctx); !reflect.DeepEqual(tags, expected) {
+		t.Errorf("bad tags: %v != %v", tags, expected)
+	}
+}
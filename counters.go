@@ -0,0 +1,237 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CounterMode represents the way counters are reported by a CounterAggregator.
+type CounterMode int
+
+const (
+	// DeltaCounters reports the sum of the increments of counters since the
+	// last flush, which is the model of statsd and its derivatives.
+	DeltaCounters CounterMode = iota
+
+	// CumulativeCounters reports the sum of the increments of counters since
+	// they were first seen, which is the model of Prometheus and of time series
+	// databases which store the values unchanged (InfluxDB or CSV files).
+	CumulativeCounters
+)
+
+// String satisfies the fmt.Stringer interface.
+func (m CounterMode) String() string {
+	switch m {
+	case DeltaCounters:
+		return "delta"
+	case CumulativeCounters:
+		return "cumulative"
+	default:
+		return "unknown"
+	}
+}
+
+// CounterAggregator is a measure handler which aggregates the increments of
+// counters and forwards them to its base handler when it is flushed, either as
+// deltas or as cumulative totals depending on Mode.
+//
+// The increments of each counter series (measure name, field name, and tags)
+// are summed. When flushed in DeltaCounters mode, the aggregator forwards the
+// series which were incremented since the last flush. In CumulativeCounters
+// mode, every series that the aggregator has seen is forwarded with its total.
+//
+// In CumulativeCounters mode, the series of long-running programs may
+// accumulate as tags change (workers departing or old versions for example).
+// Series which were not incremented for longer than their time to live are
+// evicted and stop being reported, set TTL or TTLs to enable expiration.
+//
+// Counter fields are removed from the measures forwarded by HandleMeasures,
+// other fields are forwarded unchanged.
+type CounterAggregator struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// The way counters are reported. Defaults to DeltaCounters.
+	Mode CounterMode
+
+	// Time to live of the series which were not incremented in cumulative
+	// mode. Series never expire when zero.
+	TTL time.Duration
+
+	// Times to live of the series whose measure names start with the keys of
+	// the map, the longest matching prefix is used. Series that match none of
+	// the prefixes expire after TTL, a zero value disables the expiration of
+	// the matching series.
+	//
+	// The map must not be modified after the aggregator was first used.
+	TTLs map[string]time.Duration
+
+	// The source of the current time used to expire the series and stamp the
+	// counters when the aggregator is flushed. Defaults to SystemClock.
+	ClockSource ClockSource
+
+	mutex  sync.Mutex
+	series map[string]*counterSeries
+	keys   []byte
+}
+
+type counterSeries struct {
+	name  string
+	field string
+	tags  []Tag
+	total Value
+	last  time.Time // time of the last increment
+	ttl   time.Duration
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (c *CounterAggregator) HandleMeasures(t time.Time, measures ...Measure) {
+	passthrough := measurePool.Get().(*measuresBuffer)
+	ms := passthrough.measures[:0]
+
+	c.mutex.Lock()
+
+	for _, m := range measures {
+		// The list of fields is only copied when counters were removed.
+		var fields []Field
+
+		for i, f := range m.Fields {
+			if f.Type() != Counter {
+				if fields != nil {
+					fields = append(fields, f)
+				}
+				continue
+			}
+
+			c.add(t, m, f)
+
+			if fields == nil {
+				fields = append(make([]Field, 0, len(m.Fields)), m.Fields[:i]...)
+			}
+		}
+
+		switch {
+		case fields == nil:
+			ms = append(ms, m)
+		case len(fields) != 0:
+			ms = append(ms, Measure{Name: m.Name, Fields: fields, Tags: m.Tags, Const: m.Const, SampleRate: m.SampleRate})
+		}
+	}
+
+	c.mutex.Unlock()
+
+	if len(ms) != 0 {
+		c.Handler.HandleMeasures(t, ms...)
+	}
+
+	for i := range ms {
+		ms[i] = Measure{}
+	}
+
+	passthrough.measures = ms[:0]
+	measurePool.Put(passthrough)
+}
+
+// Flush satisfies the Flusher interface, it forwards the counters to the base
+// handler, then flushes it.
+func (c *CounterAggregator) Flush() {
+	now := clockNow(c.ClockSource)
+	measures := c.measures(now)
+
+	if len(measures) != 0 {
+		c.Handler.HandleMeasures(now, measures...)
+	}
+
+	flush(c.Handler)
+}
+
+func (c *CounterAggregator) measures(now time.Time) []Measure {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	measures := make([]Measure, 0, len(c.series))
+
+	for key, s := range c.series {
+		if c.Mode != CumulativeCounters {
+			// Series are only retained across flushes to compute their
+			// totals, they are dropped in delta mode.
+			delete(c.series, key)
+		} else if s.ttl > 0 && now.Sub(s.last) > s.ttl {
+			delete(c.series, key)
+			continue
+		}
+
+		measures = append(measures, Measure{
+			Name:   s.name,
+			Fields: []Field{makeCounterField(s.field, s.total)},
+			Tags:   s.tags,
+		})
+	}
+
+	sort.Slice(measures, func(i, j int) bool {
+		if measures[i].Name != measures[j].Name {
+			return measures[i].Name < measures[j].Name
+		}
+		return measures[i].Fields[0].Name < measures[j].Fields[0].Name
+	})
+
+	return measures
+}
+
+func (c *CounterAggregator) add(t time.Time, m Measure, f Field) {
+	c.keys = appendSeriesKey(c.keys[:0], m.Name, f.Name, m.Tags)
+	s := c.series[string(c.keys)]
+
+	if s == nil {
+		if c.series == nil {
+			c.series = make(map[string]*counterSeries)
+		}
+		s = &counterSeries{
+			name:  m.Name,
+			field: f.Name,
+			tags:  copyTags(m.Tags),
+			ttl:   c.ttl(m.Name),
+		}
+		c.series[string(c.keys)] = s
+	}
+
+	// Integer increments are summed as integers, so large counters don't lose
+	// precision as they would if they were accumulated as floats.
+	s.total = addCounterValues(s.total, unsample(f.Value, m.SampleRate))
+
+	if t.After(s.last) {
+		s.last = t
+	}
+}
+
+func (c *CounterAggregator) ttl(name string) time.Duration {
+	prefix, ttl := "", c.TTL
+
+	for p, d := range c.TTLs {
+		if len(p) > len(prefix) && strings.HasPrefix(name, p) {
+			prefix, ttl = p, d
+		}
+	}
+
+	return ttl
+}
+
+// addCounterValues adds v to the total of a counter. Totals of integer values
+// remain integers, all other values are accumulated as floats.
+func addCounterValues(total Value, v Value) Value {
+	if t := v.Type(); t != Int && t != Uint {
+		v = float64Value(valueToFloat(v))
+	}
+	if total.Type() == Null {
+		return v
+	}
+	return addValues(total, v)
+}
+
+func makeCounterField(name string, total Value) Field {
+	f := Field{Name: name, Value: total}
+	f.setType(Counter)
+	return f
+}
@@ -0,0 +1,165 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestCounterAggregator(t *testing.T) {
+	tests := []struct {
+		mode   stats.CounterMode
+		values []float64 // values of the counter A after each flush
+	}{
+		{mode: stats.DeltaCounters, values: []float64{3, 2}},
+		{mode: stats.CumulativeCounters, values: []float64{3, 5, 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode.String(), func(t *testing.T) {
+			h := &statstest.Handler{}
+			c := &stats.CounterAggregator{Handler: h, Mode: test.mode}
+			e := stats.NewEngine("test", c)
+
+			var values []float64
+			collect := func() {
+				for _, m := range h.Measures() {
+					if m.Name == "test.A" {
+						values = append(values, float64(m.Fields[0].Value.Int()))
+					}
+				}
+				h.Clear()
+			}
+
+			e.Add("A", 1)
+			e.Add("A", 2)
+			e.Observe("B", time.Second)
+
+			if measures := h.Measures(); len(measures) != 1 || measures[0].Name != "test.B" {
+				t.Errorf("bad forwarded measures: %v", measures)
+			}
+			h.Clear()
+
+			e.Flush()
+			collect()
+
+			e.Add("A", 2)
+			e.Flush()
+			collect()
+
+			e.Flush()
+			collect()
+
+			if len(values) != len(test.values) {
+				t.Fatalf("bad counter values: %v", values)
+			}
+
+			for i := range values {
+				if values[i] != test.values[i] {
+					t.Errorf("bad counter values: %v", values)
+					break
+				}
+			}
+
+			if n := h.FlushCalls(); n != 3 {
+				t.Errorf("bad number of flushes: %d", n)
+			}
+		})
+	}
+}
+
+func TestCounterAggregatorTTL(t *testing.T) {
+	h := &statstest.Handler{}
+	c := &stats.CounterAggregator{
+		Handler: h,
+		Mode:    stats.CumulativeCounters,
+		TTL:     time.Minute,
+		TTLs:    map[string]time.Duration{"jobs": 0},
+	}
+
+	old := time.Now().Add(-time.Hour)
+	c.HandleMeasures(old,
+		stats.Measure{Name: "requests", Fields: []stats.Field{stats.MakeField("", 1, stats.Counter)}, Tags: []stats.Tag{stats.T("worker", "1")}},
+		stats.Measure{Name: "jobs", Fields: []stats.Field{stats.MakeField("", 1, stats.Counter)}},
+	)
+	c.HandleMeasures(time.Now(),
+		stats.Measure{Name: "requests", Fields: []stats.Field{stats.MakeField("", 1, stats.Counter)}, Tags: []stats.Tag{stats.T("worker", "2")}},
+	)
+	c.Flush()
+
+	found := []string{}
+	for _, m := range h.Measures() {
+		found = append(found, m.Name)
+		for _, tag := range m.Tags {
+			found = append(found, tag.String())
+		}
+	}
+
+	if expected := []string{"jobs", "requests", "worker=2"}; !reflect.DeepEqual(found, expected) {
+		t.Errorf("bad measures:\nexpected: %v\nfound:    %v", expected, found)
+	}
+}
+
+func TestCounterAggregatorClockSource(t *testing.T) {
+	clock := newManualClock(time.Now())
+	h := &statstest.Handler{}
+	c := &stats.CounterAggregator{
+		Handler:     h,
+		Mode:        stats.CumulativeCounters,
+		TTL:         time.Minute,
+		ClockSource: clock,
+	}
+
+	c.HandleMeasures(clock.Now(),
+		stats.Measure{Name: "requests", Fields: []stats.Field{stats.MakeField("", 1, stats.Counter)}},
+	)
+
+	clock.advance(30 * time.Second)
+	c.Flush()
+
+	if n := len(h.Measures()); n != 1 {
+		t.Error("the series expired before its TTL:", n)
+	}
+
+	h.Clear()
+	clock.advance(time.Minute)
+	c.Flush()
+
+	if n := len(h.Measures()); n != 0 {
+		t.Error("the series did not expire after its TTL:", n)
+	}
+}
+
+func TestCounterAggregatorIntPrecision(t *testing.T) {
+	h := &statstest.Handler{}
+	c := &stats.CounterAggregator{Handler: h}
+	e := stats.NewEngine("test", c)
+
+	const base = int64(1) << 53
+
+	e.AddInt("bytes", base)
+	e.AddInt("bytes", 1)
+	e.AddInt("bytes", 1)
+	e.Add("ratio", 0.5)
+	e.Add("ratio", 1)
+	e.Flush()
+
+	measures := h.Measures()
+
+	if len(measures) != 2 {
+		t.Fatalf("bad measures: %v", measures)
+	}
+
+	if v := measures[0].Fields[0].Value; v.Type() != stats.Int || v.Int() != base+2 {
+		t.Errorf("bad integer counter: %v (%s)", v, v.Type())
+	}
+
+	if v := measures[1].Fields[0].Value; v.Type() != stats.Float || v.Float() != 1.5 {
+		t.Errorf("bad float counter: %v (%s)", v, v.Type())
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !statsoff
+
 package datadog
 
 import "testing"
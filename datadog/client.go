@@ -2,6 +2,7 @@ package datadog
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"log"
 	"net"
@@ -44,6 +45,10 @@ type ClientConfig struct {
 
 	// List of tags to filter. If left nil is set to DefaultFilters.
 	Filters []string
+
+	// Handler that receives the measures which could not be sent, tagged with
+	// the error that occurred. See stats.Buffer for details.
+	DeadLetter stats.Handler
 }
 
 // Client represents an datadog client that implements the stats.Handler
@@ -97,6 +102,7 @@ func NewClientWith(config ClientConfig) *Client {
 	c.conn, c.err, c.bufferSize = conn, err, bufferSize
 	c.buffer.BufferSize = bufferSize
 	c.buffer.Serializer = &c.serializer
+	c.buffer.DeadLetter = config.DeadLetter
 	log.Printf("stats/datadog: sending metrics with a buffer of size %d B", bufferSize)
 	return c
 }
@@ -111,6 +117,13 @@ func (c *Client) Flush() {
 	c.buffer.Flush()
 }
 
+// Errors returns a channel receiving the errors which occur sending measures
+// to the agent. Once the program called the method, the errors are no longer
+// logged.
+func (c *Client) Errors() <-chan error {
+	return c.serializer.errors.C()
+}
+
 // Write satisfies the io.Writer interface.
 func (c *Client) Write(b []byte) (int, error) {
 	return c.serializer.Write(b)
@@ -127,6 +140,7 @@ type serializer struct {
 	conn       net.Conn
 	bufferSize int
 	filters    map[string]struct{}
+	errors     stats.ErrorChannel
 }
 
 func (s *serializer) AppendMeasures(b []byte, _ time.Time, measures ...stats.Measure) []byte {
@@ -137,6 +151,14 @@ func (s *serializer) AppendMeasures(b []byte, _ time.Time, measures ...stats.Mea
 }
 
 func (s *serializer) Write(b []byte) (int, error) {
+	n, err := s.write(b)
+	if err != nil {
+		s.errors.Report(err)
+	}
+	return n, err
+}
+
+func (s *serializer) write(b []byte) (int, error) {
 	if s.conn == nil {
 		return 0, io.ErrClosedPipe
 	}
@@ -159,7 +181,10 @@ func (s *serializer) Write(b []byte) (int, error) {
 			}
 			if (i + splitIndex) >= s.bufferSize {
 				if splitIndex == 0 {
-					log.Printf("stats/datadog: metric of length %d B doesn't fit in the socket buffer of size %d B: %s", i+1, s.bufferSize, string(b))
+					err := fmt.Errorf("metric of length %d B doesn't fit in the socket buffer of size %d B: %s", i+1, s.bufferSize, string(b))
+					if !s.errors.Report(err) {
+						log.Print("stats/datadog: ", err)
+					}
 					b = b[i+1:]
 					continue
 				}
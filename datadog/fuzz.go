@@ -0,0 +1,27 @@
+//go:build gofuzz
+
+package datadog
+
+import (
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+// Fuzz is the entry point for go-fuzz, it verifies that measures built from
+// the fuzzer data cannot inject records in the dogstatsd protocol.
+//
+// The dogstatsd protocol has no escaping mechanism, control characters are
+// removed from the measures by a stats.Sanitizer before they are serialized.
+func Fuzz(data []byte) int {
+	m := statstest.Sanitize(statstest.FuzzMeasure(data))
+
+	if err := statstest.CheckRecords(appendMeasure, m, len(m.Fields)); err != nil {
+		panic(err)
+	}
+
+	return 1
+}
+
+func appendMeasure(b []byte, m stats.Measure) []byte {
+	return AppendMeasure(b, m)
+}
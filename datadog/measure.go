@@ -2,6 +2,7 @@ package datadog
 
 import (
 	"math"
+	"reflect"
 	"strconv"
 
 	"github.com/segmentio/stats"
@@ -16,7 +17,16 @@ func AppendMeasure(b []byte, m stats.Measure) []byte {
 // AppendMeasureFiltered is a formatting routine to append the dogstatsd protocol
 // representation of a measure to a memory buffer. Tags listed in the filters map
 // are removed. (some tags may not be suitable for submission to DataDog)
+//
+// The sample rate of sampled measures is submitted as the sample rate of the
+// dogstatsd protocol.
 func AppendMeasureFiltered(b []byte, m stats.Measure, filters map[string]struct{}) []byte {
+	var buf [32]byte
+	rate := buf[:0]
+	if m.SampleRate > 0 && m.SampleRate < 1 {
+		rate = strconv.AppendFloat(rate, m.SampleRate, 'g', -1, 64)
+	}
+
 	for _, field := range m.Fields {
 		b = append(b, m.Name...)
 		if len(field.Name) != 0 {
@@ -53,18 +63,23 @@ func AppendMeasureFiltered(b []byte, m stats.Measure, filters map[string]struct{
 			b = append(b, '|', 'h')
 		}
 
+		if len(rate) != 0 && field.Type() != stats.Gauge {
+			b = append(b, '|', '@')
+			b = append(b, rate...)
+		}
+
 		if n := len(m.Tags); n != 0 {
 			b = append(b, '|', '#')
+			i := len(b)
 
-			for i, t := range m.Tags {
-				if _, ok := filters[t.Name]; !ok {
-					if i != 0 {
-						b = append(b, ',')
-					}
-					b = append(b, t.Name...)
-					b = append(b, ':')
-					b = append(b, t.Value...)
-				}
+			if m.Const != nil {
+				b = appendConstTags(b, m, filters)
+			} else {
+				b = appendTagsFiltered(b, m.Tags, filters)
+			}
+
+			if len(b) == i { // all tags were filtered
+				b = b[:i-2]
 			}
 		}
 
@@ -74,6 +89,59 @@ func AppendMeasureFiltered(b []byte, m stats.Measure, filters map[string]struct{
 	return b
 }
 
+func appendTagsFiltered(b []byte, tags []stats.Tag, filters map[string]struct{}) []byte {
+	n := 0
+	for _, t := range tags {
+		if _, ok := filters[t.Name]; !ok {
+			if n != 0 {
+				b = append(b, ',')
+			}
+			b = append(b, t.Name...)
+			b = append(b, ':')
+			b = append(b, t.Value...)
+			n++
+		}
+	}
+	return b
+}
+
+// tagsEncodingKey is the key used to cache the serialized representation of
+// constant tags, the encoding depends on the set of filters so the key carries
+// a pointer to the filters map.
+type tagsEncodingKey struct {
+	filters uintptr
+}
+
+func appendConstTags(b []byte, m stats.Measure, filters map[string]struct{}) []byte {
+	key := tagsEncodingKey{filters: reflect.ValueOf(filters).Pointer()}
+
+	enc := m.Const.Encoding(key, func(b []byte, tags []stats.Tag) []byte {
+		return appendTagsFiltered(b, tags, filters)
+	})
+	b = append(b, enc...)
+
+	if len(m.Tags) == m.Const.Len() {
+		return b
+	}
+
+	var buf [8]stats.Tag
+	dynamic := m.Const.Split(buf[:0], m.Tags)
+
+	i := len(b)
+
+	if len(enc) != 0 {
+		b = append(b, ',')
+	}
+
+	n := len(b)
+
+	if b = appendTagsFiltered(b, dynamic, filters); len(b) == n {
+		b = b[:i] // all dynamic tags were filtered
+	}
+
+	return b
+}
+
 func normalizeFloat(f float64) float64 {
 	switch {
 	case math.IsNaN(f):
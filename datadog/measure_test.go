@@ -1,10 +1,14 @@
+//go:build !statsoff
+
 package datadog
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
 )
 
 var (
@@ -37,6 +41,35 @@ var (
 			},
 			s: `request.count:5|c|#answer:42,hello:world
 request.rtt:0.1|h|#answer:42,hello:world
+`,
+		},
+
+		{
+			m: stats.Measure{
+				Name: "request",
+				Fields: []stats.Field{
+					stats.MakeField("count", 5, stats.Counter),
+					stats.MakeField("size", 1, stats.Gauge),
+				},
+				Tags: []stats.Tag{
+					stats.T("answer", "42"),
+				},
+				SampleRate: 0.1,
+			},
+			s: `request.count:5|c|@0.1|#answer:42
+request.size:1|g|#answer:42
+`,
+		},
+
+		{
+			m: stats.Measure{
+				Name: "request",
+				Fields: []stats.Field{
+					stats.MakeField("rtt", 100*time.Millisecond, stats.Histogram),
+				},
+				SampleRate: 0.5,
+			},
+			s: `request.rtt:0.1|h|@0.5
 `,
 		},
 	}
@@ -53,3 +86,88 @@ func TestAppendMeasure(t *testing.T) {
 		})
 	}
 }
+
+func TestAppendMeasureConstTags(t *testing.T) {
+	var b1, b2 []byte
+	filters := map[string]struct{}{"http_req_path": {}}
+
+	eng := stats.NewEngine("test", stats.HandlerFunc(func(_ time.Time, measures ...stats.Measure) {
+		for _, m := range measures {
+			if m.Const != nil {
+				b1 = AppendMeasureFiltered(b1, m, filters)
+			} else {
+				b2 = AppendMeasureFiltered(b2, m, filters)
+			}
+		}
+	}), stats.T("service", "test"))
+
+	h := eng.Handle("requests", stats.Counter, stats.T("method", "GET"), stats.T("http_req_path", "/"))
+
+	for i := 0; i != 2; i++ {
+		h.Measure(1)
+		eng.Add("requests", 1, stats.T("method", "GET"), stats.T("http_req_path", "/"))
+		h.Measure(2, stats.T("status", "200"))
+		eng.Add("requests", 2, stats.T("method", "GET"), stats.T("http_req_path", "/"), stats.T("status", "200"))
+	}
+
+	if string(b1) != string(b2) {
+		t.Errorf("serialized measures mismatch:\n%s\n%s", b1, b2)
+	}
+}
+
+func TestAppendMeasureSampledHandle(t *testing.T) {
+	var b []byte
+
+	eng := stats.NewEngine("test", stats.HandlerFunc(func(_ time.Time, measures ...stats.Measure) {
+		for _, m := range measures {
+			b = AppendMeasure(b, m)
+		}
+	}), stats.T("service", "test"))
+
+	h := eng.WithSampleRate(0.5).Handle("requests", stats.Counter, stats.T("method", "GET"))
+
+	for i := 0; i != 100; i++ {
+		h.Measure(1)
+	}
+
+	if len(b) == 0 {
+		t.Fatal("no measures were sampled")
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+		if line != "test.requests:1|c|@0.5|#method:GET,service:test" {
+			t.Errorf("bad metric representation: %q", line)
+		}
+	}
+}
+
+func TestAppendMeasureRedactedHandle(t *testing.T) {
+	var b []byte
+
+	eng := stats.NewEngine("app", &stats.Redactor{
+		Handler: stats.HandlerFunc(func(_ time.Time, measures ...stats.Measure) {
+			for _, m := range measures {
+				b = AppendMeasure(b, m)
+			}
+		}),
+		Tags: []string{"email"},
+	})
+
+	eng.Handle("logins", stats.Counter, stats.T("email", "bob@example.com")).Measure(1)
+
+	if s := string(b); s != "app.logins:1|c|#email:"+stats.RedactedValue+"\n" {
+		t.Errorf("bad metric representation: %q", s)
+	}
+}
+
+func TestAppendMeasureHostile(t *testing.T) {
+	appendMeasure := func(b []byte, m stats.Measure) []byte { return AppendMeasure(b, m) }
+
+	for _, m := range statstest.HostileMeasures() {
+		m = statstest.Sanitize(m)
+
+		if err := statstest.CheckRecords(appendMeasure, m, len(m.Fields)); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package datadog
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/stats"
+)
+
+func init() {
+	stats.RegisterScheme("dogstatsd", openURL)
+}
+
+// openURL constructs a datadog client from a URL of the form:
+//
+//	dogstatsd://host:port?buffer=1432&filters=http_req_path,user_id
+func openURL(u *url.URL) (stats.Handler, error) {
+	config := ClientConfig{Address: u.Host}
+	query := u.Query()
+
+	if s := query.Get("buffer"); len(s) != 0 {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+		config.BufferSize = n
+	}
+
+	if _, ok := query["filters"]; ok {
+		config.Filters = []string{}
+		for _, f := range strings.Split(query.Get("filters"), ",") {
+			if len(f) != 0 {
+				config.Filters = append(config.Filters, f)
+			}
+		}
+	}
+
+	return NewClientWith(config), nil
+}
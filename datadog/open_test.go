@@ -0,0 +1,28 @@
+package datadog
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+)
+
+func TestOpen(t *testing.T) {
+	h, err := stats.Open("dogstatsd://127.0.0.1:8125?buffer=1432&filters=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, ok := h.(*Client)
+	if !ok {
+		t.Fatalf("bad handler type: %T", h)
+	}
+	defer c.Close()
+
+	if c.bufferSize > 1432 {
+		t.Error("bad buffer size:", c.bufferSize)
+	}
+
+	if len(c.filters) != 0 {
+		t.Error("bad filters:", c.filters)
+	}
+}
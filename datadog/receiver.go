@@ -0,0 +1,184 @@
+package datadog
+
+import (
+	"errors"
+	"net"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// Receiver is a dogstatsd server which reports the metrics it receives to a
+// stats engine, making it possible to run programs using this package as
+// node-local aggregators.
+//
+// UDP receivers open multiple sockets bound to the same address with the
+// SO_REUSEPORT option, letting the kernel balance datagrams across them, and
+// read each socket from multiple goroutines. Unix datagram sockets cannot share
+// their address, a single socket is read from multiple goroutines.
+type Receiver struct {
+	// Network of the address that the receiver listens on, either "udp" or
+	// "unixgram". Defaults to "udp".
+	Network string
+
+	// Address that the receiver listens on. Defaults to DefaultAddress for UDP
+	// receivers.
+	Address string
+
+	// Number of sockets opened by UDP receivers. Defaults to GOMAXPROCS.
+	Sockets int
+
+	// The engine that received metrics are reported to. Defaults to
+	// stats.DefaultEngine.
+	Engine *stats.Engine
+
+	mutex  sync.Mutex
+	conns  []net.PacketConn
+	closed bool
+}
+
+// ListenAndServe opens the sockets of the receiver and serves datagrams until
+// the receiver is closed, in which case the method returns nil.
+func (r *Receiver) ListenAndServe() error {
+	conns, err := r.listen()
+	if err != nil {
+		return err
+	}
+	return r.Serve(conns...)
+}
+
+// Serve serves datagrams on conns until the receiver is closed, in which case
+// the method returns nil. The connections are owned by the receiver after the
+// call, this is useful to serve sockets which were not opened by the receiver
+// (passed by systemd socket activation for example).
+func (r *Receiver) Serve(conns ...net.PacketConn) (err error) {
+	r.mutex.Lock()
+	closed := r.closed
+	r.conns = conns
+	r.mutex.Unlock()
+
+	if closed {
+		for _, conn := range conns {
+			conn.Close()
+		}
+		return nil
+	}
+
+	errs := make(chan error, len(conns))
+
+	for _, conn := range conns {
+		go func(conn net.PacketConn) { errs <- Serve(conn, r) }(conn)
+	}
+
+	for range conns {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+
+	r.mutex.Lock()
+	if r.closed {
+		err = nil
+	}
+	r.mutex.Unlock()
+	return err
+}
+
+// Close closes the sockets of the receiver, causing ListenAndServe to return.
+func (r *Receiver) Close() error {
+	r.mutex.Lock()
+	conns := r.conns
+	r.conns, r.closed = nil, true
+	r.mutex.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+
+	return nil
+}
+
+// Addr returns the address that the receiver listens on, or nil if it is not
+// serving.
+func (r *Receiver) Addr() net.Addr {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.conns) == 0 {
+		return nil
+	}
+
+	return r.conns[0].LocalAddr()
+}
+
+// HandleMetric satisfies the Handler interface.
+func (r *Receiver) HandleMetric(m Metric, _ net.Addr) {
+	eng := r.engine()
+	now := time.Now()
+
+	switch m.Type {
+	case Counter:
+		value := m.Value
+		if m.Rate > 0 && m.Rate < 1 {
+			value /= m.Rate
+		}
+		eng.AddAt(now, m.Name, value, m.Tags...)
+
+	case Gauge:
+		eng.SetAt(now, m.Name, m.Value, m.Tags...)
+
+	case Histogram:
+		eng.ObserveAt(now, m.Name, m.Value, m.Tags...)
+	}
+}
+
+// HandleEvent satisfies the Handler interface, events are not metrics so they
+// are ignored by receivers.
+func (r *Receiver) HandleEvent(Event, net.Addr) {}
+
+func (r *Receiver) listen() ([]net.PacketConn, error) {
+	switch network := r.network(); network {
+	case "unixgram":
+		conn, err := net.ListenPacket(network, r.Address)
+		if err != nil {
+			return nil, err
+		}
+		return []net.PacketConn{conn}, nil
+
+	case "udp", "udp4", "udp6":
+		return listenReusePort(network, r.address(), r.sockets())
+
+	default:
+		return nil, errors.New("stats/datadog: unsupported receiver network: " + network)
+	}
+}
+
+func (r *Receiver) network() string {
+	if len(r.Network) != 0 {
+		return r.Network
+	}
+	return "udp"
+}
+
+func (r *Receiver) address() string {
+	if len(r.Address) != 0 {
+		return r.Address
+	}
+	return DefaultAddress
+}
+
+func (r *Receiver) sockets() int {
+	if r.Sockets > 0 {
+		return r.Sockets
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+func (r *Receiver) engine() *stats.Engine {
+	if r.Engine != nil {
+		return r.Engine
+	}
+	return stats.DefaultEngine
+}
@@ -0,0 +1,79 @@
+//go:build !statsoff
+
+package datadog
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestReceiver(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "datadog-receiver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	tests := []struct {
+		network string
+		address string
+	}{
+		{network: "udp", address: "127.0.0.1:0"},
+		{network: "unixgram", address: filepath.Join(tmp, "dogstatsd.sock")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.network, func(t *testing.T) {
+			h := &statstest.Handler{}
+			r := &Receiver{
+				Network: test.network,
+				Address: test.address,
+				Sockets: 2,
+				Engine:  stats.NewEngine("", h),
+			}
+
+			errs := make(chan error, 1)
+			go func() { errs <- r.ListenAndServe() }()
+
+			var addr net.Addr
+			for addr == nil {
+				time.Sleep(time.Millisecond)
+				addr = r.Addr()
+			}
+
+			conn, err := net.Dial(addr.Network(), addr.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			conn.Write([]byte("A:1|c|@0.5|#answer:42\nB:2|g\nC:3|h\n_e{1,1}:x|y\n"))
+
+			for i := 0; i != 100 && len(h.Measures()) < 3; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			values := map[string]float64{}
+			for _, m := range h.Measures() {
+				values[m.Name] = m.Fields[0].Value.Float()
+			}
+
+			if values["A"] != 2 || values["B"] != 2 || values["C"] != 3 {
+				t.Error("bad values:", values)
+			}
+
+			r.Close()
+
+			if err := <-errs; err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
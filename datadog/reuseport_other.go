@@ -0,0 +1,15 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package datadog
+
+import "net"
+
+// SO_REUSEPORT is not available on this platform, a single socket is opened
+// regardless of the number of sockets requested.
+func listenReusePort(network string, address string, sockets int) ([]net.PacketConn, error) {
+	conn, err := net.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return []net.PacketConn{conn}, nil
+}
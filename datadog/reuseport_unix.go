@@ -0,0 +1,42 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package datadog
+
+import (
+	"context"
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func listenReusePort(network string, address string, sockets int) ([]net.PacketConn, error) {
+	config := net.ListenConfig{Control: reusePort}
+	conns := make([]net.PacketConn, 0, sockets)
+
+	for i := 0; i != sockets; i++ {
+		conn, err := config.ListenPacket(context.Background(), network, address)
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return nil, err
+		}
+
+		// When listening on a random port the following sockets must be bound
+		// to the port that was picked for the first one.
+		address = conn.LocalAddr().String()
+		conns = append(conns, conn)
+	}
+
+	return conns, nil
+}
+
+func reusePort(network string, address string, conn syscall.RawConn) (err error) {
+	if e := conn.Control(func(fd uintptr) {
+		err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	}); e != nil {
+		err = e
+	}
+	return
+}
@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// JSONDecoder decodes the stream of measures produced by JSONEncoder, which is
+// useful to relay the measures written by a program to another one.
+//
+// Since durations are encoded as numbers of seconds, they are decoded as float
+// values. Fields with null values (which JSONEncoder produces for values that
+// are not finite numbers) are skipped.
+type JSONDecoder struct {
+	dec *json.Decoder
+}
+
+// NewJSONDecoder returns a decoder reading measures from r.
+func NewJSONDecoder(r io.Reader) *JSONDecoder {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &JSONDecoder{dec: dec}
+}
+
+type jsonMeasure struct {
+	Time       time.Time         `json:"time"`
+	Name       string            `json:"name"`
+	Fields     []jsonField       `json:"fields"`
+	Tags       map[string]string `json:"tags"`
+	SampleRate float64           `json:"sample_rate"`
+}
+
+type jsonField struct {
+	Name  string          `json:"name"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Decode decodes the next measure of the stream and the time it was taken at.
+// The method returns io.EOF when the end of the stream was reached.
+func (d *JSONDecoder) Decode() (time.Time, Measure, error) {
+	var jm jsonMeasure
+
+	if err := d.dec.Decode(&jm); err != nil {
+		return time.Time{}, Measure{}, err
+	}
+
+	m := Measure{
+		Name:       jm.Name,
+		Fields:     make([]Field, 0, len(jm.Fields)),
+		SampleRate: jm.SampleRate,
+	}
+
+	for _, jf := range jm.Fields {
+		ftype, err := parseFieldType(jf.Type)
+		if err != nil {
+			return time.Time{}, Measure{}, err
+		}
+
+		value, err := parseJSONValue(jf.Value)
+		if err != nil {
+			return time.Time{}, Measure{}, err
+		}
+
+		if value.Type() == Null {
+			continue
+		}
+
+		f := Field{Name: jf.Name, Value: value}
+		f.setType(ftype)
+		m.Fields = append(m.Fields, f)
+	}
+
+	if len(jm.Tags) != 0 {
+		m.Tags = make([]Tag, 0, len(jm.Tags))
+		for name, value := range jm.Tags {
+			m.Tags = append(m.Tags, Tag{Name: name, Value: value})
+		}
+		SortTags(m.Tags)
+	}
+
+	return jm.Time, m, nil
+}
+
+func parseFieldType(s string) (FieldType, error) {
+	switch s {
+	case "counter":
+		return Counter, nil
+	case "gauge":
+		return Gauge, nil
+	case "histogram":
+		return Histogram, nil
+	}
+	return 0, fmt.Errorf("stats: invalid field type: %q", s)
+}
+
+func parseJSONValue(b []byte) (Value, error) {
+	switch b = bytes.TrimSpace(b); string(b) {
+	case "", "null":
+		return Value{}, nil
+	case "true":
+		return boolValue(true), nil
+	case "false":
+		return boolValue(false), nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return Value{}, fmt.Errorf("stats: invalid field value: %s", b)
+	}
+
+	if i, err := n.Int64(); err == nil {
+		return int64Value(i), nil
+	}
+
+	if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+		return uint64Value(u), nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return Value{}, fmt.Errorf("stats: invalid field value: %s", b)
+	}
+	return float64Value(f), nil
+}
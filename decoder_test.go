@@ -0,0 +1,62 @@
+package stats_test
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+func TestJSONDecoder(t *testing.T) {
+	now := time.Date(2017, 6, 4, 22, 12, 0, 0, time.UTC)
+
+	measures := []stats.Measure{
+		{
+			Name: "http",
+			Fields: []stats.Field{
+				stats.MakeField("count", int64(1)<<60, stats.Counter),
+				stats.MakeField("size", uint64(math.MaxUint64), stats.Gauge),
+				stats.MakeField("ok", true, stats.Gauge),
+				stats.MakeField("nan", math.NaN(), stats.Gauge),
+				stats.MakeField("rtt", 0.25, stats.Histogram),
+			},
+			Tags:       []stats.Tag{stats.T("a", "1"), stats.T("b", "2")},
+			SampleRate: 0.5,
+		},
+		{
+			Name:   "queue",
+			Fields: []stats.Field{stats.MakeField("", -1, stats.Gauge)},
+		},
+	}
+
+	b := stats.JSONEncoder{}.AppendMeasures(nil, now, measures...)
+	d := stats.NewJSONDecoder(bytes.NewReader(b))
+
+	var found []stats.Measure
+
+	for {
+		tm, m, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tm.Equal(now) {
+			t.Errorf("bad time: %v", tm)
+		}
+		found = append(found, m)
+	}
+
+	// Null values are not decoded.
+	measures[0].Fields = append(measures[0].Fields[:3], measures[0].Fields[4])
+	measures[1].Fields[0] = stats.MakeField("", int64(-1), stats.Gauge)
+
+	if !reflect.DeepEqual(found, measures) {
+		t.Errorf("bad measures:\nexpected: %v\nfound:    %v", measures, found)
+	}
+}
@@ -0,0 +1,406 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DerivedMetrics is a measure handler which computes metrics defined as
+// arithmetic expressions over the other metrics, like error rates or cache hit
+// ratios:
+//
+//	d := &stats.DerivedMetrics{Handler: h}
+//	d.Register("myapp.http:error_rate", "myapp.http:errors / myapp.http:requests")
+//	d.Register("myapp.cache:hit_ratio", "myapp.cache:hits / (myapp.cache:hits + myapp.cache:misses)")
+//
+// Metrics are referenced in expressions by the name of their measure and field
+// separated by a colon (or by the name of the measure only for fields with no
+// names), the same way they are named when produced by an engine. Expressions
+// may use numbers, parentheses, and the + - * / operators.
+//
+// Names may contain dashes, so the - operator must be separated from the names
+// that precede it by spaces ("a - b" is a subtraction, "a-b" a name).
+//
+// The values of the metrics are aggregated until the handler is flushed: the
+// values of counters are summed, and the last values of gauges and histograms
+// are retained. When flushed, the handler evaluates each expression for the
+// combinations of tags that at least one of the metrics it references was
+// seen with, and forwards the results as gauges. Results which are not finite
+// numbers (like divisions by zero) are discarded.
+//
+// The metrics referenced by an expression do not need to carry the same tags.
+// When a metric was not seen with the combination of tags an expression is
+// evaluated for, its value is taken from the most specific combination that
+// has a subset of the tags, or is the sum of its values for the combinations
+// that have more tags. For example, if errors are tagged with a status and
+// requests are not, the error rate of each status is computed from the total
+// number of requests, and the overall error rate from the sum of the errors.
+// Metrics which were not seen at all in a window are zero.
+//
+// Measures are forwarded unchanged to the base handler.
+type DerivedMetrics struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	mutex   sync.Mutex
+	metrics []derivedMetric
+	inputs  map[string]bool
+	values  map[string]*derivedValues // by combination of tags
+	keys    []byte
+}
+
+type derivedMetric struct {
+	measure string
+	field   string
+	expr    derivedExpr
+	inputs  []string
+}
+
+type derivedValues struct {
+	tags   []Tag
+	values map[string]float64 // by metric name
+}
+
+// Register adds a metric named name, computed from the expression expr. The
+// method returns an error if the expression is invalid.
+func (d *DerivedMetrics) Register(name string, expr string) error {
+	e, err := parseDerivedExpr(expr)
+	if err != nil {
+		return fmt.Errorf("stats: invalid expression of derived metric %s: %s", name, err)
+	}
+
+	measure, field := splitMeasureField(name)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.inputs == nil {
+		d.inputs = make(map[string]bool)
+	}
+
+	m := derivedMetric{measure: measure, field: field, expr: e}
+
+	e.names(func(name string) {
+		d.inputs[name] = true
+		m.inputs = append(m.inputs, name)
+	})
+
+	d.metrics = append(d.metrics, m)
+	return nil
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (d *DerivedMetrics) HandleMeasures(t time.Time, measures ...Measure) {
+	d.mutex.Lock()
+
+	for _, m := range measures {
+		for _, f := range m.Fields {
+			name := m.Name
+			if len(f.Name) != 0 {
+				name += ":" + f.Name
+			}
+
+			if d.inputs[name] {
+				v := d.lookup(m.Tags)
+				if f.Type() == Counter {
+					v.values[name] += valueToFloat(unsample(f.Value, m.SampleRate))
+				} else {
+					v.values[name] = valueToFloat(f.Value)
+				}
+			}
+		}
+	}
+
+	d.mutex.Unlock()
+
+	d.Handler.HandleMeasures(t, measures...)
+}
+
+// Flush satisfies the Flusher interface, it forwards the derived metrics to
+// the base handler, then flushes it.
+func (d *DerivedMetrics) Flush() {
+	var measures []Measure
+
+	d.mutex.Lock()
+
+	keys := make([]string, 0, len(d.values))
+	for key := range d.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		v := d.values[key]
+
+		for _, m := range d.metrics {
+			if !v.seen(m.inputs) {
+				continue
+			}
+
+			r := m.expr.eval(func(name string) float64 { return d.value(v, name) })
+
+			if !math.IsNaN(r) && !math.IsInf(r, 0) {
+				measures = append(measures, Measure{
+					Name:   m.measure,
+					Fields: []Field{MakeField(m.field, r, Gauge)},
+					Tags:   v.tags,
+				})
+			}
+		}
+	}
+
+	d.values = nil
+	d.mutex.Unlock()
+
+	if len(measures) != 0 {
+		d.Handler.HandleMeasures(time.Now(), measures...)
+	}
+
+	flush(d.Handler)
+}
+
+// value returns the value of the metric identified by name for the tags of v,
+// see the documentation of DerivedMetrics for the combination of tags.
+func (d *DerivedMetrics) value(v *derivedValues, name string) float64 {
+	if x, ok := v.values[name]; ok {
+		return x
+	}
+
+	var subset *derivedValues
+	var sum float64
+
+	for _, u := range d.values {
+		x, ok := u.values[name]
+		switch {
+		case !ok:
+		case hasTags(v.tags, u.tags):
+			if subset == nil || len(u.tags) > len(subset.tags) {
+				subset = u
+			}
+		case hasTags(u.tags, v.tags):
+			sum += x
+		}
+	}
+
+	if subset != nil {
+		return subset.values[name]
+	}
+
+	return sum
+}
+
+func (d *DerivedMetrics) lookup(tags []Tag) *derivedValues {
+	d.keys = appendSeriesKey(d.keys[:0], "", "", tags)
+	v := d.values[string(d.keys)]
+
+	if v == nil {
+		if d.values == nil {
+			d.values = make(map[string]*derivedValues)
+		}
+		v = &derivedValues{
+			tags:   copyTags(tags),
+			values: make(map[string]float64),
+		}
+		d.values[string(d.keys)] = v
+	}
+
+	return v
+}
+
+// seen returns true if any of the metrics identified by names were seen with
+// the tags of v.
+func (v *derivedValues) seen(names []string) bool {
+	for _, name := range names {
+		if _, ok := v.values[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// derivedExpr is the syntax tree of the expressions of derived metrics.
+type derivedExpr interface {
+	eval(lookup func(string) float64) float64
+	names(f func(string))
+}
+
+type derivedNumber float64
+
+type derivedName string
+
+type derivedBinary struct {
+	op    byte
+	left  derivedExpr
+	right derivedExpr
+}
+
+type derivedNegate struct {
+	expr derivedExpr
+}
+
+func (n derivedNumber) eval(func(string) float64) float64 { return float64(n) }
+
+func (n derivedNumber) names(func(string)) {}
+
+func (n derivedName) eval(lookup func(string) float64) float64 { return lookup(string(n)) }
+
+func (n derivedName) names(f func(string)) { f(string(n)) }
+
+func (e derivedNegate) eval(lookup func(string) float64) float64 { return -e.expr.eval(lookup) }
+
+func (e derivedNegate) names(f func(string)) { e.expr.names(f) }
+
+func (e derivedBinary) eval(lookup func(string) float64) float64 {
+	a, b := e.left.eval(lookup), e.right.eval(lookup)
+	switch e.op {
+	case '+':
+		return a + b
+	case '-':
+		return a - b
+	case '*':
+		return a * b
+	default:
+		return a / b
+	}
+}
+
+func (e derivedBinary) names(f func(string)) {
+	e.left.names(f)
+	e.right.names(f)
+}
+
+// derivedParser is a recursive descent parser of the expressions of derived
+// metrics, with the following grammar:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = factor { ("*" | "/") factor }
+//	factor = number | name | "(" expr ")" | "-" factor
+type derivedParser struct {
+	s string
+	i int
+}
+
+func parseDerivedExpr(s string) (derivedExpr, error) {
+	p := &derivedParser{s: s}
+
+	e, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.skip(); p.i != len(p.s) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.s[p.i], p.i)
+	}
+
+	return e, nil
+}
+
+func (p *derivedParser) expr() (derivedExpr, error) {
+	return p.binary(p.term, '+', '-')
+}
+
+func (p *derivedParser) term() (derivedExpr, error) {
+	return p.binary(p.factor, '*', '/')
+}
+
+func (p *derivedParser) binary(operand func() (derivedExpr, error), op1 byte, op2 byte) (derivedExpr, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		p.skip()
+
+		if p.i == len(p.s) || (p.s[p.i] != op1 && p.s[p.i] != op2) {
+			return left, nil
+		}
+
+		op := p.s[p.i]
+		p.i++
+
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		left = derivedBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *derivedParser) factor() (derivedExpr, error) {
+	p.skip()
+
+	if p.i == len(p.s) {
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	switch c := p.s[p.i]; {
+	case c == '(':
+		p.i++
+
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.skip(); p.i == len(p.s) || p.s[p.i] != ')' {
+			return nil, errors.New("missing closing parenthesis")
+		}
+
+		p.i++
+		return e, nil
+
+	case c == '-':
+		p.i++
+
+		e, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+
+		return derivedNegate{expr: e}, nil
+
+	case (c >= '0' && c <= '9') || c == '.':
+		j := p.scan(func(c byte) bool { return (c >= '0' && c <= '9') || c == '.' })
+
+		v, err := strconv.ParseFloat(p.s[p.i:j], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.s[p.i:j])
+		}
+
+		p.i = j
+		return derivedNumber(v), nil
+
+	case isDerivedNameByte(c):
+		j := p.scan(isDerivedNameByte)
+		name := derivedName(p.s[p.i:j])
+		p.i = j
+		return name, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected %q at offset %d", c, p.i)
+	}
+}
+
+func (p *derivedParser) skip() {
+	p.i = p.scan(func(c byte) bool { return c == ' ' || c == '\t' })
+}
+
+func (p *derivedParser) scan(f func(byte) bool) int {
+	j := p.i
+	for j < len(p.s) && f(p.s[j]) {
+		j++
+	}
+	return j
+}
+
+func isDerivedNameByte(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+		c == '_' || c == '.' || c == ':' || c == '-'
+}
@@ -0,0 +1,133 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestDerivedMetrics(t *testing.T) {
+	h := &statstest.Handler{}
+	d := &stats.DerivedMetrics{Handler: h}
+
+	if err := d.Register("test.http:error_rate", "test.http:errors / test.http:requests"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Register("test.cache:hit_ratio", "100 * test.cache:hits / (test.cache:hits + test.cache:misses)"); err != nil {
+		t.Fatal(err)
+	}
+
+	eng := stats.NewEngine("test", d)
+	eng.Add("http:requests", 10, stats.T("path", "/a"))
+	eng.Add("http:requests", 10, stats.T("path", "/a"))
+	eng.Add("http:errors", 5, stats.T("path", "/a"))
+	eng.Add("http:requests", 10, stats.T("path", "/b"))
+	eng.Set("cache:hits", 3)
+	eng.Set("cache:misses", 1)
+
+	h.Clear()
+	eng.Flush()
+
+	values := map[string]float64{}
+	for _, m := range h.Measures() {
+		key := m.Name + ":" + m.Fields[0].Name
+		for _, tag := range m.Tags {
+			key += " " + tag.String()
+		}
+		values[key] = m.Fields[0].Value.Float()
+
+		if m.Fields[0].Type() != stats.Gauge {
+			t.Error("derived metrics must be gauges:", m)
+		}
+	}
+
+	expected := map[string]float64{
+		"test.http:error_rate path=/a": 0.25,
+		"test.http:error_rate path=/b": 0,
+		"test.cache:hit_ratio":         75,
+	}
+
+	if len(values) != len(expected) {
+		t.Errorf("bad derived metrics: %v", values)
+	}
+
+	for key, value := range expected {
+		if values[key] != value {
+			t.Errorf("bad value of %s: %g != %g", key, values[key], value)
+		}
+	}
+
+	h.Clear()
+	eng.Flush()
+
+	if found := h.Measures(); len(found) != 0 {
+		t.Error("derived metrics were reported without new measures:", found)
+	}
+}
+
+func TestDerivedMetricsInvalidExpression(t *testing.T) {
+	d := &stats.DerivedMetrics{Handler: stats.Discard}
+
+	for _, expr := range []string{"", "a +", "(a + b", "a b", "a / * b", "1..2"} {
+		if err := d.Register("test", expr); err == nil {
+			t.Errorf("no error returned for the invalid expression %q", expr)
+		}
+	}
+}
+
+func TestDerivedMetricsExtraTags(t *testing.T) {
+	h := &statstest.Handler{}
+	d := &stats.DerivedMetrics{Handler: h}
+
+	if err := d.Register("test.http:error_rate", "test.http:errors / test.http:requests"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Register("test.http:latency", "test.http:rtt-ms / 1000"); err != nil {
+		t.Fatal(err)
+	}
+
+	eng := stats.NewEngine("test", d)
+	eng.Add("http:requests", 10)
+	eng.Add("http:errors", 1, stats.T("status", "500"))
+	eng.Add("http:errors", 3, stats.T("status", "503"))
+	eng.Set("http:rtt-ms", 250, stats.T("path", "/a"))
+
+	h.Clear()
+	eng.Flush()
+
+	h.ExpectGauge(t, "test.http:error_rate", 0.1, stats.T("status", "500"))
+	h.ExpectGauge(t, "test.http:error_rate", 0.3, stats.T("status", "503"))
+	h.ExpectGauge(t, "test.http:latency", 0.25, stats.T("path", "/a"))
+
+	overall := false
+
+	for _, m := range h.Measures() {
+		switch m.Fields[0].Name {
+		case "error_rate":
+			if len(m.Tags) == 0 {
+				overall = true
+				if m.Fields[0].Value.Float() != 0.4 {
+					t.Error("bad overall error rate:", m)
+				}
+			}
+			// The error rate is not computed for the path, which none of its
+			// inputs was seen with.
+			if len(m.Tags) != 0 && m.Tags[0].Name == "path" {
+				t.Error("error rate computed for unrelated tags:", m)
+			}
+		case "latency":
+			if len(m.Tags) == 0 || m.Tags[0].Name != "path" {
+				t.Error("latency computed for unrelated tags:", m)
+			}
+		}
+	}
+
+	if !overall {
+		t.Error("the overall error rate was not computed")
+	}
+}
@@ -0,0 +1,83 @@
+package stats
+
+import "sync"
+
+// Description carries the metadata of a metric which backends can expose, for
+// example as "# HELP" and "# TYPE" lines of the Prometheus text format.
+type Description struct {
+	Help string
+	Type FieldType
+}
+
+// DescriptionRegistry maps metrics to their descriptions, so the help text of
+// metrics is registered once instead of being carried by every measure.
+//
+// DescriptionRegistry values are safe to use concurrently from multiple
+// goroutines.
+type DescriptionRegistry struct {
+	mutex        sync.RWMutex
+	descriptions map[Key]Description
+}
+
+// Set sets the description of the metric identified by key, which is the name
+// of a measure and field separated by a colon (or the name of a measure only
+// for fields with no names), including the prefix of the engine that produces
+// the metric.
+func (r *DescriptionRegistry) Set(key string, desc Description) {
+	r.set(makeKey(key), desc)
+}
+
+// Lookup returns the description of the metric identified by the given measure
+// and field names, and a boolean indicating whether one was found.
+func (r *DescriptionRegistry) Lookup(measure string, field string) (Description, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	desc, ok := r.descriptions[Key{Measure: measure, Field: field}]
+	return desc, ok
+}
+
+// Help returns the help text of the metric identified by the given measure and
+// field names, or an empty string if none was set.
+func (r *DescriptionRegistry) Help(measure string, field string) string {
+	desc, _ := r.Lookup(measure, field)
+	return desc.Help
+}
+
+func (r *DescriptionRegistry) set(key Key, desc Description) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.descriptions == nil {
+		r.descriptions = make(map[Key]Description)
+	}
+
+	r.descriptions[key] = desc
+}
+
+// Descriptions is the registry of metric descriptions used by default by
+// engines and backends.
+var Descriptions = &DescriptionRegistry{}
+
+// Describe registers the help text and type of the metric identified by name,
+// the prefix of eng is added to the name. The description is set in the
+// engine's registry, or Descriptions if the engine has none.
+func (eng *Engine) Describe(name string, ftype FieldType, help string) {
+	measure, field := splitMeasureField(name)
+	eng.descriptions().set(Key{Measure: eng.makeName(measure), Field: field}, Description{
+		Help: help,
+		Type: ftype,
+	})
+}
+
+func (eng *Engine) descriptions() *DescriptionRegistry {
+	if eng.Descriptions != nil {
+		return eng.Descriptions
+	}
+	return Descriptions
+}
+
+// Describe registers the help text and type of the metric identified by name on
+// the default engine.
+func Describe(name string, ftype FieldType, help string) {
+	DefaultEngine.Describe(name, ftype, help)
+}
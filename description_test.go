@@ -0,0 +1,43 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestEngineDescribe(t *testing.T) {
+	r := &stats.DescriptionRegistry{}
+	eng := stats.NewEngine("test", &statstest.Handler{})
+	eng.Descriptions = r
+
+	eng.WithPrefix("http").Describe("requests:count", stats.Counter, "Number of requests served.")
+
+	desc, ok := r.Lookup("test.http.requests", "count")
+
+	if !ok {
+		t.Fatal("the description was not registered")
+	}
+
+	if desc.Help != "Number of requests served." || desc.Type != stats.Counter {
+		t.Errorf("bad description: %+v", desc)
+	}
+
+	if _, ok := stats.Descriptions.Lookup("test.http.requests", "count"); ok {
+		t.Error("the description was registered in the default registry")
+	}
+}
+
+func TestDescriptionRegistry(t *testing.T) {
+	r := &stats.DescriptionRegistry{}
+	r.Set("queue:size", stats.Description{Help: "Size of the queue.", Type: stats.Gauge})
+
+	if help := r.Help("queue", "size"); help != "Size of the queue." {
+		t.Error("bad help:", help)
+	}
+
+	if help := r.Help("queue", "count"); help != "" {
+		t.Error("bad help:", help)
+	}
+}
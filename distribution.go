@@ -0,0 +1,255 @@
+package stats
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+// Distribution is a histogram of observed values aggregated into buckets.
+//
+// Distributions are mergeable: two distributions with the same buckets can be
+// combined, and their binary representation can be exchanged between processes
+// which makes it possible to aggregate histograms across a fleet of programs
+// before sending them to a metric collection backend.
+//
+// Distribution values are not safe to use concurrently from multiple
+// goroutines.
+type Distribution struct {
+	// Upper bounds of the buckets, sorted in ascending order. Values greater
+	// than the last bound are counted in an implicit +Inf bucket.
+	Bounds []float64
+
+	// Number of observations in each bucket, the slice has one more element
+	// than Bounds to account for the +Inf bucket.
+	Counts []uint64
+
+	Count uint64  // total number of observations
+	Sum   float64 // sum of all observed values
+	Min   float64 // smallest observed value
+	Max   float64 // largest observed value
+}
+
+// NewDistribution returns a distribution with the given bucket upper bounds.
+func NewDistribution(bounds ...float64) *Distribution {
+	b := make([]float64, len(bounds))
+	copy(b, bounds)
+	sort.Float64s(b)
+	return &Distribution{
+		Bounds: b,
+		Counts: make([]uint64, len(b)+1),
+	}
+}
+
+// Observe adds v to the distribution.
+func (d *Distribution) Observe(v float64) {
+	d.ObserveN(v, 1)
+}
+
+// ObserveN adds n observations of v to the distribution.
+func (d *Distribution) ObserveN(v float64, n uint64) {
+	if n == 0 || math.IsNaN(v) {
+		return
+	}
+
+	if len(d.Counts) != len(d.Bounds)+1 {
+		d.Counts = append(d.Counts, make([]uint64, len(d.Bounds)+1-len(d.Counts))...)
+	}
+
+	d.Counts[sort.SearchFloat64s(d.Bounds, v)] += n
+
+	if d.Count == 0 || v < d.Min {
+		d.Min = v
+	}
+
+	if d.Count == 0 || v > d.Max {
+		d.Max = v
+	}
+
+	d.Count += n
+	d.Sum += v * float64(n)
+}
+
+// Merge adds the observations of other to d. Both distributions must have the
+// same buckets.
+func (d *Distribution) Merge(other *Distribution) error {
+	if !equalBounds(d.Bounds, other.Bounds) {
+		return errBoundsMismatch
+	}
+
+	if other.Count == 0 {
+		return nil
+	}
+
+	if len(d.Counts) != len(d.Bounds)+1 {
+		d.Counts = make([]uint64, len(d.Bounds)+1)
+	}
+
+	for i, n := range other.Counts {
+		if i < len(d.Counts) {
+			d.Counts[i] += n
+		}
+	}
+
+	if d.Count == 0 || other.Min < d.Min {
+		d.Min = other.Min
+	}
+
+	if d.Count == 0 || other.Max > d.Max {
+		d.Max = other.Max
+	}
+
+	d.Count += other.Count
+	d.Sum += other.Sum
+	return nil
+}
+
+// Reset clears all observations from d, keeping its buckets.
+func (d *Distribution) Reset() {
+	for i := range d.Counts {
+		d.Counts[i] = 0
+	}
+	d.Count, d.Sum, d.Min, d.Max = 0, 0, 0, 0
+}
+
+// Clone returns a deep copy of d.
+func (d *Distribution) Clone() *Distribution {
+	c := *d
+	c.Bounds = append([]float64(nil), d.Bounds...)
+	c.Counts = append([]uint64(nil), d.Counts...)
+	return &c
+}
+
+const distributionVersion = 1
+
+var (
+	errBoundsMismatch      = errors.New("stats: cannot merge distributions with different buckets")
+	errInvalidDistribution = errors.New("stats: invalid distribution encoding")
+	errDistributionVersion = errors.New("stats: unsupported distribution encoding version")
+)
+
+// MarshalBinary satisfies the encoding.BinaryMarshaler interface.
+func (d *Distribution) MarshalBinary() ([]byte, error) {
+	return d.AppendBinary(nil), nil
+}
+
+// AppendBinary appends the binary representation of d to b.
+func (d *Distribution) AppendBinary(b []byte) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+
+	appendUvarint := func(b []byte, v uint64) []byte {
+		return append(b, tmp[:binary.PutUvarint(tmp[:], v)]...)
+	}
+
+	appendFloat := func(b []byte, f float64) []byte {
+		binary.LittleEndian.PutUint64(tmp[:8], math.Float64bits(f))
+		return append(b, tmp[:8]...)
+	}
+
+	b = append(b, distributionVersion)
+	b = appendUvarint(b, uint64(len(d.Bounds)))
+
+	for _, bound := range d.Bounds {
+		b = appendFloat(b, bound)
+	}
+
+	for i := 0; i <= len(d.Bounds); i++ {
+		var n uint64
+		if i < len(d.Counts) {
+			n = d.Counts[i]
+		}
+		b = appendUvarint(b, n)
+	}
+
+	b = appendUvarint(b, d.Count)
+	b = appendFloat(b, d.Sum)
+	b = appendFloat(b, d.Min)
+	b = appendFloat(b, d.Max)
+	return b
+}
+
+// UnmarshalBinary satisfies the encoding.BinaryUnmarshaler interface.
+func (d *Distribution) UnmarshalBinary(b []byte) error {
+	if len(b) == 0 {
+		return errInvalidDistribution
+	}
+
+	if b[0] != distributionVersion {
+		return errDistributionVersion
+	}
+
+	b = b[1:]
+	var err error
+
+	readUvarint := func() uint64 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			err = errInvalidDistribution
+			return 0
+		}
+		b = b[n:]
+		return v
+	}
+
+	readFloat := func() float64 {
+		if len(b) < 8 {
+			err = errInvalidDistribution
+			return 0
+		}
+		f := math.Float64frombits(binary.LittleEndian.Uint64(b))
+		b = b[8:]
+		return f
+	}
+
+	n := readUvarint()
+	if err != nil || n > uint64(len(b)/8) {
+		return errInvalidDistribution
+	}
+
+	bounds := make([]float64, n)
+	counts := make([]uint64, n+1)
+
+	for i := range bounds {
+		bounds[i] = readFloat()
+	}
+
+	for i := range counts {
+		counts[i] = readUvarint()
+	}
+
+	count := readUvarint()
+	sum := readFloat()
+	min := readFloat()
+	max := readFloat()
+
+	if err != nil {
+		return err
+	}
+
+	if len(b) != 0 {
+		return errInvalidDistribution
+	}
+
+	*d = Distribution{
+		Bounds: bounds,
+		Counts: counts,
+		Count:  count,
+		Sum:    sum,
+		Min:    min,
+		Max:    max,
+	}
+	return nil
+}
+
+func equalBounds(b1 []float64, b2 []float64) bool {
+	if len(b1) != len(b2) {
+		return false
+	}
+	for i := range b1 {
+		if b1[i] != b2[i] {
+			return false
+		}
+	}
+	return true
+}
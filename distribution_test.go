@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDistribution(t *testing.T) {
+	d1 := NewDistribution(1, 5, 10)
+	d2 := NewDistribution(1, 5, 10)
+
+	for _, v := range []float64{0.5, 2, 7} {
+		d1.Observe(v)
+	}
+
+	for _, v := range []float64{3, 20} {
+		d2.Observe(v)
+	}
+
+	b, err := d2.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d3 := &Distribution{}
+	if err := d3.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(d2, d3) {
+		t.Errorf("distributions mismatch after decoding:\n%#v\n%#v", d2, d3)
+	}
+
+	if err := d1.Merge(d3); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Distribution{
+		Bounds: []float64{1, 5, 10},
+		Counts: []uint64{1, 2, 1, 1},
+		Count:  5,
+		Sum:    32.5,
+		Min:    0.5,
+		Max:    20,
+	}
+
+	if !reflect.DeepEqual(d1, expected) {
+		t.Errorf("bad merged distribution:\n%#v\n%#v", d1, expected)
+	}
+
+	if err := d1.Merge(NewDistribution(1, 2)); err == nil {
+		t.Error("no error returned when merging distributions with different buckets")
+	}
+
+	for i := 0; i < len(b); i++ {
+		if err := (&Distribution{}).UnmarshalBinary(b[:i]); err == nil {
+			t.Error("no error returned when decoding a truncated distribution of length", i)
+		}
+	}
+}
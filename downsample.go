@@ -0,0 +1,284 @@
+package stats
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Downsampler is a measure handler which aggregates counters and gauges over
+// fixed time windows before forwarding them to its base handler, reducing the
+// volume of data exported by programs that produce the same metrics at a high
+// rate.
+//
+// Within a window, counter values of the same series (measure name, field
+// name, and tags) are summed and only the last value of gauges is retained.
+// Histograms are distributions of values and are forwarded unchanged.
+//
+// Aggregated measures are forwarded when a measure that falls in a new window
+// is received, or when the downsampler is flushed after the end of their
+// window. Closing the downsampler forwards all the aggregated measures.
+//
+// Groups of measures can be aggregated over windows of different durations by
+// setting Intervals, for example to forward the metrics of HTTP handlers every
+// 5s but those of background collectors every 60s. Each group has its own
+// windows, measures of a group only cause the measures aggregated in the same
+// group to be forwarded.
+type Downsampler struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// Duration of the aggregation windows. Defaults to 10s.
+	Interval time.Duration
+
+	// Durations of the aggregation windows of measures whose names start with
+	// the keys of the map, the longest matching prefix is used. Measures that
+	// match none of the prefixes are aggregated over Interval.
+	//
+	// The prefixes are matched against the full measure names, which include
+	// the prefix of the engine that produced them.
+	//
+	// The map must not be modified after the downsampler was first used.
+	Intervals map[string]time.Duration
+
+	// The source of the current time used to decide which windows ended when
+	// the downsampler is flushed. Defaults to SystemClock.
+	ClockSource ClockSource
+
+	mutex   sync.Mutex
+	windows map[string]*downsampledWindow
+	keys    []byte
+}
+
+type downsampledWindow struct {
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+	series   map[string]*downsampledSeries
+}
+
+type downsampledSeries struct {
+	measure Measure
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (d *Downsampler) HandleMeasures(t time.Time, measures ...Measure) {
+	var expired []downsampledWindow
+
+	passthrough := measurePool.Get().(*measuresBuffer)
+	ms := passthrough.measures[:0]
+
+	d.mutex.Lock()
+
+	for _, m := range measures {
+		w := d.window(m.Name)
+
+		switch {
+		case w.start.IsZero():
+			w.start = t
+		case t.Sub(w.start) >= w.interval:
+			if len(w.series) != 0 {
+				expired = append(expired, *w)
+			}
+			w.series, w.start = nil, t
+		}
+
+		for _, f := range m.Fields {
+			if f.Type() == Histogram {
+				ms = append(ms, Measure{Name: m.Name, Fields: []Field{f}, Tags: m.Tags, SampleRate: m.SampleRate})
+			} else {
+				d.aggregate(w, m, f)
+			}
+		}
+
+		if t.After(w.last) {
+			w.last = t
+		}
+	}
+
+	d.mutex.Unlock()
+
+	for _, w := range expired {
+		d.emit(w.last, w.series)
+	}
+
+	if len(ms) != 0 {
+		d.Handler.HandleMeasures(t, ms...)
+	}
+
+	for i := range ms {
+		ms[i] = Measure{}
+	}
+
+	passthrough.measures = ms[:0]
+	measurePool.Put(passthrough)
+}
+
+// Flush satisfies the Flusher interface, it forwards the measures aggregated
+// in the windows that ended to the base handler, then flushes it. Each group
+// of measures is only forwarded once per interval, regardless of how often the
+// downsampler is flushed.
+func (d *Downsampler) Flush() {
+	d.forward(false)
+	flush(d.Handler)
+}
+
+// Close satisfies the io.Closer interface, it forwards the measures aggregated
+// in all windows, flushes the base handler, and closes it if it implements
+// io.Closer.
+func (d *Downsampler) Close() error {
+	d.forward(true)
+	flush(d.Handler)
+
+	if c, ok := d.Handler.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+func (d *Downsampler) forward(all bool) {
+	var expired []downsampledWindow
+	var now time.Time
+
+	if !all {
+		now = clockNow(d.ClockSource)
+	}
+
+	d.mutex.Lock()
+	for _, w := range d.windows {
+		if !all && (w.start.IsZero() || now.Sub(w.start) < w.interval) {
+			continue
+		}
+		if len(w.series) != 0 {
+			expired = append(expired, *w)
+		}
+		w.series, w.start = nil, time.Time{}
+	}
+	d.mutex.Unlock()
+
+	for _, w := range expired {
+		d.emit(w.last, w.series)
+	}
+}
+
+// window returns the aggregation window of the group that measures named name
+// belong to.
+func (d *Downsampler) window(name string) *downsampledWindow {
+	prefix, interval := "", d.interval()
+
+	for p, i := range d.Intervals {
+		if len(p) > len(prefix) && strings.HasPrefix(name, p) && i != 0 {
+			prefix, interval = p, i
+		}
+	}
+
+	w := d.windows[prefix]
+
+	if w == nil {
+		if d.windows == nil {
+			d.windows = make(map[string]*downsampledWindow)
+		}
+		w = &downsampledWindow{interval: interval}
+		d.windows[prefix] = w
+	}
+
+	return w
+}
+
+func (d *Downsampler) aggregate(w *downsampledWindow, m Measure, f Field) {
+	if f.Type() == Counter && sampling(m.SampleRate) {
+		f = Field{Name: f.Name, Value: unsample(f.Value, m.SampleRate)}
+		f.setType(Counter)
+	}
+
+	d.keys = appendSeriesKey(d.keys[:0], m.Name, f.Name, m.Tags)
+	s := w.series[string(d.keys)]
+
+	if s == nil {
+		if w.series == nil {
+			w.series = make(map[string]*downsampledSeries)
+		}
+		s = &downsampledSeries{measure: Measure{
+			Name:   m.Name,
+			Fields: []Field{f},
+			Tags:   copyTags(m.Tags),
+		}}
+		w.series[string(d.keys)] = s
+		return
+	}
+
+	switch f.Type() {
+	case Counter:
+		s.measure.Fields[0].Value = addValues(s.measure.Fields[0].Value, f.Value)
+		s.measure.Fields[0].setType(Counter)
+	default:
+		s.measure.Fields[0] = f
+	}
+}
+
+func (d *Downsampler) emit(t time.Time, series map[string]*downsampledSeries) {
+	measures := make([]Measure, 0, len(series))
+
+	for _, s := range series {
+		measures = append(measures, s.measure)
+	}
+
+	d.Handler.HandleMeasures(t, measures...)
+}
+
+func (d *Downsampler) interval() time.Duration {
+	if d.Interval != 0 {
+		return d.Interval
+	}
+	return 10 * time.Second
+}
+
+func appendSeriesKey(b []byte, measure string, field string, tags []Tag) []byte {
+	b = append(b, measure...)
+	b = append(b, 0)
+	b = append(b, field...)
+
+	for _, t := range tags {
+		b = append(b, 0)
+		b = append(b, t.Name...)
+		b = append(b, '=')
+		b = append(b, t.Value...)
+	}
+
+	return b
+}
+
+// addValues returns the sum of v1 and v2. The type of the result is the type of
+// v1 unless the values have different types, in which case floats are used.
+func addValues(v1 Value, v2 Value) Value {
+	switch {
+	case v1.Type() == Int && v2.Type() == Int:
+		return int64Value(v1.Int() + v2.Int())
+	case v1.Type() == Uint && v2.Type() == Uint:
+		return uint64Value(v1.Uint() + v2.Uint())
+	case v1.Type() == Duration && v2.Type() == Duration:
+		return durationValue(v1.Duration() + v2.Duration())
+	default:
+		return float64Value(valueToFloat(v1) + valueToFloat(v2))
+	}
+}
+
+func valueToFloat(v Value) float64 {
+	switch v.Type() {
+	case Bool:
+		if v.Bool() {
+			return 1
+		}
+	case Int:
+		return float64(v.Int())
+	case Uint:
+		return float64(v.Uint())
+	case Float:
+		return v.Float()
+	case Duration:
+		return v.Duration().Seconds()
+	}
+	return 0
+}
@@ -0,0 +1,155 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestDownsampler(t *testing.T) {
+	now := time.Now()
+	h := &statstest.Handler{}
+	d := &stats.Downsampler{Handler: h, Interval: 10 * time.Second}
+	eng := stats.NewEngine("test", d)
+
+	eng.AddAt(now, "calls", 1)
+	eng.AddAt(now.Add(1*time.Second), "calls", 2)
+	eng.AddAt(now.Add(2*time.Second), "calls", 3, stats.T("a", "b"))
+	eng.SetAt(now.Add(3*time.Second), "level", 1)
+	eng.SetAt(now.Add(4*time.Second), "level", 2)
+	eng.ObserveAt(now.Add(5*time.Second), "size", 1)
+
+	if n := len(h.Measures()); n != 1 {
+		t.Fatal("only histograms should be forwarded before the end of the window, got", n)
+	}
+
+	eng.AddAt(now.Add(11*time.Second), "calls", 1)
+
+	found := h.Measures()[1:]
+	sort.Slice(found, func(i int, j int) bool {
+		return found[i].Name < found[j].Name || (found[i].Name == found[j].Name && len(found[i].Tags) < len(found[j].Tags))
+	})
+
+	expect := []struct {
+		name  string
+		value float64
+	}{
+		{"test.calls", 3},
+		{"test.calls", 3},
+		{"test.level", 2},
+	}
+
+	if len(found) != len(expect) {
+		t.Fatal("bad number of measures:", found)
+	}
+
+	for i, m := range found {
+		if m.Name != expect[i].name || m.Fields[0].Value.Int() != int64(expect[i].value) {
+			t.Errorf("bad measure at index %d: %v", i, m)
+		}
+	}
+
+	d.Close()
+
+	if n := len(h.Measures()); n != 5 {
+		t.Error("bad number of measures after close:", n)
+	}
+}
+
+func TestDownsamplerIntervals(t *testing.T) {
+	now := time.Now()
+	h := &statstest.Handler{}
+	d := &stats.Downsampler{
+		Handler:  h,
+		Interval: 10 * time.Second,
+		Intervals: map[string]time.Duration{
+			"test.http":      5 * time.Second,
+			"test.http.slow": 60 * time.Second,
+		},
+	}
+	eng := stats.NewEngine("test", d)
+
+	eng.AddAt(now, "calls", 1)
+	eng.AddAt(now, "http.requests", 1)
+	eng.AddAt(now, "http.slow.requests", 1)
+	eng.AddAt(now.Add(6*time.Second), "http.requests", 2)
+
+	if found := h.Measures(); len(found) != 1 || found[0].Name != "test.http.requests" || found[0].Fields[0].Value.Int() != 1 {
+		t.Fatal("only the measures of the 5s group should have been forwarded:", found)
+	}
+
+	eng.AddAt(now.Add(11*time.Second), "calls", 2)
+	eng.AddAt(now.Add(12*time.Second), "http.slow.requests", 2)
+
+	if found := h.Measures()[1:]; len(found) != 1 || found[0].Name != "test.calls" || found[0].Fields[0].Value.Int() != 1 {
+		t.Fatal("only the measures of the default group should have been forwarded:", found)
+	}
+
+	d.Close()
+
+	found := h.Measures()[2:]
+	sort.Slice(found, func(i int, j int) bool { return found[i].Name < found[j].Name })
+
+	expect := []struct {
+		name  string
+		value int64
+	}{
+		{"test.calls", 2},
+		{"test.http.requests", 2},
+		{"test.http.slow.requests", 3},
+	}
+
+	if len(found) != len(expect) {
+		t.Fatal("bad number of measures after close:", found)
+	}
+
+	for i, m := range found {
+		if m.Name != expect[i].name || m.Fields[0].Value.Int() != expect[i].value {
+			t.Errorf("bad measure at index %d: %v", i, m)
+		}
+	}
+}
+
+func TestDownsamplerFlushIntervals(t *testing.T) {
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := newManualClock(now)
+	h := &statstest.Handler{}
+	d := &stats.Downsampler{
+		Handler:     h,
+		Intervals:   map[string]time.Duration{"test.http": 5 * time.Second, "test.slow": time.Minute},
+		ClockSource: clock,
+	}
+	eng := stats.NewEngine("test", d)
+	eng.ClockSource = clock
+
+	eng.Add("http.requests", 1)
+	eng.Add("slow.requests", 1)
+	d.Flush()
+
+	if found := h.Measures(); len(found) != 0 {
+		t.Fatal("measures forwarded before the end of their window:", found)
+	}
+
+	clock.advance(6 * time.Second)
+	d.Flush()
+
+	if found := h.Measures(); len(found) != 1 || found[0].Name != "test.http.requests" {
+		t.Fatal("only the measures of the 5s group should have been forwarded:", found)
+	}
+
+	eng.Add("http.requests", 1)
+	clock.advance(time.Minute)
+	d.Flush()
+
+	found := h.Measures()[1:]
+	sort.Slice(found, func(i int, j int) bool { return found[i].Name < found[j].Name })
+
+	if len(found) != 2 || found[0].Name != "test.http.requests" || found[1].Name != "test.slow.requests" {
+		t.Error("both groups should have been forwarded:", found)
+	}
+}
@@ -0,0 +1,52 @@
+package stats
+
+import (
+	"context"
+	"time"
+)
+
+// Drain stops eng (and the engines sharing its state) from accepting new
+// measures, waits for the measures that are being passed to the handler to
+// complete, then flushes the handler.
+//
+// While waiting, progress is called periodically with the number of measures
+// that are still being handled, and a final time with zero once all of them
+// were passed to the handler. The progress function may be nil.
+//
+// If ctx is canceled before all measures were handled, Drain returns ctx.Err()
+// without flushing the handler. Measures produced after Drain was called are
+// silently discarded.
+func (eng *Engine) Drain(ctx context.Context, progress func(remaining int)) error {
+	state := eng.state()
+	state.close()
+
+	if progress == nil {
+		progress = func(int) {}
+	}
+
+	ticker := eng.clock().NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		n := state.pending()
+		progress(n)
+
+		if n == 0 {
+			break
+		}
+
+		select {
+		case <-ticker.C():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	eng.Flush()
+	return nil
+}
+
+// Drain drains the default engine.
+func Drain(ctx context.Context, progress func(remaining int)) error {
+	return DefaultEngine.Drain(ctx, progress)
+}
@@ -0,0 +1,85 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestEngineDrain(t *testing.T) {
+	h := &statstest.Handler{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	eng := stats.NewEngine("test", stats.MultiHandler(h, stats.HandlerFunc(func(time.Time, ...stats.Measure) {
+		started <- struct{}{}
+		<-release
+	})))
+	sub := eng.WithTags(stats.T("sub", "engine"))
+
+	go eng.Incr("calls")
+	go sub.Incr("calls")
+	<-started
+	<-started
+
+	var remaining []int
+	done := make(chan error)
+
+	go func() {
+		done <- eng.Drain(context.Background(), func(n int) {
+			if len(remaining) == 0 || remaining[len(remaining)-1] != n {
+				remaining = append(remaining, n)
+			}
+			if n == 2 {
+				close(release)
+			}
+		})
+	}()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if n := remaining[len(remaining)-1]; n != 0 {
+		t.Error("bad final progress:", n)
+	}
+
+	if n := h.FlushCalls(); n != 1 {
+		t.Error("bad number of flush calls:", n)
+	}
+
+	sub.Incr("calls")
+	eng.Report(struct {
+		N int `metric:"n"`
+	}{})
+
+	if n := len(h.Measures()); n != 2 {
+		t.Error("measures were accepted after draining the engine:", n)
+	}
+}
+
+func TestEngineDrainCanceled(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	eng := stats.NewEngine("test", stats.HandlerFunc(func(time.Time, ...stats.Measure) {
+		close(started)
+		<-release
+	}))
+
+	go eng.Incr("calls")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := eng.Drain(ctx, nil); err != context.DeadlineExceeded {
+		t.Error("bad error:", err)
+	}
+}
@@ -0,0 +1,265 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// JSONEncoder encodes measures as JSON objects, one per line (also known as
+// newline-delimited JSON):
+//
+//	{"time":"2017-06-04T22:12:00Z","name":"http","fields":[{"name":"rtt","type":"histogram","value":0.1}],"tags":{"host":"a"}}
+//
+// Durations are encoded as numbers of seconds, and values which are not
+// finite numbers are encoded as null. The objects of sampled measures have a
+// "sample_rate" key.
+//
+// When Indent is set, the objects are pretty-printed over multiple lines with
+// each level of nesting indented by the string, which is more readable when the
+// output is tailed by humans. Pretty-printed objects are still separated by new
+// lines.
+type JSONEncoder struct {
+	Indent string
+}
+
+// AppendMeasures satisfies the Encoder interface.
+func (e JSONEncoder) AppendMeasures(b []byte, t time.Time, measures ...Measure) []byte {
+	if len(e.Indent) == 0 {
+		return appendJSONMeasures(b, t, measures...)
+	}
+
+	var buf bytes.Buffer
+
+	for i := range measures {
+		buf.Reset()
+		obj := appendJSONMeasures(nil, t, measures[i])
+		// The output of appendJSONMeasures is always valid JSON, so Indent
+		// cannot fail.
+		json.Indent(&buf, obj[:len(obj)-1], "", e.Indent)
+		b = append(b, buf.Bytes()...)
+		b = append(b, '\n')
+	}
+
+	return b
+}
+
+func appendJSONMeasures(b []byte, t time.Time, measures ...Measure) []byte {
+	for _, m := range measures {
+		b = append(b, `{"time":"`...)
+		b = t.AppendFormat(b, time.RFC3339Nano)
+		b = append(b, `","name":`...)
+		b = appendJSONString(b, m.Name)
+		b = append(b, `,"fields":[`...)
+
+		for i, f := range m.Fields {
+			if i != 0 {
+				b = append(b, ',')
+			}
+			b = append(b, `{"name":`...)
+			b = appendJSONString(b, f.Name)
+			b = append(b, `,"type":"`...)
+			b = append(b, f.Type().String()...)
+			b = append(b, `","value":`...)
+			b = appendJSONValue(b, f.Value)
+			b = append(b, '}')
+		}
+
+		b = append(b, `],"tags":{`...)
+
+		for i, tag := range m.Tags {
+			if i != 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONString(b, tag.Name)
+			b = append(b, ':')
+			b = appendJSONString(b, tag.Value)
+		}
+
+		b = append(b, '}')
+
+		if sampling(m.SampleRate) {
+			b = append(b, `,"sample_rate":`...)
+			b = strconv.AppendFloat(b, m.SampleRate, 'g', -1, 64)
+		}
+
+		b = append(b, "}\n"...)
+	}
+	return b
+}
+
+// LogfmtEncoder encodes measures in the logfmt format, with one line per field:
+//
+//	time=2017-06-04T22:12:00Z name=http field=rtt type=histogram value=0.1 host=a
+//
+// The tags of the measures follow the reserved keys, durations are encoded as
+// numbers of seconds. The lines of sampled measures have a sample_rate key
+// after the value.
+type LogfmtEncoder struct{}
+
+// AppendMeasures satisfies the Encoder interface.
+func (LogfmtEncoder) AppendMeasures(b []byte, t time.Time, measures ...Measure) []byte {
+	for _, m := range measures {
+		for _, f := range m.Fields {
+			b = append(b, "time="...)
+			b = t.AppendFormat(b, time.RFC3339Nano)
+			b = append(b, " name="...)
+			b = appendLogfmtString(b, m.Name)
+			b = append(b, " field="...)
+			b = appendLogfmtString(b, f.Name)
+			b = append(b, " type="...)
+			b = append(b, f.Type().String()...)
+			b = append(b, " value="...)
+			b = appendTextValue(b, f.Value)
+
+			if sampling(m.SampleRate) {
+				b = append(b, " sample_rate="...)
+				b = strconv.AppendFloat(b, m.SampleRate, 'g', -1, 64)
+			}
+
+			for _, tag := range m.Tags {
+				b = append(b, ' ')
+				b = appendLogfmtString(b, tag.Name)
+				b = append(b, '=')
+				b = appendLogfmtString(b, tag.Value)
+			}
+
+			b = append(b, '\n')
+		}
+	}
+	return b
+}
+
+// CSVEncoder encodes measures as CSV records (RFC 4180), with one record per
+// field made of the following columns:
+//
+//	time,name,field,type,value,tags
+//
+// The tags are encoded as a single column of name=value pairs separated by
+// semicolons, durations are encoded as numbers of seconds. The encoder does not
+// produce a header. Since the records have no sample rate column, the values of
+// sampled counters are scaled by the inverse of the sample rate.
+type CSVEncoder struct{}
+
+// AppendMeasures satisfies the Encoder interface.
+func (CSVEncoder) AppendMeasures(b []byte, t time.Time, measures ...Measure) []byte {
+	for _, m := range measures {
+		var tags string
+
+		if len(m.Tags) != 0 {
+			s := make([]string, len(m.Tags))
+			for i, tag := range m.Tags {
+				s[i] = tag.Name + "=" + tag.Value
+			}
+			tags = strings.Join(s, ";")
+		}
+
+		for _, f := range m.Fields {
+			b = t.AppendFormat(b, time.RFC3339Nano)
+			b = append(b, ',')
+			b = appendCSVString(b, m.Name)
+			b = append(b, ',')
+			b = appendCSVString(b, f.Name)
+			b = append(b, ',')
+			b = append(b, f.Type().String()...)
+			b = append(b, ',')
+			if f.Type() == Counter {
+				b = appendTextValue(b, unsample(f.Value, m.SampleRate))
+			} else {
+				b = appendTextValue(b, f.Value)
+			}
+			b = append(b, ',')
+			b = appendCSVString(b, tags)
+			b = append(b, "\r\n"...)
+		}
+	}
+	return b
+}
+
+// appendTextValue appends the text representation of v to b, durations are
+// represented as numbers of seconds.
+func appendTextValue(b []byte, v Value) []byte {
+	switch v.Type() {
+	case Null:
+		return append(b, "null"...)
+	case Bool:
+		return strconv.AppendBool(b, v.Bool())
+	case Int:
+		return strconv.AppendInt(b, v.Int(), 10)
+	case Uint:
+		return strconv.AppendUint(b, v.Uint(), 10)
+	case Float:
+		return strconv.AppendFloat(b, v.Float(), 'g', -1, 64)
+	case Duration:
+		return strconv.AppendFloat(b, v.Duration().Seconds(), 'g', -1, 64)
+	default:
+		return append(b, "null"...)
+	}
+}
+
+func appendJSONValue(b []byte, v Value) []byte {
+	if v.Type() == Float && (math.IsNaN(v.Float()) || math.IsInf(v.Float(), 0)) {
+		return append(b, "null"...)
+	}
+	return appendTextValue(b, v)
+}
+
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				b = append(b, '\\', c)
+			case c == '\n':
+				b = append(b, '\\', 'n')
+			case c == '\r':
+				b = append(b, '\\', 'r')
+			case c == '\t':
+				b = append(b, '\\', 't')
+			case c < 0x20:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			default:
+				b = append(b, c)
+			}
+			i++
+			continue
+		}
+
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 {
+			b = append(b, `�`...)
+		} else {
+			b = append(b, s[i:i+n]...)
+		}
+		i += n
+	}
+
+	return append(b, '"')
+}
+
+func appendLogfmtString(b []byte, s string) []byte {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError
+	}) < 0 {
+		return append(b, s...)
+	}
+	return appendJSONString(b, s)
+}
+
+func appendCSVString(b []byte, s string) []byte {
+	if !strings.ContainsAny(s, ",\"\r\n") {
+		return append(b, s...)
+	}
+	b = append(b, '"')
+	b = append(b, strings.Replace(s, `"`, `""`, -1)...)
+	return append(b, '"')
+}
@@ -29,12 +29,52 @@ type Engine struct {
 	// that manipulates this field directly has to respect this requirement.
 	Tags []Tag
 
+	// When set, the engine computes quantile summaries of the histograms that
+	// it produces and tracks its counters and gauges, see Summaries for
+	// details.
+	Summaries *Summaries
+
+	// When set, the engine bounds the number of combinations of tags of each
+	// measure, see CardinalityLimit for details.
+	CardinalityLimit *CardinalityLimit
+
+	// When set, the engine removes the tags which are not allowed on the
+	// measures it produces, see TagAllowlist for details.
+	TagAllowlist *TagAllowlist
+
+	// The fraction of counter increments and histogram observations that the
+	// engine forwards to its handler, a value between 0 and 1. The measures
+	// are selected randomly and carry the rate in their SampleRate field so
+	// handlers can scale their values. Gauges and measures produced by Report are never
+	// sampled, and sampling is disabled when the rate is zero.
+	SampleRate float64
+
+	// When set, the engine reuses the tag sets of measures produced with the
+	// same names and tags, see TagSetCache for details.
+	TagSets *TagSetCache
+
+	// The source of the current time used to timestamp the measures and
+	// schedule the flushes of FlushEvery. Defaults to SystemClock.
+	ClockSource ClockSource
+
+	// The registry where the descriptions of the metrics set by Describe are
+	// recorded. Defaults to Descriptions.
+	Descriptions *DescriptionRegistry
+
+	// The registry where the units of the metrics set by HandleUnit are
+	// recorded. Defaults to Units.
+	Units *UnitRegistry
+
 	// This cache keeps track of the generated measure structures to avoid
 	// rebuilding them every time a same measure type is seen by the engine.
 	//
 	// The cached values include the engine prefix in the measure names, which
 	// is why the cache must be local to the engine.
 	cache measureCache
+
+	// State shared between the engine and the engines derived from it with
+	// WithPrefix and WithTags.
+	shared engineStatePointer
 }
 
 // NewEngine creates and returns a new engine configured with prefix, handler,
@@ -56,8 +96,38 @@ func (eng *Engine) Register(handler Handler) {
 	}
 }
 
-// Flush flushes eng's handler (if it implements the Flusher interface).
+// Flush flushes eng's handler (if it implements the Flusher interface). The
+// registered gauges, rates, and progress of operations are sampled, and when
+// summaries, cardinality limits, or tag allowlists are configured the quantiles
+// of the histograms and the number of collapsed or filtered measures are passed
+// to the handler before it is flushed.
 func (eng *Engine) Flush() {
+	if !Enabled {
+		return
+	}
+
+	now := eng.Now()
+	state := eng.state()
+	state.samplers.sample(now)
+
+	if eng.Summaries != nil {
+		if ms := eng.Summaries.measures(now); len(ms) != 0 {
+			eng.Handler.HandleMeasures(now, ms...)
+		}
+	}
+
+	if eng.CardinalityLimit != nil {
+		if ms := eng.CardinalityLimit.measures(now, eng.Tags); len(ms) != 0 {
+			eng.Handler.HandleMeasures(now, ms...)
+		}
+	}
+
+	if eng.TagAllowlist != nil {
+		if ms := eng.TagAllowlist.measures(eng.Tags); len(ms) != 0 {
+			eng.Handler.HandleMeasures(now, ms...)
+		}
+	}
+
 	flush(eng.Handler)
 }
 
@@ -65,11 +135,21 @@ func (eng *Engine) Flush() {
 // prefix and tags set to the merge of eng's current tags and those passed as
 // argument. Both eng and the returned engine share the same handler.
 func (eng *Engine) WithPrefix(prefix string, tags ...Tag) *Engine {
-	return &Engine{
-		Handler: eng.Handler,
-		Prefix:  eng.makeName(prefix),
-		Tags:    eng.makeTags(tags),
+	e := &Engine{
+		Handler:          eng.Handler,
+		Prefix:           eng.makeName(prefix),
+		Tags:             eng.makeTags(tags),
+		Summaries:        eng.Summaries,
+		CardinalityLimit: eng.CardinalityLimit,
+		TagAllowlist:     eng.TagAllowlist,
+		SampleRate:       eng.SampleRate,
+		TagSets:          eng.TagSets,
+		ClockSource:      eng.ClockSource,
+		Descriptions:     eng.Descriptions,
+		Units:            eng.Units,
 	}
+	e.shared.store(eng.state())
+	return e
 }
 
 // WithTags returns a copy of the engine with tags set to the merge of eng's
@@ -91,37 +171,62 @@ func (eng *Engine) IncrAt(time time.Time, name string, tags ...Tag) {
 
 // Add increments by value the counter identified by name and tags.
 func (eng *Engine) Add(name string, value interface{}, tags ...Tag) {
-	eng.measure(time.Now(), name, value, Counter, tags...)
+	eng.measure(eng.Now(), name, ValueOf(value), Counter, tags...)
 }
 
 // Add increments by value the counter identified by name and tags.
 func (eng *Engine) AddAt(t time.Time, name string, value interface{}, tags ...Tag) {
-	eng.measure(t, name, value, Counter, tags...)
+	eng.measure(t, name, ValueOf(value), Counter, tags...)
+}
+
+// AddInt increments by value the counter identified by name and tags. Unlike
+// Add, the value is guaranteed to be carried as a 64 bits integer, handlers that
+// aggregate counters sum integer values without converting them to floats, so
+// large counters (like numbers of bytes in long running programs) do not lose
+// precision.
+func (eng *Engine) AddInt(name string, value int64, tags ...Tag) {
+	eng.measure(eng.Now(), name, int64Value(value), Counter, tags...)
+}
+
+// AddIntAt increments by value the counter identified by name and tags.
+func (eng *Engine) AddIntAt(t time.Time, name string, value int64, tags ...Tag) {
+	eng.measure(t, name, int64Value(value), Counter, tags...)
 }
 
 // Set sets to value the gauge identified by name and tags.
 func (eng *Engine) Set(name string, value interface{}, tags ...Tag) {
-	eng.measure(time.Now(), name, value, Gauge, tags...)
+	eng.measure(eng.Now(), name, ValueOf(value), Gauge, tags...)
 }
 
 // Set sets to value the gauge identified by name and tags.
 func (eng *Engine) SetAt(t time.Time, name string, value interface{}, tags ...Tag) {
-	eng.measure(t, name, value, Gauge, tags...)
+	eng.measure(t, name, ValueOf(value), Gauge, tags...)
 }
 
 // Observe reports value for the histogram identified by name and tags.
 func (eng *Engine) Observe(name string, value interface{}, tags ...Tag) {
-	eng.measure(time.Now(), name, value, Histogram, tags...)
+	eng.measure(eng.Now(), name, ValueOf(value), Histogram, tags...)
 }
 
 // Observe reports value for the histogram identified by name and tags.
 func (eng *Engine) ObserveAt(t time.Time, name string, value interface{}, tags ...Tag) {
-	eng.measure(t, name, value, Histogram, tags...)
+	eng.measure(t, name, ValueOf(value), Histogram, tags...)
+}
+
+// Timing reports the duration d for the histogram identified by name and tags.
+func (eng *Engine) Timing(name string, d time.Duration, tags ...Tag) {
+	eng.measure(eng.Now(), name, durationValue(d), Histogram, tags...)
+}
+
+// TimingAt reports the duration d for the histogram identified by name and
+// tags at time t.
+func (eng *Engine) TimingAt(t time.Time, name string, d time.Duration, tags ...Tag) {
+	eng.measure(t, name, durationValue(d), Histogram, tags...)
 }
 
 // Clock returns a new clock identified by name and tags.
 func (eng *Engine) Clock(name string, tags ...Tag) *Clock {
-	return eng.ClockAt(name, time.Now(), tags...)
+	return eng.ClockAt(name, eng.Now(), tags...)
 }
 
 // ClockAt returns a new clock identified by name and tags with a specified
@@ -138,13 +243,29 @@ func (eng *Engine) ClockAt(name string, start time.Time, tags ...Tag) *Clock {
 	}
 }
 
-func (eng *Engine) measure(t time.Time, name string, value interface{}, ftype FieldType, tags ...Tag) {
+func (eng *Engine) measure(t time.Time, name string, value Value, ftype FieldType, tags ...Tag) {
+	if !Enabled {
+		return
+	}
+
+	sample := ftype != Gauge && sampling(eng.SampleRate)
+	if sample && !sampled(eng.SampleRate) {
+		return
+	}
+
+	state := eng.state()
+	if !state.acquire(1) {
+		return
+	}
+
 	name, field := splitMeasureField(name)
 	mp := measureArrayPool.Get().(*[1]Measure)
 
 	m := &(*mp)[0]
 	m.Name = eng.makeName(name) // TODO: figure out how to optimize this
-	m.Fields = append(m.Fields[:0], MakeField(field, value, ftype))
+	f := Field{Name: field, Value: value}
+	f.setType(ftype)
+	m.Fields = append(m.Fields[:0], f)
 	m.Tags = append(m.Tags[:0], eng.Tags...)
 	m.Tags = append(m.Tags, tags...)
 
@@ -152,7 +273,8 @@ func (eng *Engine) measure(t time.Time, name string, value interface{}, ftype Fi
 		SortTags(m.Tags)
 	}
 
-	eng.Handler.HandleMeasures(t, (*mp)[:]...)
+	eng.prepare(t, m, sample)
+	eng.handle(state, t, (*mp)[:])
 
 	for i := range m.Fields {
 		m.Fields[i] = Field{}
@@ -163,7 +285,50 @@ func (eng *Engine) measure(t time.Time, name string, value interface{}, ftype Fi
 	}
 
 	m.Name = ""
+	m.Const = nil
+	m.SampleRate = 0
 	measureArrayPool.Put(mp)
+	state.release(1)
+}
+
+// prepare applies the tag allowlist, cardinality limit, and sample rate of the
+// engine to m, which is about to be passed to the handler at time t. Measures
+// produced by handles carry their own sample rate, sample is false for them.
+func (eng *Engine) prepare(t time.Time, m *Measure, sample bool) {
+	tags := m.Tags
+
+	if eng.TagAllowlist != nil {
+		m.Tags = eng.TagAllowlist.filter(*m, eng.Tags)
+	}
+
+	if eng.CardinalityLimit != nil {
+		m.Tags = eng.CardinalityLimit.limit(t, *m, eng.Tags)
+	}
+
+	// The constant tags of the measure may not be part of its tags anymore.
+	if m.Const != nil && !tagsEqual(tags, m.Tags) {
+		m.Const = nil
+	}
+
+	if sample {
+		m.SampleRate = eng.SampleRate
+	}
+
+	if eng.TagSets != nil && m.Const == nil && len(m.Tags) != 0 {
+		m.Const = eng.TagSets.lookup(m.Name, m.Tags)
+	}
+}
+
+// handle passes the prepared measures to the handler of the engine, then to
+// its subscribers and summaries. The caller must have acquired the measures on
+// state, and releases them after handle returns.
+func (eng *Engine) handle(state *engineState, t time.Time, measures []Measure) {
+	eng.Handler.HandleMeasures(t, measures...)
+	state.publish(measures)
+
+	if eng.Summaries != nil {
+		eng.Summaries.observe(t, measures)
+	}
 }
 
 func (eng *Engine) makeName(name string) string {
@@ -178,15 +343,25 @@ var measureArrayPool = sync.Pool{
 	New: func() interface{} { return new([1]Measure) },
 }
 
-// Report calls ReportAt with time.Now() as first argument.
+// Report calls ReportAt with the current time of the engine's clock source as
+// first argument.
 func (eng *Engine) Report(metrics interface{}, tags ...Tag) {
-	eng.ReportAt(time.Now(), metrics, tags...)
+	eng.ReportAt(eng.Now(), metrics, tags...)
 }
 
 // ReportAt reports a set of metrics for a given time. The metrics must be of
 // type struct, pointer to struct, or a slice or array to one of those. See
 // MakeMeasures for details about how to make struct types exposing metrics.
 func (eng *Engine) ReportAt(time time.Time, metrics interface{}, tags ...Tag) {
+	if !Enabled {
+		return
+	}
+
+	state := eng.state()
+	if state.closed() {
+		return
+	}
+
 	var tb *tagsBuffer
 
 	if len(tags) == 0 {
@@ -204,7 +379,28 @@ func (eng *Engine) ReportAt(time time.Time, metrics interface{}, tags ...Tag) {
 	mb.measures = appendMeasures(mb.measures[:0], &eng.cache, eng.Prefix, reflect.ValueOf(metrics), tags...)
 
 	ms := mb.measures
-	eng.Handler.HandleMeasures(time, ms...)
+
+	if eng.TagAllowlist != nil {
+		for i := range ms {
+			ms[i].Tags = eng.TagAllowlist.filter(ms[i], eng.Tags)
+		}
+	}
+
+	if eng.CardinalityLimit != nil {
+		for i := range ms {
+			ms[i].Tags = eng.CardinalityLimit.limit(time, ms[i], eng.Tags)
+		}
+	}
+
+	if state.acquire(len(ms)) {
+		eng.Handler.HandleMeasures(time, ms...)
+		state.publish(ms)
+		state.release(len(ms))
+
+		if eng.Summaries != nil {
+			eng.Summaries.observe(time, ms)
+		}
+	}
 
 	for i := range ms {
 		ms[i].reset()
@@ -266,6 +462,16 @@ func AddAt(time time.Time, name string, value interface{}, tags ...Tag) {
 	DefaultEngine.AddAt(time, name, value, tags...)
 }
 
+// AddInt increments by value the counter identified by name and tags.
+func AddInt(name string, value int64, tags ...Tag) {
+	DefaultEngine.AddInt(name, value, tags...)
+}
+
+// AddIntAt increments by value the counter identified by name and tags.
+func AddIntAt(time time.Time, name string, value int64, tags ...Tag) {
+	DefaultEngine.AddIntAt(time, name, value, tags...)
+}
+
 // Set sets to value the gauge identified by name and tags.
 func Set(name string, value interface{}, tags ...Tag) {
 	DefaultEngine.Set(name, value, tags...)
@@ -286,6 +492,19 @@ func ObserveAt(time time.Time, name string, value interface{}, tags ...Tag) {
 	DefaultEngine.ObserveAt(time, name, value, tags...)
 }
 
+// Timing reports the duration d for the histogram identified by name and tags.
+// The package has no Gauge function since the name is taken by the field type,
+// gauges are reported with Set.
+func Timing(name string, d time.Duration, tags ...Tag) {
+	DefaultEngine.Timing(name, d, tags...)
+}
+
+// TimingAt reports the duration d for the histogram identified by name and
+// tags at time t.
+func TimingAt(time time.Time, name string, d time.Duration, tags ...Tag) {
+	DefaultEngine.TimingAt(time, name, d, tags...)
+}
+
 // Report is a helper function that delegates to DefaultEngine.
 func Report(metrics interface{}, tags ...Tag) {
 	DefaultEngine.Report(metrics, tags...)
@@ -1,3 +1,5 @@
+//go:build !statsoff
+
 package stats_test
 
 import (
@@ -48,6 +50,10 @@ func TestEngine(t *testing.T) {
 			scenario: "calling Engine.Observe produces the expected histogram value",
 			function: testEngineObserve,
 		},
+		{
+			scenario: "calling Engine.Timing produces the expected histogram duration",
+			function: testEngineTiming,
+		},
 		{
 			scenario: "calling Engine.Report produces the expected measures",
 			function: testEngineReport,
@@ -194,6 +200,18 @@ func testEngineObserve(t *testing.T, eng *stats.Engine) {
 	)
 }
 
+func testEngineTiming(t *testing.T, eng *stats.Engine) {
+	eng.Timing("measure.rtt", time.Second, stats.T("type", "testing"))
+
+	checkMeasuresEqual(t, eng,
+		stats.Measure{
+			Name:   "test.measure.rtt",
+			Fields: []stats.Field{stats.MakeField("", time.Second, stats.Histogram)},
+			Tags:   []stats.Tag{stats.T("service", "test-service"), stats.T("type", "testing")},
+		},
+	)
+}
+
 func testEngineReport(t *testing.T, eng *stats.Engine) {
 	m := struct {
 		Count int `metric:"count" type:"counter"`
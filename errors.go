@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ErrorChannel delivers the errors which occur asynchronously in handlers
+// (failures to deliver measures to a backend for example) to the program, so
+// it can consume, rate limit, and log them with its own logger.
+//
+// Handlers report errors with Report, which never blocks: the errors are
+// dropped when the program does not consume them fast enough. Until the
+// program requested the channel by calling C, Report returns false and the
+// handlers fall back to their default reporting (usually logging the errors).
+//
+// The zero value is ready to use.
+type ErrorChannel struct {
+	once    sync.Once
+	ch      chan error
+	used    int32
+	dropped uint64
+}
+
+// errorChannelSize is the capacity of the channels of ErrorChannel values.
+const errorChannelSize = 64
+
+// C returns the channel receiving the errors reported to e.
+func (e *ErrorChannel) C() <-chan error {
+	e.init()
+	atomic.StoreInt32(&e.used, 1)
+	return e.ch
+}
+
+// Report sends err to the channel of e without blocking, it returns false if
+// the program never requested the channel.
+func (e *ErrorChannel) Report(err error) bool {
+	if atomic.LoadInt32(&e.used) == 0 {
+		return false
+	}
+
+	select {
+	case e.ch <- err:
+	default:
+		atomic.AddUint64(&e.dropped, 1)
+	}
+
+	return true
+}
+
+// Dropped returns the number of errors which were dropped because the channel
+// was full.
+func (e *ErrorChannel) Dropped() uint64 {
+	return atomic.LoadUint64(&e.dropped)
+}
+
+func (e *ErrorChannel) init() {
+	e.once.Do(func() { e.ch = make(chan error, errorChannelSize) })
+}
+
+// Errors returns the channel receiving the errors reported by the handler of
+// eng, or nil if the handler does not report errors. Handlers report errors
+// by implementing a method with the signature Errors() <-chan error, like the
+// clients of the backends in the subpackages.
+func (eng *Engine) Errors() <-chan error {
+	if h, ok := eng.Handler.(interface{ Errors() <-chan error }); ok {
+		return h.Errors()
+	}
+	return nil
+}
+
+// Errors returns the channel receiving the errors reported by the handler of
+// the default engine.
+func Errors() <-chan error {
+	return DefaultEngine.Errors()
+}
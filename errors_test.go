@@ -0,0 +1,55 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+func TestErrorChannel(t *testing.T) {
+	e := &stats.ErrorChannel{}
+	err := errors.New("oops")
+
+	if e.Report(err) {
+		t.Error("errors were reported before the channel was requested")
+	}
+
+	ch := e.C()
+
+	for i := 0; i != 100; i++ {
+		if !e.Report(err) {
+			t.Fatal("the error was not reported")
+		}
+	}
+
+	if n := len(ch); n == 0 || uint64(n)+e.Dropped() != 100 {
+		t.Errorf("bad number of errors: %d received, %d dropped", n, e.Dropped())
+	}
+}
+
+func TestEngineErrors(t *testing.T) {
+	s := &testSerializer{err: errors.New("connection refused")}
+	b := &stats.Buffer{BufferSize: 1024, BufferPoolSize: 1, Serializer: s}
+	e := stats.NewEngine("test", b)
+
+	ch := e.Errors()
+	e.Incr("A")
+	e.Flush()
+
+	select {
+	case err := <-ch:
+		if err != s.err {
+			t.Error("bad error:", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("no errors were received")
+	}
+
+	if ch := stats.NewEngine("test", stats.Discard).Errors(); ch != nil {
+		t.Error("non-nil channel returned for a handler which does not report errors")
+	}
+}
@@ -0,0 +1,168 @@
+package stats
+
+import (
+	"path"
+	"time"
+)
+
+// FilterRule is the signature of the predicates that a Filter uses to select the
+// fields of measures that it forwards.
+type FilterRule func(m *Measure, f *Field) bool
+
+// Filter is a measure handler which only forwards the metrics that match its
+// rules to its base handler. It is useful to send different metrics to
+// different backends, for example high-cardinality histograms to one of them,
+// and counters only to another:
+//
+//	stats.Register(stats.FilterHandler(prom, stats.MatchType(stats.Histogram)))
+//	stats.Register(stats.FilterHandler(dd, stats.MatchType(stats.Counter)))
+//
+// Rules apply to each field of the measures, measures that have no fields left
+// after being filtered are dropped.
+type Filter struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// Fields are forwarded if they match any of these rules, or all fields
+	// are forwarded if the list is empty.
+	Allow []FilterRule
+
+	// Fields are dropped if they match any of these rules, even if they also
+	// match rules of the Allow list.
+	Deny []FilterRule
+}
+
+// FilterHandler returns a Filter which forwards to handler the fields matching
+// any of the rules passed as arguments.
+func FilterHandler(handler Handler, allow ...FilterRule) *Filter {
+	return &Filter{Handler: handler, Allow: allow}
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (h *Filter) HandleMeasures(t time.Time, measures ...Measure) {
+	passthrough := measurePool.Get().(*measuresBuffer)
+	ms := passthrough.measures[:0]
+
+	for i := range measures {
+		m := &measures[i]
+		// The list of fields is only copied when fields were removed.
+		var fields []Field
+
+		for j := range m.Fields {
+			if h.match(m, &m.Fields[j]) {
+				if fields != nil {
+					fields = append(fields, m.Fields[j])
+				}
+				continue
+			}
+
+			if fields == nil {
+				fields = append(make([]Field, 0, len(m.Fields)), m.Fields[:j]...)
+			}
+		}
+
+		switch {
+		case fields == nil:
+			ms = append(ms, *m)
+		case len(fields) != 0:
+			ms = append(ms, Measure{Name: m.Name, Fields: fields, Tags: m.Tags, Const: m.Const, SampleRate: m.SampleRate})
+		}
+	}
+
+	if len(ms) != 0 {
+		h.Handler.HandleMeasures(t, ms...)
+	}
+
+	for i := range ms {
+		ms[i] = Measure{}
+	}
+
+	passthrough.measures = ms[:0]
+	measurePool.Put(passthrough)
+}
+
+// Flush satisfies the Flusher interface.
+func (h *Filter) Flush() {
+	flush(h.Handler)
+}
+
+func (h *Filter) match(m *Measure, f *Field) bool {
+	for _, rule := range h.Deny {
+		if rule(m, f) {
+			return false
+		}
+	}
+
+	if len(h.Allow) == 0 {
+		return true
+	}
+
+	for _, rule := range h.Allow {
+		if rule(m, f) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchName returns a filter rule matching the metrics with names that match
+// pattern, using the syntax of path.Match. The pattern is matched against the
+// measure names, or against the measure and field names separated by a colon
+// if it contains one (for example "http.*:rtt"). The function panics if the
+// pattern is malformed.
+func MatchName(pattern string) FilterRule {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic("stats.MatchName: " + err.Error() + ": " + pattern)
+	}
+
+	measure, field := splitMeasureField(pattern)
+	hasField := len(measure) != len(pattern)
+
+	return func(m *Measure, f *Field) bool {
+		if ok, _ := path.Match(measure, m.Name); !ok {
+			return false
+		}
+		if hasField {
+			ok, _ := path.Match(field, f.Name)
+			return ok
+		}
+		return true
+	}
+}
+
+// MatchType returns a filter rule matching the metrics of the given types.
+func MatchType(types ...FieldType) FilterRule {
+	return func(m *Measure, f *Field) bool {
+		for _, t := range types {
+			if f.Type() == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchTag returns a filter rule matching the measures which have a tag named
+// name with a value matching pattern, using the syntax of path.Match (so "*"
+// matches any value). The function panics if the pattern is malformed.
+func MatchTag(name string, pattern string) FilterRule {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic("stats.MatchTag: " + err.Error() + ": " + pattern)
+	}
+
+	return func(m *Measure, f *Field) bool {
+		for _, t := range m.Tags {
+			if t.Name == name {
+				ok, _ := path.Match(pattern, t.Value)
+				return ok
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a filter rule matching the metrics which rule does not match.
+func Not(rule FilterRule) FilterRule {
+	return func(m *Measure, f *Field) bool { return !rule(m, f) }
+}
@@ -0,0 +1,94 @@
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		scenario string
+		filter   stats.Filter
+		expected []string
+	}{
+		{
+			scenario: "no rules forward all metrics",
+			expected: []string{"http:requests", "http:rtt", "queue:size", "db:rtt"},
+		},
+		{
+			scenario: "allow rules select the metrics of some types",
+			filter:   stats.Filter{Allow: []stats.FilterRule{stats.MatchType(stats.Counter, stats.Gauge)}},
+			expected: []string{"http:requests", "queue:size"},
+		},
+		{
+			scenario: "allow rules select metrics by name",
+			filter:   stats.Filter{Allow: []stats.FilterRule{stats.MatchName("*:rtt"), stats.MatchName("queue")}},
+			expected: []string{"http:rtt", "queue:size", "db:rtt"},
+		},
+		{
+			scenario: "deny rules take precedence over allow rules",
+			filter: stats.Filter{
+				Allow: []stats.FilterRule{stats.MatchName("*:rtt")},
+				Deny:  []stats.FilterRule{stats.MatchTag("host", "b*")},
+			},
+			expected: []string{"http:rtt"},
+		},
+		{
+			scenario: "rules can be negated",
+			filter:   stats.Filter{Deny: []stats.FilterRule{stats.Not(stats.MatchTag("host", "*"))}},
+			expected: []string{"http:requests", "http:rtt", "db:rtt"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			h := &statstest.Handler{}
+			f := test.filter
+			f.Handler = h
+
+			f.HandleMeasures(time.Now(),
+				stats.Measure{
+					Name: "http",
+					Fields: []stats.Field{
+						stats.MakeField("requests", 1, stats.Counter),
+						stats.MakeField("rtt", time.Second, stats.Histogram),
+					},
+					Tags: []stats.Tag{stats.T("host", "a")},
+				},
+				stats.Measure{
+					Name:   "queue",
+					Fields: []stats.Field{stats.MakeField("size", 1, stats.Gauge)},
+				},
+				stats.Measure{
+					Name:   "db",
+					Fields: []stats.Field{stats.MakeField("rtt", time.Second, stats.Histogram)},
+					Tags:   []stats.Tag{stats.T("host", "b1")},
+				},
+			)
+
+			found := []string{}
+			for _, m := range h.Measures() {
+				for _, f := range m.Fields {
+					found = append(found, m.Name+":"+f.Name)
+				}
+			}
+
+			if !reflect.DeepEqual(found, test.expected) {
+				t.Errorf("bad metrics:\nexpected: %v\nfound:    %v", test.expected, found)
+			}
+		})
+	}
+}
+
+func TestMatchNamePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic on malformed pattern")
+		}
+	}()
+	stats.MatchName("[")
+}
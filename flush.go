@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// FlushEvery starts a goroutine flushing eng at the given interval, which
+// sends the registered gauges, summaries, and the measures buffered by the
+// handler to the backends on a regular schedule.
+//
+// The returned function stops the goroutine, it waits for the flush in
+// progress (if any) to complete before returning, and does not flush the
+// engine. Programs exiting should call Flush or Drain after stopping the
+// goroutine to send the remaining measures.
+func (eng *Engine) FlushEvery(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		panic("stats.(*Engine).FlushEvery: interval must be positive")
+	}
+
+	if !Enabled {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	exit := make(chan struct{})
+	once := sync.Once{}
+
+	go func() {
+		defer close(exit)
+
+		ticker := eng.clock().NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+				eng.Flush()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() { close(done) })
+		<-exit
+	}
+}
+
+// FlushEvery starts a goroutine flushing the default engine at the given
+// interval.
+func FlushEvery(interval time.Duration) (stop func()) {
+	return DefaultEngine.FlushEvery(interval)
+}
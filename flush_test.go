@@ -0,0 +1,32 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestEngineFlushEvery(t *testing.T) {
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", h)
+
+	stop := eng.FlushEvery(time.Millisecond)
+
+	for h.FlushCalls() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	stop()
+	stop() // calling stop more than once is safe
+	n := h.FlushCalls()
+
+	time.Sleep(10 * time.Millisecond)
+
+	if h.FlushCalls() != n {
+		t.Error("the engine was flushed after the goroutine was stopped")
+	}
+}
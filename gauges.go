@@ -0,0 +1,46 @@
+package stats
+
+import "time"
+
+// RegisterGauge registers fn to be called each time eng is flushed, the value
+// it returns is reported as the gauge identified by name and tags.
+//
+// Registered gauges are sampled lazily, which suits values that the program
+// does not change on its own schedule, like the length of queues or the size
+// of pools. The gauges are shared by eng and the engines derived from it, and
+// sampled when any of them is flushed.
+//
+// The returned function unregisters the gauge.
+func (eng *Engine) RegisterGauge(name string, fn func() float64, tags ...Tag) (unregister func()) {
+	if !Enabled {
+		return func() {}
+	}
+
+	g := &registeredGauge{
+		eng:  eng,
+		name: name,
+		tags: copyTags(tags),
+		fn:   fn,
+	}
+	state := eng.state()
+	state.samplers.add(g)
+	return func() { state.samplers.remove(g) }
+}
+
+// RegisterGauge registers a gauge sampled when the default engine is flushed.
+func RegisterGauge(name string, fn func() float64, tags ...Tag) (unregister func()) {
+	return DefaultEngine.RegisterGauge(name, fn, tags...)
+}
+
+type registeredGauge struct {
+	eng  *Engine
+	name string
+	tags []Tag
+	fn   func() float64
+}
+
+// sample reports the value of the gauge, it is called when the engine is
+// flushed.
+func (g *registeredGauge) sample(t time.Time) {
+	g.eng.SetAt(t, g.name, g.fn(), g.tags...)
+}
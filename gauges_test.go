@@ -0,0 +1,52 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestEngineRegisterGauge(t *testing.T) {
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", h)
+	queue := 0
+
+	unregister := eng.WithPrefix("queue").RegisterGauge("size", func() float64 {
+		return float64(queue)
+	}, stats.T("name", "jobs"))
+
+	if found := h.Measures(); len(found) != 0 {
+		t.Fatal("gauges were reported before the engine was flushed:", found)
+	}
+
+	for _, size := range []int{10, 42} {
+		queue = size
+		h.Clear()
+		eng.Flush()
+
+		found := h.Measures()
+		if len(found) != 1 {
+			t.Fatal("bad number of measures:", found)
+		}
+
+		m := found[0]
+		if m.Name != "test.queue.size" || m.Fields[0].Type() != stats.Gauge || m.Fields[0].Value.Float() != float64(size) {
+			t.Error("bad measure:", m)
+		}
+
+		if len(m.Tags) != 1 || m.Tags[0] != stats.T("name", "jobs") {
+			t.Error("bad tags:", m.Tags)
+		}
+	}
+
+	unregister()
+	h.Clear()
+	eng.Flush()
+
+	if found := h.Measures(); len(found) != 0 {
+		t.Error("unregistered gauges were reported:", found)
+	}
+}
@@ -1,13 +1,19 @@
 module github.com/segmentio/stats
 
+go 1.17
+
+require (
+	github.com/segmentio/fasthash v0.0.0-20180216231524-a72b379d632e
+	github.com/segmentio/objconv v1.0.1
+	github.com/segmentio/taskstats v0.0.0-20180727163836-237d1d6b109d
+	golang.org/x/net v0.17.0
+	golang.org/x/sys v0.13.0
+)
+
 require (
 	github.com/google/go-cmp v0.2.0 // indirect
 	github.com/mdlayher/genetlink v0.0.0-20181016160152-e97704c1b795 // indirect
 	github.com/mdlayher/netlink v0.0.0-20181210160939-e069752bc835 // indirect
 	github.com/mdlayher/taskstats v0.0.0-20190204141439-073d099bf511 // indirect
-	github.com/segmentio/fasthash v0.0.0-20180216231524-a72b379d632e
-	github.com/segmentio/objconv v1.0.1
-	github.com/segmentio/taskstats v0.0.0-20180727163836-237d1d6b109d
-	golang.org/x/net v0.0.0-20190206173232-65e2d4e15006 // indirect
-	golang.org/x/sys v0.0.0-20190204203706-41f3e6584952 // indirect
+	golang.org/x/text v0.13.0 // indirect
 )
@@ -0,0 +1,97 @@
+package stats
+
+import "time"
+
+// A Handle is a metric bound to an engine, a name, a type, and a set of
+// constant tags. Handles are intended to be created once and reused to produce
+// measures on hot code paths: the constant tags are merged with the engine tags
+// and sorted only once, and handlers that support it (like the datadog client)
+// cache their serialized representation.
+//
+// Handles are safe to use concurrently from multiple goroutines.
+type Handle struct {
+	eng   *Engine
+	name  string
+	field string
+	ftype FieldType
+	tags  *TagSet
+	rate  float64 // sample rate, zero if the handle is not sampled
+	unit  Unit
+}
+
+// Handle returns a new metric handle identified by name, producing measures of
+// type ftype with the given constant tags. Counter and histogram handles are
+// sampled at the sample rate that eng had when the handle was created.
+func (eng *Engine) Handle(name string, ftype FieldType, tags ...Tag) *Handle {
+	name, field := splitMeasureField(name)
+	h := &Handle{
+		eng:   eng,
+		name:  eng.makeName(name),
+		field: field,
+		ftype: ftype,
+		tags:  NewTagSet(concatTags(eng.Tags, tags)...),
+	}
+	if ftype != Gauge && sampling(eng.SampleRate) {
+		h.rate = eng.SampleRate
+	}
+	return h
+}
+
+// Tags returns the constant tags of h, including the tags inherited from the
+// engine it was created from.
+func (h *Handle) Tags() []Tag {
+	return h.tags.Tags()
+}
+
+// Measure produces a measure of value for h, with tags added to the constant
+// tags of the handle. For best performance, the list of dynamic tags should be
+// kept small.
+func (h *Handle) Measure(value interface{}, tags ...Tag) {
+	h.MeasureAt(h.eng.Now(), value, tags...)
+}
+
+// MeasureAt produces a measure of value for h at time t.
+func (h *Handle) MeasureAt(t time.Time, value interface{}, tags ...Tag) {
+	if !Enabled {
+		return
+	}
+
+	if h.rate != 0 && !sampled(h.rate) {
+		return
+	}
+
+	state := h.eng.state()
+	if !state.acquire(1) {
+		return
+	}
+
+	mp := measureArrayPool.Get().(*[1]Measure)
+	m := &(*mp)[0]
+	m.Name = h.name
+	m.Fields = append(m.Fields[:0], MakeField(h.field, value, h.ftype))
+	m.Const = h.tags
+	m.SampleRate = h.rate
+
+	if len(tags) == 0 {
+		// The constant tags are immutable so they can be passed to the handler
+		// directly, the slice must not be retained in the pooled measure tho.
+		buf := m.Tags
+		m.Tags = h.tags.tags
+		h.eng.prepare(t, m, false)
+		h.eng.handle(state, t, (*mp)[:])
+		m.Tags = buf
+	} else {
+		tb := tagsPool.Get().(*tagsBuffer)
+		tb.append(tags...)
+		SortTags(tb.tags)
+		m.Tags = mergeTags(m.Tags[:0], h.tags.tags, tb.tags)
+		h.eng.prepare(t, m, false)
+		h.eng.handle(state, t, (*mp)[:])
+		tb.reset()
+		tagsPool.Put(tb)
+	}
+
+	m.reset()
+	measureArrayPool.Put(mp)
+	state.release(1)
+}
@@ -0,0 +1,58 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestHandle(t *testing.T) {
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", h, stats.T("service", "test-service"))
+
+	c := eng.Handle("requests:count", stats.Counter, stats.T("method", "GET"))
+	c.Measure(1)
+	c.Measure(2, stats.T("status", "200"), stats.T("code", "OK"))
+	c.Measure(3)
+
+	found := h.Measures()
+
+	for i, m := range found {
+		if m.Const == nil {
+			t.Error("no constant tags set on measure", i)
+		}
+		found[i].Const = nil
+	}
+
+	expected := []stats.Measure{
+		{
+			Name:   "test.requests",
+			Fields: []stats.Field{stats.MakeField("count", 1, stats.Counter)},
+			Tags:   []stats.Tag{stats.T("method", "GET"), stats.T("service", "test-service")},
+		},
+		{
+			Name:   "test.requests",
+			Fields: []stats.Field{stats.MakeField("count", 2, stats.Counter)},
+			Tags:   []stats.Tag{stats.T("code", "OK"), stats.T("method", "GET"), stats.T("service", "test-service"), stats.T("status", "200")},
+		},
+		{
+			Name:   "test.requests",
+			Fields: []stats.Field{stats.MakeField("count", 3, stats.Counter)},
+			Tags:   []stats.Tag{stats.T("method", "GET"), stats.T("service", "test-service")},
+		},
+	}
+
+	if !reflect.DeepEqual(found, expected) {
+		t.Error("bad measures:")
+		t.Logf("expected: %#v", expected)
+		t.Logf("found:    %#v", found)
+	}
+
+	if tags := c.Tags(); !reflect.DeepEqual(tags, []stats.Tag{stats.T("method", "GET"), stats.T("service", "test-service")}) {
+		t.Error("constant tags were modified:", tags)
+	}
+}
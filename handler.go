@@ -1,6 +1,13 @@
 package stats
 
-import "time"
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
 
 // The Handler interface is implemented by types that produce measures to
 // various metric collection backends.
@@ -39,6 +46,24 @@ func (f HandlerFunc) HandleMeasures(time time.Time, measures ...Measure) {
 // MultiHandler constructs a handler which dispatches measures to all given
 // handlers.
 func MultiHandler(handlers ...Handler) Handler {
+	return ConcurrentMultiHandler(1, handlers...)
+}
+
+// ConcurrentMultiHandler constructs a handler which dispatches measures to all
+// given handlers, calling up to parallelism of them concurrently. The limit
+// applies to all calls to the handler, including the ones made by concurrent
+// goroutines. Handlers are called sequentially when parallelism is less than
+// or equal to one.
+//
+// The returned handler waits for all handlers to return before returning, and
+// is flushed and closed (when the handlers implement io.Closer) concurrently
+// as well. Multi-handlers passed as arguments are flattened, their handlers
+// are called with the parallelism of the returned handler.
+//
+// When some of the handlers report errors (see Engine.Errors), the returned
+// handler reports them as well, wrapped in HandlerError values identifying the
+// handler which failed.
+func ConcurrentMultiHandler(parallelism int, handlers ...Handler) Handler {
 	multi := make([]Handler, 0, len(handlers))
 
 	for _, h := range handlers {
@@ -55,23 +80,159 @@ func MultiHandler(handlers ...Handler) Handler {
 		return multi[0]
 	}
 
-	return &multiHandler{handlers: multi}
+	m := &multiHandler{handlers: multi}
+
+	if parallelism > 1 {
+		m.sem = make(chan struct{}, parallelism)
+	}
+
+	return m
 }
 
 type multiHandler struct {
 	handlers []Handler
+	sem      chan struct{} // nil when the handlers are called sequentially
+	once     sync.Once
+	errors   ErrorChannel
+	reports  bool // true if some handlers report errors
 }
 
 func (m *multiHandler) HandleMeasures(time time.Time, measures ...Measure) {
+	m.each(func(_ int, h Handler) { h.HandleMeasures(time, measures...) })
+}
+
+// ObserveBucketed satisfies the BucketedHandler interface, distributions are
+// converted to measures for the handlers which do not implement it.
+func (m *multiHandler) ObserveBucketed(time time.Time, name string, field string, tags []Tag, dist *Distribution) {
+	var measures []Measure
+
 	for _, h := range m.handlers {
-		h.HandleMeasures(time, measures...)
+		if _, ok := h.(BucketedHandler); !ok && measures == nil {
+			measures = appendBucketMeasures(nil, name, field, tags, dist)
+		}
 	}
+
+	m.each(func(_ int, h Handler) {
+		if b, ok := h.(BucketedHandler); ok {
+			b.ObserveBucketed(time, name, field, tags, dist)
+		} else {
+			h.HandleMeasures(time, measures...)
+		}
+	})
 }
 
 func (m *multiHandler) Flush() {
-	for _, h := range m.handlers {
-		flush(h)
+	m.each(func(_ int, h Handler) { flush(h) })
+}
+
+// Close closes the handlers which implement io.Closer, satisfies the io.Closer
+// interface. The returned error is a MultiError of the errors returned by the
+// handlers, or nil if none failed.
+func (m *multiHandler) Close() error {
+	var mutex sync.Mutex
+	var errs MultiError
+
+	m.each(func(i int, h Handler) {
+		if c, ok := h.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				mutex.Lock()
+				errs = append(errs, &HandlerError{Handler: h, Index: i, Err: err})
+				mutex.Unlock()
+			}
+		}
+	})
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].(*HandlerError).Index < errs[j].(*HandlerError).Index
+	})
+
+	return errs
+}
+
+// Errors returns a channel receiving the errors reported by the handlers, or
+// nil if none of them report errors.
+func (m *multiHandler) Errors() <-chan error {
+	m.once.Do(func() {
+		for i, h := range m.handlers {
+			if r, ok := h.(interface{ Errors() <-chan error }); ok {
+				if ch := r.Errors(); ch != nil {
+					m.reports = true
+					go m.forward(i, h, ch)
+				}
+			}
+		}
+	})
+
+	if !m.reports {
+		return nil
+	}
+
+	return m.errors.C()
+}
+
+func (m *multiHandler) forward(index int, h Handler, errs <-chan error) {
+	for err := range errs {
+		m.errors.Report(&HandlerError{Handler: h, Index: index, Err: err})
+	}
+}
+
+// each calls fn with each handler and its index, respecting the parallelism of
+// m, and returns when all calls returned.
+func (m *multiHandler) each(fn func(int, Handler)) {
+	if m.sem == nil {
+		for i, h := range m.handlers {
+			fn(i, h)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(m.handlers))
+
+	for i, h := range m.handlers {
+		m.sem <- struct{}{}
+		go func(i int, h Handler) {
+			defer wg.Done()
+			defer func() { <-m.sem }()
+			fn(i, h)
+		}(i, h)
+	}
+
+	wg.Wait()
+}
+
+// HandlerError is the error type reported by multi-handlers, it identifies the
+// handler that an error came from.
+type HandlerError struct {
+	// The handler which failed, and its position in the list of handlers of
+	// the multi-handler.
+	Handler Handler
+	Index   int
+
+	// The error reported by the handler.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("handler #%d (%T): %s", e.Index, e.Handler, e.Err)
+}
+
+// MultiError is the error type returned by operations which fail on multiple
+// handlers.
+type MultiError []error
+
+// Error satisfies the error interface.
+func (e MultiError) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
 	}
+	return strings.Join(s, "; ")
 }
 
 // Discard is a handler that doesn't do anything with the measures it receives.
@@ -1,6 +1,11 @@
+//go:build !statsoff
+
 package stats_test
 
 import (
+	"errors"
+	"io"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -40,8 +45,73 @@ func TestMultiHandler(t *testing.T) {
 			t.Error("bad number of calls to Flush:", n2)
 		}
 	})
+
+	t.Run("concurrent multi-handlers bound the number of handlers called at the same time", func(t *testing.T) {
+		var running, max int32
+		f := stats.HandlerFunc(func(time.Time, ...stats.Measure) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+
+		m := stats.ConcurrentMultiHandler(2, f, f, f, f, f)
+		m.HandleMeasures(time.Now())
+
+		if atomic.LoadInt32(&running) != 0 {
+			t.Error("HandleMeasures returned before the handlers completed")
+		}
+
+		if n := atomic.LoadInt32(&max); n < 1 || n > 2 {
+			t.Error("bad number of concurrent calls:", n)
+		}
+	})
+
+	t.Run("closing a multi-handler returns the errors of the handlers", func(t *testing.T) {
+		errClose := errors.New("close")
+		m := stats.MultiHandler(&statstest.Handler{}, closeHandler{errClose}, closeHandler{nil})
+
+		err := m.(io.Closer).Close()
+		errs, ok := err.(stats.MultiError)
+
+		if !ok || len(errs) != 1 {
+			t.Fatal("bad error:", err)
+		}
+
+		if e := errs[0].(*stats.HandlerError); e.Index != 1 || e.Err != errClose {
+			t.Error("bad handler error:", e)
+		}
+	})
+
+	t.Run("multi-handlers report the errors of the handlers", func(t *testing.T) {
+		h := &errorHandler{errors: make(chan error, 1)}
+		m := stats.MultiHandler(&statstest.Handler{}, h)
+		errs := stats.NewEngine("test", m).Errors()
+
+		h.Flush()
+
+		select {
+		case err := <-errs:
+			if e, ok := err.(*stats.HandlerError); !ok || e.Index != 1 || e.Handler != h {
+				t.Error("bad error:", err)
+			}
+		case <-time.After(time.Second):
+			t.Error("no errors were reported")
+		}
+	})
 }
 
+type closeHandler struct{ err error }
+
+func (closeHandler) HandleMeasures(time.Time, ...stats.Measure) {}
+
+func (h closeHandler) Close() error { return h.err }
+
 func flush(h stats.Handler) {
 	if f, ok := h.(stats.Flusher); ok {
 		f.Flush()
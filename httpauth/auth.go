@@ -0,0 +1,208 @@
+// Package httpauth implements the authentication of the requests sent by the
+// handlers which export measures over HTTP, like the InfluxDB and sidecar
+// clients, with tokens, basic authentication, or mutual TLS.
+package httpauth
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Config carries the credentials used to authenticate requests.
+//
+// When Token or Refresh are set the requests carry a bearer token, otherwise
+// basic authentication is used when Username is set. The zero value disables
+// authentication.
+type Config struct {
+	// Static token sent in the Authorization header of requests.
+	Token string
+
+	// Authentication scheme of the tokens, defaults to "Bearer" (InfluxDB v2
+	// for example uses "Token").
+	Scheme string
+
+	// Credentials used for basic authentication.
+	Username string
+	Password string
+
+	// Refresh is called to obtain a new token before the first request (unless
+	// Token is set), and when the server responds with 401 Unauthorized, in
+	// which case the request is retried with the new token.
+	//
+	// Requests with a body can only be retried if their GetBody field is set,
+	// which http.NewRequest does for in-memory bodies. Other requests fail,
+	// but the following ones are sent with the new token.
+	Refresh func() (string, error)
+
+	// Provider of the client certificates used for mutual TLS authentication.
+	Certificates CertificateProvider
+
+	// TLS configuration of the connections to the server (root certificate
+	// authorities for example).
+	TLS *tls.Config
+}
+
+// Enabled returns true if the configuration contains credentials which are
+// sent with the requests.
+func (c Config) Enabled() bool {
+	return c.Token != "" || c.Username != "" || c.Refresh != nil
+}
+
+// TLSConfig returns the TLS configuration of the connections to the server,
+// which presents the client certificates of c.Certificates. The method returns
+// nil if c has no TLS settings.
+func (c Config) TLSConfig() *tls.Config {
+	if c.Certificates == nil {
+		return c.TLS
+	}
+	return ClientTLSConfig(c.TLS, c.Certificates)
+}
+
+// Transport returns a transport which sends requests to base with the
+// credentials of c, base may be nil to use http.DefaultTransport.
+//
+// When c has TLS settings, base must be nil or a *http.Transport, which is
+// copied to apply the settings, the method returns an error otherwise.
+func (c Config) Transport(base http.RoundTripper) (http.RoundTripper, error) {
+	if config := c.TLSConfig(); config != nil {
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		t, ok := base.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("stats/httpauth: cannot apply TLS settings to transports of type %T", base)
+		}
+		t = t.Clone()
+		t.TLSClientConfig = config
+		base = t
+	}
+
+	if c.Enabled() {
+		base = NewTransport(base, c)
+	}
+
+	return base, nil
+}
+
+// Transport is a http.RoundTripper which authenticates the requests that it
+// sends with the credentials of its configuration.
+//
+// Transport values are safe to use concurrently from multiple goroutines.
+type Transport struct {
+	// The transport used to send requests, http.DefaultTransport if nil.
+	Base http.RoundTripper
+
+	config Config
+	mutex  sync.Mutex
+	token  string
+}
+
+// NewTransport returns a transport sending requests authenticated with the
+// credentials of config to base.
+func NewTransport(base http.RoundTripper, config Config) *Transport {
+	return &Transport{
+		Base:   base,
+		config: config,
+		token:  config.Token,
+	}
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.currentToken()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := t.base().RoundTrip(t.authorize(req, token))
+
+	if err != nil || res.StatusCode != http.StatusUnauthorized || t.config.Refresh == nil {
+		return res, err
+	}
+
+	// The token is refreshed even if the request cannot be replayed so the
+	// next requests are sent with the new token.
+	if token, err = t.refreshToken(token); err != nil {
+		return res, nil
+	}
+
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return res, nil
+	}
+
+	retry := req.Clone(req.Context())
+
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return res, nil
+		}
+	}
+
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+	return t.base().RoundTrip(t.authorize(retry, token))
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *Transport) authorize(req *http.Request, token string) *http.Request {
+	switch {
+	case token != "":
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", t.scheme()+" "+token)
+	case t.config.Username != "":
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(t.config.Username, t.config.Password)
+	}
+	return req
+}
+
+func (t *Transport) scheme() string {
+	if t.config.Scheme != "" {
+		return t.config.Scheme
+	}
+	return "Bearer"
+}
+
+func (t *Transport) currentToken() (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.token == "" && t.config.Refresh != nil {
+		token, err := t.config.Refresh()
+		if err != nil {
+			return "", err
+		}
+		t.token = token
+	}
+
+	return t.token, nil
+}
+
+// refreshToken obtains a new token to replace stale, unless another goroutine
+// already replaced it.
+func (t *Transport) refreshToken(stale string) (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.token != stale {
+		return t.token, nil
+	}
+
+	token, err := t.config.Refresh()
+	if err != nil {
+		return "", err
+	}
+
+	t.token = token
+	return token, nil
+}
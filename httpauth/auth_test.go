@@ -0,0 +1,102 @@
+package httpauth
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		scenario string
+		config   Config
+		header   string
+	}{
+		{
+			scenario: "no authentication",
+			config:   Config{},
+			header:   "",
+		},
+		{
+			scenario: "static token",
+			config:   Config{Token: "secret"},
+			header:   "Bearer secret",
+		},
+		{
+			scenario: "static token with custom scheme",
+			config:   Config{Token: "secret", Scheme: "Token"},
+			header:   "Token secret",
+		},
+		{
+			scenario: "basic authentication",
+			config:   Config{Username: "user", Password: "pass"},
+			header:   "Basic dXNlcjpwYXNz",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			var header string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				header = req.Header.Get("Authorization")
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: NewTransport(nil, test.config)}
+
+			res, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if header != test.header {
+				t.Errorf("bad authorization header: %q != %q", header, test.header)
+			}
+		})
+	}
+}
+
+func TestTransportRefresh(t *testing.T) {
+	var refreshes int32
+	var bodies []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+
+		if req.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, Config{
+		Refresh: func() (string, error) {
+			return "token-" + strconv.Itoa(int(atomic.AddInt32(&refreshes, 1))), nil
+		},
+	})}
+
+	res, err := client.Post(server.URL, "text/plain", bytes.NewReader([]byte("hello")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Error("bad status code:", res.StatusCode)
+	}
+
+	if n := atomic.LoadInt32(&refreshes); n != 2 {
+		t.Error("bad number of token refreshes:", n)
+	}
+
+	if len(bodies) != 2 || bodies[0] != "hello" || bodies[1] != "hello" {
+		t.Error("bad request bodies:", bodies)
+	}
+}
@@ -0,0 +1,81 @@
+package httpauth
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// A CertificateProvider provides the client certificates presented to servers
+// which require mutual TLS authentication.
+//
+// The certificate is requested on every TLS handshake, which lets programs
+// rotate their certificates (issued by SPIFFE or Vault for example) without
+// reconfiguring the clients, new connections use the current certificate.
+type CertificateProvider interface {
+	ClientCertificate() (*tls.Certificate, error)
+}
+
+// CertificateProviderFunc makes it possible to use simple functions as
+// certificate providers.
+type CertificateProviderFunc func() (*tls.Certificate, error)
+
+// ClientCertificate calls f, satisfies the CertificateProvider interface.
+func (f CertificateProviderFunc) ClientCertificate() (*tls.Certificate, error) {
+	return f()
+}
+
+// FileCertificate is a certificate provider which loads a certificate and its
+// key from PEM encoded files, the files are loaded again when their
+// modification time changes.
+type FileCertificate struct {
+	CertFile string
+	KeyFile  string
+
+	mutex   sync.Mutex
+	cert    *tls.Certificate
+	modTime [2]time.Time
+}
+
+// ClientCertificate satisfies the CertificateProvider interface.
+func (f *FileCertificate) ClientCertificate() (*tls.Certificate, error) {
+	var modTime [2]time.Time
+
+	for i, file := range [...]string{f.CertFile, f.KeyFile} {
+		s, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTime[i] = s.ModTime()
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.cert == nil || modTime != f.modTime {
+		cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		f.cert, f.modTime = &cert, modTime
+	}
+
+	return f.cert, nil
+}
+
+// ClientTLSConfig returns a copy of base which presents the certificates of
+// provider to the servers, base may be nil.
+func ClientTLSConfig(base *tls.Config, provider CertificateProvider) *tls.Config {
+	config := &tls.Config{}
+
+	if base != nil {
+		config = base.Clone()
+	}
+
+	config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return provider.ClientCertificate()
+	}
+
+	return config
+}
@@ -0,0 +1,148 @@
+package httpauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := &FileCertificate{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+
+	writeCertificate(t, f, "first", time.Now().Add(-time.Hour))
+	cert1, err := f.ClientCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cert2, _ := f.ClientCertificate(); cert2 != cert1 {
+		t.Error("the certificate was loaded again but the files did not change")
+	}
+
+	writeCertificate(t, f, "second", time.Now())
+	cert2, err := f.ClientCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if leaf, _ := x509.ParseCertificate(cert2.Certificate[0]); leaf.Subject.CommonName != "second" {
+		t.Error("the rotated certificate was not loaded:", leaf.Subject.CommonName)
+	}
+}
+
+func TestTransportMutualTLS(t *testing.T) {
+	var commonName string
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(req.TLS.PeerCertificates) != 0 {
+			commonName = req.TLS.PeerCertificates[0].Subject.CommonName
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	certPEM, keyPEM := generateCertificate(t, "client")
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := Config{
+		Certificates: CertificateProviderFunc(func() (*tls.Certificate, error) { return &cert, nil }),
+		TLS:          &tls.Config{RootCAs: roots},
+	}
+
+	transport, err := config.Transport(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: transport}
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if commonName != "client" {
+		t.Errorf("bad client certificate: %q", commonName)
+	}
+}
+
+func writeCertificate(t *testing.T, f *FileCertificate, commonName string, modTime time.Time) {
+	certPEM, keyPEM := generateCertificate(t, commonName)
+
+	for file, data := range map[string][]byte{f.CertFile: certPEM, f.KeyFile: keyPEM} {
+		if err := ioutil.WriteFile(file, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTransportTLSUnsupportedBase(t *testing.T) {
+	config := Config{TLS: &tls.Config{}}
+	base := NewTransport(nil, Config{})
+
+	if _, err := config.Transport(base); err == nil {
+		t.Error("no error was returned for a base transport which is not a *http.Transport")
+	}
+}
+
+func generateCertificate(t *testing.T, commonName string) (certPEM []byte, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return
+}
@@ -17,6 +17,9 @@ func NewHandler(h http.Handler) http.Handler {
 
 // NewHandlerWith wraps h to produce metrics on eng for every request received
 // and every response sent.
+//
+// The metrics carry the tags of the requests contexts (see stats.ContextWithTags)
+// which were set before the requests were passed to the handler.
 func NewHandlerWith(eng *stats.Engine, h http.Handler) http.Handler {
 	return &handler{
 		handler: h,
@@ -27,23 +30,29 @@ func NewHandlerWith(eng *stats.Engine, h http.Handler) http.Handler {
 type handler struct {
 	handler http.Handler
 	eng     *stats.Engine
+	probes  probeMatcher
 }
 
 func (h *handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	m := &metrics{}
+	eng := h.eng
+
+	if probe := h.probes.engine(req.URL.Path); probe != nil {
+		eng = probe
+	}
 
 	w := &responseWriter{
 		ResponseWriter: res,
-		eng:            h.eng,
+		eng:            eng,
 		req:            req,
 		metrics:        m,
-		start:          time.Now(),
+		start:          eng.Now(),
 	}
 	defer w.complete()
 
 	b := &requestBody{
 		body:    req.Body,
-		eng:     h.eng,
+		eng:     eng,
 		req:     req,
 		metrics: m,
 		op:      "read",
@@ -106,7 +115,7 @@ func (w *responseWriter) complete() {
 		w.status = http.StatusOK
 	}
 
-	now := time.Now()
+	now := w.eng.Now()
 	res := &http.Response{
 		ProtoMajor:    w.req.ProtoMajor,
 		ProtoMinor:    w.req.ProtoMinor,
@@ -118,5 +127,5 @@ func (w *responseWriter) complete() {
 	}
 
 	w.metrics.observeResponse(res, "write", w.bytes, now.Sub(w.start))
-	w.eng.ReportAt(w.start, w.metrics)
+	w.eng.ReportAt(w.start, w.metrics, stats.TagsFromContext(w.req.Context())...)
 }
@@ -1,3 +1,5 @@
+//go:build !statsoff
+
 package httpstats
 
 import (
@@ -78,3 +80,66 @@ func TestHandlerHijack(t *testing.T) {
 		t.Log(m)
 	}
 }
+
+func TestHandlerProbes(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	server := httptest.NewServer(NewHandlerWithProbes(e, http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+	}), GRPCProbes...))
+	defer server.Close()
+
+	for _, path := range []string{"/grpc.health.v1.Health/Check", "/users"} {
+		res, err := http.Post(server.URL+path, "application/grpc", strings.NewReader(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.ReadAll(res.Body)
+		res.Body.Close()
+	}
+
+	probes, requests := 0, 0
+
+	for _, m := range h.Measures() {
+		switch m.Name {
+		case "probe.http":
+			probes++
+			if !hasTag(m.Tags, stats.T("grpc_service", "grpc.health.v1.Health")) {
+				t.Error("probe measure without the grpc_service tag:", m)
+			}
+		case "http":
+			requests++
+			if hasTag(m.Tags, stats.T("http_req_path", "/grpc.health.v1.Health/Check")) {
+				t.Error("probe reported with the requests:", m)
+			}
+		}
+	}
+
+	if probes == 0 || requests == 0 {
+		t.Errorf("bad number of measures: %d probes, %d requests", probes, requests)
+	}
+}
+
+func TestHandlerNoProbes(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	server := httptest.NewServer(NewHandlerWith(e, http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+	})))
+	defer server.Close()
+
+	res, err := http.Post(server.URL+"/grpc.health.v1.Health/Check", "application/grpc", strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	for _, m := range h.Measures() {
+		if strings.HasPrefix(m.Name, "probe.") {
+			t.Error("probe reported separately by a handler without probes:", m)
+		}
+	}
+}
@@ -99,6 +99,7 @@ type responseBody struct {
 	bytes   int
 	op      string
 	start   time.Time
+	tags    []stats.Tag
 	once    sync.Once
 }
 
@@ -120,8 +121,8 @@ func (r *responseBody) close() {
 }
 
 func (r *responseBody) complete() {
-	r.metrics.observeResponse(r.res, r.op, r.bytes, time.Now().Sub(r.start))
-	r.eng.ReportAt(r.start, r.metrics)
+	r.metrics.observeResponse(r.res, r.op, r.bytes, r.eng.Now().Sub(r.start))
+	r.eng.ReportAt(r.start, r.metrics, r.tags...)
 }
 
 type metrics struct {
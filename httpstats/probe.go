@@ -0,0 +1,71 @@
+package httpstats
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/segmentio/stats"
+)
+
+// GRPCProbes lists the prefixes of the paths of the standard gRPC health
+// checking and reflection services, which gRPC servers expose when served
+// through their ServeHTTP method. It is intended to be passed to
+// NewHandlerWithProbes.
+var GRPCProbes = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1.ServerReflection/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+}
+
+// NewHandlerWithProbes wraps h to produce metrics on eng like NewHandlerWith,
+// and reports the requests for paths starting with one of the prefixes in
+// probes separately.
+//
+// Probes are frequent and fast, mixing them with the requests of the services
+// would skew their latency percentiles. The handler reports the metrics of
+// probes under names prefixed with "probe" (for example "probe.http.rtt.seconds"),
+// with a "grpc_service" tag set to the prefix of the probe without slashes.
+func NewHandlerWithProbes(eng *stats.Engine, h http.Handler, probes ...string) http.Handler {
+	return &handler{
+		handler: h,
+		eng:     eng,
+		probes:  makeProbeMatcher(eng, probes),
+	}
+}
+
+// probeMatcher matches the paths of requests against a list of probes.
+type probeMatcher []probe
+
+type probe struct {
+	prefix string
+	eng    *stats.Engine // engine reporting the metrics of the probe
+}
+
+func makeProbeMatcher(eng *stats.Engine, prefixes []string) probeMatcher {
+	if len(prefixes) == 0 {
+		return nil
+	}
+
+	eng = eng.WithPrefix("probe")
+	probes := make(probeMatcher, len(prefixes))
+
+	for i, prefix := range prefixes {
+		probes[i] = probe{
+			prefix: prefix,
+			eng:    eng.WithTags(stats.T("grpc_service", strings.Trim(prefix, "/"))),
+		}
+	}
+
+	return probes
+}
+
+// engine returns the engine that the metrics of requests for path are reported
+// on, or nil if the request is not a probe.
+func (p probeMatcher) engine(path string) *stats.Engine {
+	for _, probe := range p {
+		if strings.HasPrefix(path, probe.prefix) {
+			return probe.eng
+		}
+	}
+	return nil
+}
@@ -2,7 +2,6 @@ package httpstats
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/segmentio/stats"
 )
@@ -15,6 +14,8 @@ func NewTransport(t http.RoundTripper) http.RoundTripper {
 
 // NewTransportWith wraps t to produce metrics on eng for every request sent and
 // every response received.
+//
+// The metrics carry the tags of the requests contexts, see stats.ContextWithTags.
 func NewTransportWith(eng *stats.Engine, t http.RoundTripper) http.RoundTripper {
 	return &transport{
 		transport: t,
@@ -28,7 +29,7 @@ type transport struct {
 }
 
 func (t *transport) RoundTrip(req *http.Request) (res *http.Response, err error) {
-	start := time.Now()
+	start := t.eng.Now()
 	rtrip := t.transport
 
 	if rtrip == nil {
@@ -40,6 +41,7 @@ func (t *transport) RoundTrip(req *http.Request) (res *http.Response, err error)
 	}
 
 	m := &metrics{}
+	tags := stats.TagsFromContext(req.Context())
 
 	req.Body = &requestBody{
 		eng:     t.eng,
@@ -53,8 +55,8 @@ func (t *transport) RoundTrip(req *http.Request) (res *http.Response, err error)
 	req.Body.Close() // safe guard, the transport should have done it already
 
 	if err != nil {
-		m.observeError(time.Now().Sub(start))
-		t.eng.ReportAt(start, m)
+		m.observeError(t.eng.Now().Sub(start))
+		t.eng.ReportAt(start, m, tags...)
 	} else {
 		res.Body = &responseBody{
 			eng:     t.eng,
@@ -63,6 +65,7 @@ func (t *transport) RoundTrip(req *http.Request) (res *http.Response, err error)
 			body:    res.Body,
 			op:      "read",
 			start:   start,
+			tags:    tags,
 		}
 	}
 
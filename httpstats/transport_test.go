@@ -1,3 +1,5 @@
+//go:build !statsoff
+
 package httpstats
 
 import (
@@ -103,3 +105,48 @@ func TestTransportError(t *testing.T) {
 		t.Log(m)
 	}
 }
+
+func TestTransportContextTags(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte("Hello World!"))
+	}))
+	defer server.Close()
+
+	httpc := &http.Client{
+		Transport: NewTransportWith(e, nil),
+	}
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req = req.WithContext(stats.ContextWithTags(req.Context(), stats.T("tenant", "acme")))
+
+	res, err := httpc.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	measures := h.Measures()
+
+	if len(measures) == 0 {
+		t.Error("no measures reported by http transport")
+	}
+
+	for _, m := range measures {
+		if !hasTag(m.Tags, stats.T("tenant", "acme")) {
+			t.Error("contextual tag missing from the measure:", m)
+		}
+	}
+}
+
+func hasTag(tags []stats.Tag, tag stats.Tag) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -2,7 +2,6 @@ package influxdb
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -15,6 +14,7 @@ import (
 
 	"github.com/segmentio/objconv/json"
 	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/httpauth"
 )
 
 const (
@@ -48,9 +48,26 @@ type ClientConfig struct {
 	// Maximum amount of time that requests to InfluxDB may take.
 	Timeout time.Duration
 
+	// Policy applied to retry the requests which failed. When Retry.Retryable
+	// is nil, requests rejected by InfluxDB with a client error are not
+	// retried, except on 408 Request Timeout and 429 Too Many Requests.
+	Retry stats.RetryPolicy
+
 	// Transport configures the HTTP transport used by the client to send
 	// requests to InfluxDB. By default http.DefaultTransport is used.
 	Transport http.RoundTripper
+
+	// Credentials used to authenticate the requests sent to InfluxDB.
+	Auth httpauth.Config
+
+	// Handler that receives the measures which could not be sent, tagged with
+	// the error that occurred. See stats.Buffer for details.
+	DeadLetter stats.Handler
+
+	// Source of the timestamps of the points sent to InfluxDB. Defaults to
+	// stats.CaptureTime, with stats.ReceiveTime the points carry no timestamps
+	// and InfluxDB uses the time at which it received them.
+	TimeSource stats.TimeSource
 }
 
 // Client represents an InfluxDB client that implements the stats.Handler
@@ -87,10 +104,23 @@ func NewClientWith(config ClientConfig) *Client {
 		config.Timeout = DefaultTimeout
 	}
 
+	if config.Retry.Retryable == nil {
+		config.Retry.Retryable = retryable
+	}
+
+	transport, err := config.Auth.Transport(config.Transport)
+	if err != nil {
+		// The error is reported by each request sent by the client.
+		transport = failingTransport{err}
+	}
+	config.Transport = transport
+
 	c := &Client{
 		serializer: serializer{
-			url:  makeURL(config.Address, config.Database),
-			done: make(chan struct{}),
+			url:   makeURL(config.Address, config.Database),
+			retry: config.Retry,
+			times: config.TimeSource,
+			done:  make(chan struct{}),
 			http: http.Client{
 				Timeout:   config.Timeout,
 				Transport: config.Transport,
@@ -100,6 +130,7 @@ func NewClientWith(config ClientConfig) *Client {
 
 	c.buffer.BufferSize = config.BufferSize
 	c.buffer.Serializer = &c.serializer
+	c.buffer.DeadLetter = config.DeadLetter
 	return c
 }
 
@@ -126,6 +157,13 @@ func (c *Client) HandleMeasures(time time.Time, measures ...stats.Measure) {
 	c.buffer.HandleMeasures(time, measures...)
 }
 
+// Errors returns a channel receiving the errors which occur sending measures
+// to InfluxDB. Once the program called the method, the errors are no longer
+// logged.
+func (c *Client) Errors() <-chan error {
+	return c.serializer.errors.C()
+}
+
 // Flush satisfies the stats.Flusher interface.
 func (c *Client) Flush() {
 	c.buffer.Flush()
@@ -139,49 +177,61 @@ func (c *Client) Close() error {
 }
 
 type serializer struct {
-	url  *url.URL
-	http http.Client
-	once sync.Once
-	done chan struct{}
+	url    *url.URL
+	http   http.Client
+	retry  stats.RetryPolicy
+	times  stats.TimeSource
+	errors stats.ErrorChannel
+	once   sync.Once
+	done   chan struct{}
 }
 
-func (*serializer) AppendMeasures(b []byte, time time.Time, measures ...stats.Measure) []byte {
+func (s *serializer) AppendMeasures(b []byte, t time.Time, measures ...stats.Measure) []byte {
+	if s.times == stats.ReceiveTime {
+		t = time.Time{}
+	}
 	for _, m := range measures {
-		b = AppendMeasure(b, time, m)
+		b = AppendMeasure(b, t, m)
 	}
 	return b
 }
 
-func (s *serializer) Write(b []byte) (n int, err error) {
-	for attempt := 0; attempt != 10; attempt++ {
-		var res *http.Response
-
-		if attempt != 0 {
-			select {
-			case <-time.After(s.http.Timeout):
-			case <-s.done:
-				err = context.Canceled
-				return
-			}
-		}
-
+func (s *serializer) Write(b []byte) (int, error) {
+	err := s.retry.Do(s.done, func() error {
 		req, _ := http.NewRequest("POST", s.url.String(), bytes.NewReader(b))
-		res, err = s.http.Do(req)
+		res, err := s.http.Do(req)
 		if err != nil {
-			log.Print("stats/influxdb:", err)
-			continue
+			s.report(err)
+			return err
 		}
 
 		if err = readResponse(res); err != nil {
-			log.Printf("stats/influxdb: POST %s: %d %s: %s", s.url, res.StatusCode, res.Status, err)
-			continue
+			s.report(fmt.Errorf("POST %s: %d %s: %s", s.url, res.StatusCode, res.Status, err))
+			return err
 		}
 
-		break
+		return nil
+	})
+	return len(b), err
+}
+
+// report sends err to the error channel of the client, or logs it if the
+// program does not consume the channel.
+func (s *serializer) report(err error) {
+	if !s.errors.Report(err) {
+		log.Print("stats/influxdb: ", err)
 	}
+}
+
+// failingTransport is a http.RoundTripper which fails all requests with the
+// error that prevented the configuration of the client transport.
+type failingTransport struct{ err error }
 
-	n = len(b)
-	return
+func (t failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, t.err
 }
 
 func makeURL(address string, database string) *url.URL {
@@ -227,11 +277,23 @@ func readResponse(r *http.Response) error {
 		return err
 	}
 
+	info.status = r.StatusCode
 	return info
 }
 
+// retryable is the default classifier of the errors of requests sent to
+// InfluxDB, client errors are not retried since sending the same batch again
+// would fail the same way.
+func retryable(err error) bool {
+	if e, ok := err.(*influxError); ok && e.status >= 400 && e.status < 500 {
+		return e.status == http.StatusRequestTimeout || e.status == http.StatusTooManyRequests
+	}
+	return true
+}
+
 type influxError struct {
-	Err string `json:"error"`
+	Err    string `json:"error"`
+	status int
 }
 
 func (e *influxError) Error() string {
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -47,6 +49,67 @@ func TestClient(t *testing.T) {
 	}
 }
 
+func TestClientRetry(t *testing.T) {
+	tests := []struct {
+		scenario string
+		statuses []int
+		attempts int32
+	}{
+		{
+			scenario: "server errors are retried",
+			statuses: []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusNoContent},
+			attempts: 3,
+		},
+		{
+			scenario: "client errors are not retried",
+			statuses: []int{http.StatusBadRequest, http.StatusNoContent},
+			attempts: 1,
+		},
+		{
+			scenario: "rate limited requests are retried",
+			statuses: []int{http.StatusTooManyRequests, http.StatusNoContent},
+			attempts: 2,
+		},
+		{
+			scenario: "requests are not retried after the maximum number of attempts",
+			statuses: []int{500, 500, 500, 500, 500},
+			attempts: 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			var attempts int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.ContentLength == 0 {
+					// The buffers which have no measures are flushed too.
+					return
+				}
+				n := atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(test.statuses[n-1])
+				w.Write([]byte(`{"error":"oops"}`))
+			}))
+			defer server.Close()
+
+			client := NewClientWith(ClientConfig{
+				Address: server.URL,
+				Retry:   stats.RetryPolicy{MaxAttempts: 4, MinBackoff: time.Millisecond},
+			})
+
+			client.HandleMeasures(time.Now(), stats.Measure{
+				Name:   "request",
+				Fields: []stats.Field{stats.MakeField("count", 1, stats.Counter)},
+			})
+			client.Flush()
+
+			if n := atomic.LoadInt32(&attempts); n != test.attempts {
+				t.Errorf("bad number of attempts: %d != %d", n, test.attempts)
+			}
+		})
+	}
+}
+
 func BenchmarkClient(b *testing.B) {
 	for _, N := range []int{1, 10, 100} {
 		b.Run(fmt.Sprintf("write a batch of %d measures to a client", N), func(b *testing.B) {
@@ -108,3 +171,68 @@ func (t *discardTransport) RoundTrip(req *http.Request) (*http.Response, error)
 		Request:    req,
 	}, nil
 }
+
+func TestClientTimeSource(t *testing.T) {
+	tests := []struct {
+		source stats.TimeSource
+		body   string
+	}{
+		{source: stats.CaptureTime, body: "request count=1 1500780960123456789\n"},
+		{source: stats.ReceiveTime, body: "request count=1\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source.String(), func(t *testing.T) {
+			var body []byte
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.ContentLength != 0 {
+					body, _ = ioutil.ReadAll(req.Body)
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			client := NewClientWith(ClientConfig{
+				Address:    server.URL,
+				TimeSource: test.source,
+			})
+
+			client.HandleMeasures(timestamp, stats.Measure{
+				Name:   "request",
+				Fields: []stats.Field{stats.MakeField("count", 1, stats.Counter)},
+			})
+			client.Flush()
+
+			if string(body) != test.body {
+				t.Errorf("bad body: %q != %q", body, test.body)
+			}
+		})
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"oops"}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWith(ClientConfig{Address: server.URL})
+	errors := client.Errors()
+
+	client.HandleMeasures(time.Now(), stats.Measure{
+		Name:   "request",
+		Fields: []stats.Field{stats.MakeField("count", 1, stats.Counter)},
+	})
+	client.Flush()
+
+	select {
+	case err := <-errors:
+		if !strings.Contains(err.Error(), "400") {
+			t.Error("bad error:", err)
+		}
+	default:
+		t.Error("no errors were received")
+	}
+}
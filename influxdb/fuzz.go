@@ -0,0 +1,29 @@
+//go:build gofuzz
+
+package influxdb
+
+import (
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+// Fuzz is the entry point for go-fuzz, it verifies that measures built from
+// the fuzzer data cannot inject records in the InfluxDB line protocol.
+//
+// Control characters are removed from the measures by a stats.Sanitizer before
+// they are serialized.
+func Fuzz(data []byte) int {
+	m := statstest.Sanitize(statstest.FuzzMeasure(data))
+
+	if err := statstest.CheckRecords(appendMeasure, m, 1); err != nil {
+		panic(err)
+	}
+
+	return 1
+}
+
+func appendMeasure(b []byte, m stats.Measure) []byte {
+	return AppendMeasure(b, time.Time{}, m)
+}
@@ -8,7 +8,11 @@ import (
 )
 
 // AppendMeasure is a formatting routine to append the InflxDB line protocol
-// representation of a measure to a memory buffer.
+// representation of a measure to a memory buffer. The timestamp is omitted
+// when t is the zero time.
+//
+// The line protocol has no notion of sample rates, so the increments of sampled
+// counters are scaled by the inverse of the sample rate of the measure.
 func AppendMeasure(b []byte, t time.Time, m stats.Measure) []byte {
 	b = append(b, m.Name...)
 
@@ -33,7 +37,12 @@ func AppendMeasure(b []byte, t time.Time, m stats.Measure) []byte {
 		b = append(b, field.Name...)
 		b = append(b, '=')
 
-		switch v := field.Value; v.Type() {
+		v := field.Value
+		if field.Type() == stats.Counter && m.SampleRate > 0 && m.SampleRate < 1 {
+			v = unsample(v, m.SampleRate)
+		}
+
+		switch v.Type() {
 		case stats.Null:
 		case stats.Bool:
 			if v.Bool() {
@@ -52,8 +61,24 @@ func AppendMeasure(b []byte, t time.Time, m stats.Measure) []byte {
 		}
 	}
 
-	b = append(b, ' ')
-	b = strconv.AppendInt(b, t.UnixNano(), 10)
+	if !t.IsZero() {
+		b = append(b, ' ')
+		b = strconv.AppendInt(b, t.UnixNano(), 10)
+	}
 
 	return append(b, '\n')
 }
+
+func unsample(v stats.Value, rate float64) stats.Value {
+	switch v.Type() {
+	case stats.Int:
+		return stats.ValueOf(float64(v.Int()) / rate)
+	case stats.Uint:
+		return stats.ValueOf(float64(v.Uint()) / rate)
+	case stats.Float:
+		return stats.ValueOf(v.Float() / rate)
+	case stats.Duration:
+		return stats.ValueOf(time.Duration(float64(v.Duration()) / rate))
+	}
+	return v
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
 )
 
 var (
@@ -51,3 +52,15 @@ func TestAppendMetric(t *testing.T) {
 		})
 	}
 }
+
+func TestAppendMeasureHostile(t *testing.T) {
+	appendMeasure := func(b []byte, m stats.Measure) []byte { return AppendMeasure(b, timestamp, m) }
+
+	for _, m := range statstest.HostileMeasures() {
+		m = statstest.Sanitize(m)
+
+		if err := statstest.CheckRecords(appendMeasure, m, 1); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RegisterInstrumentation makes the instrumentation of a library available
+// under name. It is intended to be called from the init function of packages
+// that produce metrics, so the program importing them can decide which engine
+// the metrics are reported to, and which libraries are instrumented at all.
+//
+// When the program calls Instrument on an engine, setup is invoked with a copy
+// of the engine prefixed with name, the library is expected to retain it and
+// use it to produce its metrics. Setup may be invoked again later, when the
+// program instruments the library with another engine, or disables it with
+// Uninstrument.
+//
+// The function panics if name is empty, if setup is nil, or if another
+// library was already registered under the same name.
+func RegisterInstrumentation(name string, setup func(*Engine)) {
+	if len(name) == 0 {
+		panic("stats.RegisterInstrumentation: empty instrumentation name")
+	}
+
+	if setup == nil {
+		panic("stats.RegisterInstrumentation: nil setup function for " + name)
+	}
+
+	instrumentations.Lock()
+	defer instrumentations.Unlock()
+
+	if _, dup := instrumentations.setup[name]; dup {
+		panic("stats.RegisterInstrumentation: instrumentation registered twice for " + name)
+	}
+
+	if instrumentations.setup == nil {
+		instrumentations.setup = make(map[string]func(*Engine))
+	}
+
+	instrumentations.setup[name] = setup
+}
+
+// UnregisterInstrumentation removes the instrumentation registered under name,
+// which is not listed by Instrumentations and cannot be enabled anymore. The
+// libraries that were already instrumented keep producing metrics, Uninstrument
+// must be called first to disable them. The function does nothing if no
+// library was registered under name.
+func UnregisterInstrumentation(name string) {
+	instrumentations.Lock()
+	delete(instrumentations.setup, name)
+	instrumentations.Unlock()
+}
+
+// Instrumentations returns the sorted list of names of libraries that have
+// registered their instrumentation.
+func Instrumentations() []string {
+	instrumentations.RLock()
+	names := make([]string, 0, len(instrumentations.setup))
+	for name := range instrumentations.setup {
+		names = append(names, name)
+	}
+	instrumentations.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Instrument enables the instrumentation of the libraries registered under the
+// given names, routing their metrics to eng. If no names are given, all
+// registered libraries are instrumented.
+//
+// The method returns an error if some of the names were not registered, the
+// libraries that were found are instrumented regardless.
+func (eng *Engine) Instrument(names ...string) error {
+	return instrument(names, func(name string) *Engine { return eng.WithPrefix(name) })
+}
+
+// Uninstrument disables the instrumentation of the libraries registered under
+// the given names, which are passed an engine discarding their measures. If no
+// names are given, all registered libraries are disabled.
+//
+// The function returns an error if some of the names were not registered, the
+// libraries that were found are disabled regardless.
+func Uninstrument(names ...string) error {
+	return instrument(names, func(name string) *Engine { return NewEngine(name, Discard) })
+}
+
+func instrument(names []string, engine func(name string) *Engine) error {
+	if len(names) == 0 {
+		names = Instrumentations()
+	}
+
+	var missing []string
+
+	for _, name := range names {
+		instrumentations.RLock()
+		setup := instrumentations.setup[name]
+		instrumentations.RUnlock()
+
+		if setup == nil {
+			missing = append(missing, name)
+			continue
+		}
+
+		setup(engine(name))
+	}
+
+	if len(missing) != 0 {
+		return fmt.Errorf("stats: no instrumentation registered for %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// Instrument enables the instrumentation of the libraries registered under the
+// given names on the default engine.
+func Instrument(names ...string) error {
+	return DefaultEngine.Instrument(names...)
+}
+
+var instrumentations struct {
+	sync.RWMutex
+	setup map[string]func(*Engine)
+}
@@ -0,0 +1,80 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+var kafka *stats.Engine
+
+func init() {
+	stats.RegisterInstrumentation("test-kafka", func(eng *stats.Engine) { kafka = eng })
+}
+
+func TestInstrument(t *testing.T) {
+	t.Run("registering the same name twice panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("no panic on duplicate registration")
+			}
+		}()
+		stats.RegisterInstrumentation("test-kafka", func(*stats.Engine) {})
+	})
+
+	t.Run("instrumenting a library passes it a namespaced engine", func(t *testing.T) {
+		h := &statstest.Handler{}
+		e := stats.NewEngine("app", h)
+
+		if err := e.Instrument("test-kafka"); err != nil {
+			t.Fatal(err)
+		}
+
+		kafka.Incr("messages")
+
+		if m := h.Measures(); len(m) != 1 || m[0].Name != "app.test-kafka.messages" {
+			t.Error("bad measures:", m)
+		}
+	})
+
+	t.Run("instrumenting an unknown library returns an error", func(t *testing.T) {
+		if err := stats.NewEngine("", stats.Discard).Instrument("test-unknown"); err == nil {
+			t.Error("no error returned")
+		}
+	})
+	t.Run("uninstrumenting a library passes it an engine discarding measures", func(t *testing.T) {
+		h := &statstest.Handler{}
+
+		if err := stats.NewEngine("app", h).Instrument("test-kafka"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := stats.Uninstrument("test-kafka"); err != nil {
+			t.Fatal(err)
+		}
+
+		kafka.Incr("messages")
+
+		if m := h.Measures(); len(m) != 0 {
+			t.Error("measures reported by a disabled instrumentation:", m)
+		}
+	})
+
+	t.Run("unregistering a library removes it from the registry", func(t *testing.T) {
+		stats.RegisterInstrumentation("test-redis", func(*stats.Engine) {})
+		stats.UnregisterInstrumentation("test-redis")
+
+		for _, name := range stats.Instrumentations() {
+			if name == "test-redis" {
+				t.Error("the instrumentation was not unregistered")
+			}
+		}
+
+		if err := stats.Uninstrument("test-redis"); err == nil {
+			t.Error("no error returned for an unregistered library")
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// Job collects the metrics of a short-lived program (a batch job or a cron
+// task for example) during its run, and reports them to a backend in a single
+// aggregated report when the program finishes:
+//
+//	job := stats.StartJob("backfill", influxdb.NewClient("localhost:8086"))
+//
+//	for _, r := range records {
+//		process(r)
+//		job.Incr("records")
+//	}
+//
+//	if err := job.Finish(0); err != nil {
+//		log.Print("failed to report the job metrics: ", err)
+//	}
+//
+// Programs which run for a few seconds often exit before a periodic flush sent
+// their metrics, and backends which compute rates from repeated reports make
+// little sense of the metrics of a single run. Jobs aggregate the measures
+// locally instead: the increments of counters are summed, the last values of
+// gauges are retained, and all histogram observations are kept.
+//
+// Jobs are engines, the measures produced by the engines derived from them
+// with WithPrefix and WithTags are part of the report.
+type Job struct {
+	*Engine
+
+	handler Handler
+	start   time.Time
+	once    sync.Once
+	err     error
+	report  jobReport
+}
+
+// StartJob starts collecting the metrics of a job named name, which are
+// reported to handler when the job is finished. The name is used as prefix of
+// the metrics produced by the job.
+func StartJob(name string, handler Handler, tags ...Tag) *Job {
+	job := &Job{handler: handler}
+	job.Engine = NewEngine(name, &job.report, tags...)
+	job.start = job.Now()
+	return job
+}
+
+// Finish reports the metrics collected during the run of the job to its
+// handler, with a measure named after the job carrying the duration of the
+// run and the exit status of the program, then flushes the handler.
+//
+// The method blocks until the handler was flushed. When the handler reports
+// delivery errors (see Engine.Errors), Finish returns the first error that
+// occurred sending the report. Calling Finish more than once only reports the
+// metrics once and returns the same error.
+func (job *Job) Finish(status int) error {
+	job.once.Do(func() { job.err = job.finish(status) })
+	return job.err
+}
+
+func (job *Job) finish(status int) error {
+	now := job.Now()
+	result := "success"
+
+	if status != 0 {
+		result = "failure"
+	}
+
+	measures := job.report.measures()
+	measures = append(measures, Measure{
+		Name: job.Prefix,
+		Fields: []Field{
+			MakeField("duration.seconds", now.Sub(job.start), Gauge),
+			MakeField("exit_status", status, Gauge),
+		},
+		Tags: job.makeTags([]Tag{T("status", result)}),
+	})
+
+	var errs <-chan error
+	if h, ok := job.handler.(interface{ Errors() <-chan error }); ok {
+		errs = h.Errors()
+		// Errors which were reported before the job finished are not about
+		// the report.
+		drainErrors(errs)
+	}
+
+	job.handler.HandleMeasures(now, measures...)
+	flush(job.handler)
+
+	return drainErrors(errs)
+}
+
+// drainErrors consumes the errors available on errs and returns the first one.
+func drainErrors(errs <-chan error) (first error) {
+	for {
+		select {
+		case err := <-errs:
+			if first == nil {
+				first = err
+			}
+		default:
+			return
+		}
+	}
+}
+
+// jobReport is the handler aggregating the measures of jobs.
+type jobReport struct {
+	mutex  sync.Mutex
+	series map[string]*jobSeries
+	order  []*jobSeries
+	keys   []byte
+}
+
+type jobSeries struct {
+	name   string
+	field  Field
+	tags   []Tag
+	values []Value // observations of histograms, which carry the field type
+}
+
+func (r *jobReport) HandleMeasures(t time.Time, measures ...Measure) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, m := range measures {
+		for _, f := range m.Fields {
+			r.keys = appendSeriesKey(r.keys[:0], m.Name, f.Name, m.Tags)
+			s := r.series[string(r.keys)]
+
+			if s == nil {
+				if r.series == nil {
+					r.series = make(map[string]*jobSeries)
+				}
+				s = &jobSeries{name: m.Name, field: f, tags: copyTags(m.Tags)}
+				r.series[string(r.keys)] = s
+				r.order = append(r.order, s)
+
+				if f.Type() == Histogram {
+					s.values = append(s.values, f.Value)
+				}
+				continue
+			}
+
+			switch f.Type() {
+			case Counter:
+				s.field.Value = addValues(s.field.Value, f.Value)
+				s.field.setType(Counter)
+			case Gauge:
+				s.field = f
+			case Histogram:
+				s.values = append(s.values, f.Value)
+			}
+		}
+	}
+}
+
+// measures returns the aggregated measures, in the order the series were first
+// seen.
+func (r *jobReport) measures() []Measure {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	measures := make([]Measure, 0, len(r.order))
+
+	for _, s := range r.order {
+		if s.field.Type() != Histogram {
+			measures = append(measures, Measure{Name: s.name, Fields: []Field{s.field}, Tags: s.tags})
+			continue
+		}
+		for _, v := range s.values {
+			measures = append(measures, Measure{
+				Name:   s.name,
+				Fields: []Field{{Name: s.field.Name, Value: v}},
+				Tags:   s.tags,
+			})
+		}
+	}
+
+	return measures
+}
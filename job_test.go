@@ -0,0 +1,80 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestJob(t *testing.T) {
+	h := &statstest.Handler{}
+	job := stats.StartJob("backfill", h, stats.T("env", "test"))
+
+	job.Incr("records")
+	job.Add("records", 2)
+	job.Set("queue.size", 10)
+	job.Set("queue.size", 5)
+	job.Observe("record.size", 100)
+	job.Observe("record.size", 200)
+
+	if n := len(h.Measures()); n != 0 {
+		t.Fatal("measures were reported before the job finished:", n)
+	}
+
+	if err := job.Finish(1); err != nil {
+		t.Fatal(err)
+	}
+	job.Finish(0) // the report is only sent once
+
+	if h.FlushCalls() != 1 {
+		t.Error("bad number of flushes:", h.FlushCalls())
+	}
+
+	env := stats.T("env", "test")
+	h.ExpectCounter(t, "backfill.records", 3, env)
+	h.ExpectGauge(t, "backfill.queue.size", 5, env)
+	h.ExpectHistogram(t, "backfill.record.size", []float64{100, 200}, env)
+	h.ExpectGauge(t, "backfill:exit_status", 1, stats.T("status", "failure"), env)
+
+	if values := h.Values("backfill:duration.seconds"); len(values) != 1 || values[0] < 0 {
+		t.Error("bad job duration:", values)
+	}
+}
+
+func TestJobErrors(t *testing.T) {
+	h := &errorHandler{errors: make(chan error, 10)}
+
+	h.errors <- errors.New("reported before the job finished")
+	job := stats.StartJob("backfill", h)
+	job.Incr("records")
+
+	if err := job.Finish(0); err == nil || err.Error() != "failed" {
+		t.Error("bad error:", err)
+	}
+
+	if !reflect.DeepEqual(h.names, []string{"backfill.records", "backfill"}) {
+		t.Error("bad measures:", h.names)
+	}
+}
+
+// errorHandler is a handler whose flushes fail.
+type errorHandler struct {
+	errors chan error
+	names  []string
+}
+
+func (h *errorHandler) HandleMeasures(_ time.Time, measures ...stats.Measure) {
+	for _, m := range measures {
+		h.names = append(h.names, m.Name)
+	}
+}
+
+func (h *errorHandler) Flush() { h.errors <- errors.New("failed") }
+
+func (h *errorHandler) Errors() <-chan error { return h.errors }
@@ -0,0 +1,104 @@
+package stats
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// ConcurrencyLimit is a semaphore bounding the number of concurrent operations
+// a program performs, which reports metrics about its saturation.
+//
+// The limit produces the following fields on the measure it was named after:
+//
+//	inflight      (gauge)     number of operations in progress
+//	limit         (gauge)     maximum number of concurrent operations
+//	utilization   (gauge)     ratio of inflight over limit
+//	wait.seconds  (histogram) time spent waiting to acquire the limit
+//	rejected      (counter)   number of operations rejected by TryAcquire or
+//	                          because their context was canceled
+//
+// ConcurrencyLimit values are safe to use concurrently from multiple
+// goroutines.
+type ConcurrencyLimit struct {
+	eng      *Engine
+	name     string
+	tags     []Tag
+	limit    int
+	sem      chan struct{}
+	inflight int64
+}
+
+// ConcurrencyLimit returns a new concurrency limit allowing up to limit
+// concurrent operations, reporting metrics on the measure identified by name
+// and tags.
+func (eng *Engine) ConcurrencyLimit(name string, limit int, tags ...Tag) *ConcurrencyLimit {
+	if limit <= 0 {
+		panic("stats.(*Engine).ConcurrencyLimit: limit must be positive")
+	}
+	return &ConcurrencyLimit{
+		eng:   eng,
+		name:  name,
+		tags:  copyTags(tags),
+		limit: limit,
+		sem:   make(chan struct{}, limit),
+	}
+}
+
+// Acquire blocks until an operation can start or ctx is canceled, in which
+// case the context error is returned. Every successful call to Acquire must be
+// followed by a call to Release.
+func (c *ConcurrencyLimit) Acquire(ctx context.Context) error {
+	start := c.eng.Now()
+
+	select {
+	case c.sem <- struct{}{}:
+	default:
+		select {
+		case c.sem <- struct{}{}:
+		case <-ctx.Done():
+			c.eng.Incr(c.name+":rejected", c.tags...)
+			return ctx.Err()
+		}
+	}
+
+	c.eng.Observe(c.name+":wait.seconds", c.eng.Now().Sub(start), c.tags...)
+	c.update(+1)
+	return nil
+}
+
+// TryAcquire attempts to start an operation without blocking, returning false
+// if the limit was reached. Every successful call to TryAcquire must be
+// followed by a call to Release.
+func (c *ConcurrencyLimit) TryAcquire() bool {
+	select {
+	case c.sem <- struct{}{}:
+		c.update(+1)
+		return true
+	default:
+		c.eng.Incr(c.name+":rejected", c.tags...)
+		return false
+	}
+}
+
+// Release marks the end of an operation.
+func (c *ConcurrencyLimit) Release() {
+	<-c.sem
+	c.update(-1)
+}
+
+// InFlight returns the number of operations in progress.
+func (c *ConcurrencyLimit) InFlight() int {
+	return int(atomic.LoadInt64(&c.inflight))
+}
+
+// Limit returns the maximum number of concurrent operations.
+func (c *ConcurrencyLimit) Limit() int {
+	return c.limit
+}
+
+func (c *ConcurrencyLimit) update(delta int64) {
+	n := atomic.AddInt64(&c.inflight, delta)
+	c.eng.Set(c.name+":inflight", n, c.tags...)
+	c.eng.Set(c.name+":limit", c.limit, c.tags...)
+	c.eng.Set(c.name+":utilization", float64(n)/float64(c.limit), c.tags...)
+}
@@ -0,0 +1,63 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", h)
+	lim := eng.ConcurrencyLimit("workers", 2)
+
+	if !lim.TryAcquire() || !lim.TryAcquire() {
+		t.Fatal("failed to acquire the limit")
+	}
+
+	if lim.TryAcquire() {
+		t.Fatal("acquired more than the limit")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := lim.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Error("bad error:", err)
+	}
+
+	lim.Release()
+
+	if err := lim.Acquire(context.Background()); err != nil {
+		t.Error(err)
+	}
+
+	if n := lim.InFlight(); n != 2 {
+		t.Error("bad number of inflight operations:", n)
+	}
+
+	var rejected int
+	var utilization float64
+
+	for _, m := range h.Measures() {
+		switch f := m.Fields[0]; f.Name {
+		case "rejected":
+			rejected += int(f.Value.Int())
+		case "utilization":
+			utilization = f.Value.Float()
+		}
+	}
+
+	if rejected != 2 {
+		t.Error("bad number of rejected operations:", rejected)
+	}
+
+	if utilization != 1 {
+		t.Error("bad utilization:", utilization)
+	}
+}
@@ -20,6 +20,18 @@ type Measure struct {
 	Name   string
 	Fields []Field
 	Tags   []Tag
+
+	// When the measure was produced by a Handle, Const holds the constant tags
+	// that were bound to it (they are also part of Tags). Handlers may use it
+	// to avoid serializing those tags on every measure.
+	Const *TagSet
+
+	// When the measure was sampled, SampleRate is the fraction of the counter
+	// increments and histogram observations that were kept (for example 0.1),
+	// it is zero if the measure was not sampled. Handlers aggregating values
+	// scale them by the inverse of the rate, and backends supporting sample
+	// rates (like the statsd protocol) forward it.
+	SampleRate float64
 }
 
 // Clone creates and returns a deep copy of m. The original and returned values
@@ -27,9 +39,11 @@ type Measure struct {
 // for example).
 func (m Measure) Clone() Measure {
 	return Measure{
-		Name:   m.Name,
-		Fields: copyFields(m.Fields),
-		Tags:   copyTags(m.Tags),
+		Name:       m.Name,
+		Fields:     copyFields(m.Fields),
+		Tags:       copyTags(m.Tags),
+		Const:      m.Const,
+		SampleRate: m.SampleRate,
 	}
 }
 
@@ -208,6 +222,8 @@ func (m *Measure) reset() {
 	m.Name = ""
 	m.Fields = m.Fields[:0]
 	m.Tags = m.Tags[:0]
+	m.Const = nil
+	m.SampleRate = 0
 }
 
 type measureFuncs struct {
@@ -443,6 +459,11 @@ func concat(prefix string, suffix string) string {
 	if len(suffix) == 0 {
 		return prefix
 	}
+	if prefix[len(prefix)-1] == '.' {
+		// Namespaces are commonly written with a trailing separator, like
+		// "myservice.", which must not be repeated.
+		return prefix + suffix
+	}
 	return prefix + "." + suffix
 }
 
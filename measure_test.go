@@ -119,3 +119,23 @@ func TestMakeMeasures(t *testing.T) {
 		t.Logf("founc:    %#v", measures)
 	}
 }
+
+func TestConcat(t *testing.T) {
+	tests := []struct {
+		prefix string
+		suffix string
+		name   string
+	}{
+		{"", "", ""},
+		{"", "requests", "requests"},
+		{"myservice", "", "myservice"},
+		{"myservice", "requests", "myservice.requests"},
+		{"myservice.", "requests", "myservice.requests"},
+	}
+
+	for _, test := range tests {
+		if name := concat(test.prefix, test.suffix); name != test.name {
+			t.Errorf("concat(%q, %q) => %q != %q", test.prefix, test.suffix, name, test.name)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package stats
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// MsgpackEncoder encodes measures as MessagePack maps with the same structure
+// as the objects produced by JSONEncoder, one per measure. Times are encoded
+// with the timestamp extension type, durations as numbers of seconds.
+type MsgpackEncoder struct{}
+
+// AppendMeasures satisfies the Encoder interface.
+func (MsgpackEncoder) AppendMeasures(b []byte, t time.Time, measures ...Measure) []byte {
+	for _, m := range measures {
+		if sampling(m.SampleRate) {
+			b = append(b, 0x85) // fixmap of 5 entries
+		} else {
+			b = append(b, 0x84) // fixmap of 4 entries
+		}
+		b = appendMsgpackString(b, "time")
+		b = appendMsgpackTime(b, t)
+		b = appendMsgpackString(b, "name")
+		b = appendMsgpackString(b, m.Name)
+
+		b = appendMsgpackString(b, "fields")
+		b = appendMsgpackHeader(b, len(m.Fields), 0x90, 0xdc)
+
+		for _, f := range m.Fields {
+			b = append(b, 0x83) // fixmap of 3 entries
+			b = appendMsgpackString(b, "name")
+			b = appendMsgpackString(b, f.Name)
+			b = appendMsgpackString(b, "type")
+			b = appendMsgpackString(b, f.Type().String())
+			b = appendMsgpackString(b, "value")
+			b = appendMsgpackValue(b, f.Value)
+		}
+
+		b = appendMsgpackString(b, "tags")
+		b = appendMsgpackHeader(b, len(m.Tags), 0x80, 0xde)
+
+		for _, tag := range m.Tags {
+			b = appendMsgpackString(b, tag.Name)
+			b = appendMsgpackString(b, tag.Value)
+		}
+
+		if sampling(m.SampleRate) {
+			b = appendMsgpackString(b, "sample_rate")
+			b = appendMsgpackValue(b, float64Value(m.SampleRate))
+		}
+	}
+	return b
+}
+
+// appendMsgpackHeader appends the header of an array or map of n elements,
+// fix is the type of the fixed size variant and typ the one of the 16 bits
+// variant, the 32 bits variant follows it.
+func appendMsgpackHeader(b []byte, n int, fix byte, typ byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, typ)
+		return appendUint16(b, uint16(n))
+	default:
+		b = append(b, typ+1)
+		return appendUint32(b, uint32(n))
+	}
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, 0xda)
+		b = appendUint16(b, uint16(n))
+	default:
+		b = append(b, 0xdb)
+		b = appendUint32(b, uint32(n))
+	}
+	return append(b, s...)
+}
+
+func appendMsgpackValue(b []byte, v Value) []byte {
+	switch v.Type() {
+	case Bool:
+		if v.Bool() {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case Int:
+		b = append(b, 0xd3)
+		return appendUint64(b, uint64(v.Int()))
+	case Uint:
+		b = append(b, 0xcf)
+		return appendUint64(b, v.Uint())
+	case Float:
+		b = append(b, 0xcb)
+		return appendUint64(b, math.Float64bits(v.Float()))
+	case Duration:
+		b = append(b, 0xcb)
+		return appendUint64(b, math.Float64bits(v.Duration().Seconds()))
+	default:
+		return append(b, 0xc0)
+	}
+}
+
+// appendMsgpackTime appends t with the 96 bits variant of the timestamp
+// extension type.
+func appendMsgpackTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xc7, 12, 0xff)
+	b = appendUint32(b, uint32(t.Nanosecond()))
+	return appendUint64(b, uint64(t.Unix()))
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	var a [2]byte
+	binary.BigEndian.PutUint16(a[:], v)
+	return append(b, a[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var a [4]byte
+	binary.BigEndian.PutUint32(a[:], v)
+	return append(b, a[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var a [8]byte
+	binary.BigEndian.PutUint64(a[:], v)
+	return append(b, a[:]...)
+}
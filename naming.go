@@ -0,0 +1,227 @@
+package stats
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NameCase is an enumeration of the conventions that a NamingTemplate may
+// convert the names of metrics to.
+type NameCase int
+
+const (
+	// KeepCase leaves the names unchanged.
+	KeepCase NameCase = iota
+
+	// SnakeCase converts the names to lower case words separated by
+	// underscores, like "http_requests", which is the convention of Prometheus.
+	SnakeCase
+
+	// CamelCase converts the names to words starting with an upper case
+	// letter except for the first one, with no separators, like "httpRequests".
+	CamelCase
+
+	// DotCase converts the names to lower case words separated by dots, like
+	// "http.requests", which is the convention of Graphite and Datadog.
+	DotCase
+)
+
+// String satisfies the fmt.Stringer interface.
+func (c NameCase) String() string {
+	switch c {
+	case KeepCase:
+		return "keep"
+	case SnakeCase:
+		return "snake_case"
+	case CamelCase:
+		return "camelCase"
+	case DotCase:
+		return "dot.case"
+	default:
+		return "unknown"
+	}
+}
+
+// NamingTemplate is a measure handler which rewrites the names of measures and
+// fields before forwarding them to its base handler, so the same program can
+// satisfy the naming conventions of different backends at the same time:
+//
+//	stats.Register(stats.MultiHandler(
+//		&stats.NamingTemplate{Handler: datadogClient, Case: stats.DotCase},
+//		&stats.NamingTemplate{
+//			Handler:  prometheusHandler,
+//			Case:     stats.SnakeCase,
+//			Suffixes: map[stats.Type]string{stats.Duration: "seconds"},
+//		},
+//	))
+//
+// Words in names are delimited by dots, underscores, dashes, spaces, and by
+// upper case letters following lower case letters or digits.
+//
+// Tags are forwarded unchanged.
+type NamingTemplate struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// A prefix prepended to the measure names, before the case conversion.
+	Prefix string
+
+	// The convention that measure and field names are converted to. Defaults
+	// to KeepCase.
+	Case NameCase
+
+	// Words appended to the names of fields holding values of the types used
+	// as keys of the map (like "seconds" for durations), before the case
+	// conversion. Suffixes are not repeated on fields whose names already end
+	// with them.
+	//
+	// The map must not be modified after the template was first used.
+	Suffixes map[Type]string
+
+	mutex sync.RWMutex
+	names map[string]string
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (n *NamingTemplate) HandleMeasures(t time.Time, measures ...Measure) {
+	b := measurePool.Get().(*measuresBuffer)
+	ms := b.measures[:0]
+
+	for _, m := range measures {
+		fields := make([]Field, len(m.Fields))
+
+		for i, f := range m.Fields {
+			fields[i] = Field{Name: n.fieldName(f), Value: f.Value}
+		}
+
+		ms = append(ms, Measure{
+			Name:       n.convert(concat(n.Prefix, m.Name)),
+			Fields:     fields,
+			Tags:       m.Tags,
+			Const:      m.Const,
+			SampleRate: m.SampleRate,
+		})
+	}
+
+	if len(ms) != 0 {
+		n.Handler.HandleMeasures(t, ms...)
+	}
+
+	for i := range ms {
+		ms[i] = Measure{}
+	}
+
+	b.measures = ms[:0]
+	measurePool.Put(b)
+}
+
+// Flush satisfies the Flusher interface.
+func (n *NamingTemplate) Flush() {
+	flush(n.Handler)
+}
+
+func (n *NamingTemplate) fieldName(f Field) string {
+	name := f.Name
+
+	if suffix := n.Suffixes[f.Value.Type()]; suffix != "" && !hasNameSuffix(name, suffix) {
+		name = concat(name, suffix)
+	}
+
+	return n.convert(name)
+}
+
+// convert returns name converted to the case of the template, conversions are
+// cached since programs produce measures with a small set of names.
+func (n *NamingTemplate) convert(name string) string {
+	if n.Case == KeepCase {
+		return name
+	}
+
+	n.mutex.RLock()
+	s, ok := n.names[name]
+	n.mutex.RUnlock()
+
+	if ok {
+		return s
+	}
+
+	s = convertNameCase(name, n.Case)
+
+	n.mutex.Lock()
+	if n.names == nil {
+		n.names = make(map[string]string)
+	}
+	n.names[name] = s
+	n.mutex.Unlock()
+	return s
+}
+
+func convertNameCase(name string, c NameCase) string {
+	words := splitNameWords(name)
+	b := make([]byte, 0, len(name))
+
+	for i, w := range words {
+		switch c {
+		case SnakeCase:
+			if i != 0 {
+				b = append(b, '_')
+			}
+			b = append(b, strings.ToLower(w)...)
+
+		case DotCase:
+			if i != 0 {
+				b = append(b, '.')
+			}
+			b = append(b, strings.ToLower(w)...)
+
+		case CamelCase:
+			w = strings.ToLower(w)
+			if i != 0 {
+				r, n := utf8.DecodeRuneInString(w)
+				b = append(b, string(unicode.ToUpper(r))...)
+				w = w[n:]
+			}
+			b = append(b, w...)
+		}
+	}
+
+	return string(b)
+}
+
+// splitNameWords splits name into words.
+func splitNameWords(name string) []string {
+	var words []string
+	start := -1
+	prev := rune(0)
+
+	for i, r := range name {
+		switch {
+		case r == '.' || r == '_' || r == '-' || r == ' ':
+			if start >= 0 {
+				words = append(words, name[start:i])
+				start = -1
+			}
+		case start < 0:
+			start = i
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			words = append(words, name[start:i])
+			start = i
+		}
+		prev = r
+	}
+
+	if start >= 0 {
+		words = append(words, name[start:])
+	}
+
+	return words
+}
+
+// hasNameSuffix returns true if the last word of name is suffix.
+func hasNameSuffix(name string, suffix string) bool {
+	words := splitNameWords(name)
+	return len(words) != 0 && strings.EqualFold(words[len(words)-1], suffix)
+}
@@ -0,0 +1,94 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestNamingTemplate(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		naming   stats.NameCase
+		suffixes map[stats.Type]string
+		name     string
+		field    string
+	}{
+		{
+			naming: stats.KeepCase,
+			name:   "myapp.httpServer",
+			field:  "request_time",
+		},
+		{
+			naming:   stats.SnakeCase,
+			suffixes: map[stats.Type]string{stats.Duration: "seconds"},
+			name:     "myapp_http_server",
+			field:    "request_time_seconds",
+		},
+		{
+			prefix: "prod",
+			naming: stats.CamelCase,
+			name:   "prodMyappHttpServer",
+			field:  "requestTime",
+		},
+		{
+			naming: stats.DotCase,
+			name:   "myapp.http.server",
+			field:  "request.time",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.naming.String(), func(t *testing.T) {
+			h := &statstest.Handler{}
+			n := &stats.NamingTemplate{
+				Handler:  h,
+				Prefix:   test.prefix,
+				Case:     test.naming,
+				Suffixes: test.suffixes,
+			}
+
+			eng := stats.NewEngine("myapp", n)
+			eng.Observe("httpServer:request_time", time.Second, stats.T("A", "1"))
+
+			measures := h.Measures()
+			if len(measures) != 1 {
+				t.Fatal("bad number of measures:", len(measures))
+			}
+
+			m := measures[0]
+
+			if m.Name != test.name {
+				t.Errorf("bad measure name: %q != %q", m.Name, test.name)
+			}
+
+			if m.Fields[0].Name != test.field {
+				t.Errorf("bad field name: %q != %q", m.Fields[0].Name, test.field)
+			}
+
+			if !reflect.DeepEqual(m.Tags, []stats.Tag{stats.T("A", "1")}) {
+				t.Error("bad tags:", m.Tags)
+			}
+		})
+	}
+}
+
+func TestNamingTemplateSuffixNotRepeated(t *testing.T) {
+	h := &statstest.Handler{}
+	n := &stats.NamingTemplate{
+		Handler:  h,
+		Case:     stats.SnakeCase,
+		Suffixes: map[stats.Type]string{stats.Duration: "seconds"},
+	}
+
+	stats.NewEngine("", n).Observe("rtt.seconds", time.Second)
+
+	if m := h.Measures()[0]; m.Name != "rtt_seconds" {
+		t.Errorf("bad measure name: %q", m.Name)
+	}
+}
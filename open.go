@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// OpenFunc is the signature of functions registered to construct handlers from
+// connection strings.
+type OpenFunc func(u *url.URL) (Handler, error)
+
+// RegisterScheme makes a handler constructor available under the given URL
+// scheme, so handlers can be created by calling Open with a connection string.
+//
+// Sub-packages implementing handlers for metric collection backends register
+// their scheme in their init function (for example the datadog package
+// registers "dogstatsd"), programs that want to use Open have to import those
+// packages for their side effect. Third-party packages can register their own
+// schemes the same way.
+//
+// The function panics if scheme is empty, if open is nil, or if the scheme was
+// already registered.
+func RegisterScheme(scheme string, open OpenFunc) {
+	if len(scheme) == 0 {
+		panic("stats.RegisterScheme: empty scheme")
+	}
+
+	if open == nil {
+		panic("stats.RegisterScheme: nil open function for " + scheme)
+	}
+
+	schemes.Lock()
+	defer schemes.Unlock()
+
+	if _, dup := schemes.open[scheme]; dup {
+		panic("stats.RegisterScheme: scheme registered twice: " + scheme)
+	}
+
+	if schemes.open == nil {
+		schemes.open = make(map[string]OpenFunc)
+	}
+
+	schemes.open[scheme] = open
+}
+
+// Schemes returns the sorted list of schemes that can be passed to Open.
+func Schemes() []string {
+	schemes.RLock()
+	list := make([]string, 0, len(schemes.open))
+	for scheme := range schemes.open {
+		list = append(list, scheme)
+	}
+	schemes.RUnlock()
+	sort.Strings(list)
+	return list
+}
+
+// Open constructs a handler from a connection string, for example:
+//
+//	h, err := stats.Open("dogstatsd://127.0.0.1:8125?buffer=1432")
+//
+// The scheme of the URL selects the constructor that was registered with
+// RegisterScheme, the rest of the URL is interpreted by the constructor.
+func Open(rawurl string) (Handler, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	schemes.RLock()
+	open := schemes.open[u.Scheme]
+	schemes.RUnlock()
+
+	if open == nil {
+		return nil, fmt.Errorf("stats: unknown scheme %q (forgotten import?)", u.Scheme)
+	}
+
+	return open(u)
+}
+
+var schemes struct {
+	sync.RWMutex
+	open map[string]OpenFunc
+}
@@ -0,0 +1,372 @@
+// Package perfcounter implements a stats handler which publishes measures as
+// Windows performance counters, making them available to Windows-native
+// monitoring tools like Performance Monitor or typeperf.
+//
+// Windows requires counter sets to be registered before they can be published,
+// the manifest generated by CounterSet.WriteManifest must be installed with:
+//
+//	lodctr /m:<manifest> <directory of the executable>
+//
+// On other platforms NewHandler returns an error, manifests can still be
+// generated as part of a cross-platform build process.
+package perfcounter
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// CounterType is an enumeration of the types of performance counters that
+// measures can be published as.
+type CounterType int
+
+const (
+	// RawCount counters display the last value they were set to, they are
+	// usually used to publish gauges.
+	RawCount CounterType = iota
+
+	// BulkCount counters display the rate per second at which their value
+	// increases, they are usually used to publish stats counters.
+	BulkCount
+)
+
+// String satisfies the fmt.Stringer interface.
+func (t CounterType) String() string {
+	switch t {
+	case RawCount:
+		return "perf_counter_large_rawcount"
+	case BulkCount:
+		return "perf_counter_bulk_count"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Counter describes a measure field published as a performance counter.
+type Counter struct {
+	// Identifier of the counter within its counter set.
+	ID uint32
+
+	// Name of the measure field published by the counter, formatted as the
+	// measure name and field name separated by a colon (or the measure name
+	// only for fields with no names), the same way engines name metrics (for
+	// example "http.req:count"). The colon is replaced with a dot in the URI
+	// of the counter in the manifest, where URIs are dot separated paths.
+	Name string
+
+	// Description of the counter displayed by monitoring tools.
+	Description string
+
+	// Type of the performance counter.
+	Type CounterType
+
+	// Factor applied to values before they are converted to the unsigned
+	// integers held by performance counters. Defaults to 1.
+	Scale float64
+}
+
+// CounterSet describes a set of performance counters published by a program.
+type CounterSet struct {
+	// Name and GUID of the provider publishing the counter set, the GUID is
+	// formatted as "{xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx}".
+	ProviderName string
+	ProviderGUID string
+
+	// Name, description, and GUID of the counter set.
+	Name        string
+	Description string
+	GUID        string
+
+	// Name of the counter set instance created by the program. The counter
+	// set is declared as single-instance when the name is empty.
+	Instance string
+
+	// The list of counters in the set.
+	Counters []Counter
+}
+
+// Handler is a measure handler which publishes the values of the measure
+// fields selected by a counter set as performance counters.
+//
+// Fields of stats counters increment the performance counter values, other
+// fields set them.
+type Handler struct {
+	set      CounterSet
+	counters map[string]*Counter
+	provider provider
+	once     sync.Once
+}
+
+// provider is implemented by the platform-specific performance counter
+// providers.
+type provider interface {
+	set(id uint32, value uint64) error
+	add(id uint32, delta uint64) error
+	close() error
+}
+
+// NewHandler starts publishing the given counter set, returning a handler
+// which updates the performance counters with the measures it receives.
+func NewHandler(set CounterSet) (*Handler, error) {
+	if err := set.validate(); err != nil {
+		return nil, err
+	}
+
+	p, err := startProvider(&set)
+	if err != nil {
+		return nil, err
+	}
+
+	return newHandler(set, p), nil
+}
+
+func newHandler(set CounterSet, p provider) *Handler {
+	h := &Handler{
+		set:      set,
+		counters: make(map[string]*Counter, len(set.Counters)),
+		provider: p,
+	}
+
+	for i := range set.Counters {
+		c := &h.set.Counters[i]
+		h.counters[c.Name] = c
+	}
+
+	return h
+}
+
+// HandleMeasures satisfies the stats.Handler interface.
+func (h *Handler) HandleMeasures(time time.Time, measures ...stats.Measure) {
+	var name []byte
+
+	for _, m := range measures {
+		for _, f := range m.Fields {
+			name = append(name[:0], m.Name...)
+			if len(f.Name) != 0 {
+				name = append(name, ':')
+				name = append(name, f.Name...)
+			}
+
+			c := h.counters[string(name)]
+			if c == nil {
+				continue
+			}
+
+			v := c.value(f.Value)
+
+			if f.Type() == stats.Counter {
+				h.provider.add(c.ID, v)
+			} else {
+				h.provider.set(c.ID, v)
+			}
+		}
+	}
+}
+
+// Close stops publishing the performance counters, satisfies the io.Closer
+// interface.
+func (h *Handler) Close() (err error) {
+	h.once.Do(func() { err = h.provider.close() })
+	return
+}
+
+// WriteManifest writes the instrumentation manifest declaring the counter set
+// to w. The application argument is the file name of the executable that
+// publishes the counters.
+func (s *CounterSet) WriteManifest(w io.Writer, application string) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	instances := "single"
+	if len(s.Instance) != 0 {
+		instances = "multiple"
+	}
+
+	m := manifest{
+		Xmlns: "http://schemas.microsoft.com/win/2004/08/events",
+		Instrumentation: manifestInstrumentation{
+			Counters: manifestCounters{
+				Xmlns:         "http://schemas.microsoft.com/win/2005/12/counters",
+				SchemaVersion: "2.0",
+				Provider: manifestProvider{
+					ProviderName:        s.ProviderName,
+					ProviderGUID:        s.ProviderGUID,
+					ApplicationIdentity: application,
+					ProviderType:        "userMode",
+					CounterSet: manifestCounterSet{
+						GUID:        s.GUID,
+						URI:         s.ProviderName + "." + s.Name,
+						Name:        s.Name,
+						Description: s.Description,
+						Instances:   instances,
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range s.Counters {
+		m.Instrumentation.Counters.Provider.CounterSet.Counters = append(m.Instrumentation.Counters.Provider.CounterSet.Counters, manifestCounter{
+			ID:          c.ID,
+			URI:         s.ProviderName + "." + s.Name + "." + strings.Replace(c.Name, ":", ".", -1),
+			Name:        c.Name,
+			Description: c.Description,
+			Type:        c.Type.String(),
+			DetailLevel: "standard",
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+
+	if err := e.Encode(m); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (s *CounterSet) validate() error {
+	if len(s.ProviderName) == 0 || len(s.Name) == 0 {
+		return errors.New("stats/perfcounter: counter sets must have a provider name and a name")
+	}
+
+	if _, err := parseGUID(s.ProviderGUID); err != nil {
+		return err
+	}
+
+	if _, err := parseGUID(s.GUID); err != nil {
+		return err
+	}
+
+	ids := make(map[uint32]bool, len(s.Counters))
+
+	for _, c := range s.Counters {
+		if ids[c.ID] {
+			return fmt.Errorf("stats/perfcounter: duplicate counter id: %d", c.ID)
+		}
+		if c.Type != RawCount && c.Type != BulkCount {
+			return fmt.Errorf("stats/perfcounter: %s: invalid counter type: %d", c.Name, c.Type)
+		}
+		ids[c.ID] = true
+	}
+
+	return nil
+}
+
+func (c *Counter) value(v stats.Value) uint64 {
+	var f float64
+
+	switch v.Type() {
+	case stats.Bool:
+		if v.Bool() {
+			f = 1
+		}
+	case stats.Int:
+		f = float64(v.Int())
+	case stats.Uint:
+		f = float64(v.Uint())
+	case stats.Float:
+		f = v.Float()
+	case stats.Duration:
+		f = v.Duration().Seconds()
+	}
+
+	if c.Scale != 0 {
+		f *= c.Scale
+	}
+
+	switch {
+	case f <= 0 || math.IsNaN(f):
+		return 0
+	case f >= math.MaxUint64:
+		return math.MaxUint64
+	default:
+		return uint64(f)
+	}
+}
+
+// guid is the binary representation of Windows GUIDs.
+type guid struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+func parseGUID(s string) (g guid, err error) {
+	// {xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx}
+	if len(s) != 38 || s[0] != '{' || s[37] != '}' || s[9] != '-' || s[14] != '-' || s[19] != '-' || s[24] != '-' {
+		err = fmt.Errorf("stats/perfcounter: malformed GUID: %q", s)
+		return
+	}
+
+	b, err := hex.DecodeString(strings.Replace(s[1:37], "-", "", -1))
+	if err != nil {
+		err = fmt.Errorf("stats/perfcounter: malformed GUID: %q", s)
+		return
+	}
+
+	g.Data1 = uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+	g.Data2 = uint16(b[4])<<8 | uint16(b[5])
+	g.Data3 = uint16(b[6])<<8 | uint16(b[7])
+	copy(g.Data4[:], b[8:])
+	return
+}
+
+type manifest struct {
+	XMLName         xml.Name                `xml:"instrumentationManifest"`
+	Xmlns           string                  `xml:"xmlns,attr"`
+	Instrumentation manifestInstrumentation `xml:"instrumentation"`
+}
+
+type manifestInstrumentation struct {
+	Counters manifestCounters `xml:"counters"`
+}
+
+type manifestCounters struct {
+	Xmlns         string           `xml:"xmlns,attr"`
+	SchemaVersion string           `xml:"schemaVersion,attr"`
+	Provider      manifestProvider `xml:"provider"`
+}
+
+type manifestProvider struct {
+	ProviderName        string             `xml:"providerName,attr"`
+	ProviderGUID        string             `xml:"providerGuid,attr"`
+	ApplicationIdentity string             `xml:"applicationIdentity,attr"`
+	ProviderType        string             `xml:"providerType,attr"`
+	CounterSet          manifestCounterSet `xml:"counterSet"`
+}
+
+type manifestCounterSet struct {
+	GUID        string            `xml:"guid,attr"`
+	URI         string            `xml:"uri,attr"`
+	Name        string            `xml:"name,attr"`
+	Description string            `xml:"description,attr"`
+	Instances   string            `xml:"instances,attr"`
+	Counters    []manifestCounter `xml:"counter"`
+}
+
+type manifestCounter struct {
+	ID          uint32 `xml:"id,attr"`
+	URI         string `xml:"uri,attr"`
+	Name        string `xml:"name,attr"`
+	Description string `xml:"description,attr"`
+	Type        string `xml:"type,attr"`
+	DetailLevel string `xml:"detailLevel,attr"`
+}
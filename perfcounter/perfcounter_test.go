@@ -0,0 +1,125 @@
+//go:build !statsoff
+
+package perfcounter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+var testCounterSet = CounterSet{
+	ProviderName: "MyService",
+	ProviderGUID: "{51e5a3e0-6d65-4d2a-8b1a-2b4f4a1e6c01}",
+	Name:         "Requests",
+	Description:  "HTTP requests served by MyService",
+	GUID:         "{51e5a3e0-6d65-4d2a-8b1a-2b4f4a1e6c02}",
+	Counters: []Counter{
+		{ID: 1, Name: "http.req:count", Description: "Requests per second", Type: BulkCount},
+		{ID: 2, Name: "http.inflight", Description: "Requests in progress", Type: RawCount},
+		{ID: 3, Name: "http.rtt:seconds", Description: "Latency (ms)", Type: RawCount, Scale: 1000},
+	},
+}
+
+type testProvider struct {
+	values map[uint32]uint64
+}
+
+func (p *testProvider) set(id uint32, value uint64) error {
+	p.values[id] = value
+	return nil
+}
+
+func (p *testProvider) add(id uint32, delta uint64) error {
+	p.values[id] += delta
+	return nil
+}
+
+func (p *testProvider) close() error {
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	p := &testProvider{values: map[uint32]uint64{}}
+	h := newHandler(testCounterSet, p)
+	eng := stats.NewEngine("http", h)
+
+	eng.Incr("req:count")
+	eng.Incr("req:count")
+	eng.Set("inflight", 3)
+	eng.Set("inflight", 2)
+	eng.Observe("rtt:seconds", 25*time.Millisecond)
+	eng.Incr("ignored")
+
+	expected := map[uint32]uint64{1: 2, 2: 2, 3: 25}
+
+	for id, value := range expected {
+		if p.values[id] != value {
+			t.Errorf("counter %d: bad value: %d != %d", id, p.values[id], value)
+		}
+	}
+
+	if len(p.values) != len(expected) {
+		t.Error("bad number of counters:", p.values)
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	if err := testCounterSet.WriteManifest(b, "myservice.exe"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		`providerName="MyService"`,
+		`applicationIdentity="myservice.exe"`,
+		`instances="single"`,
+		`<counter id="1" uri="MyService.Requests.http.req.count" name="http.req:count" description="Requests per second" type="perf_counter_bulk_count" detailLevel="standard"></counter>`,
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("manifest does not contain %s:\n%s", s, b.String())
+		}
+	}
+}
+
+func TestCounterSetValidate(t *testing.T) {
+	tests := []struct {
+		scenario string
+		set      CounterSet
+	}{
+		{
+			scenario: "missing names",
+			set:      CounterSet{ProviderGUID: testCounterSet.ProviderGUID, GUID: testCounterSet.GUID},
+		},
+		{
+			scenario: "malformed GUID",
+			set:      CounterSet{ProviderName: "A", Name: "B", ProviderGUID: "51e5a3e0", GUID: testCounterSet.GUID},
+		},
+		{
+			scenario: "duplicate counter ids",
+			set: CounterSet{
+				ProviderName: "A",
+				Name:         "B",
+				ProviderGUID: testCounterSet.ProviderGUID,
+				GUID:         testCounterSet.GUID,
+				Counters:     []Counter{{ID: 1, Name: "a"}, {ID: 1, Name: "b"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			if err := test.set.validate(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if err := testCounterSet.validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package perfcounter
+
+import "errors"
+
+func startProvider(set *CounterSet) (provider, error) {
+	return nil, errors.New("stats/perfcounter: performance counters are only supported on windows")
+}
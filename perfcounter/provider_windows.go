@@ -0,0 +1,174 @@
+package perfcounter
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32 = syscall.NewLazyDLL("advapi32.dll")
+
+	procPerfStartProviderEx                = advapi32.NewProc("PerfStartProviderEx")
+	procPerfStopProvider                   = advapi32.NewProc("PerfStopProvider")
+	procPerfSetCounterSetInfo              = advapi32.NewProc("PerfSetCounterSetInfo")
+	procPerfCreateInstance                 = advapi32.NewProc("PerfCreateInstance")
+	procPerfDeleteInstance                 = advapi32.NewProc("PerfDeleteInstance")
+	procPerfSetULongLongCounterValue       = advapi32.NewProc("PerfSetULongLongCounterValue")
+	procPerfIncrementULongLongCounterValue = advapi32.NewProc("PerfIncrementULongLongCounterValue")
+)
+
+const (
+	perfCounterLargeRawcount = 0x00010100
+	perfCounterBulkCount     = 0x00010500
+
+	perfDetailNovice = 100
+
+	perfCountersetSingleInstance = 0
+	perfCountersetMultiInstances = 2
+)
+
+// perfProviderContext mirrors the PERF_PROVIDER_CONTEXT structure.
+type perfProviderContext struct {
+	ContextSize     uint32
+	Reserved        uint32
+	ControlCallback uintptr
+	MemAllocRoutine uintptr
+	MemFreeRoutine  uintptr
+	MemContext      uintptr
+}
+
+// perfCountersetInfo mirrors the PERF_COUNTERSET_INFO structure, which is
+// followed by NumCounters PERF_COUNTER_INFO structures in templates.
+type perfCountersetInfo struct {
+	CounterSetGUID guid
+	ProviderGUID   guid
+	NumCounters    uint32
+	InstanceType   uint32
+}
+
+// perfCounterInfo mirrors the PERF_COUNTER_INFO structure.
+type perfCounterInfo struct {
+	CounterID   uint32
+	Type        uint32
+	Attrib      uint64
+	Size        uint32
+	DetailLevel uint32
+	Scale       int32
+	Offset      uint32
+}
+
+type windowsProvider struct {
+	handle   syscall.Handle
+	instance uintptr
+}
+
+func startProvider(set *CounterSet) (provider, error) {
+	providerGUID, _ := parseGUID(set.ProviderGUID)
+	counterSetGUID, _ := parseGUID(set.GUID)
+
+	p := &windowsProvider{}
+	ctx := perfProviderContext{ContextSize: uint32(unsafe.Sizeof(perfProviderContext{}))}
+
+	if r, _, _ := procPerfStartProviderEx.Call(
+		uintptr(unsafe.Pointer(&providerGUID)),
+		uintptr(unsafe.Pointer(&ctx)),
+		uintptr(unsafe.Pointer(&p.handle)),
+	); r != 0 {
+		return nil, fmt.Errorf("stats/perfcounter: PerfStartProviderEx: %s", syscall.Errno(r))
+	}
+
+	info := perfCountersetInfo{
+		CounterSetGUID: counterSetGUID,
+		ProviderGUID:   providerGUID,
+		NumCounters:    uint32(len(set.Counters)),
+		InstanceType:   perfCountersetSingleInstance,
+	}
+
+	if len(set.Instance) != 0 {
+		info.InstanceType = perfCountersetMultiInstances
+	}
+
+	template := make([]byte, 0, unsafe.Sizeof(info)+uintptr(len(set.Counters))*unsafe.Sizeof(perfCounterInfo{}))
+	template = append(template, (*[unsafe.Sizeof(perfCountersetInfo{})]byte)(unsafe.Pointer(&info))[:]...)
+
+	for i, c := range set.Counters {
+		counter := perfCounterInfo{
+			CounterID:   c.ID,
+			Type:        perfCounterLargeRawcount,
+			Size:        8,
+			DetailLevel: perfDetailNovice,
+			Offset:      uint32(8 * i),
+		}
+		if c.Type == BulkCount {
+			counter.Type = perfCounterBulkCount
+		}
+		template = append(template, (*[unsafe.Sizeof(perfCounterInfo{})]byte)(unsafe.Pointer(&counter))[:]...)
+	}
+
+	if r, _, _ := procPerfSetCounterSetInfo.Call(
+		uintptr(p.handle),
+		uintptr(unsafe.Pointer(&template[0])),
+		uintptr(len(template)),
+	); r != 0 {
+		p.close()
+		return nil, fmt.Errorf("stats/perfcounter: PerfSetCounterSetInfo: %s", syscall.Errno(r))
+	}
+
+	instance := set.Instance
+	if len(instance) == 0 {
+		instance = "_Default"
+	}
+
+	name, err := syscall.UTF16PtrFromString(instance)
+	if err != nil {
+		p.close()
+		return nil, err
+	}
+
+	r, _, e := procPerfCreateInstance.Call(
+		uintptr(p.handle),
+		uintptr(unsafe.Pointer(&counterSetGUID)),
+		uintptr(unsafe.Pointer(name)),
+		0,
+	)
+	if r == 0 {
+		p.close()
+		return nil, fmt.Errorf("stats/perfcounter: PerfCreateInstance: %s", e)
+	}
+
+	p.instance = r
+	return p, nil
+}
+
+func (p *windowsProvider) set(id uint32, value uint64) error {
+	return perfCall(procPerfSetULongLongCounterValue, append([]uintptr{uintptr(p.handle), p.instance, uintptr(id)}, ulonglong(value)...)...)
+}
+
+func (p *windowsProvider) add(id uint32, delta uint64) error {
+	return perfCall(procPerfIncrementULongLongCounterValue, append([]uintptr{uintptr(p.handle), p.instance, uintptr(id)}, ulonglong(delta)...)...)
+}
+
+func (p *windowsProvider) close() error {
+	if p.instance != 0 {
+		perfCall(procPerfDeleteInstance, uintptr(p.handle), p.instance)
+		p.instance = 0
+	}
+	return perfCall(procPerfStopProvider, uintptr(p.handle))
+}
+
+func perfCall(proc *syscall.LazyProc, args ...uintptr) error {
+	if r, _, _ := proc.Call(args...); r != 0 {
+		return fmt.Errorf("stats/perfcounter: %s: %s", proc.Name, syscall.Errno(r))
+	}
+	return nil
+}
+
+// ulonglong returns the arguments used to pass v by value, which takes two
+// registers on 32 bits platforms.
+func ulonglong(v uint64) []uintptr {
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		return []uintptr{uintptr(v)}
+	}
+	return []uintptr{uintptr(v), uintptr(v >> 32)}
+}
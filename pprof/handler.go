@@ -0,0 +1,224 @@
+// Package pprof exports the latency histograms produced by programs as
+// profiles in the format of the pprof tool, so the tools which visualize CPU
+// and memory profiles (flame graphs for example) can be used to explore where
+// programs spend time:
+//
+//	h := &pprof.Handler{}
+//	stats.Register(h)
+//	http.Handle("/debug/pprof/latency", h)
+//
+//	$ go tool pprof -http :8080 http://localhost:6060/debug/pprof/latency
+package pprof
+
+import (
+	"compress/gzip"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// Handler is a stats handler which records the histograms of durations that
+// a program produces, and exports them as pprof profiles.
+//
+// Each histogram series (measure name, field name, and tags) is a sample of
+// the profiles, with the count and total duration of the values observed for
+// the series. The stack of a sample is made of the components of the measure
+// name separated by dots, followed by the field name, so the metrics of the
+// same subsystem are grouped together in the visualizations. The tags of the
+// series are set as labels of the samples.
+//
+// Histograms which do not have duration values are ignored.
+type Handler struct {
+	mutex  sync.Mutex
+	start  time.Time
+	series map[string]*series
+	keys   []byte
+}
+
+type series struct {
+	stack []string // leaf first
+	tags  []stats.Tag
+	count int64
+	total time.Duration
+}
+
+// HandleMeasures satisfies the stats.Handler interface.
+func (h *Handler) HandleMeasures(t time.Time, measures ...stats.Measure) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.start.IsZero() {
+		h.start = time.Now()
+	}
+
+	for _, m := range measures {
+		for _, f := range m.Fields {
+			if f.Type() != stats.Histogram || f.Value.Type() != stats.Duration {
+				continue
+			}
+
+			h.keys = appendKey(h.keys[:0], m.Name, f.Name, m.Tags)
+			s := h.series[string(h.keys)]
+
+			if s == nil {
+				if h.series == nil {
+					h.series = make(map[string]*series)
+				}
+				s = &series{
+					stack: makeStack(m.Name, f.Name),
+					tags:  append([]stats.Tag(nil), m.Tags...),
+				}
+				h.series[string(h.keys)] = s
+			}
+
+			n := observations(m.SampleRate)
+			s.count += n
+			s.total += time.Duration(n) * f.Value.Duration()
+		}
+	}
+}
+
+// observations returns the number of values that a histogram value sampled at
+// rate stands for.
+func observations(rate float64) int64 {
+	if rate <= 0 || rate >= 1 {
+		return 1
+	}
+	return int64(math.Round(1 / rate))
+}
+
+// Reset discards the histograms recorded by the handler.
+func (h *Handler) Reset() {
+	h.mutex.Lock()
+	h.series, h.start = nil, time.Time{}
+	h.mutex.Unlock()
+}
+
+// WriteProfile writes the histograms recorded by the handler to w, as a gzip
+// compressed pprof profile.
+func (h *Handler) WriteProfile(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+
+	if _, err := zw.Write(h.profile(time.Now())); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// ServeHTTP satisfies the http.Handler interface, it responds with the profile
+// of the histograms recorded by the handler.
+func (h *Handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case "GET", "HEAD":
+	default:
+		res.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/octet-stream")
+	res.Header().Set("Content-Disposition", `attachment; filename="latency.pb.gz"`)
+	h.WriteProfile(res)
+}
+
+// profile returns the protobuf representation of the profile, see
+// https://github.com/google/pprof/blob/master/proto/profile.proto
+func (h *Handler) profile(now time.Time) []byte {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	p := &profileBuilder{strings: map[string]int64{"": 0}, table: []string{""}}
+
+	list := make([]*series, 0, len(h.series))
+	for _, s := range h.series {
+		list = append(list, s)
+	}
+
+	// The output is deterministic, which makes it possible to compare the
+	// profiles written at different times.
+	sort.Slice(list, func(i, j int) bool {
+		return seriesLess(list[i], list[j])
+	})
+
+	p.valueType(profileSampleType, "samples", "count")
+	p.valueType(profileSampleType, "delay", "nanoseconds")
+
+	for _, s := range list {
+		p.sample(s)
+	}
+
+	p.functions()
+
+	start := h.start
+	if start.IsZero() {
+		start = now
+	}
+
+	p.int(profileTimeNanos, start.UnixNano())
+	p.int(profileDurationNanos, int64(now.Sub(start)))
+	p.valueType(profilePeriodType, "delay", "nanoseconds")
+	p.int(profilePeriod, 1)
+	p.stringTable()
+
+	return p.b.b
+}
+
+func seriesLess(s1 *series, s2 *series) bool {
+	for i, j := len(s1.stack)-1, len(s2.stack)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if s1.stack[i] != s2.stack[j] {
+			return s1.stack[i] < s2.stack[j]
+		}
+	}
+	if len(s1.stack) != len(s2.stack) {
+		return len(s1.stack) < len(s2.stack)
+	}
+	for i := 0; i < len(s1.tags) && i < len(s2.tags); i++ {
+		if s1.tags[i] != s2.tags[i] {
+			if s1.tags[i].Name != s2.tags[i].Name {
+				return s1.tags[i].Name < s2.tags[i].Name
+			}
+			return s1.tags[i].Value < s2.tags[i].Value
+		}
+	}
+	return len(s1.tags) < len(s2.tags)
+}
+
+// makeStack returns the frames of the stack of a series, leaf first.
+func makeStack(measure string, field string) []string {
+	var stack []string
+
+	if field != "" {
+		stack = append(stack, field)
+	}
+
+	parts := strings.Split(measure, ".")
+
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] != "" {
+			stack = append(stack, parts[i])
+		}
+	}
+
+	return stack
+}
+
+func appendKey(b []byte, measure string, field string, tags []stats.Tag) []byte {
+	b = append(b, measure...)
+	b = append(b, 0)
+	b = append(b, field...)
+
+	for _, t := range tags {
+		b = append(b, 0)
+		b = append(b, t.Name...)
+		b = append(b, '=')
+		b = append(b, t.Value...)
+	}
+
+	return b
+}
@@ -0,0 +1,153 @@
+//go:build !statsoff
+
+package pprof
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+func TestHandler(t *testing.T) {
+	h := &Handler{}
+	eng := stats.NewEngine("myapp", h)
+
+	eng.Observe("http:rtt", 100*time.Millisecond, stats.T("path", "/a"))
+	eng.Observe("http:rtt", 300*time.Millisecond, stats.T("path", "/b"))
+	eng.Observe("http:rtt", 200*time.Millisecond, stats.T("path", "/b"))
+	eng.Observe("http:size", 42) // not a duration
+	eng.Incr("http:count")
+
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, httptest.NewRequest("GET", "/debug/pprof/latency", nil))
+
+	zr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := decodeFields(t, b)
+	strings := fields[profileStringTable]
+
+	if len(fields[profileSample]) != 2 {
+		t.Fatal("bad number of samples:", len(fields[profileSample]))
+	}
+
+	// The samples are sorted, the one of path=/b is second.
+	sample := decodeFields(t, fields[profileSample][1])
+	values := decodeVarints(t, sample[sampleValue][0])
+
+	if !reflect.DeepEqual(values, []uint64{2, uint64(500 * time.Millisecond)}) {
+		t.Error("bad sample values:", values)
+	}
+
+	var stack []string
+	for _, id := range decodeVarints(t, sample[sampleLocationID][0]) {
+		fn := decodeFields(t, fields[profileFunction][id-1])
+		name := decodeVarints(t, fn[functionName][0])[0]
+		stack = append(stack, string(strings[name]))
+	}
+
+	if !reflect.DeepEqual(stack, []string{"rtt", "http", "myapp"}) {
+		t.Error("bad sample stack:", stack)
+	}
+
+	label := decodeFields(t, sample[sampleLabel][0])
+	key := decodeVarints(t, label[labelKey][0])[0]
+	str := decodeVarints(t, label[labelStr][0])[0]
+
+	if string(strings[key]) != "path" || string(strings[str]) != "/b" {
+		t.Errorf("bad sample label: %s=%s", strings[key], strings[str])
+	}
+
+	h.Reset()
+	buf := &bytes.Buffer{}
+	h.WriteProfile(buf)
+
+	if zr, _ = gzip.NewReader(buf); zr != nil {
+		b, _ = ioutil.ReadAll(zr)
+		if n := len(decodeFields(t, b)[profileSample]); n != 0 {
+			t.Error("samples found after the handler was reset:", n)
+		}
+	}
+}
+
+// decodeFields decodes a protobuf message, the values of varint fields are
+// returned encoded.
+func TestHandlerSampleRate(t *testing.T) {
+	h := &Handler{}
+
+	h.HandleMeasures(time.Now(), stats.Measure{
+		Name:       "myapp.http",
+		Fields:     []stats.Field{stats.MakeField("rtt", 100*time.Millisecond, stats.Histogram)},
+		SampleRate: 0.1,
+	})
+
+	for _, s := range h.series {
+		if s.count != 10 || s.total != time.Second {
+			t.Errorf("bad sample: count=%d total=%s", s.count, s.total)
+		}
+	}
+
+	if len(h.series) != 1 {
+		t.Error("bad number of series:", len(h.series))
+	}
+}
+
+func decodeFields(t *testing.T, b []byte) map[int][][]byte {
+	fields := make(map[int][][]byte)
+
+	for len(b) != 0 {
+		key, n := decodeVarint(t, b)
+		b = b[n:]
+
+		switch key & 7 {
+		case 0:
+			_, n = decodeVarint(t, b)
+			fields[int(key>>3)] = append(fields[int(key>>3)], b[:n])
+			b = b[n:]
+		case 2:
+			size, n := decodeVarint(t, b)
+			b = b[n:]
+			fields[int(key>>3)] = append(fields[int(key>>3)], b[:size])
+			b = b[size:]
+		default:
+			t.Fatal("unexpected wire type:", key&7)
+		}
+	}
+
+	return fields
+}
+
+func decodeVarints(t *testing.T, b []byte) []uint64 {
+	var values []uint64
+	for len(b) != 0 {
+		v, n := decodeVarint(t, b)
+		values = append(values, v)
+		b = b[n:]
+	}
+	return values
+}
+
+func decodeVarint(t *testing.T, b []byte) (uint64, int) {
+	v := uint64(0)
+	for i, c := range b {
+		v |= uint64(c&0x7F) << (7 * uint(i))
+		if c < 0x80 {
+			return v, i + 1
+		}
+	}
+	t.Fatal("truncated varint")
+	return 0, 0
+}
@@ -0,0 +1,162 @@
+package pprof
+
+// profileBuilder builds the protobuf representation of profiles, the strings
+// and functions are interned as the samples are added.
+type profileBuilder struct {
+	b       protobuf
+	strings map[string]int64
+	table   []string
+	funcs   map[string]uint64
+	names   []string // names of the functions, the ids are the indexes + 1
+}
+
+// Field numbers of the messages of the profile.proto definition.
+const (
+	profileSampleType    = 1
+	profileSample        = 2
+	profileLocation      = 4
+	profileFunction      = 5
+	profileStringTable   = 6
+	profileTimeNanos     = 9
+	profileDurationNanos = 10
+	profilePeriodType    = 11
+	profilePeriod        = 12
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+	sampleLabel      = 3
+
+	labelKey = 1
+	labelStr = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+)
+
+func (p *profileBuilder) str(s string) int64 {
+	i, ok := p.strings[s]
+	if !ok {
+		i = int64(len(p.table))
+		p.strings[s] = i
+		p.table = append(p.table, s)
+	}
+	return i
+}
+
+func (p *profileBuilder) function(name string) uint64 {
+	id, ok := p.funcs[name]
+	if !ok {
+		if p.funcs == nil {
+			p.funcs = make(map[string]uint64)
+		}
+		p.names = append(p.names, name)
+		id = uint64(len(p.names))
+		p.funcs[name] = id
+	}
+	return id
+}
+
+func (p *profileBuilder) int(field int, v int64) {
+	p.b.varintField(field, uint64(v))
+}
+
+func (p *profileBuilder) valueType(field int, typ string, unit string) {
+	var m protobuf
+	m.varintField(valueTypeType, uint64(p.str(typ)))
+	m.varintField(valueTypeUnit, uint64(p.str(unit)))
+	p.b.bytesField(field, m.b)
+}
+
+func (p *profileBuilder) sample(s *series) {
+	var m protobuf
+
+	ids := make([]uint64, len(s.stack))
+	for i, frame := range s.stack {
+		ids[i] = p.function(frame)
+	}
+
+	m.packedField(sampleLocationID, ids)
+	m.packedField(sampleValue, []uint64{uint64(s.count), uint64(s.total)})
+
+	for _, t := range s.tags {
+		var l protobuf
+		l.varintField(labelKey, uint64(p.str(t.Name)))
+		l.varintField(labelStr, uint64(p.str(t.Value)))
+		m.bytesField(sampleLabel, l.b)
+	}
+
+	p.b.bytesField(profileSample, m.b)
+}
+
+// functions writes the functions of the profile, and a location with the same
+// id for each of them.
+func (p *profileBuilder) functions() {
+	for i, name := range p.names {
+		id := uint64(i + 1)
+
+		var fn protobuf
+		fn.varintField(functionID, id)
+		fn.varintField(functionName, uint64(p.str(name)))
+		fn.varintField(functionSystemName, uint64(p.str(name)))
+		p.b.bytesField(profileFunction, fn.b)
+
+		var line protobuf
+		line.varintField(lineFunctionID, id)
+
+		var loc protobuf
+		loc.varintField(locationID, id)
+		loc.bytesField(locationLine, line.b)
+		p.b.bytesField(profileLocation, loc.b)
+	}
+}
+
+func (p *profileBuilder) stringTable() {
+	for _, s := range p.table {
+		p.b.bytesField(profileStringTable, []byte(s))
+	}
+}
+
+// protobuf is a minimal encoder of protobuf messages.
+type protobuf struct {
+	b []byte
+}
+
+func (p *protobuf) varint(v uint64) {
+	for v >= 0x80 {
+		p.b = append(p.b, byte(v)|0x80)
+		v >>= 7
+	}
+	p.b = append(p.b, byte(v))
+}
+
+func (p *protobuf) key(field int, wireType int) {
+	p.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (p *protobuf) varintField(field int, v uint64) {
+	p.key(field, 0)
+	p.varint(v)
+}
+
+func (p *protobuf) bytesField(field int, b []byte) {
+	p.key(field, 2)
+	p.varint(uint64(len(b)))
+	p.b = append(p.b, b...)
+}
+
+func (p *protobuf) packedField(field int, values []uint64) {
+	var m protobuf
+	for _, v := range values {
+		m.varint(v)
+	}
+	p.bytesField(field, m.b)
+}
@@ -0,0 +1,75 @@
+package procstats
+
+import (
+	"runtime"
+	"runtime/metrics"
+
+	"github.com/segmentio/stats"
+)
+
+// CgoMetrics is a metric collector that reports cgo calls and the OS threads
+// used by the Go runtime, including the threads held by goroutines blocked in
+// cgo calls or system calls, for programs whose latency issues hide in cgo.
+//
+// The runtime does not expose the number of spinning threads (it is only
+// visible through GODEBUG=schedtrace), so it is not reported.
+type CgoMetrics struct {
+	engine *stats.Engine
+
+	cgo struct {
+		calls uint64 `metric:"calls.count" type:"counter"` // calls from Go to C
+	} `metric:"go.cgo"`
+
+	threads struct {
+		total   uint64 `metric:"total.count"   type:"gauge"` // OS threads owned by the runtime
+		created uint64 `metric:"created.count" type:"gauge"` // OS threads created by the runtime
+		blocked uint64 `metric:"blocked.count" type:"gauge"` // threads held by goroutines in cgo calls or system calls
+	} `metric:"go.threads"`
+
+	samples      []metrics.Sample
+	lastCgoCalls uint64
+}
+
+// NewCgoMetrics collects cgo and thread metrics of the Go runtime and reports
+// them to the default stats engine.
+func NewCgoMetrics() *CgoMetrics {
+	return NewCgoMetricsWith(stats.DefaultEngine)
+}
+
+// NewCgoMetricsWith collects cgo and thread metrics of the Go runtime and
+// reports them to eng.
+func NewCgoMetricsWith(eng *stats.Engine) *CgoMetrics {
+	return &CgoMetrics{
+		engine: eng,
+		samples: []metrics.Sample{
+			{Name: "/sched/threads/total:threads"},
+			{Name: "/sched/goroutines/not-in-go:goroutines"},
+		},
+		lastCgoCalls: uint64(runtime.NumCgoCall()),
+	}
+}
+
+// Collect satisfies the Collector interface.
+func (c *CgoMetrics) Collect() {
+	cgoCalls := uint64(runtime.NumCgoCall())
+	c.cgo.calls = cgoCalls - c.lastCgoCalls
+	c.lastCgoCalls = cgoCalls
+
+	c.threads.created = uint64(threadcreate.Count())
+	c.threads.total = c.threads.created
+	c.threads.blocked = 0
+
+	// Older versions of the runtime don't support those metrics, their
+	// samples have the KindBad kind and are ignored.
+	metrics.Read(c.samples)
+
+	if v := c.samples[0].Value; v.Kind() == metrics.KindUint64 {
+		c.threads.total = v.Uint64()
+	}
+
+	if v := c.samples[1].Value; v.Kind() == metrics.KindUint64 {
+		c.threads.blocked = v.Uint64()
+	}
+
+	c.engine.Report(c)
+}
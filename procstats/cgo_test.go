@@ -0,0 +1,30 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestCgoMetrics(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	NewCgoMetricsWith(e).Collect()
+
+	measures := h.Measures()
+	if len(measures) != 2 {
+		t.Fatalf("bad number of measures: %v", measures)
+	}
+
+	if m := measures[0]; m.Name != "go.cgo" || m.Fields[0].Name != "calls.count" {
+		t.Errorf("bad cgo measure: %v", m)
+	}
+
+	if m := measures[1]; m.Name != "go.threads" || len(m.Fields) != 3 || m.Fields[0].Value.Uint() == 0 {
+		t.Errorf("bad threads measure: %v", m)
+	}
+}
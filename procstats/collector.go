@@ -2,9 +2,9 @@ package procstats
 
 import (
 	"io"
-	"runtime"
-	"sync"
 	"time"
+
+	"github.com/segmentio/stats"
 )
 
 type Collector interface {
@@ -35,32 +35,12 @@ func StartCollector(collector Collector) io.Closer {
 func StartCollectorWith(config Config) io.Closer {
 	config = setConfigDefaults(config)
 
-	stop := make(chan struct{})
-	join := make(chan struct{})
-
-	go func() {
-		// Locks the OS thread, stats collection heavily relies on blocking
-		// syscalls, letting other goroutines execute on the same thread
-		// increases the chance for the Go runtime to detected that the thread
-		// is blocked and schedule a new one.
-		runtime.LockOSThread()
-
-		defer runtime.UnlockOSThread()
-		defer close(join)
-
-		ticker := time.NewTicker(config.CollectInterval)
-		config.Collector.Collect()
-		for {
-			select {
-			case <-ticker.C:
-				config.Collector.Collect()
-			case <-stop:
-				return
-			}
-		}
-	}()
-
-	return &closer{stop: stop, join: join}
+	s := NewSampler(stats.DefaultEngine,
+		WithInterval(config.CollectInterval),
+		WithCollectors(config.Collector),
+	)
+	s.Start()
+	return s
 }
 
 func setConfigDefaults(config Config) Config {
@@ -74,19 +54,3 @@ func setConfigDefaults(config Config) Config {
 
 	return config
 }
-
-type closer struct {
-	once sync.Once
-	stop chan<- struct{}
-	join <-chan struct{}
-}
-
-func (c *closer) Close() error {
-	c.once.Do(c.close)
-	return nil
-}
-
-func (c *closer) close() {
-	close(c.stop)
-	<-c.join
-}
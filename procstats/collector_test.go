@@ -1,3 +1,5 @@
+//go:build !statsoff
+
 package procstats
 
 import (
@@ -39,3 +41,49 @@ func TestCollectorCloser(t *testing.T) {
 		t.Error("unexpected error reported when closing a collector:", err)
 	}
 }
+
+func TestSampler(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	errors := 0
+	s := NewSampler(e,
+		WithInterval(100*time.Microsecond),
+		WithCollectors(
+			NewGoMetricsWith(e),
+			CollectorFunc(func() { panic("oops") }),
+		),
+		WithErrorHandler(func(c Collector, err error) { errors++ }),
+	)
+
+	s.Start()
+	s.Start() // no-op, the sampler is already running
+	time.Sleep(time.Millisecond)
+	s.Stop()
+	s.Stop() // no-op, the sampler is already stopped
+
+	if len(h.Measures()) == 0 {
+		t.Error("no measures were reported by the sampler")
+	}
+
+	if errors == 0 {
+		t.Error("the panic of the collector was not reported to the error handler")
+	}
+}
+
+func TestSamplerErrorCount(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	s := NewSampler(e, WithCollectors(CollectorFunc(func() { panic("oops") })))
+	s.Collect()
+
+	measures := h.Measures()
+	if len(measures) != 1 {
+		t.Fatalf("bad number of measures: %v", measures)
+	}
+
+	if m := measures[0]; m.Name != "procstats" || m.Fields[0].Name != "errors.count" || m.Tags[0] != stats.T("collector", "CollectorFunc") {
+		t.Errorf("bad measure: %v", m)
+	}
+}
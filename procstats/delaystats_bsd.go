@@ -0,0 +1,8 @@
+//go:build freebsd || openbsd
+
+package procstats
+
+func collectDelayInfo(pid int) (info DelayInfo) {
+	// TODO
+	return
+}
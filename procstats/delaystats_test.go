@@ -1,3 +1,5 @@
+//go:build !statsoff
+
 package procstats_test
 
 import (
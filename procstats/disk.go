@@ -0,0 +1,153 @@
+package procstats
+
+import (
+	"os"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+)
+
+// DiskMetrics is a metric collector that reports I/O statistics of the block
+// devices of the system, tagged by device name.
+type DiskMetrics struct {
+	engine  *stats.Engine
+	devices map[string]*diskDevice
+}
+
+type diskDevice struct {
+	reads struct {
+		count uint64        `metric:"count"   type:"counter"`
+		bytes uint64        `metric:"bytes"   type:"counter"`
+		time  time.Duration `metric:"seconds" type:"counter"`
+		op    string        `tag:"operation"` // read
+	} `metric:"disk"`
+	writes struct {
+		count uint64        `metric:"count"   type:"counter"`
+		bytes uint64        `metric:"bytes"   type:"counter"`
+		time  time.Duration `metric:"seconds" type:"counter"`
+		op    string        `tag:"operation"` // write
+	} `metric:"disk"`
+	io struct {
+		inflight uint64        `metric:"inflight.count" type:"gauge"`
+		time     time.Duration `metric:"io.seconds"     type:"counter"`
+		weighted time.Duration `metric:"io_weighted.seconds" type:"counter"`
+	} `metric:"disk"`
+	name string `tag:"device"`
+	last linux.DiskStat
+}
+
+// sectorSize is the unit of the sector counts in /proc/diskstats, regardless
+// of the actual sector size of the devices.
+const sectorSize = 512
+
+// NewDiskMetrics collects I/O statistics of the block devices of the system
+// and reports them to the default stats engine.
+func NewDiskMetrics() *DiskMetrics {
+	return NewDiskMetricsWith(stats.DefaultEngine)
+}
+
+// NewDiskMetricsWith collects I/O statistics of the block devices of the
+// system and reports them to eng.
+func NewDiskMetricsWith(eng *stats.Engine) *DiskMetrics {
+	return &DiskMetrics{engine: eng, devices: make(map[string]*diskDevice)}
+}
+
+// Collect satisfies the Collector interface.
+func (d *DiskMetrics) Collect() {
+	disks, err := linux.ReadDiskStats()
+	if err != nil {
+		return
+	}
+
+	for name, disk := range disks {
+		dev := d.devices[name]
+
+		if dev == nil {
+			// The first collection only initializes the baseline of the
+			// counters of new devices.
+			dev = &diskDevice{name: name, last: disk}
+			dev.reads.op = "read"
+			dev.writes.op = "write"
+			d.devices[name] = dev
+			continue
+		}
+
+		dev.reads.count = disk.ReadsCompleted - dev.last.ReadsCompleted
+		dev.reads.bytes = sectorSize * (disk.SectorsRead - dev.last.SectorsRead)
+		dev.reads.time = disk.ReadTime - dev.last.ReadTime
+
+		dev.writes.count = disk.WritesCompleted - dev.last.WritesCompleted
+		dev.writes.bytes = sectorSize * (disk.SectorsWritten - dev.last.SectorsWritten)
+		dev.writes.time = disk.WriteTime - dev.last.WriteTime
+
+		dev.io.inflight = disk.IOsInProgress
+		dev.io.time = disk.IOTime - dev.last.IOTime
+		dev.io.weighted = disk.WeightedIOTime - dev.last.WeightedIOTime
+
+		dev.last = disk
+		d.engine.Report(dev)
+	}
+
+	for name := range d.devices {
+		if _, ok := disks[name]; !ok {
+			delete(d.devices, name)
+		}
+	}
+}
+
+// IOMetrics is a metric collector that reports I/O statistics of a process.
+type IOMetrics struct {
+	engine *stats.Engine
+	pid    int
+	read   ioCounters `metric:"io"`
+	write  ioCounters `metric:"io"`
+	last   linux.ProcIO
+	ok     bool
+}
+
+type ioCounters struct {
+	bytes    uint64 `metric:"bytes"         type:"counter"` // bytes transferred by syscalls (including page cache hits)
+	storage  uint64 `metric:"storage.bytes" type:"counter"` // bytes transferred from or to the storage layer
+	syscalls uint64 `metric:"syscalls"      type:"counter"`
+	op       string `tag:"operation"` // read | write
+}
+
+// NewIOMetrics collects I/O statistics of the current process and reports
+// them to the default stats engine.
+func NewIOMetrics() *IOMetrics {
+	return NewIOMetricsWith(stats.DefaultEngine, os.Getpid())
+}
+
+// NewIOMetricsWith collects I/O statistics of the process identified by pid
+// and reports them to eng.
+func NewIOMetricsWith(eng *stats.Engine, pid int) *IOMetrics {
+	p := &IOMetrics{engine: eng, pid: pid}
+	p.read.op = "read"
+	p.write.op = "write"
+	return p
+}
+
+// Collect satisfies the Collector interface.
+func (p *IOMetrics) Collect() {
+	io, err := linux.ReadProcIO(p.pid)
+	if err != nil {
+		return
+	}
+
+	p.read.bytes = io.RChar - p.last.RChar
+	p.read.storage = io.ReadBytes - p.last.ReadBytes
+	p.read.syscalls = io.SyscR - p.last.SyscR
+
+	p.write.bytes = io.WChar - p.last.WChar
+	p.write.storage = (io.WriteBytes - io.CancelledWriteBytes) - (p.last.WriteBytes - p.last.CancelledWriteBytes)
+	p.write.syscalls = io.SyscW - p.last.SyscW
+
+	p.last = io
+
+	// The first collection only initializes the baseline of the counters.
+	if p.ok {
+		p.engine.Report(p)
+	}
+	p.ok = true
+}
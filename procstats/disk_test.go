@@ -0,0 +1,72 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestDiskMetrics(t *testing.T) {
+	if _, err := linux.ReadDiskStats(); err != nil {
+		t.Skip("disk statistics are not available:", err)
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	disk := NewDiskMetricsWith(e)
+	disk.Collect()
+
+	if n := len(h.Measures()); n != 0 {
+		t.Error("measures were reported by the first collection:", n)
+	}
+
+	disk.Collect()
+
+	for _, m := range h.Measures() {
+		if m.Name != "disk" {
+			t.Errorf("bad measure: %v", m)
+		}
+	}
+}
+
+func TestIOMetrics(t *testing.T) {
+	if _, err := linux.ReadProcIO(os.Getpid()); err != nil {
+		t.Skip("process I/O statistics are not available:", err)
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	io := NewIOMetricsWith(e, os.Getpid())
+	io.Collect()
+
+	if n := len(h.Measures()); n != 0 {
+		t.Error("measures were reported by the first collection:", n)
+	}
+
+	// Generate some reads so the syscall counters move.
+	ioutil.ReadFile("/proc/self/stat")
+
+	io.Collect()
+
+	var syscalls uint64
+
+	for _, m := range h.Measures() {
+		if m.Name != "io" || len(m.Fields) != 3 {
+			t.Errorf("bad measure: %v", m)
+			continue
+		}
+		syscalls += m.Fields[2].Value.Uint()
+	}
+
+	if syscalls == 0 {
+		t.Error("no syscalls were reported")
+	}
+}
@@ -0,0 +1,43 @@
+package procstats
+
+import (
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+)
+
+// EntropyMetrics is a metric collector that reports the entropy available in
+// the kernel random pool. On kernels older than 5.6 reads from /dev/random
+// block when the pool runs low, which may cause latency in programs that do a
+// lot of TLS handshakes or token generation.
+type EntropyMetrics struct {
+	engine  *stats.Engine
+	entropy struct {
+		available uint64 `metric:"available.bits" type:"gauge"`
+		poolsize  uint64 `metric:"poolsize.bits"  type:"gauge"`
+	} `metric:"entropy"`
+}
+
+// NewEntropyMetrics collects the entropy available on the host and reports it
+// to the default stats engine.
+func NewEntropyMetrics() *EntropyMetrics {
+	return NewEntropyMetricsWith(stats.DefaultEngine)
+}
+
+// NewEntropyMetricsWith collects the entropy available on the host and
+// reports it to eng.
+func NewEntropyMetricsWith(eng *stats.Engine) *EntropyMetrics {
+	return &EntropyMetrics{engine: eng}
+}
+
+// Collect satisfies the Collector interface.
+func (e *EntropyMetrics) Collect() {
+	available, err := linux.ReadEntropyAvail()
+	if err != nil {
+		return
+	}
+	poolsize, _ := linux.ReadEntropyPoolSize()
+
+	e.entropy.available = available
+	e.entropy.poolsize = poolsize
+	e.engine.Report(e)
+}
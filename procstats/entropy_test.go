@@ -0,0 +1,31 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestEntropyMetrics(t *testing.T) {
+	if _, err := linux.ReadEntropyAvail(); err != nil {
+		t.Skip("entropy is not available:", err)
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	NewEntropyMetricsWith(e).Collect()
+
+	measures := h.Measures()
+	if len(measures) != 1 {
+		t.Fatalf("bad number of measures: %v", measures)
+	}
+
+	if m := measures[0]; m.Name != "entropy" || len(m.Fields) != 2 || m.Fields[1].Value.Uint() == 0 {
+		t.Errorf("bad measure: %v", m)
+	}
+}
@@ -0,0 +1,87 @@
+package procstats
+
+import (
+	"github.com/segmentio/stats"
+)
+
+// FilesystemMetrics is a metric collector that reports the space and inodes
+// used on the file systems that a list of paths belong to, tagged by path and
+// file system type.
+type FilesystemMetrics struct {
+	engine *stats.Engine
+	paths  []string
+}
+
+type filesystem struct {
+	space struct {
+		total     uint64  `metric:"total.bytes"     type:"gauge"`
+		used      uint64  `metric:"used.bytes"      type:"gauge"`
+		available uint64  `metric:"available.bytes" type:"gauge"` // space available to unprivileged users
+		percent   float64 `metric:"used.percent"    type:"gauge"`
+	} `metric:"filesystem"`
+	inodes struct {
+		total   uint64  `metric:"inodes_total.count" type:"gauge"`
+		used    uint64  `metric:"inodes_used.count"  type:"gauge"`
+		percent float64 `metric:"inodes_used.percent" type:"gauge"`
+	} `metric:"filesystem"`
+	path   string `tag:"path"`
+	fstype string `tag:"fstype"`
+}
+
+// NewFilesystemMetrics collects usage metrics of the file systems that paths
+// belong to and reports them to the default stats engine.
+func NewFilesystemMetrics(paths ...string) *FilesystemMetrics {
+	return NewFilesystemMetricsWith(stats.DefaultEngine, paths...)
+}
+
+// NewFilesystemMetricsWith collects usage metrics of the file systems that
+// paths belong to and reports them to eng.
+func NewFilesystemMetricsWith(eng *stats.Engine, paths ...string) *FilesystemMetrics {
+	return &FilesystemMetrics{engine: eng, paths: append([]string{}, paths...)}
+}
+
+// Collect satisfies the Collector interface.
+func (f *FilesystemMetrics) Collect() {
+	for _, path := range f.paths {
+		info, err := CollectFilesystemInfo(path)
+		if err != nil {
+			continue
+		}
+
+		fs := &filesystem{path: path, fstype: info.Type}
+		fs.space.total = info.Total
+		fs.space.used = info.Total - info.Free
+		fs.space.available = info.Available
+		fs.inodes.total = info.Files
+		fs.inodes.used = info.Files - info.FilesFree
+
+		// The used percentage is computed the same way df does, relative to
+		// the space that unprivileged users can use.
+		if n := fs.space.used + fs.space.available; n != 0 {
+			fs.space.percent = 100 * float64(fs.space.used) / float64(n)
+		}
+		if fs.inodes.total != 0 {
+			fs.inodes.percent = 100 * float64(fs.inodes.used) / float64(fs.inodes.total)
+		}
+
+		f.engine.Report(fs)
+	}
+}
+
+// FilesystemInfo represents the usage of a file system.
+type FilesystemInfo struct {
+	Type      string // file system type (ext4, xfs, ...), empty if unknown
+	Total     uint64 // size of the file system in bytes
+	Free      uint64 // free bytes
+	Available uint64 // free bytes available to unprivileged users
+	Files     uint64 // total number of inodes, zero if unknown
+	FilesFree uint64 // free inodes
+}
+
+// CollectFilesystemInfo returns the usage of the file system that path belongs
+// to.
+func CollectFilesystemInfo(path string) (info FilesystemInfo, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	info = collectFilesystemInfo(path)
+	return
+}
@@ -0,0 +1,25 @@
+package procstats
+
+import "syscall"
+
+func collectFilesystemInfo(path string) (info FilesystemInfo) {
+	var fs syscall.Statfs_t
+	check(syscall.Statfs(path, &fs))
+
+	fstype := make([]byte, 0, len(fs.Fstypename))
+	for _, c := range fs.Fstypename {
+		if c == 0 {
+			break
+		}
+		fstype = append(fstype, byte(c))
+	}
+
+	bsize := uint64(fs.Bsize)
+	info.Type = string(fstype)
+	info.Total = bsize * fs.Blocks
+	info.Free = bsize * fs.Bfree
+	info.Available = bsize * fs.Bavail
+	info.Files = fs.Files
+	info.FilesFree = fs.Ffree
+	return
+}
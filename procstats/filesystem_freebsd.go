@@ -0,0 +1,35 @@
+package procstats
+
+import "syscall"
+
+func collectFilesystemInfo(path string) (info FilesystemInfo) {
+	var fs syscall.Statfs_t
+	check(syscall.Statfs(path, &fs))
+
+	bsize := fs.Bsize
+	info.Type = fstypename(fs.Fstypename[:])
+	info.Total = bsize * fs.Blocks
+	info.Free = bsize * fs.Bfree
+	info.Files = fs.Files
+
+	// The number of available blocks and free inodes are negative when the
+	// space reserved for the super-user is in use.
+	if fs.Bavail > 0 {
+		info.Available = bsize * uint64(fs.Bavail)
+	}
+	if fs.Ffree > 0 {
+		info.FilesFree = uint64(fs.Ffree)
+	}
+	return
+}
+
+func fstypename(name []int8) string {
+	b := make([]byte, 0, len(name))
+	for _, c := range name {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return string(b)
+}
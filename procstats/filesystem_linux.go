@@ -0,0 +1,27 @@
+package procstats
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/segmentio/stats/procstats/linux"
+)
+
+func collectFilesystemInfo(path string) (info FilesystemInfo) {
+	var fs syscall.Statfs_t
+	check(syscall.Statfs(path, &fs))
+
+	if mounts, err := linux.ReadMounts(os.Getpid()); err == nil {
+		if mount, ok := linux.LookupMount(mounts, path); ok {
+			info.Type = mount.Type
+		}
+	}
+
+	bsize := uint64(fs.Bsize)
+	info.Total = bsize * fs.Blocks
+	info.Free = bsize * fs.Bfree
+	info.Available = bsize * fs.Bavail
+	info.Files = fs.Files
+	info.FilesFree = fs.Ffree
+	return
+}
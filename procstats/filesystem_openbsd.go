@@ -0,0 +1,33 @@
+package procstats
+
+import "syscall"
+
+func collectFilesystemInfo(path string) (info FilesystemInfo) {
+	var fs syscall.Statfs_t
+	check(syscall.Statfs(path, &fs))
+
+	bsize := uint64(fs.F_bsize)
+	info.Type = fstypename(fs.F_fstypename[:])
+	info.Total = bsize * fs.F_blocks
+	info.Free = bsize * fs.F_bfree
+	info.Files = fs.F_files
+	info.FilesFree = fs.F_ffree
+
+	// The number of available blocks is negative when the space reserved for
+	// the super-user is in use.
+	if fs.F_bavail > 0 {
+		info.Available = bsize * uint64(fs.F_bavail)
+	}
+	return
+}
+
+func fstypename(name []int8) string {
+	b := make([]byte, 0, len(name))
+	for _, c := range name {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return string(b)
+}
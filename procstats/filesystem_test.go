@@ -0,0 +1,38 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"os"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestFilesystemMetrics(t *testing.T) {
+	dir := os.TempDir()
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	NewFilesystemMetricsWith(e, dir, "/path/that/does/not/exist").Collect()
+
+	measures := h.Measures()
+	if len(measures) != 2 {
+		t.Fatalf("bad number of measures: %v", measures)
+	}
+
+	for _, m := range measures {
+		if m.Name != "filesystem" {
+			t.Errorf("bad measure: %v", m)
+		}
+		if m.Tags[1] != stats.T("path", dir) {
+			t.Errorf("bad path tag: %v", m.Tags)
+		}
+	}
+
+	if m := measures[0]; m.Fields[0].Name != "total.bytes" || m.Fields[0].Value.Uint() == 0 {
+		t.Errorf("the total size of the file system was not reported: %v", m)
+	}
+}
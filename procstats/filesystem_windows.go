@@ -0,0 +1,55 @@
+package procstats
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	procGetDiskFreeSpaceExW   = kernel32.NewProc("GetDiskFreeSpaceExW")
+	procGetVolumePathNameW    = kernel32.NewProc("GetVolumePathNameW")
+	procGetVolumeInformationW = kernel32.NewProc("GetVolumeInformationW")
+)
+
+func collectFilesystemInfo(path string) (info FilesystemInfo) {
+	p, err := syscall.UTF16PtrFromString(path)
+	check(err)
+
+	var available, total, free uint64
+	check(call(procGetDiskFreeSpaceExW,
+		uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&available)),
+		uintptr(unsafe.Pointer(&total)),
+		uintptr(unsafe.Pointer(&free)),
+	))
+
+	info.Type = volumeFileSystem(p)
+	info.Total = total
+	info.Free = free
+	info.Available = available
+	return
+}
+
+func volumeFileSystem(path *uint16) string {
+	var root [syscall.MAX_PATH + 1]uint16
+	var name [syscall.MAX_PATH + 1]uint16
+
+	if call(procGetVolumePathNameW,
+		uintptr(unsafe.Pointer(path)),
+		uintptr(unsafe.Pointer(&root[0])),
+		uintptr(len(root)),
+	) != nil {
+		return ""
+	}
+
+	if call(procGetVolumeInformationW,
+		uintptr(unsafe.Pointer(&root[0])),
+		0, 0, 0, 0, 0,
+		uintptr(unsafe.Pointer(&name[0])),
+		uintptr(len(name)),
+	) != nil {
+		return ""
+	}
+
+	return syscall.UTF16ToString(name[:])
+}
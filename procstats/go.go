@@ -3,6 +3,8 @@ package procstats
 import (
 	"math"
 	"runtime"
+	"runtime/pprof"
+	"sort"
 	"time"
 
 	"github.com/segmentio/stats"
@@ -22,15 +24,20 @@ func init() {
 }
 
 // GoMetrics is a metric collector that reports metrics from the Go runtime.
+//
+// The collector reports values at the interval that it is run at, which is set
+// by the Sampler or Config used to run it.
 type GoMetrics struct {
 	engine  *stats.Engine
 	version string `tag:"version"`
 
 	runtime struct {
 		// Runtime info.
-		numCPU         int `metric:"cpu.num"       type:"gauge"`
-		numGoroutine   int `metric:"goroutine.num" type:"gauge"`
-		numCgoCall     int `metric:"cgo.calls"     type:"counter"`
+		numCPU         int `metric:"cpu.num"        type:"gauge"`
+		gomaxprocs     int `metric:"cpu.gomaxprocs" type:"gauge"` // max number of threads executing Go code simultaneously
+		numGoroutine   int `metric:"goroutine.num"  type:"gauge"`
+		numThread      int `metric:"thread.num"     type:"gauge"` // OS threads created by the runtime
+		numCgoCall     int `metric:"cgo.calls"      type:"counter"`
 		lastNumCgoCall int
 	} `metric:"go.runtime"`
 
@@ -96,6 +103,9 @@ type GoMetrics struct {
 		gcPauseAvg    time.Duration `metric:"gc_pause.seconds.avg" type:"gauge"`
 		gcPauseMin    time.Duration `metric:"gc_pause.seconds.min" type:"gauge"`
 		gcPauseMax    time.Duration `metric:"gc_pause.seconds.max" type:"gauge"`
+		gcPauseP50    time.Duration `metric:"gc_pause.seconds.p50" type:"gauge"`
+		gcPauseP95    time.Duration `metric:"gc_pause.seconds.p95" type:"gauge"`
+		gcPauseP99    time.Duration `metric:"gc_pause.seconds.p99" type:"gauge"`
 		gcCPUFraction float64       `metric:"gc_cpu.fraction"      type:"gauge"` // fraction of CPU time used by GC
 	} `metric:"go.memstats"`
 
@@ -142,7 +152,9 @@ func (g *GoMetrics) Collect() {
 	g.runtime.lastNumCgoCall = int(runtime.NumCgoCall())
 
 	g.runtime.numCPU = runtime.NumCPU()
+	g.runtime.gomaxprocs = runtime.GOMAXPROCS(0)
 	g.runtime.numGoroutine = runtime.NumGoroutine()
+	g.runtime.numThread = threadcreate.Count()
 	g.runtime.numCgoCall = g.runtime.lastNumCgoCall - lastNumCgoCall
 
 	pauses := collectMemoryStats(&g.ms, lastNumGC)
@@ -178,6 +190,9 @@ func (g *GoMetrics) Collect() {
 		g.memstats.gcPauseAvg = 0
 		g.memstats.gcPauseMin = 0
 		g.memstats.gcPauseMax = 0
+		g.memstats.gcPauseP50 = 0
+		g.memstats.gcPauseP95 = 0
+		g.memstats.gcPauseP99 = 0
 	} else {
 		g.memstats.gcPauseMin = pauses[0]
 		g.memstats.gcPauseMax = pauses[0]
@@ -194,11 +209,34 @@ func (g *GoMetrics) Collect() {
 		}
 
 		g.memstats.gcPauseAvg /= time.Duration(len(pauses))
+
+		sort.Sort(durations(pauses))
+		g.memstats.gcPauseP50 = quantile(pauses, 0.50)
+		g.memstats.gcPauseP95 = quantile(pauses, 0.95)
+		g.memstats.gcPauseP99 = quantile(pauses, 0.99)
 	}
 
 	g.engine.ReportAt(now, g)
 }
 
+var threadcreate = pprof.Lookup("threadcreate")
+
+// quantile returns the value at the q quantile of sorted using the
+// nearest-rank method.
+func quantile(sorted []time.Duration, q float64) time.Duration {
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
 func collectMemoryStats(memstats *runtime.MemStats, lastNumGC uint32) (pauses []time.Duration) {
 	runtime.ReadMemStats(memstats)
 	return makeGCPauses(memstats, lastNumGC)
@@ -1,3 +1,5 @@
+//go:build !statsoff
+
 package procstats
 
 import (
@@ -36,3 +38,22 @@ func TestGoMetrics(t *testing.T) {
 		time.Sleep(10 * time.Millisecond)
 	}
 }
+
+func TestQuantile(t *testing.T) {
+	pauses := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	for _, test := range []struct {
+		q float64
+		d time.Duration
+	}{
+		{q: 0, d: 1},
+		{q: 0.50, d: 5},
+		{q: 0.95, d: 10},
+		{q: 0.99, d: 10},
+		{q: 1, d: 10},
+	} {
+		if d := quantile(pauses, test.q); d != test.d {
+			t.Errorf("bad quantile %g: %d != %d", test.q, d, test.d)
+		}
+	}
+}
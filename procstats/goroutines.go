@@ -0,0 +1,62 @@
+package procstats
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// GoroutineMetrics is a metric collector that reports the number of goroutines
+// of the program, and how long that number has been growing for.
+//
+// The count is considered to be growing as long as it never decreases between
+// two collections; a goroutine leak shows up as a growth duration that keeps
+// increasing, which can be alerted on well before the program runs out of
+// memory.
+type GoroutineMetrics struct {
+	engine     *stats.Engine
+	goroutines struct {
+		num    int           `metric:"num"            type:"gauge"`
+		growth time.Duration `metric:"growth.seconds" type:"gauge"` // duration of uninterrupted growth
+		added  int           `metric:"growth.count"   type:"gauge"` // goroutines added since the growth started
+	} `metric:"go.goroutine"`
+
+	now        func() time.Time
+	numGo      func() int
+	last       int
+	startTime  time.Time // time at which the current growth started
+	startCount int       // number of goroutines when the current growth started
+}
+
+// NewGoroutineMetrics collects goroutine metrics and reports them to the
+// default stats engine.
+func NewGoroutineMetrics() *GoroutineMetrics {
+	return NewGoroutineMetricsWith(stats.DefaultEngine)
+}
+
+// NewGoroutineMetricsWith collects goroutine metrics and reports them to eng.
+func NewGoroutineMetricsWith(eng *stats.Engine) *GoroutineMetrics {
+	return &GoroutineMetrics{
+		engine: eng,
+		now:    time.Now,
+		numGo:  runtime.NumGoroutine,
+	}
+}
+
+// Collect satisfies the Collector interface.
+func (g *GoroutineMetrics) Collect() {
+	now, num := g.now(), g.numGo()
+
+	// On the first collection, or when the number of goroutines went down,
+	// the growth (re)starts from here.
+	if g.startTime.IsZero() || num < g.last {
+		g.startTime, g.startCount = now, num
+	}
+
+	g.goroutines.num = num
+	g.goroutines.growth = now.Sub(g.startTime)
+	g.goroutines.added = num - g.startCount
+	g.last = num
+	g.engine.ReportAt(now, g)
+}
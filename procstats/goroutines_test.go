@@ -0,0 +1,59 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestGoroutineMetrics(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	num := 0
+
+	g := NewGoroutineMetricsWith(e)
+	g.now = func() time.Time { return now }
+	g.numGo = func() int { return num }
+
+	for _, test := range []struct {
+		num    int
+		growth time.Duration
+		added  int
+	}{
+		{num: 10, growth: 0, added: 0},
+		{num: 12, growth: 1 * time.Minute, added: 2},
+		{num: 12, growth: 2 * time.Minute, added: 2},
+		{num: 15, growth: 3 * time.Minute, added: 5},
+		{num: 11, growth: 0, added: 0}, // decrease, the growth restarts
+		{num: 13, growth: 1 * time.Minute, added: 2},
+	} {
+		num = test.num
+		h.Clear()
+		g.Collect()
+		now = now.Add(time.Minute)
+
+		m := h.Measures()[0]
+
+		if m.Name != "go.goroutine" {
+			t.Errorf("bad measure name: %s", m.Name)
+		}
+
+		if v := m.Fields[0].Value.Int(); v != int64(test.num) {
+			t.Errorf("bad goroutine count: %d != %d", v, test.num)
+		}
+
+		if v := m.Fields[1].Value.Duration(); v != test.growth {
+			t.Errorf("bad growth duration at %d goroutines: %s != %s", test.num, v, test.growth)
+		}
+
+		if v := m.Fields[2].Value.Int(); v != int64(test.added) {
+			t.Errorf("bad growth count at %d goroutines: %d != %d", test.num, v, test.added)
+		}
+	}
+}
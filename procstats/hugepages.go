@@ -0,0 +1,79 @@
+package procstats
+
+import (
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+)
+
+// HugePageMetrics is a metric collector that reports the usage of the hugetlb
+// pool and of transparent huge pages (THP) on the host.
+type HugePageMetrics struct {
+	engine  *stats.Engine
+	hugetlb hugetlbMetrics `metric:"hugetlb"`
+	thp     thpMetrics     `metric:"thp"`
+	last    linux.VMStat
+}
+
+type hugetlbMetrics struct {
+	total    uint64 `metric:"pages_total.count"    type:"gauge"` // size of the pool of huge pages
+	free     uint64 `metric:"pages_free.count"     type:"gauge"` // huge pages not yet allocated
+	reserved uint64 `metric:"pages_reserved.count" type:"gauge"` // huge pages committed to but not yet allocated
+	surplus  uint64 `metric:"pages_surplus.count"  type:"gauge"` // huge pages allocated above the size of the pool
+	size     uint64 `metric:"page_size.bytes"      type:"gauge"` // default huge page size
+	usage    uint64 `metric:"usage.bytes"          type:"gauge"` // memory consumed by huge pages of all sizes
+}
+
+type thpMetrics struct {
+	anon          uint64 `metric:"anon.bytes"            type:"gauge"`   // anonymous memory backed by transparent huge pages
+	faultAlloc    uint64 `metric:"fault_alloc.count"     type:"counter"` // page faults satisfied with a huge page
+	faultFallback uint64 `metric:"fault_fallback.count"  type:"counter"` // page faults that fell back to regular pages
+	collapseAlloc uint64 `metric:"collapse_alloc.count"  type:"counter"` // huge pages allocated by khugepaged to collapse regular pages
+	splitPage     uint64 `metric:"split_page.count"      type:"counter"` // huge pages split into regular pages
+}
+
+// NewHugePageMetrics collects huge page metrics of the host and reports them
+// to the default stats engine.
+func NewHugePageMetrics() *HugePageMetrics {
+	return NewHugePageMetricsWith(stats.DefaultEngine)
+}
+
+// NewHugePageMetricsWith collects huge page metrics of the host and reports
+// them to eng.
+func NewHugePageMetricsWith(eng *stats.Engine) *HugePageMetrics {
+	return &HugePageMetrics{engine: eng}
+}
+
+// Collect satisfies the Collector interface.
+func (h *HugePageMetrics) Collect() {
+	info, err := linux.ReadMemInfo()
+	if err != nil {
+		return
+	}
+
+	stat, err := linux.ReadVMStat()
+	if err != nil {
+		return
+	}
+
+	last := h.last
+
+	h.hugetlb.total = info["HugePages_Total"]
+	h.hugetlb.free = info["HugePages_Free"]
+	h.hugetlb.reserved = info["HugePages_Rsvd"]
+	h.hugetlb.surplus = info["HugePages_Surp"]
+	h.hugetlb.size = info["Hugepagesize"]
+	h.hugetlb.usage = info["Hugetlb"]
+
+	h.thp.anon = info["AnonHugePages"]
+	h.thp.faultAlloc = stat["thp_fault_alloc"] - last["thp_fault_alloc"]
+	h.thp.faultFallback = stat["thp_fault_fallback"] - last["thp_fault_fallback"]
+	h.thp.collapseAlloc = stat["thp_collapse_alloc"] - last["thp_collapse_alloc"]
+	h.thp.splitPage = stat["thp_split_page"] - last["thp_split_page"]
+
+	h.last = stat
+
+	// The first collection only initializes the baseline of the counters.
+	if last != nil {
+		h.engine.Report(h)
+	}
+}
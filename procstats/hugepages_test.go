@@ -0,0 +1,42 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestHugePageMetrics(t *testing.T) {
+	if _, err := linux.ReadVMStat(); err != nil {
+		t.Skip("vmstat is not available:", err)
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	hugepages := NewHugePageMetricsWith(e)
+	hugepages.Collect()
+
+	if n := len(h.Measures()); n != 0 {
+		t.Error("measures were reported by the first collection:", n)
+	}
+
+	hugepages.Collect()
+
+	measures := h.Measures()
+	if len(measures) != 2 {
+		t.Fatalf("bad number of measures: %v", measures)
+	}
+
+	if m := measures[0]; m.Name != "hugetlb" || len(m.Fields) != 6 {
+		t.Errorf("bad hugetlb measure: %v", m)
+	}
+
+	if m := measures[1]; m.Name != "thp" || len(m.Fields) != 5 {
+		t.Errorf("bad thp measure: %v", m)
+	}
+}
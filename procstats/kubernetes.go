@@ -0,0 +1,88 @@
+package procstats
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/segmentio/stats"
+)
+
+// Directory where kubernetes mounts the service account credentials of pods.
+const kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// KubernetesInfo describes the kubernetes pod and container that a program is
+// running in.
+//
+// Kubernetes does not expose the node or container names to pods by default,
+// they are read from the NODE_NAME and CONTAINER_NAME environment variables
+// which must be configured in the pod spec (using the downward API for the
+// node name). The pod name and namespace are read from the POD_NAME and
+// POD_NAMESPACE environment variables when they are set, and otherwise from
+// the host name and the service account mounted in the container.
+type KubernetesInfo struct {
+	Pod       string
+	Namespace string
+	Node      string
+	Container string
+}
+
+// DetectKubernetes returns information about the kubernetes pod that the
+// program is running in, the returned boolean is false if the program does
+// not appear to be running in kubernetes.
+func DetectKubernetes() (KubernetesInfo, bool) {
+	return detectKubernetes(os.Getenv, kubernetesServiceAccountDir)
+}
+
+func detectKubernetes(getenv func(string) string, serviceAccountDir string) (info KubernetesInfo, ok bool) {
+	if getenv("KUBERNETES_SERVICE_HOST") == "" && !fileExists(serviceAccountDir) {
+		return
+	}
+
+	info.Pod = getenv("POD_NAME")
+	info.Namespace = getenv("POD_NAMESPACE")
+	info.Node = getenv("NODE_NAME")
+	info.Container = getenv("CONTAINER_NAME")
+
+	if info.Pod == "" {
+		// Containers of a pod have the pod name as host name unless the
+		// pod spec overrides it.
+		if info.Pod = getenv("HOSTNAME"); info.Pod == "" {
+			info.Pod, _ = os.Hostname()
+		}
+	}
+
+	if info.Namespace == "" {
+		if b, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "namespace")); err == nil {
+			info.Namespace = strings.TrimSpace(string(b))
+		}
+	}
+
+	ok = true
+	return
+}
+
+// Tags returns the list of tags describing the kubernetes environment, empty
+// values are omitted.
+func (k KubernetesInfo) Tags() []stats.Tag {
+	tags := make([]stats.Tag, 0, 4)
+
+	for _, tag := range [...]stats.Tag{
+		{Name: "pod", Value: k.Pod},
+		{Name: "namespace", Value: k.Namespace},
+		{Name: "node", Value: k.Node},
+		{Name: "container", Value: k.Container},
+	} {
+		if tag.Value != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,95 @@
+package procstats
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/stats"
+)
+
+func TestDetectKubernetes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "serviceaccount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "namespace"), []byte("default\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		scenario string
+		env      map[string]string
+		dir      string
+		info     KubernetesInfo
+		ok       bool
+	}{
+		{
+			scenario: "not running in kubernetes",
+			env:      map[string]string{"HOSTNAME": "localhost"},
+			dir:      filepath.Join(dir, "missing"),
+		},
+		{
+			scenario: "downward api environment variables",
+			env: map[string]string{
+				"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+				"HOSTNAME":                "api-5d8f7c9b4-xkq2z",
+				"POD_NAME":                "api-5d8f7c9b4-abcde",
+				"POD_NAMESPACE":           "production",
+				"NODE_NAME":               "ip-10-0-1-23",
+				"CONTAINER_NAME":          "api",
+			},
+			dir: filepath.Join(dir, "missing"),
+			info: KubernetesInfo{
+				Pod:       "api-5d8f7c9b4-abcde",
+				Namespace: "production",
+				Node:      "ip-10-0-1-23",
+				Container: "api",
+			},
+			ok: true,
+		},
+		{
+			scenario: "service account and host name",
+			env:      map[string]string{"HOSTNAME": "api-5d8f7c9b4-xkq2z"},
+			dir:      dir,
+			info: KubernetesInfo{
+				Pod:       "api-5d8f7c9b4-xkq2z",
+				Namespace: "default",
+			},
+			ok: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			getenv := func(name string) string { return test.env[name] }
+			info, ok := detectKubernetes(getenv, test.dir)
+
+			if ok != test.ok {
+				t.Error("bad detection:", ok)
+			}
+
+			if info != test.info {
+				t.Errorf("bad info:\n- expected: %+v\n- found:    %+v", test.info, info)
+			}
+		})
+	}
+}
+
+func TestKubernetesTags(t *testing.T) {
+	info := KubernetesInfo{Pod: "api-5d8f7c9b4-xkq2z", Namespace: "default"}
+
+	tags := info.Tags()
+	expected := []stats.Tag{
+		{Name: "pod", Value: "api-5d8f7c9b4-xkq2z"},
+		{Name: "namespace", Value: "default"},
+	}
+
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("bad tags:\n- expected: %v\n- found:    %v", expected, tags)
+	}
+}
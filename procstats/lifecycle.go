@@ -0,0 +1,131 @@
+package procstats
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// Lifecycle reports the lifecycle events of a program: its start, the
+// duration of the phases of its initialization, and its shutdown. The metrics
+// give deploy tooling a standard set of signals to detect slow starts and
+// crash loops.
+//
+// The lifecycle produces the following fields on the "lifecycle" measure:
+//
+//	start          (counter)   incremented when the program starts, tagged
+//	                           with the outcome of the previous run
+//	phase.seconds  (histogram) duration of each phase of the initialization,
+//	                           tagged with the phase name
+//	ready.seconds  (histogram) time from the process start to the program
+//	                           being ready
+//	shutdown       (counter)   incremented when the program shuts down
+//
+// The outcome of the previous run is detected with a sentinel file, created
+// when the lifecycle starts and removed on shutdown. The "previous" tag of the
+// start counter is set to "clean" if the file did not exist, "crash" if the
+// previous run did not remove it, or "unknown" if no sentinel file was
+// configured or it could not be created.
+//
+// Lifecycle values are safe to use concurrently from multiple goroutines.
+type Lifecycle struct {
+	eng      *stats.Engine
+	sentinel string
+	start    time.Time
+
+	mutex sync.Mutex
+	last  time.Time
+	ready bool
+}
+
+// StartLifecycle starts the lifecycle of the program, reporting to the default
+// engine and using the given sentinel file, which may be empty.
+func StartLifecycle(sentinel string) *Lifecycle {
+	return StartLifecycleWith(stats.DefaultEngine, sentinel)
+}
+
+// StartLifecycleWith starts the lifecycle of the program, reporting to eng and
+// using the given sentinel file, which may be empty.
+func StartLifecycleWith(eng *stats.Engine, sentinel string) *Lifecycle {
+	now := time.Now()
+	start := now
+
+	if info, err := CollectProcInfo(os.Getpid()); err == nil && !info.StartTime.IsZero() && info.StartTime.Before(now) {
+		start = info.StartTime
+	}
+
+	l := &Lifecycle{
+		eng:      eng,
+		sentinel: sentinel,
+		start:    start,
+		last:     now,
+	}
+
+	eng.Incr("lifecycle:start", stats.T("previous", l.previous()))
+	return l
+}
+
+// previous returns the outcome of the previous run and creates the sentinel
+// file for the current one.
+func (l *Lifecycle) previous() string {
+	if l.sentinel == "" {
+		return "unknown"
+	}
+
+	outcome := "clean"
+
+	if _, err := os.Stat(l.sentinel); err == nil {
+		outcome = "crash"
+	} else if !os.IsNotExist(err) {
+		return "unknown"
+	}
+
+	f, err := os.Create(l.sentinel)
+	if err != nil {
+		return "unknown"
+	}
+	f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	f.Close()
+	return outcome
+}
+
+// Phase reports the duration of the initialization phase named name, which
+// ended now and started at the end of the previous phase (or when the
+// lifecycle started).
+func (l *Lifecycle) Phase(name string) {
+	now := time.Now()
+
+	l.mutex.Lock()
+	d := now.Sub(l.last)
+	l.last = now
+	l.mutex.Unlock()
+
+	l.eng.Observe("lifecycle:phase.seconds", d, stats.T("phase", name))
+}
+
+// Ready reports the time it took for the program to be ready since the
+// process started. Only the first call reports the duration.
+func (l *Lifecycle) Ready() {
+	l.mutex.Lock()
+	ready := l.ready
+	l.ready = true
+	l.mutex.Unlock()
+
+	if !ready {
+		l.eng.Observe("lifecycle:ready.seconds", time.Since(l.start))
+	}
+}
+
+// Shutdown reports the clean shutdown of the program and removes the sentinel
+// file, then flushes the engine so the metrics are sent before the program
+// exits.
+func (l *Lifecycle) Shutdown() {
+	if l.sentinel != "" {
+		os.Remove(l.sentinel)
+	}
+	l.eng.Incr("lifecycle:shutdown")
+	l.eng.Flush()
+}
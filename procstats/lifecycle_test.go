@@ -0,0 +1,98 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestLifecycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lifecycle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sentinel := filepath.Join(dir, "running")
+
+	tests := []struct {
+		scenario string
+		shutdown bool
+		previous string
+	}{
+		{scenario: "the first start is clean", shutdown: false, previous: "clean"},
+		{scenario: "a start after a crash reports it", shutdown: true, previous: "crash"},
+		{scenario: "a start after a clean shutdown is clean", shutdown: false, previous: "clean"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			h := &statstest.Handler{}
+			e := stats.NewEngine("", h)
+
+			l := StartLifecycleWith(e, sentinel)
+			l.Phase("config")
+			l.Ready()
+			l.Ready()
+
+			if _, err := os.Stat(sentinel); err != nil {
+				t.Error("sentinel file was not created:", err)
+			}
+
+			if test.shutdown {
+				l.Shutdown()
+
+				if _, err := os.Stat(sentinel); !os.IsNotExist(err) {
+					t.Error("sentinel file was not removed:", err)
+				}
+			}
+
+			measures := h.Measures()
+			if len(measures) < 3 {
+				t.Fatalf("bad number of measures: %v", measures)
+			}
+
+			if m := measures[0]; m.Name != "lifecycle" || m.Fields[0].Name != "start" || m.Tags[0] != stats.T("previous", test.previous) {
+				t.Errorf("bad start measure: %v", m)
+			}
+
+			if m := measures[1]; m.Fields[0].Name != "phase.seconds" || m.Tags[0] != stats.T("phase", "config") {
+				t.Errorf("bad phase measure: %v", m)
+			}
+
+			if m := measures[2]; m.Fields[0].Name != "ready.seconds" || m.Fields[0].Value.Duration() <= 0 {
+				t.Errorf("bad ready measure: %v", m)
+			}
+
+			if test.shutdown {
+				if len(measures) != 4 || measures[3].Fields[0].Name != "shutdown" {
+					t.Errorf("bad shutdown measures: %v", measures)
+				}
+			} else if len(measures) != 3 {
+				t.Errorf("bad number of measures: %v", measures)
+			}
+		})
+	}
+}
+
+func TestLifecycleWithoutSentinel(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	StartLifecycleWith(e, "").Shutdown()
+
+	measures := h.Measures()
+	if len(measures) != 2 {
+		t.Fatalf("bad number of measures: %v", measures)
+	}
+
+	if m := measures[0]; m.Tags[0] != stats.T("previous", "unknown") {
+		t.Errorf("bad start measure: %v", m)
+	}
+}
@@ -1,9 +1,6 @@
 package linux
 
-import (
-	"strings"
-	"time"
-)
+import "time"
 
 type ProcCGroup []CGroup
 
@@ -14,10 +11,10 @@ type CGroup struct {
 }
 
 func (pcg ProcCGroup) Lookup(name string) (cgroup CGroup, ok bool) {
-	forEachToken(name, ",", func(key1 string) {
+	forEachToken([]byte(name), ',', func(key1 []byte) {
 		for _, cg := range pcg {
-			forEachToken(cg.Name, ",", func(key2 string) {
-				if key1 == key2 {
+			forEachToken([]byte(cg.Name), ',', func(key2 []byte) {
+				if string(key1) == string(key2) {
 					cgroup, ok = cg, true
 				}
 			})
@@ -34,24 +31,28 @@ func ReadProcCGroup(pid int) (proc ProcCGroup, err error) {
 
 func ParseProcCGroup(s string) (proc ProcCGroup, err error) {
 	defer func() { err = convertPanicToError(recover()) }()
-	proc = parseProcCGroup(s)
+	proc = parseProcCGroup([]byte(s))
 	return
 }
 
-func parseProcCGroup(s string) (proc ProcCGroup) {
-	forEachLine(s, func(line string) {
+func parseProcCGroup(b []byte) (proc ProcCGroup) {
+	forEachLine(b, func(line []byte) {
 		id, line := split(line, ':')
 		name, path := split(line, ':')
 
+		if len(name) == 0 { // cgroup v2 (unified hierarchy)
+			proc = append(proc, CGroup{ID: atoi(id), Path: string(path)})
+		}
+
 		for len(name) != 0 {
-			var next string
+			var next []byte
 			name, next = split(name, ',')
 
-			if strings.HasPrefix(name, "name=") { // WTF?
-				name = strings.TrimSpace(name[5:])
+			if n, ok := trimPrefix(name, "name="); ok { // WTF?
+				name = trimSpace(n)
 			}
 
-			proc = append(proc, CGroup{ID: atoi(id), Name: name, Path: path})
+			proc = append(proc, CGroup{ID: atoi(id), Name: string(name), Path: string(path)})
 			name = next
 		}
 	})
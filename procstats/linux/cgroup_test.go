@@ -1,8 +1,10 @@
 package linux
 
 import (
+	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestParseProcCGroup(t *testing.T) {
@@ -103,3 +105,72 @@ func TestReadCPUShares(t *testing.T) {
 		t.Fatal("invalid CPU shares:", shares)
 	}
 }
+
+func TestParseProcCGroupUnified(t *testing.T) {
+	proc, err := ParseProcCGroup("0::/system.slice/docker-4f3c.scope\n")
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !reflect.DeepEqual(proc, ProcCGroup{{0, "", "/system.slice/docker-4f3c.scope"}}) {
+		t.Error(proc)
+	}
+}
+
+func TestParseCPUMax(t *testing.T) {
+	tests := []struct {
+		text   string
+		quota  time.Duration
+		period time.Duration
+	}{
+		{"max 100000\n", 0, 100 * time.Millisecond},
+		{"50000 100000\n", 50 * time.Millisecond, 100 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		quota, period, err := ParseCPUMax(test.text)
+
+		if err != nil {
+			t.Error(err)
+		} else if quota != test.quota || period != test.period {
+			t.Errorf("bad values from %q: quota=%s period=%s", test.text, quota, period)
+		}
+	}
+}
+
+func TestParseCPUStat(t *testing.T) {
+	tests := []struct {
+		text string
+		stat CPUStat
+	}{
+		{
+			text: "nr_periods 120\nnr_throttled 12\nthrottled_time 1500000000\n",
+			stat: CPUStat{Periods: 120, ThrottledPeriods: 12, ThrottledTime: 1500 * time.Millisecond},
+		},
+		{
+			text: "usage_usec 8001\nuser_usec 4000\nsystem_usec 4001\nnr_periods 120\nnr_throttled 12\nthrottled_usec 1500000\n",
+			stat: CPUStat{Periods: 120, ThrottledPeriods: 12, ThrottledTime: 1500 * time.Millisecond},
+		},
+	}
+
+	for _, test := range tests {
+		if stat, err := ParseCPUStat(test.text); err != nil {
+			t.Error(err)
+		} else if stat != test.stat {
+			t.Errorf("bad cpu stat from %q: %+v", test.text, stat)
+		}
+	}
+}
+
+func TestReadCGroupInfo(t *testing.T) {
+	info, err := ReadCGroupInfo(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != 1 && info.Version != 2 {
+		t.Fatal("invalid cgroup version:", info.Version)
+	}
+	t.Logf("%+v", info)
+}
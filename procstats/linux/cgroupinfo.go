@@ -0,0 +1,198 @@
+package linux
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CGroupInfo represents the resource limits and usage of the cgroups that a
+// process belongs to, on both cgroup v1 and v2 (unified) hierarchies.
+//
+// Values which could not be read (for example because the controller is not
+// enabled) are zero.
+type CGroupInfo struct {
+	Version int // cgroup version, 1 or 2
+
+	MemoryLimit uint64 // memory limit in bytes, zero if unlimited
+	MemoryUsage uint64 // memory usage in bytes
+
+	CPUPeriod time.Duration // scheduler period
+	CPUQuota  time.Duration // time quota in the scheduler period, zero if unlimited
+	CPUStat   CPUStat       // throttling statistics
+}
+
+// CPUStat represents the throttling statistics of a cgroup, read from its
+// cpu.stat file.
+type CPUStat struct {
+	Periods          uint64        // number of elapsed scheduler periods
+	ThrottledPeriods uint64        // number of periods where the cgroup was throttled
+	ThrottledTime    time.Duration // total time that the cgroup was throttled for
+}
+
+func ReadCGroupInfo(pid int) (info CGroupInfo, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	info = readCGroupInfo(pid)
+	return
+}
+
+func ParseCPUMax(s string) (quota time.Duration, period time.Duration, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	quota, period = parseCPUMax([]byte(s))
+	return
+}
+
+func ParseCPUStat(s string) (stat CPUStat, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	stat = parseCPUStat([]byte(s))
+	return
+}
+
+func readCGroupInfo(pid int) (info CGroupInfo) {
+	cgroups := parseProcCGroup(readProcFile(pid, "cgroup"))
+
+	if unified, ok := cgroups.unified(); ok && fileExists(cgroupPath("", "", "cgroup.controllers")) {
+		dir := cgroupDir("", unified.Path)
+		info.Version = 2
+
+		if s, ok := tryReadFile(filepath.Join(dir, "memory.max")); ok {
+			info.MemoryLimit = parseLimit(s)
+		}
+
+		if s, ok := tryReadFile(filepath.Join(dir, "memory.current")); ok {
+			info.MemoryUsage = parseLimit(s)
+		}
+
+		if s, ok := tryReadFile(filepath.Join(dir, "cpu.max")); ok {
+			info.CPUQuota, info.CPUPeriod = parseCPUMax(s)
+		}
+
+		if s, ok := tryReadFile(filepath.Join(dir, "cpu.stat")); ok {
+			info.CPUStat = parseCPUStat(s)
+		}
+
+		return
+	}
+
+	info.Version = 1
+
+	if memory, ok := cgroups.Lookup("memory"); ok {
+		dir := cgroupDir("memory", memory.Path)
+
+		if s, ok := tryReadFile(filepath.Join(dir, "memory.limit_in_bytes")); ok {
+			info.MemoryLimit = parseLimit(s)
+		}
+
+		if s, ok := tryReadFile(filepath.Join(dir, "memory.usage_in_bytes")); ok {
+			info.MemoryUsage = parseLimit(s)
+		}
+	}
+
+	if cpu, ok := cgroups.Lookup("cpu"); ok {
+		dir := cgroupDir("cpu", cpu.Path)
+
+		if s, ok := tryReadFile(filepath.Join(dir, "cpu.cfs_period_us")); ok {
+			info.CPUPeriod = time.Duration(parseInt(trimSpace(s))) * time.Microsecond
+		}
+
+		if s, ok := tryReadFile(filepath.Join(dir, "cpu.cfs_quota_us")); ok {
+			if quota := parseInt(trimSpace(s)); quota > 0 {
+				info.CPUQuota = time.Duration(quota) * time.Microsecond
+			}
+		}
+
+		if s, ok := tryReadFile(filepath.Join(dir, "cpu.stat")); ok {
+			info.CPUStat = parseCPUStat(s)
+		}
+	}
+
+	return
+}
+
+// parseCPUMax parses the content of cgroup v2 cpu.max files, which have the
+// form "$MAX $PERIOD" where $MAX may be "max" when there is no limit.
+func parseCPUMax(b []byte) (quota time.Duration, period time.Duration) {
+	max, p := split(trimSpace(b), ' ')
+
+	if len(p) != 0 {
+		period = time.Duration(parseInt(p)) * time.Microsecond
+	}
+
+	if string(max) != "max" {
+		quota = time.Duration(parseInt(max)) * time.Microsecond
+	}
+
+	return
+}
+
+// parseCPUStat parses the content of cpu.stat files, the throttled time is
+// expressed in nanoseconds on cgroup v1 and microseconds on cgroup v2.
+func parseCPUStat(b []byte) (stat CPUStat) {
+	forEachLine(b, func(line []byte) {
+		key, val := split(line, ' ')
+
+		switch string(key) {
+		case "nr_periods":
+			stat.Periods = uint64(parseInt(val))
+		case "nr_throttled":
+			stat.ThrottledPeriods = uint64(parseInt(val))
+		case "throttled_time":
+			stat.ThrottledTime = time.Duration(parseInt(val))
+		case "throttled_usec":
+			stat.ThrottledTime = time.Duration(parseInt(val)) * time.Microsecond
+		}
+	})
+	return
+}
+
+// parseLimit parses memory values, returning zero for "max" or values so large
+// that they represent the absence of a limit.
+func parseLimit(b []byte) uint64 {
+	b = trimSpace(b)
+
+	if string(b) == "max" {
+		return 0
+	}
+
+	v := parseUint(b)
+
+	if v >= unlimitedMemoryLimit {
+		v = 0
+	}
+
+	return v
+}
+
+// unified returns the entry of the cgroup v2 hierarchy, which has the id zero
+// and no controller names.
+func (pcg ProcCGroup) unified() (cgroup CGroup, ok bool) {
+	for _, cg := range pcg {
+		if cg.ID == 0 && len(cg.Name) == 0 {
+			return cg, true
+		}
+	}
+	return
+}
+
+// cgroupDir returns the directory of a cgroup. Within containers the cgroup
+// namespace usually mounts the cgroup of the process at the root of the
+// hierarchy, in which case the path listed in /proc/<pid>/cgroup does not
+// exist on the file system.
+func cgroupDir(controller string, path string) string {
+	if dir := cgroupPath(controller, path, ""); fileExists(dir) {
+		return dir
+	}
+	return cgroupPath(controller, "", "")
+}
+
+func tryReadFile(path string) ([]byte, bool) {
+	if !fileExists(path) {
+		return nil, false
+	}
+	return readFile(path), true
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,76 @@
+package linux
+
+import (
+	"errors"
+	"time"
+)
+
+// DiskStats represents the I/O statistics of the block devices of the system,
+// indexed by device name.
+type DiskStats map[string]DiskStat
+
+// DiskStat represents the I/O statistics of a block device, see
+// https://www.kernel.org/doc/Documentation/iostats.txt
+type DiskStat struct {
+	ReadsCompleted  uint64        // number of reads completed
+	ReadsMerged     uint64        // number of adjacent reads merged
+	SectorsRead     uint64        // number of 512 bytes sectors read
+	ReadTime        time.Duration // time spent reading
+	WritesCompleted uint64        // number of writes completed
+	WritesMerged    uint64        // number of adjacent writes merged
+	SectorsWritten  uint64        // number of 512 bytes sectors written
+	WriteTime       time.Duration // time spent writing
+	IOsInProgress   uint64        // number of I/Os currently in progress
+	IOTime          time.Duration // time spent doing I/Os
+	WeightedIOTime  time.Duration // time spent doing I/Os weighted by the number of I/Os in progress
+}
+
+// ReadDiskStats reads the I/O statistics of the block devices from
+// /proc/diskstats.
+func ReadDiskStats() (stats DiskStats, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	stats = parseDiskStats(readFile("/proc/diskstats"))
+	return
+}
+
+func ParseDiskStats(s string) (stats DiskStats, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	stats = parseDiskStats([]byte(s))
+	return
+}
+
+func parseDiskStats(b []byte) DiskStats {
+	stats := DiskStats{}
+
+	forEachLine(b, func(line []byte) {
+		var buf [24][]byte
+		var v [11]uint64
+
+		// major minor name, followed by at least 11 fields (newer kernels add
+		// discard and flush statistics)
+		f := fields(line, buf[:0])
+		if len(f) < 14 {
+			panic(errors.New("malformed line in /proc/diskstats: " + string(line)))
+		}
+
+		for i := range v {
+			v[i] = parseUint(f[3+i])
+		}
+
+		stats[string(f[2])] = DiskStat{
+			ReadsCompleted:  v[0],
+			ReadsMerged:     v[1],
+			SectorsRead:     v[2],
+			ReadTime:        time.Duration(v[3]) * time.Millisecond,
+			WritesCompleted: v[4],
+			WritesMerged:    v[5],
+			SectorsWritten:  v[6],
+			WriteTime:       time.Duration(v[7]) * time.Millisecond,
+			IOsInProgress:   v[8],
+			IOTime:          time.Duration(v[9]) * time.Millisecond,
+			WeightedIOTime:  time.Duration(v[10]) * time.Millisecond,
+		}
+	})
+
+	return stats
+}
@@ -0,0 +1,37 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDiskStats(t *testing.T) {
+	text := `   7       0 loop0 0 0 0 0 0 0 0 0 0 0 0
+ 259       0 nvme0n1 41278 1377 2936846 9531 79620 52383 4217744 61206 0 60596 75634 0 0 0 0 2940 4896
+`
+
+	stats, err := ParseDiskStats(text)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(stats, DiskStats{
+		"loop0": {},
+		"nvme0n1": {
+			ReadsCompleted:  41278,
+			ReadsMerged:     1377,
+			SectorsRead:     2936846,
+			ReadTime:        9531 * time.Millisecond,
+			WritesCompleted: 79620,
+			WritesMerged:    52383,
+			SectorsWritten:  4217744,
+			WriteTime:       61206 * time.Millisecond,
+			IOTime:          60596 * time.Millisecond,
+			WeightedIOTime:  75634 * time.Millisecond,
+		},
+	}) {
+		t.Errorf("%+v", stats)
+	}
+}
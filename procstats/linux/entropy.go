@@ -0,0 +1,17 @@
+package linux
+
+// ReadEntropyAvail reads the number of bits of entropy available in the
+// kernel random pool from /proc/sys/kernel/random/entropy_avail.
+func ReadEntropyAvail() (bits uint64, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	bits = uint64(readIntFile("/proc/sys/kernel/random/entropy_avail"))
+	return
+}
+
+// ReadEntropyPoolSize reads the size of the kernel random pool, in bits, from
+// /proc/sys/kernel/random/poolsize.
+func ReadEntropyPoolSize() (bits uint64, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	bits = uint64(readIntFile("/proc/sys/kernel/random/poolsize"))
+	return
+}
@@ -4,18 +4,16 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"time"
 )
 
-func readFile(path string) string {
+func readFile(path string) []byte {
 	b, err := ioutil.ReadFile(path)
 	check(err)
-	return string(b)
+	return b
 }
 
-func readProcFile(who interface{}, what string) string {
+func readProcFile(who interface{}, what string) []byte {
 	return readFile(procPath(who, what))
 }
 
@@ -24,13 +22,7 @@ func readMicrosecondFile(path string) time.Duration {
 }
 
 func readIntFile(path string) int64 {
-	return parseInt(strings.TrimSpace(readFile(path)))
-}
-
-func parseInt(s string) int64 {
-	i, err := strconv.ParseInt(s, 10, 64)
-	check(err)
-	return i
+	return parseInt(trimSpace(readFile(path)))
 }
 
 func procPath(who interface{}, what string) string {
@@ -11,7 +11,7 @@ func TestReadFile(t *testing.T) {
 	f.Write([]byte("Hello World!\n"))
 	f.Close()
 
-	if s := readFile(path); s != "Hello World!\n" {
+	if s := readFile(path); string(s) != "Hello World!\n" {
 		t.Error("invalid file content:", s)
 	}
 }
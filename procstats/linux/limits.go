@@ -1,7 +1,5 @@
 package linux
 
-import "strconv"
-
 const (
 	Unlimited uint64 = 1<<64 - 1
 )
@@ -40,43 +38,31 @@ func ReadProcLimits(pid int) (proc ProcLimits, err error) {
 
 func ParseProcLimits(s string) (proc ProcLimits, err error) {
 	defer func() { err = convertPanicToError(recover()) }()
-	proc = parseProcLimits(s)
+	proc = parseProcLimits([]byte(s))
 	return
 }
 
-func parseProcLimits(s string) (proc ProcLimits) {
-	index := map[string]*Limits{
-		"Max cpu time":          &proc.CPUTime,
-		"Max file size":         &proc.FileSize,
-		"Max data size":         &proc.DataSize,
-		"Max stack size":        &proc.StackSize,
-		"Max core file size":    &proc.CoreFileSize,
-		"Max resident set":      &proc.ResidentSet,
-		"Max processes":         &proc.Processes,
-		"Max open files":        &proc.OpenFiles,
-		"Max locked memory":     &proc.LockedMemory,
-		"Max address space":     &proc.AddressSpace,
-		"Max file locks":        &proc.FileLocks,
-		"Max pending signals":   &proc.PendingSignals,
-		"Max msgqueue size":     &proc.MsgqueueSize,
-		"Max nice priority":     &proc.NicePriority,
-		"Max realtime priority": &proc.RealtimePriority,
-		"Max realtime timeout":  &proc.RealtimeTimeout,
-	}
-
-	columns := make([]string, 0, 4)
-	forEachLineExceptFirst(s, func(line string) {
+func parseProcLimits(b []byte) (proc ProcLimits) {
+	forEachLineExceptFirst(b, func(line []byte) {
+		var buf [4][]byte
 
-		columns = columns[:0]
-		forEachColumn(line, func(col string) { columns = append(columns, col) })
+		columns := buf[:0]
+		forEachColumn(line, func(col []byte) { columns = append(columns, col) })
 
 		var limits Limits
 		var length = len(columns)
 
-		if length > 0 {
-			limits.Name = columns[0]
+		if length == 0 {
+			return
+		}
+
+		ptr := proc.field(columns[0])
+		if ptr == nil {
+			return
 		}
 
+		limits.Name = string(columns[0])
+
 		if length > 1 {
 			limits.Soft = parseLimitUint(columns[1])
 		}
@@ -86,22 +72,57 @@ func parseProcLimits(s string) (proc ProcLimits) {
 		}
 
 		if length > 3 {
-			limits.Unit = columns[3]
+			limits.Unit = string(columns[3])
 		}
 
-		if ptr := index[limits.Name]; ptr != nil {
-			*ptr = limits
-		}
+		*ptr = limits
 	})
 
 	return
 }
 
-func parseLimitUint(s string) uint64 {
-	if s == "unlimited" {
+func (proc *ProcLimits) field(name []byte) *Limits {
+	switch string(name) {
+	case "Max cpu time":
+		return &proc.CPUTime
+	case "Max file size":
+		return &proc.FileSize
+	case "Max data size":
+		return &proc.DataSize
+	case "Max stack size":
+		return &proc.StackSize
+	case "Max core file size":
+		return &proc.CoreFileSize
+	case "Max resident set":
+		return &proc.ResidentSet
+	case "Max processes":
+		return &proc.Processes
+	case "Max open files":
+		return &proc.OpenFiles
+	case "Max locked memory":
+		return &proc.LockedMemory
+	case "Max address space":
+		return &proc.AddressSpace
+	case "Max file locks":
+		return &proc.FileLocks
+	case "Max pending signals":
+		return &proc.PendingSignals
+	case "Max msgqueue size":
+		return &proc.MsgqueueSize
+	case "Max nice priority":
+		return &proc.NicePriority
+	case "Max realtime priority":
+		return &proc.RealtimePriority
+	case "Max realtime timeout":
+		return &proc.RealtimeTimeout
+	default:
+		return nil
+	}
+}
+
+func parseLimitUint(b []byte) uint64 {
+	if string(b) == "unlimited" {
 		return Unlimited
 	}
-	v, e := strconv.ParseUint(s, 10, 64)
-	check(e)
-	return v
+	return parseUint(b)
 }
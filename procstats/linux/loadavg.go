@@ -0,0 +1,78 @@
+package linux
+
+import (
+	"errors"
+	"time"
+)
+
+// LoadAvg represents the content of /proc/loadavg.
+type LoadAvg struct {
+	Load1    float64 // load average over the last minute
+	Load5    float64 // load average over the last 5 minutes
+	Load15   float64 // load average over the last 15 minutes
+	Runnable uint64  // number of currently runnable tasks
+	Total    uint64  // number of tasks on the system
+	LastPID  int     // pid of the most recently created process
+}
+
+func ReadLoadAvg() (load LoadAvg, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	load = parseLoadAvg(readFile("/proc/loadavg"))
+	return
+}
+
+func ParseLoadAvg(s string) (load LoadAvg, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	load = parseLoadAvg([]byte(s))
+	return
+}
+
+func parseLoadAvg(b []byte) (load LoadAvg) {
+	var buf [5][]byte
+
+	f := fields(b, buf[:0])
+	if len(f) != 5 {
+		panic(errors.New("malformed load average: " + string(b)))
+	}
+
+	runnable, total := split(f[3], '/')
+
+	load.Load1 = parseFloat(f[0])
+	load.Load5 = parseFloat(f[1])
+	load.Load15 = parseFloat(f[2])
+	load.Runnable = parseUint(runnable)
+	load.Total = parseUint(total)
+	load.LastPID = atoi(f[4])
+	return
+}
+
+// Uptime represents the content of /proc/uptime.
+type Uptime struct {
+	Uptime time.Duration // time since the system booted
+	Idle   time.Duration // time spent idle, summed over all cpus
+}
+
+func ReadUptime() (uptime Uptime, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	uptime = parseUptime(readFile("/proc/uptime"))
+	return
+}
+
+func ParseUptime(s string) (uptime Uptime, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	uptime = parseUptime([]byte(s))
+	return
+}
+
+func parseUptime(b []byte) (uptime Uptime) {
+	var buf [2][]byte
+
+	f := fields(b, buf[:0])
+	if len(f) != 2 {
+		panic(errors.New("malformed uptime: " + string(b)))
+	}
+
+	uptime.Uptime = time.Duration(parseFloat(f[0]) * float64(time.Second))
+	uptime.Idle = time.Duration(parseFloat(f[1]) * float64(time.Second))
+	return
+}
@@ -0,0 +1,39 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLoadAvg(t *testing.T) {
+	load, err := ParseLoadAvg("0.52 1.04 2.50 3/415 12345\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(load, LoadAvg{
+		Load1:    0.52,
+		Load5:    1.04,
+		Load15:   2.50,
+		Runnable: 3,
+		Total:    415,
+		LastPID:  12345,
+	}) {
+		t.Error("bad load average:", load)
+	}
+}
+
+func TestParseUptime(t *testing.T) {
+	uptime, err := ParseUptime("350735.47 234388.90\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(uptime, Uptime{
+		Uptime: 350735470 * time.Millisecond,
+		Idle:   234388900 * time.Millisecond,
+	}) {
+		t.Error("bad uptime:", uptime)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build freebsd || openbsd
+
+package linux
+
+func readMemoryLimit(pid int) (limit uint64, err error) {
+	limit = unlimitedMemoryLimit
+	return
+}
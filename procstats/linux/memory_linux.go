@@ -3,7 +3,6 @@ package linux
 import (
 	"io/ioutil"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"syscall"
 )
@@ -25,15 +24,29 @@ func readCGroupMemoryLimit(pid int) (limit uint64) {
 func readProcCGroupMemoryLimit(cgroups ProcCGroup) (limit uint64) {
 	if memory, ok := cgroups.Lookup("memory"); ok {
 		limit = readMemoryCGroupMemoryLimit(memory)
+	} else if unified, ok := cgroups.unified(); ok {
+		limit = readUnifiedCGroupMemoryLimit(unified)
 	}
 	return
 }
 
+func readUnifiedCGroupMemoryLimit(cgroup CGroup) (limit uint64) {
+	limit = unlimitedMemoryLimit // default value if something doesn't work
+
+	if b, err := ioutil.ReadFile(filepath.Join(cgroupDir("", cgroup.Path), "memory.max")); err == nil {
+		if v, err := scanUint(trimSpace(b), 10, 64); err == nil {
+			limit = v
+		}
+	}
+
+	return
+}
+
 func readMemoryCGroupMemoryLimit(cgroup CGroup) (limit uint64) {
 	limit = unlimitedMemoryLimit // default value if something doesn't work
 
 	if b, err := ioutil.ReadFile(readMemoryCGroupMemoryLimitFilePath(cgroup.Path)); err == nil {
-		if v, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64); err == nil {
+		if v, err := scanUint(trimSpace(b), 10, 64); err == nil {
 			limit = v
 		}
 	}
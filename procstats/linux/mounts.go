@@ -0,0 +1,93 @@
+package linux
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// Mount represents an entry of /proc/<pid>/mounts.
+type Mount struct {
+	Device  string
+	Path    string
+	Type    string
+	Options string
+}
+
+// ReadMounts reads the list of file systems mounted in the mount namespace of
+// the process identified by pid.
+func ReadMounts(pid int) (mounts []Mount, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	mounts = parseMounts(readProcFile(pid, "mounts"))
+	return
+}
+
+func ParseMounts(s string) (mounts []Mount, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	mounts = parseMounts([]byte(s))
+	return
+}
+
+// LookupMount returns the mount that path belongs to, which is the one with
+// the longest mount point that contains it. When file systems are mounted
+// over each other, the last one wins.
+func LookupMount(mounts []Mount, path string) (mount Mount, ok bool) {
+	path = filepath.Clean(path)
+
+	for _, m := range mounts {
+		if len(m.Path) < len(mount.Path) {
+			continue
+		}
+		if m.Path == path || m.Path == "/" || strings.HasPrefix(path, m.Path+"/") {
+			mount, ok = m, true
+		}
+	}
+
+	return
+}
+
+func parseMounts(b []byte) []Mount {
+	mounts := make([]Mount, 0, 32)
+
+	forEachLine(b, func(line []byte) {
+		var buf [8][]byte
+
+		f := fields(line, buf[:0])
+		if len(f) < 4 {
+			panic(errors.New("malformed mount entry: " + string(line)))
+		}
+		mounts = append(mounts, Mount{
+			Device:  unescapeMountField(f[0]),
+			Path:    unescapeMountField(f[1]),
+			Type:    unescapeMountField(f[2]),
+			Options: unescapeMountField(f[3]),
+		})
+	})
+
+	return mounts
+}
+
+// unescapeMountField decodes the octal escape sequences that the kernel uses
+// for spaces, tabs, new lines, and backslashes in mount entries (\040, \011,
+// \012, \134).
+func unescapeMountField(b []byte) string {
+	if bytes.IndexByte(b, '\\') < 0 {
+		return string(b)
+	}
+
+	s := make([]byte, 0, len(b))
+
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\\' && i+4 <= len(b) {
+			if c, err := scanUint(b[i+1:i+4], 8, 8); err == nil {
+				s = append(s, byte(c))
+				i += 3
+				continue
+			}
+		}
+		s = append(s, b[i])
+	}
+
+	return string(s)
+}
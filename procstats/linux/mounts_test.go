@@ -0,0 +1,43 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMounts(t *testing.T) {
+	text := `proc /proc proc rw,relatime 0 0
+/dev/sda1 / ext4 rw,relatime 0 0
+/dev/sdb1 /mnt/my\040data xfs rw,noatime 0 0
+tmpfs /mnt/my\040data/tmp tmpfs rw 0 0
+`
+
+	mounts, err := ParseMounts(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(mounts, []Mount{
+		{Device: "proc", Path: "/proc", Type: "proc", Options: "rw,relatime"},
+		{Device: "/dev/sda1", Path: "/", Type: "ext4", Options: "rw,relatime"},
+		{Device: "/dev/sdb1", Path: "/mnt/my data", Type: "xfs", Options: "rw,noatime"},
+		{Device: "tmpfs", Path: "/mnt/my data/tmp", Type: "tmpfs", Options: "rw"},
+	}) {
+		t.Error("bad mounts:", mounts)
+	}
+
+	for path, fstype := range map[string]string{
+		"/":                    "ext4",
+		"/home":                "ext4",
+		"/proc/self":           "proc",
+		"/processes":           "ext4",
+		"/mnt/my data":         "xfs",
+		"/mnt/my data/file":    "xfs",
+		"/mnt/my data/tmp/":    "tmpfs",
+		"/mnt/my data/tmp/abc": "tmpfs",
+	} {
+		if m, ok := LookupMount(mounts, path); !ok || m.Type != fstype {
+			t.Errorf("bad mount for %s: %+v", path, m)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package linux
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// NetDev represents the statistics of the network interfaces visible to a
+// process, indexed by interface name.
+type NetDev map[string]NetDevStats
+
+// NetDevStats represents the statistics of a network interface.
+type NetDevStats struct {
+	Receive  NetDevCounters
+	Transmit NetDevCounters
+}
+
+// NetDevCounters represents the counters of a network interface in one
+// direction.
+type NetDevCounters struct {
+	Bytes   uint64
+	Packets uint64
+	Errors  uint64
+	Drops   uint64
+}
+
+// ReadNetDev reads the statistics of the network interfaces from
+// /proc/<pid>/net/dev, which lists the interfaces of the network namespace
+// that the process belongs to.
+func ReadNetDev(pid int) (dev NetDev, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	dev = parseNetDev(readProcFile(pid, "net/dev"))
+	return
+}
+
+func ParseNetDev(s string) (dev NetDev, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	dev = parseNetDev([]byte(s))
+	return
+}
+
+// ReadNetDevStatistics reads the statistics of the network interfaces from
+// /sys/class/net/*/statistics, which lists the interfaces of the network
+// namespace that sysfs was mounted in.
+func ReadNetDevStatistics() (dev NetDev, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	dev = readNetDevStatistics("/sys/class/net")
+	return
+}
+
+func parseNetDev(b []byte) NetDev {
+	dev := NetDev{}
+
+	b = skipLine(b) // Inter-|   Receive ...
+	b = skipLine(b) //  face |bytes    packets ...
+
+	forEachLine(b, func(line []byte) {
+		var buf [16][]byte
+		var values [12]uint64
+
+		name, line := split(line, ':')
+
+		f := fields(line, buf[:0])
+		if len(f) < len(values) {
+			panic(errors.New("malformed line in /proc/net/dev: " + string(name)))
+		}
+
+		for i := range values {
+			values[i] = parseUint(f[i])
+		}
+
+		// Receive:  bytes packets errs drop fifo frame compressed multicast
+		// Transmit: bytes packets errs drop fifo colls carrier compressed
+		dev[string(name)] = NetDevStats{
+			Receive:  NetDevCounters{Bytes: values[0], Packets: values[1], Errors: values[2], Drops: values[3]},
+			Transmit: NetDevCounters{Bytes: values[8], Packets: values[9], Errors: values[10], Drops: values[11]},
+		}
+	})
+
+	return dev
+}
+
+func readNetDevStatistics(dir string) NetDev {
+	files, err := ioutil.ReadDir(dir)
+	check(err)
+
+	dev := make(NetDev, len(files))
+
+	for _, f := range files {
+		path := filepath.Join(dir, f.Name(), "statistics")
+		read := func(name string) uint64 {
+			return uint64(readIntFile(filepath.Join(path, name)))
+		}
+
+		dev[f.Name()] = NetDevStats{
+			Receive: NetDevCounters{
+				Bytes:   read("rx_bytes"),
+				Packets: read("rx_packets"),
+				Errors:  read("rx_errors"),
+				Drops:   read("rx_dropped"),
+			},
+			Transmit: NetDevCounters{
+				Bytes:   read("tx_bytes"),
+				Packets: read("tx_packets"),
+				Errors:  read("tx_errors"),
+				Drops:   read("tx_dropped"),
+			},
+		}
+	}
+
+	return dev
+}
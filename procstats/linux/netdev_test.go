@@ -0,0 +1,33 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNetDev(t *testing.T) {
+	text := `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 41998038    6663    0    0    0     0          0         0 41998038    6663    0    0    0     0       0          0
+  eth0:   28922     289    1    2    0     0          0         0    26521     301    3    4    0     0       0          0
+`
+
+	dev, err := ParseNetDev(text)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(dev, NetDev{
+		"lo": {
+			Receive:  NetDevCounters{Bytes: 41998038, Packets: 6663},
+			Transmit: NetDevCounters{Bytes: 41998038, Packets: 6663},
+		},
+		"eth0": {
+			Receive:  NetDevCounters{Bytes: 28922, Packets: 289, Errors: 1, Drops: 2},
+			Transmit: NetDevCounters{Bytes: 26521, Packets: 301, Errors: 3, Drops: 4},
+		},
+	}) {
+		t.Errorf("%+v", dev)
+	}
+}
@@ -0,0 +1,178 @@
+package linux
+
+import "errors"
+
+// SocketState represents the state of a socket, the values match the TCP_*
+// constants of the linux kernel.
+type SocketState uint8
+
+const (
+	SocketEstablished SocketState = 0x01
+	SocketSynSent     SocketState = 0x02
+	SocketSynRecv     SocketState = 0x03
+	SocketFinWait1    SocketState = 0x04
+	SocketFinWait2    SocketState = 0x05
+	SocketTimeWait    SocketState = 0x06
+	SocketClose       SocketState = 0x07
+	SocketCloseWait   SocketState = 0x08
+	SocketLastAck     SocketState = 0x09
+	SocketListen      SocketState = 0x0A
+	SocketClosing     SocketState = 0x0B
+	SocketNewSynRecv  SocketState = 0x0C
+)
+
+func (s SocketState) String() string {
+	switch s {
+	case SocketEstablished:
+		return "established"
+	case SocketSynSent:
+		return "syn_sent"
+	case SocketSynRecv:
+		return "syn_recv"
+	case SocketFinWait1:
+		return "fin_wait1"
+	case SocketFinWait2:
+		return "fin_wait2"
+	case SocketTimeWait:
+		return "time_wait"
+	case SocketClose:
+		return "close"
+	case SocketCloseWait:
+		return "close_wait"
+	case SocketLastAck:
+		return "last_ack"
+	case SocketListen:
+		return "listen"
+	case SocketClosing:
+		return "closing"
+	case SocketNewSynRecv:
+		return "new_syn_recv"
+	default:
+		return "unknown"
+	}
+}
+
+// Socket represents an entry of /proc/net/{tcp,tcp6,udp,udp6}.
+type Socket struct {
+	LocalPort  uint16
+	RemotePort uint16
+	State      SocketState
+	TxQueue    uint64 // bytes in the send queue
+	RxQueue    uint64 // bytes in the receive queue (or pending connections of listening sockets)
+	Inode      uint64
+}
+
+// ReadNetSockets reads the sockets of the network namespace that the process
+// identified by pid belongs to, proto is one of "tcp", "tcp6", "udp", or
+// "udp6".
+func ReadNetSockets(pid int, proto string) (sockets []Socket, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	sockets = parseNetSockets(readProcFile(pid, "net/"+proto))
+	return
+}
+
+func ParseNetSockets(s string) (sockets []Socket, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	sockets = parseNetSockets([]byte(s))
+	return
+}
+
+func parseNetSockets(b []byte) []Socket {
+	sockets := make([]Socket, 0, 64)
+
+	b = skipLine(b) // sl  local_address rem_address   st tx_queue rx_queue ...
+
+	forEachLine(b, func(line []byte) {
+		var buf [24][]byte
+
+		f := fields(line, buf[:0])
+		if len(f) < 10 {
+			panic(errors.New("malformed socket entry: " + string(line)))
+		}
+
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		_, localPort := split(f[1], ':')
+		_, remotePort := split(f[2], ':')
+		txQueue, rxQueue := split(f[4], ':')
+
+		sockets = append(sockets, Socket{
+			LocalPort:  uint16(parseHex(localPort, 16)),
+			RemotePort: uint16(parseHex(remotePort, 16)),
+			State:      SocketState(parseHex(f[3], 8)),
+			TxQueue:    parseHex(txQueue, 64),
+			RxQueue:    parseHex(rxQueue, 64),
+			Inode:      parseUint(f[9]),
+		})
+	})
+
+	return sockets
+}
+
+// NetStat represents the counters of /proc/net/netstat and /proc/net/snmp,
+// indexed by "<group>.<name>" (for example "TcpExt.ListenOverflows").
+type NetStat map[string]int64
+
+// ReadNetStat reads the network counters of the network namespace that the
+// process identified by pid belongs to.
+func ReadNetStat(pid int) (stat NetStat, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	stat = NetStat{}
+	parseNetStat(stat, readProcFile(pid, "net/netstat"))
+	parseNetStat(stat, readProcFile(pid, "net/snmp"))
+	return
+}
+
+func ParseNetStat(s string) (stat NetStat, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	stat = NetStat{}
+	parseNetStat(stat, []byte(s))
+	return
+}
+
+func parseNetStat(stat NetStat, b []byte) {
+	// Counters come in pairs of lines, the first one has the names and the
+	// second one the values:
+	//
+	//	TcpExt: SyncookiesSent SyncookiesRecv ...
+	//	TcpExt: 0 0 ...
+	var group []byte
+	var names, values [][]byte
+	var header bool // the previous line had the names of the counters
+
+	forEachLine(b, func(line []byte) {
+		prefix, line := split(line, ':')
+
+		if !header || string(prefix) != string(group) {
+			group, names, header = prefix, fields(line, names[:0]), true
+			return
+		}
+
+		if values = fields(line, values[:0]); len(values) != len(names) {
+			panic(errors.New("malformed network counters: " + string(group)))
+		}
+
+		for i, name := range names {
+			stat[string(group)+"."+string(name)] = parseInt(values[i])
+		}
+
+		header = false
+	})
+}
+
+// ReadLocalPortRange reads the range of local ports that the kernel picks
+// ephemeral ports from.
+func ReadLocalPortRange() (min uint16, max uint16, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	min, max = parseLocalPortRange(readFile("/proc/sys/net/ipv4/ip_local_port_range"))
+	return
+}
+
+func parseLocalPortRange(b []byte) (min uint16, max uint16) {
+	var buf [2][]byte
+
+	f := fields(b, buf[:0])
+	if len(f) != 2 {
+		panic(errors.New("malformed local port range: " + string(b)))
+	}
+	return uint16(parseUint(f[0])), uint16(parseUint(f[1]))
+}
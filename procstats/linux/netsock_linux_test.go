@@ -0,0 +1,22 @@
+package linux
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadNetSockets(t *testing.T) {
+	if _, err := ReadNetSockets(os.Getpid(), "tcp"); err != nil {
+		t.Error("ReadNetSockets:", err)
+	}
+}
+
+func TestReadNetStat(t *testing.T) {
+	stat, err := ReadNetStat(os.Getpid())
+	if err != nil {
+		t.Fatal("ReadNetStat:", err)
+	}
+	if _, ok := stat["TcpExt.ListenOverflows"]; !ok {
+		t.Error("ReadNetStat: missing TcpExt.ListenOverflows counter")
+	}
+}
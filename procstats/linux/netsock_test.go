@@ -0,0 +1,54 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNetSockets(t *testing.T) {
+	text := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:07E8 00000000:0000 0A 00000000:00000002 00:00000000 00000000     0        0 662 1 0000000085202020 100 0 0 10 0
+   1: 0100007F:BC8F 0100007F:E07A 01 00000010:00000000 00:00000000 00000000 65534        0 3524 2 000000004efb71a9 20 4 26 26 -1
+   2: 00000000000000000000000001000000:1F90 00000000000000000000000001000000:C350 06 00000000:00000000 03:00000ED0 00000000     0        0 0 3 0000000000000000
+`
+
+	sockets, err := ParseNetSockets(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(sockets, []Socket{
+		{LocalPort: 2024, RemotePort: 0, State: SocketListen, RxQueue: 2, Inode: 662},
+		{LocalPort: 48271, RemotePort: 57466, State: SocketEstablished, TxQueue: 16, Inode: 3524},
+		{LocalPort: 8080, RemotePort: 50000, State: SocketTimeWait},
+	}) {
+		t.Error("bad sockets:", sockets)
+	}
+
+	if s := sockets[0].State.String(); s != "listen" {
+		t.Error("bad socket state:", s)
+	}
+}
+
+func TestParseNetStat(t *testing.T) {
+	text := `TcpExt: SyncookiesSent ListenOverflows ListenDrops
+TcpExt: 0 12 13
+IpExt: InNoRoutes InOctets
+IpExt: 1 1024
+`
+
+	stat, err := ParseNetStat(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(stat, NetStat{
+		"TcpExt.SyncookiesSent":  0,
+		"TcpExt.ListenOverflows": 12,
+		"TcpExt.ListenDrops":     13,
+		"IpExt.InNoRoutes":       1,
+		"IpExt.InOctets":         1024,
+	}) {
+		t.Error("bad network counters:", stat)
+	}
+}
@@ -1,36 +1,40 @@
 package linux
 
 import (
+	"bytes"
+	"errors"
 	"strconv"
-	"strings"
-	"unicode"
 )
 
-func forEachToken(text, split string, call func(string)) {
-	for len(text) != 0 {
-		var line string
+// The parsing functions of this package work on the raw content of the files
+// they read and iterate over it by index, so sampling /proc does not allocate
+// more than the buffer that the files are loaded into. Values that need to
+// outlive this buffer (names, paths, ...) must be copied by the callers with
+// an explicit string conversion.
 
-		if i := strings.Index(text, split); i >= 0 {
-			line, text = text[:i], text[i+len(split):]
-		} else {
-			line, text = text, ""
+func forEachToken(text []byte, sep byte, call func([]byte)) {
+	for len(text) != 0 {
+		i := bytes.IndexByte(text, sep)
+		if i < 0 {
+			call(text)
+			return
 		}
-
-		call(line)
+		call(text[:i])
+		text = text[i+1:]
 	}
 }
 
-func forEachLine(text string, call func(string)) {
-	forEachToken(text, "\n", func(line string) {
-		if line = strings.TrimSpace(line); line != "" {
+func forEachLine(text []byte, call func([]byte)) {
+	forEachToken(text, '\n', func(line []byte) {
+		if line = trimSpace(line); len(line) != 0 {
 			call(line)
 		}
 	})
 }
 
-func forEachLineExceptFirst(text string, call func(string)) {
+func forEachLineExceptFirst(text []byte, call func([]byte)) {
 	first := true
-	forEachLine(text, func(line string) {
+	forEachLine(text, func(line []byte) {
 		if first {
 			first = false
 		} else {
@@ -39,57 +43,265 @@ func forEachLineExceptFirst(text string, call func(string)) {
 	})
 }
 
-func forEachColumn(line string, call func(string)) {
+func forEachColumn(line []byte, call func([]byte)) {
 	for line = skipSpaces(line); len(line) != 0; line = skipSpaces(line) {
-		var column string
-
-		if i := strings.Index(line, "  "); i >= 0 {
-			column, line = line[:i], line[i+2:]
-		} else {
-			column, line = line, ""
+		i := indexColumnSeparator(line)
+		if i < 0 {
+			call(line)
+			return
 		}
+		call(line[:i])
+		line = line[i+2:]
+	}
+}
 
-		call(column)
+// indexColumnSeparator returns the index of the first two consecutive spaces
+// in line, which is how columns are separated in files like /proc/<pid>/limits
+// where values may contain single spaces.
+func indexColumnSeparator(line []byte) int {
+	for i := 0; i < len(line)-1; i++ {
+		if line[i] == ' ' && line[i+1] == ' ' {
+			return i
+		}
 	}
+	return -1
 }
 
-func forEachProperty(text string, call func(string, string)) {
-	forEachLine(text, func(line string) { call(splitProperty(line)) })
+func forEachProperty(text []byte, call func([]byte, value)) {
+	forEachLine(text, func(line []byte) { call(splitProperty(line)) })
 }
 
-func splitProperty(text string) (key string, val string) {
+func splitProperty(text []byte) (key []byte, val value) {
 	return split(text, ':')
 }
 
-func split(text string, sep byte) (head string, tail string) {
-	if i := strings.IndexByte(text, sep); i >= 0 {
+// value is the value of a property, which may be a number followed by a unit
+// ("1024 kB"), a quoted string, or a list of values separated by white spaces.
+type value []byte
+
+// count returns the value as an unsigned integer without unit.
+func (v value) count() uint64 {
+	return parseUint(v)
+}
+
+// bytes returns the value converted to bytes according to its unit. Values
+// without a unit are returned as is.
+func (v value) bytes() uint64 {
+	num, unit := v.unit()
+	return parseUint(num) * unitScale(unit)
+}
+
+// unit splits the number and the unit of the value, unit is empty if the value
+// has none.
+func (v value) unit() (num []byte, unit []byte) {
+	for i := len(v) - 1; i >= 0; i-- {
+		if isSpace(v[i]) {
+			return trimSpace(v[:i]), v[i+1:]
+		}
+	}
+	return v, nil
+}
+
+// text returns the value as a string, removing the surrounding double quotes
+// and escape sequences of quoted values.
+func (v value) text() string {
+	if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+		return string(v)
+	}
+	s, err := strconv.Unquote(string(v))
+	check(err)
+	return s
+}
+
+// values splits the value around white spaces and appends the results to dst,
+// like fields.
+func (v value) values(dst [][]byte) [][]byte {
+	return fields(v, dst)
+}
+
+// unitScale returns the number of bytes of unit. The kernel uses multiples of
+// 1024 even when the units are spelled like powers of 10 (kB in /proc/meminfo
+// for example).
+func unitScale(unit []byte) uint64 {
+	switch string(unit) {
+	case "", "B":
+		return 1
+	case "k", "K", "kB", "KB", "KiB":
+		return 1 << 10
+	case "m", "M", "mB", "MB", "MiB":
+		return 1 << 20
+	case "g", "G", "gB", "GB", "GiB":
+		return 1 << 30
+	case "t", "T", "tB", "TB", "TiB":
+		return 1 << 40
+	default:
+		panic(errors.New("unknown unit: " + string(unit)))
+	}
+}
+
+func split(text []byte, sep byte) (head []byte, tail []byte) {
+	if i := bytes.IndexByte(text, sep); i >= 0 {
 		head, tail = text[:i], text[i+1:]
 	} else {
 		head = text
 	}
-	head = strings.TrimSpace(head)
-	tail = strings.TrimSpace(tail)
+	head = trimSpace(head)
+	tail = trimSpace(tail)
 	return
 }
 
-func skipSpaces(text string) string {
+// fields splits text around runs of white spaces and appends the results to
+// dst, which lets callers use an array allocated on the stack.
+func fields(text []byte, dst [][]byte) [][]byte {
+	for text = skipSpaces(text); len(text) != 0; text = skipSpaces(text) {
+		i := 0
+		for i < len(text) && !isSpace(text[i]) {
+			i++
+		}
+		dst, text = append(dst, text[:i]), text[i:]
+	}
+	return dst
+}
+
+func trimSpace(text []byte) []byte {
+	text = skipSpaces(text)
+	for len(text) != 0 && isSpace(text[len(text)-1]) {
+		text = text[:len(text)-1]
+	}
+	return text
+}
+
+func trimPrefix(text []byte, prefix string) ([]byte, bool) {
+	if len(text) >= len(prefix) && string(text[:len(prefix)]) == prefix {
+		return text[len(prefix):], true
+	}
+	return text, false
+}
+
+func skipSpaces(text []byte) []byte {
 	for i, c := range text {
-		if !unicode.IsSpace(c) {
+		if !isSpace(c) {
 			return text[i:]
 		}
 	}
-	return ""
+	return nil
 }
 
-func skipLine(text string) string {
-	if i := strings.IndexByte(text, '\n'); i >= 0 {
+func skipLine(text []byte) []byte {
+	if i := bytes.IndexByte(text, '\n'); i >= 0 {
 		return text[i+1:]
 	}
-	return ""
+	return nil
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\v', '\f', '\r':
+		return true
+	default:
+		return false
+	}
+}
+
+func atoi(b []byte) int {
+	return int(parseInt(b))
+}
+
+func parseInt(b []byte) int64 {
+	v, err := scanInt(b, 64)
+	check(err)
+	return v
 }
 
-func atoi(s string) int {
-	v, e := strconv.Atoi(s)
-	check(e)
+func parseUint(b []byte) uint64 {
+	v, err := scanUint(b, 10, 64)
+	check(err)
 	return v
 }
+
+func parseHex(b []byte, bitSize int) uint64 {
+	v, err := scanUint(b, 16, bitSize)
+	check(err)
+	return v
+}
+
+func parseFloat(b []byte) float64 {
+	// The conversion does not allocate because strconv.ParseFloat does not
+	// retain its argument, the compiler uses a temporary buffer instead.
+	f, err := strconv.ParseFloat(string(b), 64)
+	check(err)
+	return f
+}
+
+// scanInt is the equivalent of strconv.ParseInt(string(b), 10, bitSize)
+// without the conversion of b to a string. Errors are only allocated when b
+// is not a valid number.
+func scanInt(b []byte, bitSize int) (int64, error) {
+	s, neg := b, false
+
+	if len(s) != 0 && (s[0] == '-' || s[0] == '+') {
+		s, neg = s[1:], s[0] == '-'
+	}
+
+	u, err := scanUint(s, 10, 64)
+	if err != nil {
+		return 0, numError("ParseInt", b, err.(*strconv.NumError).Err)
+	}
+
+	cutoff := uint64(1) << uint(bitSize-1)
+
+	if neg {
+		if u > cutoff {
+			return 0, numError("ParseInt", b, strconv.ErrRange)
+		}
+		return -int64(u), nil
+	}
+
+	if u >= cutoff {
+		return 0, numError("ParseInt", b, strconv.ErrRange)
+	}
+	return int64(u), nil
+}
+
+// scanUint is the equivalent of strconv.ParseUint(string(b), base, bitSize)
+// without the conversion of b to a string. Errors are only allocated when b
+// is not a valid number.
+func scanUint(b []byte, base int, bitSize int) (uint64, error) {
+	if len(b) == 0 {
+		return 0, numError("ParseUint", b, strconv.ErrSyntax)
+	}
+
+	max := uint64(1)<<uint(bitSize) - 1
+	val := uint64(0)
+
+	for _, c := range b {
+		var d uint64
+
+		switch {
+		case c >= '0' && c <= '9':
+			d = uint64(c - '0')
+		case c >= 'a' && c <= 'z':
+			d = uint64(c-'a') + 10
+		case c >= 'A' && c <= 'Z':
+			d = uint64(c-'A') + 10
+		default:
+			return 0, numError("ParseUint", b, strconv.ErrSyntax)
+		}
+
+		if d >= uint64(base) {
+			return 0, numError("ParseUint", b, strconv.ErrSyntax)
+		}
+
+		if val > (max-d)/uint64(base) {
+			return 0, numError("ParseUint", b, strconv.ErrRange)
+		}
+
+		val = val*uint64(base) + d
+	}
+
+	return val, nil
+}
+
+func numError(fn string, b []byte, err error) error {
+	return &strconv.NumError{Func: fn, Num: string(b), Err: err}
+}
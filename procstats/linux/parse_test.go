@@ -1,7 +1,9 @@
 package linux
 
 import (
+	"math"
 	"reflect"
+	"strconv"
 	"testing"
 )
 
@@ -22,7 +24,7 @@ func TestForEachLine(t *testing.T) {
 
 	for _, test := range tests {
 		lines := []string{}
-		forEachLine(test.text, func(line string) { lines = append(lines, line) })
+		forEachLine([]byte(test.text), func(line []byte) { lines = append(lines, string(line)) })
 
 		if !reflect.DeepEqual(lines, test.lines) {
 			t.Error(lines)
@@ -51,7 +53,7 @@ func TestForEachColumn(t *testing.T) {
 
 	for _, test := range tests {
 		columns := []string{}
-		forEachColumn(test.text, func(column string) { columns = append(columns, column) })
+		forEachColumn([]byte(test.text), func(column []byte) { columns = append(columns, string(column)) })
 
 		if !reflect.DeepEqual(columns, test.columns) {
 			t.Error(columns)
@@ -81,7 +83,7 @@ func TestForEachProperty(t *testing.T) {
 
 	for _, test := range tests {
 		kv := []KV{}
-		forEachProperty(test.text, func(k string, v string) { kv = append(kv, KV{k, v}) })
+		forEachProperty([]byte(test.text), func(k []byte, v value) { kv = append(kv, KV{string(k), string(v)}) })
 
 		if !reflect.DeepEqual(kv, test.kv) {
 			t.Error(kv)
@@ -89,6 +91,86 @@ func TestForEachProperty(t *testing.T) {
 	}
 }
 
+func TestValue(t *testing.T) {
+	tests := []struct {
+		val    string
+		count  uint64
+		bytes  uint64
+		text   string
+		values []string
+	}{
+		{
+			val:    "42",
+			count:  42,
+			bytes:  42,
+			text:   "42",
+			values: []string{"42"},
+		},
+		{
+			val:    "748 kB",
+			bytes:  748 * 1024,
+			text:   "748 kB",
+			values: []string{"748", "kB"},
+		},
+		{
+			val:    "2 MB",
+			bytes:  2 * 1024 * 1024,
+			text:   "2 MB",
+			values: []string{"2", "MB"},
+		},
+		{
+			val:    `"Hello \"World\"!"`,
+			text:   `Hello "World"!`,
+			values: []string{`"Hello`, `\"World\"!"`},
+		},
+		{
+			val:    "1000\t1000\t1000\t1000",
+			text:   "1000\t1000\t1000\t1000",
+			values: []string{"1000", "1000", "1000", "1000"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.val, func(t *testing.T) {
+			v := value(test.val)
+
+			if test.count != 0 {
+				if n := v.count(); n != test.count {
+					t.Error("bad count:", n)
+				}
+			}
+
+			if test.bytes != 0 {
+				if n := v.bytes(); n != test.bytes {
+					t.Error("bad bytes:", n)
+				}
+			}
+
+			if s := v.text(); s != test.text {
+				t.Error("bad text:", s)
+			}
+
+			values := []string{}
+			for _, x := range v.values(nil) {
+				values = append(values, string(x))
+			}
+
+			if !reflect.DeepEqual(values, test.values) {
+				t.Error("bad values:", values)
+			}
+		})
+	}
+}
+
+func TestValueUnknownUnit(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("should have raised a panic")
+		}
+	}()
+	value("1 parsec").bytes()
+}
+
 func TestSkipLine(t *testing.T) {
 	tests := []struct {
 		s1 string
@@ -109,8 +191,159 @@ func TestSkipLine(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		if s := skipLine(test.s1); s != test.s2 {
+		if s := string(skipLine([]byte(test.s1))); s != test.s2 {
 			t.Errorf("skipLine(%#v) => %#v != %#v", test.s1, test.s2, s)
 		}
 	}
 }
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		text   string
+		tokens []string
+	}{
+		{
+			text:   "",
+			tokens: []string{},
+		},
+		{
+			text:   "  1 \t2\n3  ",
+			tokens: []string{"1", "2", "3"},
+		},
+	}
+
+	for _, test := range tests {
+		tokens := []string{}
+		for _, f := range fields([]byte(test.text), nil) {
+			tokens = append(tokens, string(f))
+		}
+
+		if !reflect.DeepEqual(tokens, test.tokens) {
+			t.Error(tokens)
+		}
+	}
+}
+
+func TestScanInt(t *testing.T) {
+	tests := []struct {
+		text string
+		val  int64
+		err  error
+	}{
+		{text: "0", val: 0},
+		{text: "42", val: 42},
+		{text: "-42", val: -42},
+		{text: "+42", val: 42},
+		{text: "9223372036854775807", val: math.MaxInt64},
+		{text: "-9223372036854775808", val: math.MinInt64},
+		{text: "9223372036854775808", err: strconv.ErrRange},
+		{text: "", err: strconv.ErrSyntax},
+		{text: "-", err: strconv.ErrSyntax},
+		{text: "4a", err: strconv.ErrSyntax},
+	}
+
+	for _, test := range tests {
+		val, err := scanInt([]byte(test.text), 64)
+
+		if test.err != nil {
+			if e, ok := err.(*strconv.NumError); !ok || e.Err != test.err || e.Num != test.text {
+				t.Errorf("scanInt(%q): bad error: %v", test.text, err)
+			}
+		} else if err != nil {
+			t.Errorf("scanInt(%q): %v", test.text, err)
+		} else if val != test.val {
+			t.Errorf("scanInt(%q): %d != %d", test.text, val, test.val)
+		}
+	}
+}
+
+func TestScanUint(t *testing.T) {
+	tests := []struct {
+		text    string
+		base    int
+		bitSize int
+		val     uint64
+		err     error
+	}{
+		{text: "0", base: 10, bitSize: 64, val: 0},
+		{text: "18446744073709551615", base: 10, bitSize: 64, val: math.MaxUint64},
+		{text: "18446744073709551616", base: 10, bitSize: 64, err: strconv.ErrRange},
+		{text: "0050", base: 16, bitSize: 16, val: 80},
+		{text: "FFFF", base: 16, bitSize: 16, val: 65535},
+		{text: "10000", base: 16, bitSize: 16, err: strconv.ErrRange},
+		{text: "040", base: 8, bitSize: 8, val: 32},
+		{text: "8", base: 8, bitSize: 8, err: strconv.ErrSyntax},
+		{text: "-1", base: 10, bitSize: 64, err: strconv.ErrSyntax},
+		{text: "", base: 10, bitSize: 64, err: strconv.ErrSyntax},
+	}
+
+	for _, test := range tests {
+		val, err := scanUint([]byte(test.text), test.base, test.bitSize)
+
+		if test.err != nil {
+			if e, ok := err.(*strconv.NumError); !ok || e.Err != test.err || e.Num != test.text {
+				t.Errorf("scanUint(%q): bad error: %v", test.text, err)
+			}
+		} else if err != nil {
+			t.Errorf("scanUint(%q): %v", test.text, err)
+		} else if val != test.val {
+			t.Errorf("scanUint(%q): %d != %d", test.text, val, test.val)
+		}
+	}
+}
+
+func TestParseAllocs(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		parse  func([]byte)
+		allocs float64
+	}{
+		{
+			name:   "stat",
+			text:   procStatText,
+			parse:  func(b []byte) { parseProcStat(b) },
+			allocs: 1, // comm
+		},
+		{
+			name:  "statm",
+			text:  "1134 172 153 12 0 115 0",
+			parse: func(b []byte) { parseProcStatm(b) },
+		},
+		{
+			name:  "status",
+			text:  "Name:\tcat\nVmRSS:\t     748 kB\nvoluntary_ctxt_switches:\t3\n",
+			parse: func(b []byte) { parseProcStatus(b) },
+		},
+		{
+			name:  "io",
+			text:  "rchar: 2012\nwchar: 0\nsyscr: 7\nsyscw: 0\nread_bytes: 0\nwrite_bytes: 0\ncancelled_write_bytes: 0\n",
+			parse: func(b []byte) { parseProcIO(b) },
+		},
+		{
+			name:  "loadavg",
+			text:  "0.24 0.31 0.35 2/1031 12345\n",
+			parse: func(b []byte) { parseLoadAvg(b) },
+		},
+		{
+			name:  "pressure",
+			text:  "some avg10=0.00 avg60=0.12 avg300=0.05 total=1234\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=56\n",
+			parse: func(b []byte) { parsePressure(b) },
+		},
+		{
+			name:  "cpu.stat",
+			text:  "nr_periods 10\nnr_throttled 2\nthrottled_usec 3000\n",
+			parse: func(b []byte) { parseCPUStat(b) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := []byte(test.text)
+
+			if n := testing.AllocsPerRun(100, func() { test.parse(b) }); n > test.allocs {
+				t.Errorf("too many allocations: %g > %g", n, test.allocs)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package linux
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+)
+
+// Pressure represents the Pressure Stall Information (PSI) of a resource, see
+// https://www.kernel.org/doc/Documentation/accounting/psi.txt
+type Pressure struct {
+	Some PressureStall // some tasks were stalled on the resource
+	Full PressureStall // all non-idle tasks were stalled on the resource
+}
+
+// PressureStall represents the share of time that tasks were stalled waiting on
+// a resource.
+type PressureStall struct {
+	Avg10  float64       // percentage of stalled time over the last 10 seconds
+	Avg60  float64       // percentage of stalled time over the last 60 seconds
+	Avg300 float64       // percentage of stalled time over the last 300 seconds
+	Total  time.Duration // total stalled time
+}
+
+// ReadPressure reads the system-wide pressure stall information of resource,
+// which is one of "cpu", "memory", or "io".
+func ReadPressure(resource string) (pressure Pressure, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	pressure = parsePressure(readFile(filepath.Join("/proc/pressure", resource)))
+	return
+}
+
+// ReadCGroupPressure reads the pressure stall information of resource for the
+// cgroup v2 that the process identified by pid belongs to.
+func ReadCGroupPressure(pid int, resource string) (pressure Pressure, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	pressure = parsePressure(readFile(filepath.Join(cgroupDir("", readUnifiedCGroup(pid).Path), resource+".pressure")))
+	return
+}
+
+func ParsePressure(s string) (pressure Pressure, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	pressure = parsePressure([]byte(s))
+	return
+}
+
+func parsePressure(b []byte) (pressure Pressure) {
+	forEachLine(b, func(line []byte) {
+		var stall *PressureStall
+		kind, line := split(line, ' ')
+
+		switch string(kind) {
+		case "some":
+			stall = &pressure.Some
+		case "full":
+			stall = &pressure.Full
+		default:
+			return
+		}
+
+		forEachToken(line, ' ', func(token []byte) {
+			key, val := split(token, '=')
+
+			switch string(key) {
+			case "avg10":
+				stall.Avg10 = parseFloat(val)
+			case "avg60":
+				stall.Avg60 = parseFloat(val)
+			case "avg300":
+				stall.Avg300 = parseFloat(val)
+			case "total":
+				stall.Total = time.Duration(parseInt(val)) * time.Microsecond
+			}
+		})
+	})
+	return
+}
+
+func readUnifiedCGroup(pid int) CGroup {
+	cgroup, ok := parseProcCGroup(readProcFile(pid, "cgroup")).unified()
+	if !ok || !fileExists(cgroupPath("", "", "cgroup.controllers")) {
+		panic(errors.New("the process does not belong to a cgroup v2 hierarchy"))
+	}
+	return cgroup
+}
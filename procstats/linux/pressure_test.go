@@ -0,0 +1,25 @@
+package linux
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePressure(t *testing.T) {
+	text := `some avg10=6.75 avg60=3.19 avg300=3.54 total=72084292
+full avg10=0.50 avg60=0.00 avg300=0.00 total=1500
+`
+
+	pressure, err := ParsePressure(text)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pressure != (Pressure{
+		Some: PressureStall{Avg10: 6.75, Avg60: 3.19, Avg300: 3.54, Total: 72084292 * time.Microsecond},
+		Full: PressureStall{Avg10: 0.50, Total: 1500 * time.Microsecond},
+	}) {
+		t.Errorf("%+v", pressure)
+	}
+}
@@ -0,0 +1,55 @@
+package linux
+
+// ProcIO represents the I/O statistics of a process, read from
+// /proc/<pid>/io.
+type ProcIO struct {
+	RChar               uint64 // rchar: bytes read by read syscalls
+	WChar               uint64 // wchar: bytes written by write syscalls
+	SyscR               uint64 // syscr: number of read syscalls
+	SyscW               uint64 // syscw: number of write syscalls
+	ReadBytes           uint64 // read_bytes: bytes fetched from the storage layer
+	WriteBytes          uint64 // write_bytes: bytes sent to the storage layer
+	CancelledWriteBytes uint64 // cancelled_write_bytes: bytes which were not written because of truncation
+}
+
+func ReadProcIO(pid int) (proc ProcIO, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	proc = parseProcIO(readProcFile(pid, "io"))
+	return
+}
+
+func ParseProcIO(s string) (proc ProcIO, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	proc = parseProcIO([]byte(s))
+	return
+}
+
+func parseProcIO(b []byte) (proc ProcIO) {
+	forEachProperty(b, func(key []byte, val value) {
+		if field := proc.field(key); field != nil {
+			*field = val.count()
+		}
+	})
+	return
+}
+
+func (proc *ProcIO) field(key []byte) *uint64 {
+	switch string(key) {
+	case "rchar":
+		return &proc.RChar
+	case "wchar":
+		return &proc.WChar
+	case "syscr":
+		return &proc.SyscR
+	case "syscw":
+		return &proc.SyscW
+	case "read_bytes":
+		return &proc.ReadBytes
+	case "write_bytes":
+		return &proc.WriteBytes
+	case "cancelled_write_bytes":
+		return &proc.CancelledWriteBytes
+	default:
+		return nil
+	}
+}
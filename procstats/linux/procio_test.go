@@ -0,0 +1,31 @@
+package linux
+
+import "testing"
+
+func TestParseProcIO(t *testing.T) {
+	text := `rchar: 3980
+wchar: 120
+syscr: 9
+syscw: 2
+read_bytes: 4096
+write_bytes: 8192
+cancelled_write_bytes: 0
+`
+
+	proc, err := ParseProcIO(text)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if proc != (ProcIO{
+		RChar:      3980,
+		WChar:      120,
+		SyscR:      9,
+		SyscW:      2,
+		ReadBytes:  4096,
+		WriteBytes: 8192,
+	}) {
+		t.Errorf("%+v", proc)
+	}
+}
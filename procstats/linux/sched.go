@@ -1,7 +1,5 @@
 package linux
 
-import "strconv"
-
 type ProcSched struct {
 	NRSwitches            uint64 // nr_switches
 	NRVoluntarySwitches   uint64 // nr_voluntary_switches
@@ -20,35 +18,43 @@ func ReadProcSched(pid int) (proc ProcSched, err error) {
 
 func ParseProcSched(s string) (proc ProcSched, err error) {
 	defer func() { err = convertPanicToError(recover()) }()
-	proc = parseProcSched(s)
+	proc = parseProcSched([]byte(s))
 	return
 }
 
-func parseProcSched(s string) (proc ProcSched) {
-	intFields := map[string]*uint64{
-		"nr_switches":             &proc.NRSwitches,
-		"nr_voluntary_switches":   &proc.NRVoluntarySwitches,
-		"nr_involuntary_switches": &proc.NRInvoluntarySwitches,
-		"se.avg.load_sum":         &proc.SEAvgLoadSum,
-		"se.avg.util_sum":         &proc.SEAvgUtilSum,
-		"se.avg.load_avg":         &proc.SEAvgLoadAvg,
-		"se.avg.util_avg":         &proc.SEAvgUtilAvg,
-	}
-
-	s = skipLine(s) // <progname> (<pid>, #threads: 1)
-	s = skipLine(s) // -------------------------------
-
-	forEachProperty(s, func(key string, val string) {
-		if field := intFields[key]; field != nil {
-			v, e := strconv.ParseUint(val, 10, 64)
-			check(e)
+func parseProcSched(b []byte) (proc ProcSched) {
+	b = skipLine(b) // <progname> (<pid>, #threads: 1)
+	b = skipLine(b) // -------------------------------
 
+	forEachProperty(b, func(key []byte, val value) {
+		if field := proc.field(key); field != nil {
 			// There's no much doc on the structure of this file, unsure if
 			// there would be a breakdown per thread... unlikely but this should
 			// cover for it.
-			*field += v
+			*field += val.count()
 		}
 	})
 
 	return
 }
+
+func (proc *ProcSched) field(key []byte) *uint64 {
+	switch string(key) {
+	case "nr_switches":
+		return &proc.NRSwitches
+	case "nr_voluntary_switches":
+		return &proc.NRVoluntarySwitches
+	case "nr_involuntary_switches":
+		return &proc.NRInvoluntarySwitches
+	case "se.avg.load_sum":
+		return &proc.SEAvgLoadSum
+	case "se.avg.util_sum":
+		return &proc.SEAvgUtilSum
+	case "se.avg.load_avg":
+		return &proc.SEAvgLoadAvg
+	case "se.avg.util_avg":
+		return &proc.SEAvgUtilAvg
+	default:
+		return nil
+	}
+}
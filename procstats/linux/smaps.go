@@ -0,0 +1,85 @@
+package linux
+
+// ProcSmaps represents the memory usage of a process, summed over all its
+// mappings, as reported by /proc/<pid>/smaps_rollup or /proc/<pid>/smaps. All
+// values are in bytes.
+type ProcSmaps struct {
+	Rss           uint64 // resident set size
+	Pss           uint64 // proportional set size (shared pages divided by the number of processes sharing them)
+	SharedClean   uint64
+	SharedDirty   uint64
+	PrivateClean  uint64
+	PrivateDirty  uint64
+	Anonymous     uint64
+	AnonHugePages uint64 // anonymous memory backed by transparent huge pages
+	Swap          uint64
+	SwapPss       uint64 // proportional swap usage
+}
+
+// Uss returns the unique set size of the process, which is the amount of
+// memory that would be freed if the process exited.
+func (s ProcSmaps) Uss() uint64 {
+	return s.PrivateClean + s.PrivateDirty
+}
+
+// ReadProcSmapsRollup reads /proc/<pid>/smaps_rollup, which is only available
+// on linux 4.14 and above.
+func ReadProcSmapsRollup(pid int) (proc ProcSmaps, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	proc = parseProcSmaps(readProcFile(pid, "smaps_rollup"))
+	return
+}
+
+// ReadProcSmaps reads /proc/<pid>/smaps and sums the memory usage of all the
+// mappings of the process. Reading this file is expensive for processes that
+// have a large number of mappings.
+func ReadProcSmaps(pid int) (proc ProcSmaps, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	proc = parseProcSmaps(readProcFile(pid, "smaps"))
+	return
+}
+
+func ParseProcSmaps(s string) (proc ProcSmaps, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	proc = parseProcSmaps([]byte(s))
+	return
+}
+
+func parseProcSmaps(b []byte) (proc ProcSmaps) {
+	// Lines that are not properties (the headers of the mappings) or that
+	// are not listed below are ignored.
+	forEachProperty(b, func(key []byte, val value) {
+		if field := proc.field(key); field != nil {
+			*field += val.bytes()
+		}
+	})
+
+	return
+}
+
+func (proc *ProcSmaps) field(key []byte) *uint64 {
+	switch string(key) {
+	case "Rss":
+		return &proc.Rss
+	case "Pss":
+		return &proc.Pss
+	case "Shared_Clean":
+		return &proc.SharedClean
+	case "Shared_Dirty":
+		return &proc.SharedDirty
+	case "Private_Clean":
+		return &proc.PrivateClean
+	case "Private_Dirty":
+		return &proc.PrivateDirty
+	case "Anonymous":
+		return &proc.Anonymous
+	case "AnonHugePages":
+		return &proc.AnonHugePages
+	case "Swap":
+		return &proc.Swap
+	case "SwapPss":
+		return &proc.SwapPss
+	default:
+		return nil
+	}
+}
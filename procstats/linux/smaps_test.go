@@ -0,0 +1,58 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProcSmaps(t *testing.T) {
+	text := `558e61df6000-558e61e00000 r-xp 00000000 08:01 1234                       /usr/bin/cat
+Size:                 40 kB
+Rss:                  32 kB
+Pss:                  16 kB
+Shared_Clean:         32 kB
+Shared_Dirty:          0 kB
+Private_Clean:         0 kB
+Private_Dirty:         0 kB
+Anonymous:             0 kB
+AnonHugePages:         0 kB
+Swap:                  0 kB
+SwapPss:               0 kB
+VmFlags: rd ex mr mw me dw
+7ffedb720000-7ffedb740000 rw-p 00000000 00:00 0                          [stack]
+Size:                132 kB
+Rss:                  12 kB
+Pss:                  12 kB
+Shared_Clean:          0 kB
+Shared_Dirty:          0 kB
+Private_Clean:         4 kB
+Private_Dirty:         8 kB
+Anonymous:            12 kB
+AnonHugePages:         0 kB
+Swap:                  4 kB
+SwapPss:               4 kB
+VmFlags: rd wr mr mw me gd ac
+`
+
+	proc, err := ParseProcSmaps(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(proc, ProcSmaps{
+		Rss:          44 * 1024,
+		Pss:          28 * 1024,
+		SharedClean:  32 * 1024,
+		PrivateClean: 4 * 1024,
+		PrivateDirty: 8 * 1024,
+		Anonymous:    12 * 1024,
+		Swap:         4 * 1024,
+		SwapPss:      4 * 1024,
+	}) {
+		t.Error("bad smaps:", proc)
+	}
+
+	if uss := proc.Uss(); uss != 12*1024 {
+		t.Error("bad unique set size:", uss)
+	}
+}
@@ -1,6 +1,10 @@
 package linux
 
-import "fmt"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
 
 type ProcState rune
 
@@ -86,63 +90,84 @@ type ProcStat struct {
 
 func ReadProcStat(pid int) (proc ProcStat, err error) {
 	defer func() { err = convertPanicToError(recover()) }()
-	return ParseProcStat(readProcFile(pid, "stat"))
+	proc = parseProcStat(readProcFile(pid, "stat"))
+	return
 }
 
 func ParseProcStat(s string) (proc ProcStat, err error) {
-	_, err = fmt.Sscan(s,
-		&proc.Pid,
-		&proc.Comm,
-		&proc.State,
-		&proc.Ppid,
-		&proc.Pgrp,
-		&proc.Session,
-		&proc.TTY,
-		&proc.Tpgid,
-		&proc.Flags,
-		&proc.Minflt,
-		&proc.Cminflt,
-		&proc.Majflt,
-		&proc.Cmajflt,
-		&proc.Utime,
-		&proc.Stime,
-		&proc.Cutime,
-		&proc.Cstime,
-		&proc.Priority,
-		&proc.Nice,
-		&proc.NumThreads,
-		&proc.Itrealvalue,
-		&proc.Starttime,
-		&proc.Vsize,
-		&proc.Rss,
-		&proc.Rsslim,
-		&proc.Startcode,
-		&proc.Endcode,
-		&proc.Startstack,
-		&proc.Kstkeep,
-		&proc.Kstkeip,
-		&proc.Signal,
-		&proc.Blocked,
-		&proc.Sigignore,
-		&proc.Sigcatch,
-		&proc.Wchan,
-		&proc.Nswap,
-		&proc.Cnswap,
-		&proc.ExitSignal,
-		&proc.Processor,
-		&proc.RTPriority,
-		&proc.Policy,
-		&proc.DelayacctBlkioTicks,
-		&proc.GuestTime,
-		&proc.CguestTime,
-		&proc.StartData,
-		&proc.EndData,
-		&proc.StartBrk,
-		&proc.ArgStart,
-		&proc.ArgEnd,
-		&proc.EnvStart,
-		&proc.EnvEnd,
-		&proc.ExitCode,
-	)
+	defer func() { err = convertPanicToError(recover()) }()
+	proc = parseProcStat([]byte(s))
+	return
+}
+
+func parseProcStat(b []byte) (proc ProcStat) {
+	// The process name is wrapped in parenthesis and may contain spaces or
+	// parenthesis itself, the last closing parenthesis marks its end.
+	i := bytes.IndexByte(b, '(')
+	j := bytes.LastIndexByte(b, ')')
+	if i < 0 || j < i {
+		panic(errors.New("malformed process stat: " + string(b)))
+	}
+
+	var buf [64][]byte
+
+	// Fields after the name start at (3) state.
+	f := fields(b[j+1:], buf[:0])
+	if len(f) < 50 || len(f[0]) != 1 {
+		panic(errors.New("malformed process stat: " + string(b)))
+	}
+
+	proc.Pid = int32(parseInt(trimSpace(b[:i])))
+	proc.Comm = string(b[i : j+1])
+	proc.State = ProcState(f[0][0])
+	proc.Ppid = int32(parseInt(f[1]))
+	proc.Pgrp = int32(parseInt(f[2]))
+	proc.Session = int32(parseInt(f[3]))
+	proc.TTY = int32(parseInt(f[4]))
+	proc.Tpgid = int32(parseInt(f[5]))
+	proc.Flags = uint32(parseUint(f[6]))
+	proc.Minflt = parseUint(f[7])
+	proc.Cminflt = parseUint(f[8])
+	proc.Majflt = parseUint(f[9])
+	proc.Cmajflt = parseUint(f[10])
+	proc.Utime = parseUint(f[11])
+	proc.Stime = parseUint(f[12])
+	proc.Cutime = parseInt(f[13])
+	proc.Cstime = parseInt(f[14])
+	proc.Priority = parseInt(f[15])
+	proc.Nice = parseInt(f[16])
+	proc.NumThreads = parseInt(f[17])
+	proc.Itrealvalue = parseInt(f[18])
+	proc.Starttime = parseUint(f[19])
+	proc.Vsize = parseUint(f[20])
+	proc.Rss = parseUint(f[21])
+	proc.Rsslim = parseUint(f[22])
+	proc.Startcode = uintptr(parseUint(f[23]))
+	proc.Endcode = uintptr(parseUint(f[24]))
+	proc.Startstack = uintptr(parseUint(f[25]))
+	proc.Kstkeep = parseUint(f[26])
+	proc.Kstkeip = parseUint(f[27])
+	proc.Signal = parseUint(f[28])
+	proc.Blocked = parseUint(f[29])
+	proc.Sigignore = parseUint(f[30])
+	proc.Sigcatch = parseUint(f[31])
+	proc.Wchan = uintptr(parseUint(f[32]))
+	proc.Nswap = parseUint(f[33])
+	proc.Cnswap = parseUint(f[34])
+	proc.ExitSignal = int32(parseInt(f[35]))
+	proc.Processor = int32(parseInt(f[36]))
+	proc.RTPriority = uint32(parseUint(f[37]))
+	proc.Policy = uint32(parseUint(f[38]))
+	proc.DelayacctBlkioTicks = parseUint(f[39])
+	proc.GuestTime = parseUint(f[40])
+	proc.CguestTime = parseInt(f[41])
+	proc.StartData = uintptr(parseUint(f[42]))
+	proc.EndData = uintptr(parseUint(f[43]))
+	proc.StartBrk = uintptr(parseUint(f[44]))
+	proc.ArgStart = uintptr(parseUint(f[45]))
+	proc.ArgEnd = uintptr(parseUint(f[46]))
+	proc.EnvStart = uintptr(parseUint(f[47]))
+	proc.EnvEnd = uintptr(parseUint(f[48]))
+	proc.ExitCode = int32(parseInt(f[49]))
 	return
 }
@@ -2,13 +2,14 @@ package linux
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
-func TestParseProcStat(t *testing.T) {
-	text := `69 (cat) R 56 1 1 0 -1 4210944 83 0 0 0 0 0 0 0 20 0 1 0 1977676 4644864 193 18446744073709551615 4194304 4240332 140724300789216 140724300788568 140342654634416 0 0 0 0 0 0 0 17 0 0 0 0 0 0 6340112 6341364 24690688 140724300791495 140724300791515 140724300791515 140724300791791 0`
+const procStatText = `69 (cat) R 56 1 1 0 -1 4210944 83 0 0 0 0 0 0 0 20 0 1 0 1977676 4644864 193 18446744073709551615 4194304 4240332 140724300789216 140724300788568 140342654634416 0 0 0 0 0 0 0 17 0 0 0 0 0 0 6340112 6341364 24690688 140724300791495 140724300791515 140724300791515 140724300791791 0`
 
-	proc, err := ParseProcStat(text)
+func TestParseProcStat(t *testing.T) {
+	proc, err := ParseProcStat(procStatText)
 
 	if err != nil {
 		t.Error(err)
@@ -72,3 +73,24 @@ func TestParseProcStat(t *testing.T) {
 		t.Error(proc)
 	}
 }
+
+func TestParseProcStatCommWithSpaces(t *testing.T) {
+	proc, err := ParseProcStat(strings.Replace(procStatText, "(cat)", "(my (cat) 2)", 1))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if proc.Comm != "(my (cat) 2)" || proc.State != Running || proc.ExitCode != 0 || proc.EnvEnd != 140724300791791 {
+		t.Error(proc)
+	}
+}
+
+func BenchmarkParseProcStat(b *testing.B) {
+	text := []byte(procStatText)
+
+	for i := 0; i < b.N; i++ {
+		parseProcStat(text)
+	}
+}
@@ -1,6 +1,6 @@
 package linux
 
-import "fmt"
+import "errors"
 
 type ProcStatm struct {
 	Size     uint64 // (1) size
@@ -14,18 +14,30 @@ type ProcStatm struct {
 
 func ReadProcStatm(pid int) (proc ProcStatm, err error) {
 	defer func() { err = convertPanicToError(recover()) }()
-	return ParseProcStatm(readProcFile(pid, "statm"))
+	proc = parseProcStatm(readProcFile(pid, "statm"))
+	return
 }
 
 func ParseProcStatm(s string) (proc ProcStatm, err error) {
-	_, err = fmt.Sscan(s,
-		&proc.Size,
-		&proc.Resident,
-		&proc.Share,
-		&proc.Text,
-		&proc.Lib,
-		&proc.Data,
-		&proc.Dt,
-	)
+	defer func() { err = convertPanicToError(recover()) }()
+	proc = parseProcStatm([]byte(s))
+	return
+}
+
+func parseProcStatm(b []byte) (proc ProcStatm) {
+	var buf [8][]byte
+
+	f := fields(b, buf[:0])
+	if len(f) < 7 {
+		panic(errors.New("malformed process statm: " + string(b)))
+	}
+
+	proc.Size = parseUint(f[0])
+	proc.Resident = parseUint(f[1])
+	proc.Share = parseUint(f[2])
+	proc.Text = parseUint(f[3])
+	proc.Lib = parseUint(f[4])
+	proc.Data = parseUint(f[5])
+	proc.Dt = parseUint(f[6])
 	return
 }
@@ -0,0 +1,99 @@
+package linux
+
+// ProcStatus represents the memory usage and context switches reported by
+// /proc/<pid>/status. Memory sizes are in bytes.
+type ProcStatus struct {
+	VmPeak       uint64 // peak virtual memory size
+	VmSize       uint64 // virtual memory size
+	VmLck        uint64 // locked memory size
+	VmPin        uint64 // pinned memory size
+	VmHWM        uint64 // peak resident set size
+	VmRSS        uint64 // resident set size
+	RssAnon      uint64 // resident anonymous memory
+	RssFile      uint64 // resident file mappings
+	RssShmem     uint64 // resident shared memory
+	VmData       uint64 // size of the data segment
+	VmStk        uint64 // size of the stack segment
+	VmExe        uint64 // size of the text segment
+	VmLib        uint64 // shared library code size
+	VmPTE        uint64 // page table entries size
+	VmSwap       uint64 // swapped-out anonymous memory
+	HugetlbPages uint64 // size of the hugetlb memory
+
+	VoluntaryCtxtSwitches    uint64 // context switches caused by the process blocking
+	NonvoluntaryCtxtSwitches uint64 // context switches caused by the process being preempted
+}
+
+func ReadProcStatus(pid int) (proc ProcStatus, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	proc = parseProcStatus(readProcFile(pid, "status"))
+	return
+}
+
+func ParseProcStatus(s string) (proc ProcStatus, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	proc = parseProcStatus([]byte(s))
+	return
+}
+
+func parseProcStatus(b []byte) (proc ProcStatus) {
+	forEachProperty(b, func(key []byte, val value) {
+		if field := proc.byteField(key); field != nil {
+			*field = val.bytes()
+		} else if field := proc.intField(key); field != nil {
+			*field = val.count()
+		}
+	})
+
+	return
+}
+
+func (proc *ProcStatus) byteField(key []byte) *uint64 {
+	switch string(key) {
+	case "VmPeak":
+		return &proc.VmPeak
+	case "VmSize":
+		return &proc.VmSize
+	case "VmLck":
+		return &proc.VmLck
+	case "VmPin":
+		return &proc.VmPin
+	case "VmHWM":
+		return &proc.VmHWM
+	case "VmRSS":
+		return &proc.VmRSS
+	case "RssAnon":
+		return &proc.RssAnon
+	case "RssFile":
+		return &proc.RssFile
+	case "RssShmem":
+		return &proc.RssShmem
+	case "VmData":
+		return &proc.VmData
+	case "VmStk":
+		return &proc.VmStk
+	case "VmExe":
+		return &proc.VmExe
+	case "VmLib":
+		return &proc.VmLib
+	case "VmPTE":
+		return &proc.VmPTE
+	case "VmSwap":
+		return &proc.VmSwap
+	case "HugetlbPages":
+		return &proc.HugetlbPages
+	default:
+		return nil
+	}
+}
+
+func (proc *ProcStatus) intField(key []byte) *uint64 {
+	switch string(key) {
+	case "voluntary_ctxt_switches":
+		return &proc.VoluntaryCtxtSwitches
+	case "nonvoluntary_ctxt_switches":
+		return &proc.NonvoluntaryCtxtSwitches
+	default:
+		return nil
+	}
+}
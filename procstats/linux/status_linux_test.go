@@ -0,0 +1,14 @@
+package linux
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadProcStatus(t *testing.T) {
+	if status, err := ReadProcStatus(os.Getpid()); err != nil {
+		t.Error("ReadProcStatus:", err)
+	} else if status.VmRSS == 0 {
+		t.Error("ReadProcStatus: no resident memory was reported")
+	}
+}
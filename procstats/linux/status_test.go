@@ -0,0 +1,38 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProcStatus(t *testing.T) {
+	text := "Name:\tcat\n" +
+		"State:\tR (running)\n" +
+		"VmPeak:\t    3348 kB\n" +
+		"VmSize:\t    3340 kB\n" +
+		"VmHWM:\t    1768 kB\n" +
+		"VmRSS:\t    1768 kB\n" +
+		"VmSwap:\t     512 kB\n" +
+		"HugetlbPages:\t       0 kB\n" +
+		"Threads:\t1\n" +
+		"voluntary_ctxt_switches:\t15\n" +
+		"nonvoluntary_ctxt_switches:\t3\n"
+
+	proc, err := ParseProcStatus(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(proc, ProcStatus{
+		VmPeak: 3348 * 1024,
+		VmSize: 3340 * 1024,
+		VmHWM:  1768 * 1024,
+		VmRSS:  1768 * 1024,
+		VmSwap: 512 * 1024,
+
+		VoluntaryCtxtSwitches:    15,
+		NonvoluntaryCtxtSwitches: 3,
+	}) {
+		t.Error("bad status:", proc)
+	}
+}
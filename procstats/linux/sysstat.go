@@ -0,0 +1,69 @@
+package linux
+
+import (
+	"bytes"
+	"time"
+)
+
+// SystemStat represents the system-wide counters of /proc/stat.
+type SystemStat struct {
+	Interrupts      uint64    // intr: interrupts serviced since boot
+	SoftIRQs        uint64    // softirq: softirqs serviced since boot
+	ContextSwitches uint64    // ctxt: context switches since boot
+	Processes       uint64    // processes: forks since boot
+	ProcsRunning    uint64    // procs_running: processes in runnable state
+	ProcsBlocked    uint64    // procs_blocked: processes blocked waiting for I/O
+	BootTime        time.Time // btime: time at which the system booted
+}
+
+func ReadSystemStat() (stat SystemStat, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	stat = parseSystemStat(readFile("/proc/stat"))
+	return
+}
+
+func ParseSystemStat(s string) (stat SystemStat, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	stat = parseSystemStat([]byte(s))
+	return
+}
+
+func parseSystemStat(b []byte) (stat SystemStat) {
+	forEachLine(b, func(line []byte) {
+		key, val := split(line, ' ')
+
+		// The intr and softirq lines start with the total, followed by the
+		// per-source counts.
+		if i := bytes.IndexByte(val, ' '); i >= 0 {
+			val = val[:i]
+		}
+
+		switch field := stat.field(key); {
+		case field != nil:
+			*field = parseUint(val)
+		case string(key) == "btime":
+			stat.BootTime = time.Unix(parseInt(val), 0)
+		}
+	})
+
+	return
+}
+
+func (stat *SystemStat) field(key []byte) *uint64 {
+	switch string(key) {
+	case "intr":
+		return &stat.Interrupts
+	case "softirq":
+		return &stat.SoftIRQs
+	case "ctxt":
+		return &stat.ContextSwitches
+	case "processes":
+		return &stat.Processes
+	case "procs_running":
+		return &stat.ProcsRunning
+	case "procs_blocked":
+		return &stat.ProcsBlocked
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,37 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSystemStat(t *testing.T) {
+	text := `cpu  10132153 290696 3084719 46828483 16683 0 25195 0 0 0
+cpu0 1393280 32966 572056 13343292 6130 0 17875 0 0 0
+intr 868488 0 0 0 0 1 1 2 0 667 44
+ctxt 2501892
+btime 1792136011
+processes 22081
+procs_running 2
+procs_blocked 1
+softirq 167625 0 71303 3 6022 0 0 5 0 0 90292
+`
+
+	stat, err := ParseSystemStat(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(stat, SystemStat{
+		Interrupts:      868488,
+		SoftIRQs:        167625,
+		ContextSwitches: 2501892,
+		Processes:       22081,
+		ProcsRunning:    2,
+		ProcsBlocked:    1,
+		BootTime:        time.Unix(1792136011, 0),
+	}) {
+		t.Error("bad system stat:", stat)
+	}
+}
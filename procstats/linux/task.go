@@ -0,0 +1,91 @@
+package linux
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ProcTaskStat represents the CPU usage of a thread of a process, read from
+// /proc/<pid>/task/<tid>/stat.
+type ProcTaskStat struct {
+	Tid   int    // thread id
+	Comm  string // thread name
+	Utime uint64 // user cpu time, in clock ticks
+	Stime uint64 // system cpu time, in clock ticks
+}
+
+// ReadProcTaskStats reads the CPU usage of each thread of the process
+// identified by pid. Threads that exit while the function runs are omitted
+// from the result.
+func ReadProcTaskStats(pid int) (tasks []ProcTaskStat, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	tasks = readProcTaskStats(pid)
+	return
+}
+
+func ParseProcTaskStat(s string) (task ProcTaskStat, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	task = parseProcTaskStat([]byte(s))
+	return
+}
+
+func readProcTaskStats(pid int) []ProcTaskStat {
+	f, err := os.Open(procPath(pid, "task"))
+	check(err)
+	defer f.Close()
+
+	tids, err := f.Readdirnames(-1)
+	check(err)
+
+	tasks := make([]ProcTaskStat, 0, len(tids))
+
+	for _, tid := range tids {
+		b, err := ioutil.ReadFile(procPath(pid, filepath.Join("task", tid, "stat")))
+		if err != nil {
+			if os.IsNotExist(err) || isErrno(err, syscall.ESRCH) {
+				continue // the thread exited
+			}
+			check(err)
+		}
+		tasks = append(tasks, parseProcTaskStat(b))
+	}
+
+	return tasks
+}
+
+func isErrno(err error, errno syscall.Errno) bool {
+	if e, ok := err.(*os.PathError); ok {
+		err = e.Err
+	}
+	return err == errno
+}
+
+func parseProcTaskStat(b []byte) (task ProcTaskStat) {
+	// The thread name is wrapped in parenthesis and may contain spaces or
+	// parenthesis itself, the last closing parenthesis marks its end.
+	i := bytes.IndexByte(b, '(')
+	j := bytes.LastIndexByte(b, ')')
+	if i < 0 || j < i {
+		panic(errors.New("malformed task stat: " + string(b)))
+	}
+
+	task.Tid = atoi(trimSpace(b[:i]))
+	task.Comm = string(b[i+1 : j])
+
+	var buf [64][]byte
+
+	// Fields after the name start at (3) state, utime and stime are (14) and
+	// (15).
+	f := fields(b[j+1:], buf[:0])
+	if len(f) < 13 {
+		panic(errors.New("malformed task stat: " + string(b)))
+	}
+
+	task.Utime = parseUint(f[11])
+	task.Stime = parseUint(f[12])
+	return
+}
@@ -0,0 +1,21 @@
+package linux
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadProcTaskStats(t *testing.T) {
+	tasks, err := ReadProcTaskStats(os.Getpid())
+	if err != nil {
+		t.Fatal("ReadProcTaskStats:", err)
+	}
+
+	for _, task := range tasks {
+		if task.Tid == os.Getpid() {
+			return
+		}
+	}
+
+	t.Error("ReadProcTaskStats: the main thread was not found:", tasks)
+}
@@ -0,0 +1,30 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProcTaskStat(t *testing.T) {
+	text := `42 (my (worker) 1) S 1 1 1 0 -1 4194560 105 0 0 0 17 5 0 0 20 0 3 0 2213 12345 567 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 -1 3 0 0 0 0 0 0 0 0 0 0 0 0 0`
+
+	task, err := ParseProcTaskStat(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(task, ProcTaskStat{
+		Tid:   42,
+		Comm:  "my (worker) 1",
+		Utime: 17,
+		Stime: 5,
+	}) {
+		t.Error("bad task stat:", task)
+	}
+}
+
+func TestParseProcTaskStatMalformed(t *testing.T) {
+	if _, err := ParseProcTaskStat("42 worker S"); err == nil {
+		t.Error("no error was returned when parsing a malformed task stat")
+	}
+}
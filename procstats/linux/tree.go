@@ -0,0 +1,61 @@
+package linux
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// ReadProcParents reads the parent pid of each process visible in /proc,
+// indexed by pid. Processes that exit while the function runs are omitted
+// from the result.
+func ReadProcParents() (parents map[int]int, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	parents = readProcParents()
+	return
+}
+
+func readProcParents() map[int]int {
+	f, err := os.Open("/proc")
+	check(err)
+	defer f.Close()
+
+	names, err := f.Readdirnames(-1)
+	check(err)
+
+	parents := make(map[int]int, len(names))
+
+	for _, name := range names {
+		pid, err := strconv.Atoi(name)
+		if err != nil {
+			continue // not a process directory
+		}
+
+		b, err := ioutil.ReadFile(procPath(pid, "stat"))
+		if err != nil {
+			if os.IsNotExist(err) || isErrno(err, syscall.ESRCH) {
+				continue // the process exited
+			}
+			check(err)
+		}
+
+		parents[pid] = parseProcParent(b)
+	}
+
+	return parents
+}
+
+func parseProcParent(b []byte) int {
+	var buf [64][]byte
+
+	// The parent pid is the second field after the process name, which is
+	// wrapped in parenthesis and may contain spaces.
+	f := fields(b[bytes.LastIndexByte(b, ')')+1:], buf[:0])
+	if len(f) < 2 {
+		panic(errors.New("malformed process stat: " + string(b)))
+	}
+	return atoi(f[1])
+}
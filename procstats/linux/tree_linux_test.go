@@ -0,0 +1,16 @@
+package linux
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadProcParents(t *testing.T) {
+	parents, err := ReadProcParents()
+	if err != nil {
+		t.Fatal("ReadProcParents:", err)
+	}
+	if ppid, ok := parents[os.Getpid()]; !ok || ppid != os.Getppid() {
+		t.Errorf("ReadProcParents: bad parent pid of the current process: %d", ppid)
+	}
+}
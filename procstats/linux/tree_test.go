@@ -0,0 +1,9 @@
+package linux
+
+import "testing"
+
+func TestParseProcParent(t *testing.T) {
+	if ppid := parseProcParent([]byte("42 (my (worker) 1) S 7 42 42 0 -1 4194560")); ppid != 7 {
+		t.Error("bad parent pid:", ppid)
+	}
+}
@@ -0,0 +1,76 @@
+package linux
+
+import "strings"
+
+// VMStat represents the virtual memory counters of /proc/vmstat, indexed by
+// name. The set of counters varies between kernel versions.
+type VMStat map[string]uint64
+
+func ReadVMStat() (stat VMStat, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	stat = parseVMStat(readFile("/proc/vmstat"))
+	return
+}
+
+func ParseVMStat(s string) (stat VMStat, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	stat = parseVMStat([]byte(s))
+	return
+}
+
+func parseVMStat(b []byte) VMStat {
+	stat := VMStat{}
+
+	forEachLine(b, func(line []byte) {
+		key, val := split(line, ' ')
+		stat[string(key)] = parseUint(val)
+	})
+
+	return stat
+}
+
+// Sum returns the sum of the counters which name starts with prefix, which is
+// useful to aggregate counters that are broken down by zone on some kernel
+// versions (pgscan_kswapd_normal, pgscan_kswapd_dma32, ...).
+func (stat VMStat) Sum(prefix string, exclude ...string) (sum uint64) {
+search:
+	for key, val := range stat {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		for _, x := range exclude {
+			if key == x {
+				continue search
+			}
+		}
+		sum += val
+	}
+	return
+}
+
+// MemInfo represents the content of /proc/meminfo, indexed by name. Values
+// expressed in kB are converted to bytes, others (like HugePages_Total) are
+// kept as is.
+type MemInfo map[string]uint64
+
+func ReadMemInfo() (info MemInfo, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	info = parseMemInfo(readFile("/proc/meminfo"))
+	return
+}
+
+func ParseMemInfo(s string) (info MemInfo, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	info = parseMemInfo([]byte(s))
+	return
+}
+
+func parseMemInfo(b []byte) MemInfo {
+	info := MemInfo{}
+
+	forEachProperty(b, func(key []byte, val value) {
+		info[string(key)] = val.bytes()
+	})
+
+	return info
+}
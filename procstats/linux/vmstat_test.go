@@ -0,0 +1,64 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVMStat(t *testing.T) {
+	text := `nr_dirty 3252
+pgfault 16847826
+pgmajfault 438
+pgscan_kswapd_dma32 10
+pgscan_kswapd_normal 32
+pgscan_direct 5
+pgscan_direct_throttle 1
+`
+
+	stat, err := ParseVMStat(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(stat, VMStat{
+		"nr_dirty":               3252,
+		"pgfault":                16847826,
+		"pgmajfault":             438,
+		"pgscan_kswapd_dma32":    10,
+		"pgscan_kswapd_normal":   32,
+		"pgscan_direct":          5,
+		"pgscan_direct_throttle": 1,
+	}) {
+		t.Error("bad vmstat:", stat)
+	}
+
+	if sum := stat.Sum("pgscan_kswapd"); sum != 42 {
+		t.Error("bad pgscan_kswapd sum:", sum)
+	}
+
+	if sum := stat.Sum("pgscan_direct", "pgscan_direct_throttle"); sum != 5 {
+		t.Error("bad pgscan_direct sum:", sum)
+	}
+}
+
+func TestParseMemInfo(t *testing.T) {
+	text := `MemTotal:       16318412 kB
+MemAvailable:   12210284 kB
+HugePages_Total:       4
+Hugepagesize:       2048 kB
+`
+
+	info, err := ParseMemInfo(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(info, MemInfo{
+		"MemTotal":        16318412 * 1024,
+		"MemAvailable":    12210284 * 1024,
+		"HugePages_Total": 4,
+		"Hugepagesize":    2048 * 1024,
+	}) {
+		t.Error("bad meminfo:", info)
+	}
+}
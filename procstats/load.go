@@ -0,0 +1,59 @@
+package procstats
+
+import (
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+)
+
+// LoadMetrics is a metric collector that reports the load average, the number
+// of tasks, and the uptime of the host.
+type LoadMetrics struct {
+	engine *stats.Engine
+
+	load struct {
+		avg1     float64 `metric:"avg1"           type:"gauge"`
+		avg5     float64 `metric:"avg5"           type:"gauge"`
+		avg15    float64 `metric:"avg15"          type:"gauge"`
+		runnable uint64  `metric:"runnable.count" type:"gauge"` // tasks currently runnable
+		tasks    uint64  `metric:"tasks.count"    type:"gauge"` // tasks on the system
+	} `metric:"load"`
+
+	host struct {
+		uptime time.Duration `metric:"uptime.seconds" type:"gauge"`
+	} `metric:"host"`
+}
+
+// NewLoadMetrics collects the load average and uptime of the host and reports
+// them to the default stats engine.
+func NewLoadMetrics() *LoadMetrics {
+	return NewLoadMetricsWith(stats.DefaultEngine)
+}
+
+// NewLoadMetricsWith collects the load average and uptime of the host and
+// reports them to eng.
+func NewLoadMetricsWith(eng *stats.Engine) *LoadMetrics {
+	return &LoadMetrics{engine: eng}
+}
+
+// Collect satisfies the Collector interface.
+func (l *LoadMetrics) Collect() {
+	load, err := linux.ReadLoadAvg()
+	if err != nil {
+		return
+	}
+
+	uptime, err := linux.ReadUptime()
+	if err != nil {
+		return
+	}
+
+	l.load.avg1 = load.Load1
+	l.load.avg5 = load.Load5
+	l.load.avg15 = load.Load15
+	l.load.runnable = load.Runnable
+	l.load.tasks = load.Total
+	l.host.uptime = uptime.Uptime
+	l.engine.Report(l)
+}
@@ -0,0 +1,35 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestLoadMetrics(t *testing.T) {
+	if _, err := linux.ReadLoadAvg(); err != nil {
+		t.Skip("load average is not available:", err)
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	NewLoadMetricsWith(e).Collect()
+
+	measures := h.Measures()
+	if len(measures) != 2 {
+		t.Fatalf("bad number of measures: %v", measures)
+	}
+
+	if m := measures[0]; m.Name != "load" || len(m.Fields) != 5 {
+		t.Errorf("bad load measure: %v", m)
+	}
+
+	if m := measures[1]; m.Name != "host" || m.Fields[0].Value.Duration() <= 0 {
+		t.Errorf("bad host measure: %v", m)
+	}
+}
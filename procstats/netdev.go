@@ -0,0 +1,92 @@
+package procstats
+
+import (
+	"os"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+)
+
+// NetDevMetrics is a metric collector that reports statistics of the network
+// interfaces visible to a process, tagged by interface name and direction.
+//
+// Interfaces are read from /proc/<pid>/net/dev, falling back to
+// /sys/class/net/*/statistics if the file is not available.
+type NetDevMetrics struct {
+	engine     *stats.Engine
+	pid        int
+	interfaces map[string]*netInterface
+}
+
+type netInterface struct {
+	receive  netCounters `metric:"net"`
+	transmit netCounters `metric:"net"`
+	name     string      `tag:"interface"`
+	last     linux.NetDevStats
+}
+
+type netCounters struct {
+	bytes     uint64 `metric:"bytes"   type:"counter"`
+	packets   uint64 `metric:"packets" type:"counter"`
+	errors    uint64 `metric:"errors"  type:"counter"`
+	drops     uint64 `metric:"drops"   type:"counter"`
+	direction string `tag:"direction"` // rx | tx
+}
+
+// NewNetDevMetrics collects statistics of the network interfaces visible to
+// the current process and reports them to the default stats engine.
+func NewNetDevMetrics() *NetDevMetrics {
+	return NewNetDevMetricsWith(stats.DefaultEngine, os.Getpid())
+}
+
+// NewNetDevMetricsWith collects statistics of the network interfaces visible
+// to the process identified by pid and reports them to eng.
+func NewNetDevMetricsWith(eng *stats.Engine, pid int) *NetDevMetrics {
+	return &NetDevMetrics{
+		engine:     eng,
+		pid:        pid,
+		interfaces: make(map[string]*netInterface),
+	}
+}
+
+// Collect satisfies the Collector interface.
+func (n *NetDevMetrics) Collect() {
+	dev, err := linux.ReadNetDev(n.pid)
+	if err != nil {
+		if dev, err = linux.ReadNetDevStatistics(); err != nil {
+			return
+		}
+	}
+
+	for name, ifstats := range dev {
+		iface := n.interfaces[name]
+
+		if iface == nil {
+			// The first collection only initializes the baseline of the
+			// counters of new interfaces.
+			iface = &netInterface{name: name, last: ifstats}
+			iface.receive.direction = "rx"
+			iface.transmit.direction = "tx"
+			n.interfaces[name] = iface
+			continue
+		}
+
+		iface.receive.set(ifstats.Receive, iface.last.Receive)
+		iface.transmit.set(ifstats.Transmit, iface.last.Transmit)
+		iface.last = ifstats
+		n.engine.Report(iface)
+	}
+
+	for name := range n.interfaces {
+		if _, ok := dev[name]; !ok {
+			delete(n.interfaces, name)
+		}
+	}
+}
+
+func (c *netCounters) set(counters linux.NetDevCounters, last linux.NetDevCounters) {
+	c.bytes = counters.Bytes - last.Bytes
+	c.packets = counters.Packets - last.Packets
+	c.errors = counters.Errors - last.Errors
+	c.drops = counters.Drops - last.Drops
+}
@@ -0,0 +1,52 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestNetDevMetrics(t *testing.T) {
+	if _, err := linux.ReadNetDev(os.Getpid()); err != nil {
+		t.Skip("network interface statistics are not available:", err)
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	netdev := NewNetDevMetricsWith(e, os.Getpid())
+	netdev.Collect()
+
+	if n := len(h.Measures()); n != 0 {
+		t.Error("measures were reported by the first collection:", n)
+	}
+
+	// Generate some traffic on the loopback interface.
+	if conn, err := net.Dial("udp", "127.0.0.1:9"); err == nil {
+		conn.Write([]byte("Hello World!"))
+		conn.Close()
+	}
+
+	netdev.Collect()
+
+	var loopback uint64
+
+	for _, m := range h.Measures() {
+		if m.Name != "net" || len(m.Fields) != 4 {
+			t.Errorf("bad measure: %v", m)
+		}
+		if m.Tags[1] == stats.T("interface", "lo") {
+			loopback += m.Fields[1].Value.Uint()
+		}
+	}
+
+	if loopback == 0 {
+		t.Error("no packets were reported on the loopback interface")
+	}
+}
@@ -0,0 +1,117 @@
+package procstats
+
+import (
+	"os"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+)
+
+// PressureMetrics is a metric collector that reports Pressure Stall
+// Information (PSI) on the cpu, memory, and io resources of the system or of
+// the cgroup that a process belongs to.
+//
+// Pressure stall information is only available on linux 4.20 and above,
+// resources that cannot be read are not reported.
+type PressureMetrics struct {
+	engine *stats.Engine
+	pid    int
+	cgroup bool
+	cpu    pressureResource
+	memory pressureResource
+	io     pressureResource
+}
+
+type pressureResource struct {
+	some     pressureStall `metric:"pressure"`
+	full     pressureStall `metric:"pressure"`
+	resource string        `tag:"resource"`
+	last     linux.Pressure
+	ok       bool
+}
+
+type pressureStall struct {
+	avg10  float64       `metric:"avg10.percent"  type:"gauge"`
+	avg60  float64       `metric:"avg60.percent"  type:"gauge"`
+	avg300 float64       `metric:"avg300.percent" type:"gauge"`
+	total  time.Duration `metric:"stall.seconds"  type:"counter"`
+	typ    string        `tag:"type"` // some | full
+}
+
+// NewPressureMetrics collects system-wide pressure stall information and
+// reports it to the default stats engine.
+func NewPressureMetrics() *PressureMetrics {
+	return NewPressureMetricsWith(stats.DefaultEngine)
+}
+
+// NewPressureMetricsWith collects system-wide pressure stall information and
+// reports it to eng.
+func NewPressureMetricsWith(eng *stats.Engine) *PressureMetrics {
+	return newPressureMetrics(eng, 0, false)
+}
+
+// NewCGroupPressureMetrics collects pressure stall information of the cgroup
+// of the current process and reports it to the default stats engine.
+func NewCGroupPressureMetrics() *PressureMetrics {
+	return NewCGroupPressureMetricsWith(stats.DefaultEngine, os.Getpid())
+}
+
+// NewCGroupPressureMetricsWith collects pressure stall information of the
+// cgroup v2 that the process identified by pid belongs to, and reports it to
+// eng.
+func NewCGroupPressureMetricsWith(eng *stats.Engine, pid int) *PressureMetrics {
+	return newPressureMetrics(eng, pid, true)
+}
+
+func newPressureMetrics(eng *stats.Engine, pid int, cgroup bool) *PressureMetrics {
+	p := &PressureMetrics{engine: eng, pid: pid, cgroup: cgroup}
+
+	p.cpu.resource = "cpu"
+	p.memory.resource = "memory"
+	p.io.resource = "io"
+
+	for _, r := range [...]*pressureResource{&p.cpu, &p.memory, &p.io} {
+		r.some.typ = "some"
+		r.full.typ = "full"
+	}
+
+	return p
+}
+
+// Collect satisfies the Collector interface.
+func (p *PressureMetrics) Collect() {
+	for _, r := range [...]*pressureResource{&p.cpu, &p.memory, &p.io} {
+		var pressure linux.Pressure
+		var err error
+
+		if p.cgroup {
+			pressure, err = linux.ReadCGroupPressure(p.pid, r.resource)
+		} else {
+			pressure, err = linux.ReadPressure(r.resource)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		r.some.set(pressure.Some, r.last.Some)
+		r.full.set(pressure.Full, r.last.Full)
+		r.last = pressure
+
+		// Resources are reported separately so the ones that could not be read
+		// are omitted, the first collection only initializes the baseline of
+		// the stall time counters.
+		if r.ok {
+			p.engine.Report(r)
+		}
+		r.ok = true
+	}
+}
+
+func (s *pressureStall) set(stall linux.PressureStall, last linux.PressureStall) {
+	s.avg10 = stall.Avg10
+	s.avg60 = stall.Avg60
+	s.avg300 = stall.Avg300
+	s.total = stall.Total - last.Total
+}
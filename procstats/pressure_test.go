@@ -0,0 +1,50 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"os"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestPressureMetrics(t *testing.T) {
+	if _, err := linux.ReadPressure("cpu"); err != nil {
+		t.Skip("pressure stall information is not available:", err)
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	pressure := NewPressureMetricsWith(e)
+	pressure.Collect()
+
+	if n := len(h.Measures()); n != 0 {
+		t.Error("measures were reported by the first collection:", n)
+	}
+
+	pressure.Collect()
+
+	resources := map[string]bool{}
+
+	for _, m := range h.Measures() {
+		if m.Name != "pressure" || len(m.Fields) != 4 {
+			t.Errorf("bad measure: %v", m)
+		}
+		for _, tag := range m.Tags {
+			if tag.Name == "resource" {
+				resources[tag.Value] = true
+			}
+		}
+	}
+
+	if !resources["cpu"] {
+		t.Error("no cpu pressure was reported:", resources)
+	}
+
+	// Cgroup pressure may not be available, but collecting must not fail.
+	NewCGroupPressureMetricsWith(e, os.Getpid()).Collect()
+}
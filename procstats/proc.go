@@ -12,12 +12,15 @@ import (
 type ProcMetrics struct {
 	engine   *stats.Engine
 	pid      int
-	cpu      procCPU     `metric:"cpu"`
-	memory   procMemory  `metric:"memory"`
-	files    procFiles   `metric:"files"`
-	threads  procThreads `metric:"threads"`
+	cpu      procCPU       `metric:"cpu"`
+	memory   procMemory    `metric:"memory"`
+	files    procFiles     `metric:"files"`
+	threads  procThreads   `metric:"threads"`
+	start    procStart     `metric:"start"`
+	uptime   time.Duration `metric:"uptime.seconds" type:"gauge"` // time since the process started
 	last     ProcInfo
 	lastTime time.Time
+	tree     *procTree // set when the metrics aggregate descendant processes
 }
 
 type procCPU struct {
@@ -36,6 +39,16 @@ type procCPU struct {
 		time    time.Duration `metric:"usage_total.seconds" type:"counter"`
 		percent float64       `metric:"usage_total.percent" type:"gauge"`
 	}
+
+	// CPU limits (cgroup quota), the usage percentages above are relative to
+	// the quota when one is set
+	limit struct {
+		cores float64 `metric:"limit.cores" type:"gauge"` // number of cores allowed by the quota
+	}
+	throttled struct {
+		count uint64        `metric:"throttled.count"   type:"counter"` // number of throttled periods
+		time  time.Duration `metric:"throttled.seconds" type:"counter"` // time spent throttled
+	}
 }
 
 type procMemory struct {
@@ -64,6 +77,22 @@ type procMemory struct {
 		typ   string `tag:"type"` // data
 	}
 
+	swap struct { // anonymous memory swapped out
+		usage uint64 `metric:"usage.bytes" type:"gauge"`
+		typ   string `tag:"type"` // swap
+	}
+
+	hugetlb struct { // memory backed by pages of the hugetlb pool
+		usage uint64 `metric:"usage.bytes" type:"gauge"`
+		typ   string `tag:"type"` // hugetlb
+	}
+
+	cgroup struct { // memory charged to the cgroup of the process (including page cache)
+		usage   uint64  `metric:"usage.bytes"   type:"gauge"`
+		percent float64 `metric:"usage.percent" type:"gauge"`
+		typ     string  `tag:"type"` // cgroup
+	}
+
 	// Page faults
 	pagefault struct {
 		major struct {
@@ -79,8 +108,10 @@ type procMemory struct {
 
 type procFiles struct {
 	// File descriptors
-	open uint64 `metric:"open.count" type:"gauge"` // fds opened by the process
-	max  uint64 `metric:"open.max"   type:"gauge"` // max number of fds the process can open
+	open    uint64  `metric:"open.count"    type:"gauge"` // fds opened by the process
+	max     uint64  `metric:"open.max"      type:"gauge"` // max number of fds the process can open (soft limit)
+	hardmax uint64  `metric:"open.hard_max" type:"gauge"` // max value that the soft limit can be raised to
+	percent float64 `metric:"open.percent"  type:"gauge"` // fds opened by the process relative to the soft limit
 }
 
 type procThreads struct {
@@ -100,6 +131,11 @@ type procThreads struct {
 	} `metric:"switch"`
 }
 
+type procStart struct {
+	time  float64 `metric:"time.seconds" type:"gauge"`   // unix time at which the process started
+	count uint64  `metric:"count"        type:"counter"` // number of process starts observed by the collector
+}
+
 // NewProdMetrics collects metrics on the current process and reports them to
 // the default stats engine.
 func NewProcMetrics() *ProcMetrics {
@@ -118,6 +154,9 @@ func NewProcMetricsWith(eng *stats.Engine, pid int) *ProcMetrics {
 	p.memory.shared.typ = "shared"
 	p.memory.text.typ = "text"
 	p.memory.data.typ = "data"
+	p.memory.swap.typ = "swap"
+	p.memory.hugetlb.typ = "hugetlb"
+	p.memory.cgroup.typ = "cgroup"
 
 	p.memory.pagefault.major.typ = "major"
 	p.memory.pagefault.minor.typ = "minor"
@@ -130,7 +169,7 @@ func NewProcMetricsWith(eng *stats.Engine, pid int) *ProcMetrics {
 
 // Collect satisfies the Collector interface.
 func (p *ProcMetrics) Collect() {
-	if m, err := CollectProcInfo(p.pid); err == nil {
+	if m, err := p.collect(); err == nil {
 		now := time.Now()
 
 		if !p.lastTime.IsZero() {
@@ -155,6 +194,13 @@ func (p *ProcMetrics) Collect() {
 			p.cpu.total.time = (m.CPU.User + m.CPU.Sys) - (p.last.CPU.User + p.last.CPU.Sys)
 			p.cpu.total.percent = 100 * float64(p.cpu.total.time) / interval
 
+			p.cpu.throttled.count = m.CPU.ThrottledPeriods - p.last.CPU.ThrottledPeriods
+			p.cpu.throttled.time = m.CPU.ThrottledTime - p.last.CPU.ThrottledTime
+		}
+
+		p.cpu.limit.cores = 0
+		if m.CPU.Period > 0 && m.CPU.Quota > 0 {
+			p.cpu.limit.cores = float64(m.CPU.Quota) / float64(m.CPU.Period)
 		}
 
 		p.memory.available = m.Memory.Available
@@ -164,11 +210,36 @@ func (p *ProcMetrics) Collect() {
 		p.memory.shared.usage = m.Memory.Shared
 		p.memory.text.usage = m.Memory.Text
 		p.memory.data.usage = m.Memory.Data
+		p.memory.swap.usage = m.Memory.Swap
+		p.memory.hugetlb.usage = m.Memory.HugeTLB
+		p.memory.cgroup.usage = m.Memory.CGroupUsage
+		p.memory.cgroup.percent = 100 * float64(p.memory.cgroup.usage) / float64(p.memory.available)
 		p.memory.pagefault.major.count = m.Memory.MajorPageFaults - p.last.Memory.MajorPageFaults
 		p.memory.pagefault.minor.count = m.Memory.MinorPageFaults - p.last.Memory.MinorPageFaults
 
 		p.files.open = m.Files.Open
 		p.files.max = m.Files.Max
+		p.files.hardmax = m.Files.HardMax
+		p.files.percent = 0
+		if p.files.max != 0 {
+			p.files.percent = 100 * float64(p.files.open) / float64(p.files.max)
+		}
+
+		// The start time changes when the process is restarted with the same
+		// pid (for example pid 1 in a container), the first collection also
+		// counts as a start so restarts are visible when aggregating the
+		// metrics of multiple instances of a program.
+		p.start.count = 0
+		if p.lastTime.IsZero() || !m.StartTime.Equal(p.last.StartTime) {
+			p.start.count = 1
+		}
+
+		p.start.time = 0
+		p.uptime = 0
+		if !m.StartTime.IsZero() {
+			p.start.time = float64(m.StartTime.UnixNano()) / 1e9
+			p.uptime = now.Sub(m.StartTime)
+		}
 
 		p.threads.num = m.Threads.Num
 		p.threads.switches.voluntary.count = m.Threads.VoluntaryContextSwitches - p.last.Threads.VoluntaryContextSwitches
@@ -180,11 +251,21 @@ func (p *ProcMetrics) Collect() {
 	}
 }
 
+func (p *ProcMetrics) collect() (ProcInfo, error) {
+	if p.tree != nil {
+		return p.tree.collect(p.pid)
+	}
+	return CollectProcInfo(p.pid)
+}
+
 type ProcInfo struct {
 	CPU     CPUInfo
 	Memory  MemoryInfo
 	Files   FileInfo
 	Threads ThreadInfo
+
+	// Time at which the process started, zero if it is not known.
+	StartTime time.Time
 }
 
 func CollectProcInfo(pid int) (ProcInfo, error) {
@@ -205,6 +286,9 @@ type CPUInfo struct {
 	Period time.Duration // scheduler period
 	Quota  time.Duration // time quota in the scheduler period
 	Shares int64         // 1024 scaled value representing the CPU shares
+
+	ThrottledPeriods uint64        // number of periods where the process cgroup was throttled
+	ThrottledTime    time.Duration // total time that the process cgroup was throttled for
 }
 
 type MemoryInfo struct {
@@ -214,14 +298,21 @@ type MemoryInfo struct {
 	Shared    uint64 // shared pages (i.e., backed by a file)
 	Text      uint64 // text (code)
 	Data      uint64 // data + stack
+	Swap      uint64 // anonymous memory swapped out
+	HugeTLB   uint64 // memory backed by pages of the hugetlb pool
+
+	// Linux-specific memory usage of the cgroup that the process belongs to,
+	// zero if it is not known.
+	CGroupUsage uint64
 
 	MajorPageFaults uint64
 	MinorPageFaults uint64
 }
 
 type FileInfo struct {
-	Open uint64 // fds opened by the process
-	Max  uint64 // max number of fds the process can open
+	Open    uint64 // fds opened by the process
+	Max     uint64 // max number of fds the process can open (soft limit)
+	HardMax uint64 // max value that the soft limit can be raised to (hard limit)
 }
 
 type ThreadInfo struct {
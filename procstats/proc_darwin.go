@@ -1,122 +1,160 @@
 package procstats
 
-/*
-#include <mach/mach_init.h>
-#include <mach/mach_host.h>
-#include <mach/mach_port.h>
-#include <mach/mach_traps.h>
-#include <mach/task_info.h>
-#include <mach/task.h>
-#include <sys/proc_info.h>
-#include <sys/types.h>
-#include <sys/sysctl.h>
-
-// Defined in kern/proc_info.c
-extern int proc_pidinfo(int pid, int flavor, uint64_t arg, user_addr_t buffer, uint32_t buffersize, register_t * retval);
-
-// CGO fails to call a macro that just references a global variable...
-#ifdef mach_task_self
-#undef mach_task_self
-static mach_port_t mach_task_self() { return mach_task_self_; }
-#endif
-*/
-import "C"
 import (
-	"errors"
-	"fmt"
 	"os"
+	"runtime"
 	"syscall"
 	"time"
 	"unsafe"
+
+	"golang.org/x/sys/unix"
 )
 
-func collectProcInfo(pid int) (info ProcInfo, err error) {
-	defer func() { err = convertPanicToError(recover()) }()
+// Constants and structures of the proc_info system call, defined in
+// sys/proc_info.h.
+const (
+	sysProcInfo         = 336
+	procInfoCallPidInfo = 2
+	procPidListFDs      = 1
+	procPidTBSDInfo     = 3
+	procPidTaskInfo     = 4
+	procPidListFDSize   = 8
+)
 
-	if pid != os.Getpid() {
-		panic(errors.New("on darwin systems only metrics of the current process can be collected"))
-	}
+type procTaskInfo struct {
+	VirtualSize      uint64
+	ResidentSize     uint64
+	TotalUser        uint64
+	TotalSystem      uint64
+	ThreadsUser      uint64
+	ThreadsSystem    uint64
+	Policy           int32
+	Faults           int32
+	Pageins          int32
+	CowFaults        int32
+	MessagesSent     int32
+	MessagesReceived int32
+	SyscallsMach     int32
+	SyscallsUnix     int32
+	Csw              int32
+	Threadnum        int32
+	Numrunning       int32
+	Priority         int32
+}
 
-	self := C.mach_port_name_t(C.mach_task_self())
-	task := C.mach_port_name_t(0)
-	checkKern(C.task_for_pid(self, C.int(pid), &task))
+type procBSDInfo struct {
+	Flags       uint32
+	Status      uint32
+	Xstatus     uint32
+	Pid         uint32
+	Ppid        uint32
+	_           [6]uint32 // pbi_uid ... pbi_svgid
+	_           uint32    // rfu_1
+	Comm        [16]byte
+	Name        [32]byte
+	Nfiles      uint32
+	Pgid        uint32
+	Pjobc       uint32
+	Tdev        uint32
+	Tpgid       uint32
+	Nice        int32
+	StartTvsec  uint64
+	StartTvusec uint64
+}
 
-	rusage := syscall.Rusage{}
-	check(syscall.Getrusage(syscall.RUSAGE_SELF, &rusage))
+func collectProcInfo(pid int) (info ProcInfo, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
 
-	nofile := syscall.Rlimit{}
-	check(syscall.Getrlimit(syscall.RLIMIT_NOFILE, &nofile))
+	task := taskInfo(pid)
+	bsd := bsdInfo(pid)
 
-	info.CPU.User = time.Duration(rusage.Utime.Nano())
-	info.CPU.Sys = time.Duration(rusage.Stime.Nano())
+	info.CPU.User = machTimeToDuration(task.TotalUser)
+	info.CPU.Sys = machTimeToDuration(task.TotalSystem)
 
-	_, resident, suspend := taskInfo(task)
 	info.Memory.Available = memoryAvailable()
-	info.Memory.Size = resident
-	info.Memory.Resident = resident
-	info.Memory.MajorPageFaults = uint64(rusage.Majflt)
-	info.Memory.MinorPageFaults = uint64(rusage.Minflt)
+	info.Memory.Size = task.VirtualSize
+	info.Memory.Resident = task.ResidentSize
+	info.Memory.MajorPageFaults = uint64(task.Pageins)
+	info.Memory.MinorPageFaults = uint64(task.Faults - task.Pageins)
 
-	info.Files.Max = nofile.Cur
 	info.Files.Open = fdCount(pid)
+	info.Threads.Num = uint64(task.Threadnum)
+
+	info.StartTime = time.Unix(int64(bsd.StartTvsec), int64(bsd.StartTvusec)*1e3)
+
+	if pid == os.Getpid() {
+		// Resource usage and limits are only available for the current
+		// process, they provide more accurate values than the task info.
+		rusage := syscall.Rusage{}
+		check(syscall.Getrusage(syscall.RUSAGE_SELF, &rusage))
+
+		nofile := syscall.Rlimit{}
+		check(syscall.Getrlimit(syscall.RLIMIT_NOFILE, &nofile))
+
+		info.CPU.User = time.Duration(rusage.Utime.Nano())
+		info.CPU.Sys = time.Duration(rusage.Stime.Nano())
+
+		info.Memory.MajorPageFaults = uint64(rusage.Majflt)
+		info.Memory.MinorPageFaults = uint64(rusage.Minflt)
+
+		info.Files.Max = nofile.Cur
+		info.Files.HardMax = nofile.Max
+
+		info.Threads.VoluntaryContextSwitches = uint64(rusage.Nvcsw)
+		info.Threads.InvoluntaryContextSwitches = uint64(rusage.Nivcsw)
+	}
 
-	info.Threads.Num = threadCount(task)
-	info.Threads.InvoluntaryContextSwitches = suspend
 	return
 }
 
 func memoryAvailable() uint64 {
-	mib := [2]C.int{C.CTL_HW, C.HW_MEMSIZE}
-	mem := C.int64_t(0)
-	len := C.size_t(8) // sizeof(int64_t)
-
-	_, err := C.sysctl(&mib[0], 2, unsafe.Pointer(&mem), &len, nil, 0)
+	mem, err := unix.SysctlUint64("hw.memsize")
 	check(err)
-
-	return uint64(mem)
+	return mem
 }
 
-func taskInfo(task C.mach_port_name_t) (virtual uint64, resident uint64, suspend uint64) {
-	info := C.mach_task_basic_info_data_t{}
-	count := C.mach_msg_type_number_t(C.MACH_TASK_BASIC_INFO_COUNT)
+func taskInfo(pid int) (info procTaskInfo) {
+	size := unsafe.Sizeof(info)
+	n := procInfo(pid, procPidTaskInfo, uintptr(unsafe.Pointer(&info)), size)
 
-	checkKern(C.task_info(
-		C.task_name_t(task),
-		C.MACH_TASK_BASIC_INFO, (*C.integer_t)(unsafe.Pointer(&info)),
-		&count,
-	))
+	if n != size {
+		panic(syscall.EINVAL)
+	}
 
-	return uint64(info.virtual_size), uint64(info.resident_size), uint64(info.suspend_count)
+	return
 }
 
-func fdCount(pid int) uint64 {
-	return uint64(C.proc_pidinfo(C.int(pid), C.PROC_PIDLISTFDS, 0, 0, 0, nil)) / uint64(C.PROC_PIDLISTFD_SIZE)
-}
+func bsdInfo(pid int) (info procBSDInfo) {
+	size := unsafe.Sizeof(info)
+	n := procInfo(pid, procPidTBSDInfo, uintptr(unsafe.Pointer(&info)), size)
 
-func threadCount(task C.mach_port_name_t) uint64 {
-	threads := make([]C.thread_act_t, 100)
-	count := C.mach_msg_type_number_t(0)
+	if n != size {
+		panic(syscall.EINVAL)
+	}
 
-	for {
-		checkKern(C.task_threads(
-			C.task_inspect_t(task),
-			(*C.thread_act_array_t)(unsafe.Pointer(&threads[0])),
-			&count,
-		))
+	return
+}
 
-		if uint64(count) < uint64(len(threads)) {
-			break
-		}
+func fdCount(pid int) uint64 {
+	// Calling proc_info without a buffer returns the size needed to list the
+	// file descriptors of the process.
+	return uint64(procInfo(pid, procPidListFDs, 0, 0)) / procPidListFDSize
+}
 
-		threads = make([]C.thread_act_t, 10*len(threads))
-		count = 0
+func procInfo(pid int, flavor int, buffer uintptr, size uintptr) uintptr {
+	n, _, e := syscall.Syscall6(sysProcInfo, procInfoCallPidInfo, uintptr(pid), uintptr(flavor), 0, buffer, size)
+	if e != 0 {
+		panic(e)
 	}
-
-	return uint64(count)
+	return n
 }
 
-func checkKern(rc C.kern_return_t) {
-	if rc != C.KERN_SUCCESS {
-		panic(fmt.Errorf("mach trap kernel error code: %d", rc))
+// machTimeToDuration converts CPU times reported in mach absolute time units to
+// durations. The units are nanoseconds on intel processors, and ticks of a
+// 24MHz clock on apple silicon.
+func machTimeToDuration(t uint64) time.Duration {
+	if runtime.GOARCH == "arm64" {
+		return time.Duration(t * 125 / 3)
 	}
+	return time.Duration(t)
 }
@@ -0,0 +1,133 @@
+package procstats
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// kinfoProc mirrors the kinfo_proc structure defined in sys/user.h, fields
+// which are not used by the package are left blank.
+type kinfoProc struct {
+	Structsize int32
+	Layout     int32
+	_          [8]uintptr // ki_args ... ki_wchan
+	Pid        int32
+	Ppid       int32
+	_          [4]int32     // ki_pgid, ki_tpgid, ki_sid, ki_tsid
+	_          [2]int16     // ki_jobc, ki_spare_short1
+	_          uint32       // ki_tdev_freebsd11
+	_          [4][4]uint32 // ki_siglist, ki_sigmask, ki_sigignore, ki_sigcatch
+	_          [5]uint32    // ki_uid, ki_ruid, ki_svuid, ki_rgid, ki_svgid
+	_          [2]int16     // ki_ngroups, ki_spare_short2
+	_          [16]uint32   // ki_groups
+	Size       uintptr      // virtual size in bytes
+	Rssize     int          // resident set size in pages
+	_          [4]int       // ki_swrss, ki_tsize, ki_dsize, ki_ssize
+	_          [2]uint16    // ki_xstat, ki_acflag
+	_          [5]uint32    // ki_pctcpu, ki_estcpu, ki_slptime, ki_swtime, ki_cow
+	_          uint64       // ki_runtime
+	Start      syscall.Timeval
+	_          syscall.Timeval // ki_childtime
+	_          [2]int          // ki_flag, ki_kiflag
+	_          int32           // ki_traceflag
+	_          [6]int8         // ki_stat, ki_nice, ki_lock, ki_rqindex, ki_oncpu_old, ki_lastcpu_old
+	_          [158]int8       // ki_tdname ... ki_sparestrings
+	_          [2]int32        // ki_spareints
+	_          uint64          // ki_tdev
+	_          [7]int32        // ki_oncpu, ki_lastcpu, ki_tracer, ki_flag2, ki_fibnum, ki_cr_flags, ki_jid
+	Numthreads int32
+	_          int32    // ki_tid
+	_          [4]uint8 // ki_pri
+	Rusage     syscall.Rusage
+	_          syscall.Rusage // ki_rusage_ch
+	_          [10]uintptr    // ki_pcb ... ki_spareptrs
+	_          [14]int        // ki_sparelongs, ki_sflag, ki_tdflags
+}
+
+func collectProcInfo(pid int) (info ProcInfo, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+
+	proc := kinfoProcOf(pid)
+	nofile := rlimitOf(pid, unix.RLIMIT_NOFILE)
+
+	info.CPU.User = time.Duration(proc.Rusage.Utime.Nano())
+	info.CPU.Sys = time.Duration(proc.Rusage.Stime.Nano())
+
+	info.Memory.Available = memoryAvailable()
+	info.Memory.Size = uint64(proc.Size)
+	info.Memory.Resident = uint64(proc.Rssize) * uint64(os.Getpagesize())
+	info.Memory.MajorPageFaults = uint64(proc.Rusage.Majflt)
+	info.Memory.MinorPageFaults = uint64(proc.Rusage.Minflt)
+
+	info.Files.Open = fdCount(pid)
+	info.Files.Max = uint64(nofile.Cur)
+	info.Files.HardMax = uint64(nofile.Max)
+
+	info.Threads.Num = uint64(proc.Numthreads)
+	info.Threads.VoluntaryContextSwitches = uint64(proc.Rusage.Nvcsw)
+	info.Threads.InvoluntaryContextSwitches = uint64(proc.Rusage.Nivcsw)
+
+	info.StartTime = time.Unix(0, proc.Start.Nano())
+	return
+}
+
+func memoryAvailable() uint64 {
+	mem, err := unix.SysctlUint64("hw.physmem")
+	check(err)
+	return mem
+}
+
+func kinfoProcOf(pid int) (proc kinfoProc) {
+	b, err := unix.SysctlRaw("kern.proc.pid", pid)
+	check(err)
+
+	// The size check protects against layout changes of the structure in
+	// future versions of the kernel.
+	if uintptr(len(b)) != unsafe.Sizeof(proc) {
+		panic(syscall.EINVAL)
+	}
+
+	proc = *(*kinfoProc)(unsafe.Pointer(&b[0]))
+
+	if int(proc.Structsize) != len(b) || int(proc.Pid) != pid {
+		panic(syscall.EINVAL)
+	}
+
+	return
+}
+
+func rlimitOf(pid int, resource int) (limit unix.Rlimit) {
+	b, err := unix.SysctlRaw("kern.proc.rlimit", pid, resource)
+	check(err)
+
+	if uintptr(len(b)) != unsafe.Sizeof(limit) {
+		panic(syscall.EINVAL)
+	}
+
+	return *(*unix.Rlimit)(unsafe.Pointer(&b[0]))
+}
+
+func fdCount(pid int) (n uint64) {
+	b, err := unix.SysctlRaw("kern.proc.filedesc", pid)
+	check(err)
+
+	// The kinfo_file entries have variable sizes, each starts with its size,
+	// type, and file descriptor number, which is negative for the entries
+	// describing the working directory, root directory, etc...
+	for len(b) >= 12 {
+		size := int(*(*int32)(unsafe.Pointer(&b[0])))
+		if size <= 0 || size > len(b) {
+			break
+		}
+		if fd := *(*int32)(unsafe.Pointer(&b[8])); fd >= 0 {
+			n++
+		}
+		b = b[size:]
+	}
+
+	return
+}
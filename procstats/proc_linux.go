@@ -17,6 +17,10 @@ var (
 	clockTickOnce  sync.Once
 	clockTickHertz uint64
 	clockTickError error
+
+	bootTimeOnce  sync.Once
+	bootTimeValue time.Time
+	bootTimeError error
 )
 
 func clockTicksToDuration(ticks uint64) time.Duration {
@@ -25,6 +29,17 @@ func clockTicksToDuration(ticks uint64) time.Duration {
 	return time.Duration(1e9 * float64(ticks) / float64(clockTickHertz))
 }
 
+// bootTime returns the time at which the system booted, which is the reference
+// of the process start times in /proc/<pid>/stat.
+func bootTime() time.Time {
+	bootTimeOnce.Do(func() {
+		stat, err := linux.ReadSystemStat()
+		bootTimeValue, bootTimeError = stat.BootTime, err
+	})
+	check(bootTimeError)
+	return bootTimeValue
+}
+
 func clockTick() (uint64, error) {
 	s, err := getconf("CLK_TCK")
 	if err != nil {
@@ -77,7 +92,7 @@ func collectProcInfo(pid int) (info ProcInfo, err error) {
 	statm, err := linux.ReadProcStatm(pid)
 	check(err)
 
-	sched, err := linux.ReadProcSched(pid)
+	status, err := linux.ReadProcStatus(pid)
 	check(err)
 
 	fds, err := linux.ReadOpenFileCount(pid)
@@ -136,6 +151,24 @@ func collectProcInfo(pid int) (info ProcInfo, err error) {
 		}
 	}
 
+	// The cgroup v1 files read above do not exist on cgroup v2 systems, in
+	// which case the limits are taken from the unified hierarchy.
+	cgroup, _ := linux.ReadCGroupInfo(pid)
+
+	if cpu.Period == 0 {
+		cpu.Period = cgroup.CPUPeriod
+		cpu.Quota = cgroup.CPUQuota
+	}
+
+	// Containers usually see the memory of the whole host, the utilization
+	// ratios are more meaningful relative to the cgroup limit when one is set.
+	if cgroup.MemoryLimit != 0 && cgroup.MemoryLimit < memoryLimit {
+		memoryLimit = cgroup.MemoryLimit
+	}
+
+	cpu.ThrottledPeriods = cgroup.CPUStat.ThrottledPeriods
+	cpu.ThrottledTime = cgroup.CPUStat.ThrottledTime
+
 	info = ProcInfo{
 		CPU: cpu,
 
@@ -146,20 +179,26 @@ func collectProcInfo(pid int) (info ProcInfo, err error) {
 			Shared:          pagesize * statm.Share,
 			Text:            pagesize * statm.Text,
 			Data:            pagesize * statm.Data,
+			Swap:            status.VmSwap,
+			HugeTLB:         status.HugetlbPages,
+			CGroupUsage:     cgroup.MemoryUsage,
 			MajorPageFaults: stat.Majflt,
 			MinorPageFaults: stat.Minflt,
 		},
 
 		Files: FileInfo{
-			Open: fds,
-			Max:  limits.OpenFiles.Soft,
+			Open:    fds,
+			Max:     limits.OpenFiles.Soft,
+			HardMax: limits.OpenFiles.Hard,
 		},
 
 		Threads: ThreadInfo{
 			Num: uint64(stat.NumThreads),
-			VoluntaryContextSwitches:   sched.NRVoluntarySwitches,
-			InvoluntaryContextSwitches: sched.NRInvoluntarySwitches,
+			VoluntaryContextSwitches:   status.VoluntaryCtxtSwitches,
+			InvoluntaryContextSwitches: status.NonvoluntaryCtxtSwitches,
 		},
+
+		StartTime: bootTime().Add(clockTicksToDuration(stat.Starttime)),
 	}
 
 	return
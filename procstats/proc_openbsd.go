@@ -0,0 +1,137 @@
+package procstats
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Constants of the kern.proc sysctl, defined in sys/sysctl.h.
+const (
+	kernProcAll         = 0
+	kernProcPid         = 1
+	kernProcShowThreads = 0x40000000
+
+	// Upper bound on the number of file descriptors probed by fdCount, in
+	// case the soft limit is set to infinity.
+	maxFdProbe = 1 << 16
+)
+
+// kinfoProc mirrors the beginning of the kinfo_proc structure defined in
+// sys/sysctl.h, the sysctl takes the size of the entries as argument so the
+// structure can be truncated after the last field used by the package.
+type kinfoProc struct {
+	_          [12]uint64 // p_forw ... p_ru
+	_          [3]int32   // p_eflag, p_exitsig, p_flag
+	Pid        int32
+	Ppid       int32
+	_          [3]int32   // p_sid, p__pgid, p_tpgid
+	_          [4]uint32  // p_uid, p_ruid, p_gid, p_rgid
+	_          [16]uint32 // p_groups
+	_          [2]int16   // p_ngroups, p_jobc
+	_          [9]uint32  // p_tdev ... p_schedflags
+	_          [4]uint64  // p_uticks, p_sticks, p_iticks, p_tracep
+	_          [6]int32   // p_traceflag ... p_sigcatch
+	_          [4]uint8   // p_stat, p_priority, p_usrpri, p_nice
+	_          [2]uint16  // p_xstat, p_spare
+	_          [24]int8   // p_comm
+	_          [8]int8    // p_wmesg
+	_          uint64     // p_wchan
+	_          [32]int8   // p_login
+	VMRssize   int32      // resident set size in pages
+	VMTsize    int32      // text size in pages
+	VMDsize    int32      // data size in pages
+	VMSsize    int32      // stack size in pages
+	_          int64      // p_uvalid
+	UstartSec  uint64
+	UstartUsec uint32
+	UutimeSec  uint32
+	UutimeUsec uint32
+	UstimeSec  uint32
+	UstimeUsec uint32
+	_          [4]uint64 // p_uru_maxrss ... p_uru_isrss
+	UruMinflt  uint64
+	UruMajflt  uint64
+	_          [6]uint64 // p_uru_nswap ... p_uru_nsignals
+	UruNvcsw   uint64
+	UruNivcsw  uint64
+}
+
+func collectProcInfo(pid int) (info ProcInfo, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+
+	procs := kinfoProcs(kernProcPid|kernProcShowThreads, pid)
+	if len(procs) == 0 || int(procs[0].Pid) != pid {
+		panic(syscall.ESRCH)
+	}
+	proc := procs[0]
+	pagesize := uint64(os.Getpagesize())
+
+	info.CPU.User = time.Duration(proc.UutimeSec)*time.Second + time.Duration(proc.UutimeUsec)*time.Microsecond
+	info.CPU.Sys = time.Duration(proc.UstimeSec)*time.Second + time.Duration(proc.UstimeUsec)*time.Microsecond
+
+	info.Memory.Available = memoryAvailable()
+	info.Memory.Size = uint64(proc.VMTsize+proc.VMDsize+proc.VMSsize) * pagesize
+	info.Memory.Resident = uint64(proc.VMRssize) * pagesize
+	info.Memory.MajorPageFaults = proc.UruMajflt
+	info.Memory.MinorPageFaults = proc.UruMinflt
+
+	// The first entry describes the process, the following ones its threads.
+	info.Threads.Num = uint64(len(procs) - 1)
+	info.Threads.VoluntaryContextSwitches = proc.UruNvcsw
+	info.Threads.InvoluntaryContextSwitches = proc.UruNivcsw
+
+	info.StartTime = time.Unix(int64(proc.UstartSec), int64(proc.UstartUsec)*1e3)
+
+	if pid == os.Getpid() {
+		// Limits and file descriptors of other processes are only exposed
+		// through kernel memory.
+		nofile := unix.Rlimit{}
+		check(unix.Getrlimit(unix.RLIMIT_NOFILE, &nofile))
+
+		info.Files.Open = fdCount(nofile.Cur)
+		info.Files.Max = nofile.Cur
+		info.Files.HardMax = nofile.Max
+	}
+
+	return
+}
+
+func memoryAvailable() uint64 {
+	// hw.physmem is mapped to HW_PHYSMEM64 by golang.org/x/sys/unix.
+	mem, err := unix.SysctlUint64("hw.physmem")
+	check(err)
+	return mem
+}
+
+func kinfoProcs(op int, arg int) []kinfoProc {
+	size := unsafe.Sizeof(kinfoProc{})
+
+	b, err := unix.SysctlRaw("kern.proc", op, arg, int(size), 1<<20)
+	check(err)
+
+	procs := make([]kinfoProc, 0, uintptr(len(b))/size)
+
+	for ; uintptr(len(b)) >= size; b = b[size:] {
+		procs = append(procs, *(*kinfoProc)(unsafe.Pointer(&b[0])))
+	}
+
+	return procs
+}
+
+// fdCount counts the open file descriptors of the current process by probing
+// the descriptors up to max.
+func fdCount(max uint64) (n uint64) {
+	if max > maxFdProbe {
+		max = maxFdProbe
+	}
+	for fd := 0; uint64(fd) < max; fd++ {
+		if _, err := unix.FcntlInt(uintptr(fd), unix.F_GETFD, 0); err == nil {
+			n++
+		}
+	}
+	return
+}
@@ -1,3 +1,5 @@
+//go:build !statsoff
+
 package procstats
 
 import (
@@ -51,3 +53,52 @@ func testProcMetrics(t *testing.T, pid int) {
 		time.Sleep(10 * time.Millisecond)
 	}
 }
+
+func TestCollectProcInfoFiles(t *testing.T) {
+	info, err := CollectProcInfo(os.Getpid())
+	if err != nil {
+		t.Skip("process info is not available:", err)
+	}
+
+	if info.Files.Open == 0 {
+		t.Error("no open files were reported")
+	}
+
+	if info.Files.HardMax != 0 && info.Files.HardMax < info.Files.Max {
+		t.Errorf("the hard limit is lower than the soft limit: %d < %d", info.Files.HardMax, info.Files.Max)
+	}
+}
+
+func TestCollectProcInfoStartTime(t *testing.T) {
+	info, err := CollectProcInfo(os.Getpid())
+	if err != nil {
+		t.Skip("process info is not available:", err)
+	}
+
+	// The start time is rounded to the clock ticks on linux, and the boot time
+	// to the second.
+	if now := time.Now(); info.StartTime.After(now.Add(time.Second)) || info.StartTime.Before(now.Add(-time.Hour)) {
+		t.Errorf("the process start time is not within the expected range: %s (now: %s)", info.StartTime, now)
+	}
+}
+
+func TestProcMetricsStartCount(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	proc := NewProcMetricsWith(e, os.Getpid())
+
+	for i := 0; i != 3; i++ {
+		proc.Collect()
+
+		// Only the first collection observes the start of the process.
+		expect := uint64(0)
+		if i == 0 {
+			expect = 1
+		}
+
+		if proc.start.count != expect {
+			t.Errorf("bad start count at collection %d: %d (expected %d)", i, proc.start.count, expect)
+		}
+	}
+}
@@ -1,6 +1,190 @@
 package procstats
 
-func collectProcMetrics(pid int) (m proc, err error) {
-	// TODO
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+	psapi    = windows.NewLazySystemDLL("psapi.dll")
+
+	procGetProcessHandleCount     = kernel32.NewProc("GetProcessHandleCount")
+	procGlobalMemoryStatusEx      = kernel32.NewProc("GlobalMemoryStatusEx")
+	procCreateToolhelp32Snapshot  = kernel32.NewProc("CreateToolhelp32Snapshot")
+	procProcess32FirstW           = kernel32.NewProc("Process32FirstW")
+	procProcess32NextW            = kernel32.NewProc("Process32NextW")
+	procQueryInformationJobObject = kernel32.NewProc("QueryInformationJobObject")
+	procGetProcessMemoryInfo      = psapi.NewProc("GetProcessMemoryInfo")
+)
+
+const (
+	processQueryLimitedInformation = 0x1000
+	th32csSnapProcess              = 0x2
+	jobObjectExtendedLimitInfo     = 9
+	jobObjectLimitProcessMemory    = 0x100
+	jobObjectLimitJobMemory        = 0x200
+)
+
+// processMemoryCountersEx mirrors the PROCESS_MEMORY_COUNTERS_EX structure.
+type processMemoryCountersEx struct {
+	CB                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+	PrivateUsage               uintptr
+}
+
+// memoryStatusEx mirrors the MEMORYSTATUSEX structure.
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// processEntry32 mirrors the PROCESSENTRY32W structure.
+type processEntry32 struct {
+	Size            uint32
+	Usage           uint32
+	ProcessID       uint32
+	DefaultHeapID   uintptr
+	ModuleID        uint32
+	Threads         uint32
+	ParentProcessID uint32
+	PriClassBase    int32
+	Flags           uint32
+	ExeFile         [260]uint16
+}
+
+// jobObjectExtendedLimitInformation mirrors the
+// JOBOBJECT_EXTENDED_LIMIT_INFORMATION structure.
+type jobObjectExtendedLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+	IoInfo                  [6]uint64
+	ProcessMemoryLimit      uintptr
+	JobMemoryLimit          uintptr
+	PeakProcessMemoryUsed   uintptr
+	PeakJobMemoryUsed       uintptr
+}
+
+func collectProcInfo(pid int) (info ProcInfo, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+
+	process, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	check(err)
+	defer syscall.CloseHandle(process)
+
+	var creation, exit, kernel, user syscall.Filetime
+	check(syscall.GetProcessTimes(process, &creation, &exit, &kernel, &user))
+
+	memory := processMemoryCountersEx{}
+	memory.CB = uint32(unsafe.Sizeof(memory))
+	check(call(procGetProcessMemoryInfo, uintptr(process), uintptr(unsafe.Pointer(&memory)), uintptr(memory.CB)))
+
+	handles := uint32(0)
+	check(call(procGetProcessHandleCount, uintptr(process), uintptr(unsafe.Pointer(&handles))))
+
+	info.CPU.User = filetimeToDuration(user)
+	info.CPU.Sys = filetimeToDuration(kernel)
+
+	info.Memory.Available = memoryAvailable(pid)
+	info.Memory.Size = uint64(memory.PrivateUsage)
+	info.Memory.Resident = uint64(memory.WorkingSetSize)
+	info.Memory.MinorPageFaults = uint64(memory.PageFaultCount)
+
+	info.Files.Open = uint64(handles)
+	info.Threads.Num = threadCount(pid)
+
+	info.StartTime = time.Unix(0, creation.Nanoseconds())
 	return
 }
+
+// memoryAvailable returns the amount of physical memory, or the memory limit of
+// the job object that the current process is part of if it is lower.
+func memoryAvailable(pid int) uint64 {
+	status := memoryStatusEx{}
+	status.Length = uint32(unsafe.Sizeof(status))
+	check(call(procGlobalMemoryStatusEx, uintptr(unsafe.Pointer(&status))))
+
+	available := status.TotalPhys
+
+	if pid == os.Getpid() {
+		// Passing a null handle queries the job of the calling process, the
+		// call fails if the process is not part of a job.
+		limits := jobObjectExtendedLimitInformation{}
+
+		if call(procQueryInformationJobObject, 0, jobObjectExtendedLimitInfo,
+			uintptr(unsafe.Pointer(&limits)), unsafe.Sizeof(limits), 0) == nil {
+			if limits.LimitFlags&jobObjectLimitProcessMemory != 0 && uint64(limits.ProcessMemoryLimit) < available {
+				available = uint64(limits.ProcessMemoryLimit)
+			}
+			if limits.LimitFlags&jobObjectLimitJobMemory != 0 && uint64(limits.JobMemoryLimit) < available {
+				available = uint64(limits.JobMemoryLimit)
+			}
+		}
+	}
+
+	return available
+}
+
+func threadCount(pid int) uint64 {
+	r, _, e := procCreateToolhelp32Snapshot.Call(th32csSnapProcess, 0)
+	if syscall.Handle(r) == syscall.InvalidHandle {
+		panic(e)
+	}
+
+	snapshot := syscall.Handle(r)
+	defer syscall.CloseHandle(snapshot)
+
+	entry := processEntry32{}
+	entry.Size = uint32(unsafe.Sizeof(entry))
+
+	for err := call(procProcess32FirstW, uintptr(snapshot), uintptr(unsafe.Pointer(&entry))); err == nil; err = call(procProcess32NextW, uintptr(snapshot), uintptr(unsafe.Pointer(&entry))) {
+		if entry.ProcessID == uint32(pid) {
+			return uint64(entry.Threads)
+		}
+	}
+
+	return 0
+}
+
+func filetimeToDuration(ft syscall.Filetime) time.Duration {
+	// Filetime values of CPU times are expressed in 100ns intervals.
+	return time.Duration(uint64(ft.HighDateTime)<<32|uint64(ft.LowDateTime)) * 100
+}
+
+// call invokes a win32 function returning a BOOL, converting failures to
+// errors.
+func call(proc *windows.LazyProc, args ...uintptr) error {
+	if r, _, e := proc.Call(args...); r == 0 {
+		if e == nil || e.(syscall.Errno) == 0 {
+			return syscall.EINVAL
+		}
+		return e
+	}
+	return nil
+}
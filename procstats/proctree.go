@@ -0,0 +1,121 @@
+package procstats
+
+import (
+	"os"
+	"sort"
+
+	"github.com/segmentio/stats"
+)
+
+// NewProcTreeMetrics collects metrics on the current process and all its
+// descendants and reports them to the default stats engine.
+func NewProcTreeMetrics() *ProcMetrics {
+	return NewProcTreeMetricsWith(stats.DefaultEngine, os.Getpid())
+}
+
+// NewProcTreeMetricsWith collects metrics on the process identified by pid and
+// all its descendants, and reports them to eng.
+//
+// The metrics are aggregated into a single view of the resources used by the
+// process tree, which is useful for supervisors that fork workers. Counters
+// of processes that exit between two collections lose their last interval.
+func NewProcTreeMetricsWith(eng *stats.Engine, pid int) *ProcMetrics {
+	p := NewProcMetricsWith(eng, pid)
+	p.tree = &procTree{last: make(map[int]ProcInfo)}
+	return p
+}
+
+type procTree struct {
+	last  map[int]ProcInfo
+	total ProcInfo // counters accumulated over the lifetime of the collector
+}
+
+// collect returns the aggregated metrics of the process tree rooted at pid,
+// where counters are accumulated from the per-process deltas so they remain
+// monotonic when processes come and go.
+func (t *procTree) collect(pid int) (ProcInfo, error) {
+	root, err := CollectProcInfo(pid)
+	if err != nil {
+		return root, err
+	}
+
+	infos := map[int]ProcInfo{pid: root}
+
+	for _, child := range CollectProcDescendants(pid) {
+		if info, err := CollectProcInfo(child); err == nil {
+			infos[child] = info
+		}
+	}
+
+	m := root
+	m.Memory.Size = 0
+	m.Memory.Resident = 0
+	m.Memory.Shared = 0
+	m.Memory.Text = 0
+	m.Memory.Data = 0
+	m.Memory.Swap = 0
+	m.Memory.HugeTLB = 0
+	m.Files.Open = 0
+	m.Threads.Num = 0
+
+	for pid, info := range infos {
+		last, ok := t.last[pid]
+		switch {
+		case !ok && len(t.last) == 0:
+			last = info // first collection, only initialize the baseline
+		case info.CPU.User+info.CPU.Sys < last.CPU.User+last.CPU.Sys:
+			last = ProcInfo{} // the pid was reused by a new process
+		}
+
+		t.total.CPU.User += info.CPU.User - last.CPU.User
+		t.total.CPU.Sys += info.CPU.Sys - last.CPU.Sys
+		t.total.Memory.MajorPageFaults += info.Memory.MajorPageFaults - last.Memory.MajorPageFaults
+		t.total.Memory.MinorPageFaults += info.Memory.MinorPageFaults - last.Memory.MinorPageFaults
+		t.total.Threads.VoluntaryContextSwitches += info.Threads.VoluntaryContextSwitches - last.Threads.VoluntaryContextSwitches
+		t.total.Threads.InvoluntaryContextSwitches += info.Threads.InvoluntaryContextSwitches - last.Threads.InvoluntaryContextSwitches
+
+		m.Memory.Size += info.Memory.Size
+		m.Memory.Resident += info.Memory.Resident
+		m.Memory.Shared += info.Memory.Shared
+		m.Memory.Text += info.Memory.Text
+		m.Memory.Data += info.Memory.Data
+		m.Memory.Swap += info.Memory.Swap
+		m.Memory.HugeTLB += info.Memory.HugeTLB
+		m.Files.Open += info.Files.Open
+		m.Threads.Num += info.Threads.Num
+	}
+
+	m.CPU.User = t.total.CPU.User
+	m.CPU.Sys = t.total.CPU.Sys
+	m.Memory.MajorPageFaults = t.total.Memory.MajorPageFaults
+	m.Memory.MinorPageFaults = t.total.Memory.MinorPageFaults
+	m.Threads.VoluntaryContextSwitches = t.total.Threads.VoluntaryContextSwitches
+	m.Threads.InvoluntaryContextSwitches = t.total.Threads.InvoluntaryContextSwitches
+
+	t.last = infos
+	return m, nil
+}
+
+// CollectProcDescendants returns the pids of all the descendants of the
+// process identified by pid, sorted in ascending order.
+func CollectProcDescendants(pid int) []int {
+	parents := collectProcParents()
+	children := make(map[int][]int, len(parents))
+
+	for child, parent := range parents {
+		if child != parent {
+			children[parent] = append(children[parent], child)
+		}
+	}
+
+	var descendants []int
+
+	for queue := children[pid]; len(queue) != 0; {
+		child := queue[0]
+		queue = append(queue[1:], children[child]...)
+		descendants = append(descendants, child)
+	}
+
+	sort.Ints(descendants)
+	return descendants
+}
@@ -0,0 +1,6 @@
+package procstats
+
+func collectProcParents() map[int]int {
+	// TODO
+	return nil
+}
@@ -0,0 +1,27 @@
+package procstats
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+func collectProcParents() map[int]int {
+	b, err := unix.SysctlRaw("kern.proc.proc")
+	if err != nil {
+		return nil
+	}
+
+	size := int(unsafe.Sizeof(kinfoProc{}))
+	parents := make(map[int]int, len(b)/size)
+
+	for ; len(b) >= size; b = b[size:] {
+		proc := (*kinfoProc)(unsafe.Pointer(&b[0]))
+		if int(proc.Structsize) != size {
+			return nil
+		}
+		parents[int(proc.Pid)] = int(proc.Ppid)
+	}
+
+	return parents
+}
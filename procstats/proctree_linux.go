@@ -0,0 +1,8 @@
+package procstats
+
+import "github.com/segmentio/stats/procstats/linux"
+
+func collectProcParents() map[int]int {
+	parents, _ := linux.ReadProcParents()
+	return parents
+}
@@ -0,0 +1,18 @@
+package procstats
+
+func collectProcParents() (parents map[int]int) {
+	defer func() {
+		if recover() != nil {
+			parents = nil
+		}
+	}()
+
+	procs := kinfoProcs(kernProcAll, 0)
+	parents = make(map[int]int, len(procs))
+
+	for _, proc := range procs {
+		parents[int(proc.Pid)] = int(proc.Ppid)
+	}
+
+	return parents
+}
@@ -0,0 +1,53 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestProcTreeMetrics(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Skip("cannot start a child process:", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	found := false
+	for _, pid := range CollectProcDescendants(os.Getpid()) {
+		found = found || pid == cmd.Process.Pid
+	}
+	if !found {
+		t.Skip("process descendants are not available")
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	proc := NewProcTreeMetricsWith(e, os.Getpid())
+	proc.Collect()
+	proc.Collect()
+
+	self, err := CollectProcInfo(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if proc.last.Threads.Num <= self.Threads.Num {
+		t.Errorf("the threads of the child process were not aggregated: %d <= %d", proc.last.Threads.Num, self.Threads.Num)
+	}
+
+	if proc.last.Files.Open <= self.Files.Open {
+		t.Errorf("the files of the child process were not aggregated: %d <= %d", proc.last.Files.Open, self.Files.Open)
+	}
+
+	if len(h.Measures()) == 0 {
+		t.Error("no measures were reported by the stats collector")
+	}
+}
@@ -0,0 +1,26 @@
+package procstats
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func collectProcParents() map[int]int {
+	r, _, _ := procCreateToolhelp32Snapshot.Call(th32csSnapProcess, 0)
+	if syscall.Handle(r) == syscall.InvalidHandle {
+		return nil
+	}
+
+	snapshot := syscall.Handle(r)
+	defer syscall.CloseHandle(snapshot)
+
+	parents := make(map[int]int)
+	entry := processEntry32{}
+	entry.Size = uint32(unsafe.Sizeof(entry))
+
+	for err := call(procProcess32FirstW, uintptr(snapshot), uintptr(unsafe.Pointer(&entry))); err == nil; err = call(procProcess32NextW, uintptr(snapshot), uintptr(unsafe.Pointer(&entry))) {
+		parents[int(entry.ProcessID)] = int(entry.ParentProcessID)
+	}
+
+	return parents
+}
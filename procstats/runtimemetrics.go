@@ -0,0 +1,173 @@
+package procstats
+
+import (
+	"math"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// RuntimeMetrics is a metric collector that reports the metrics exposed by the
+// runtime/metrics package.
+//
+// Metric names are converted to measure and field names by replacing slashes
+// with dots and appending the unit, for example "/gc/heap/allocs:bytes" is
+// reported as "go.gc.heap:allocs.bytes". Cumulative metrics are reported as
+// counters, others as gauges. Histograms (like "/sched/latencies:seconds")
+// are reported as the p50, p90, p99, and max gauges of the distribution of
+// values observed since the previous collection.
+type RuntimeMetrics struct {
+	engine  *stats.Engine
+	samples []metrics.Sample
+	metrics []runtimeMetric
+}
+
+type runtimeMetric struct {
+	name       string
+	cumulative bool
+	lastUint   uint64
+	lastFloat  float64
+	lastCounts []uint64
+	counts     []uint64
+}
+
+// NewRuntimeMetrics collects the runtime metrics listed in names and reports
+// them to the default stats engine, all metrics supported by the runtime are
+// collected if names is empty.
+func NewRuntimeMetrics(names ...string) *RuntimeMetrics {
+	return NewRuntimeMetricsWith(stats.DefaultEngine, names...)
+}
+
+// NewRuntimeMetricsWith collects the runtime metrics listed in names and
+// reports them to eng, all metrics supported by the runtime are collected if
+// names is empty. Names that the runtime does not support are ignored.
+func NewRuntimeMetricsWith(eng *stats.Engine, names ...string) *RuntimeMetrics {
+	r := &RuntimeMetrics{engine: eng}
+	all := metrics.All()
+
+	for _, desc := range all {
+		if len(names) != 0 && !containsString(names, desc.Name) {
+			continue
+		}
+		r.samples = append(r.samples, metrics.Sample{Name: desc.Name})
+		r.metrics = append(r.metrics, runtimeMetric{
+			name:       runtimeMetricName(desc.Name),
+			cumulative: desc.Cumulative,
+		})
+	}
+
+	return r
+}
+
+// Collect satisfies the Collector interface.
+func (r *RuntimeMetrics) Collect() {
+	now := time.Now()
+	metrics.Read(r.samples)
+
+	for i := range r.samples {
+		m, v := &r.metrics[i], r.samples[i].Value
+
+		switch v.Kind() {
+		case metrics.KindUint64:
+			if value := v.Uint64(); m.cumulative {
+				r.engine.AddAt(now, m.name, value-m.lastUint)
+				m.lastUint = value
+			} else {
+				r.engine.SetAt(now, m.name, value)
+			}
+
+		case metrics.KindFloat64:
+			if value := v.Float64(); m.cumulative {
+				r.engine.AddAt(now, m.name, value-m.lastFloat)
+				m.lastFloat = value
+			} else {
+				r.engine.SetAt(now, m.name, value)
+			}
+
+		case metrics.KindFloat64Histogram:
+			r.reportHistogram(now, m, v.Float64Histogram())
+		}
+	}
+}
+
+func (r *RuntimeMetrics) reportHistogram(now time.Time, m *runtimeMetric, h *metrics.Float64Histogram) {
+	// Histograms are cumulative, the distribution of the values observed
+	// since the last collection is the difference of the bucket counts.
+	if len(m.lastCounts) != len(h.Counts) {
+		m.lastCounts = make([]uint64, len(h.Counts))
+		m.counts = make([]uint64, len(h.Counts))
+	}
+
+	total := uint64(0)
+	for i, count := range h.Counts {
+		m.counts[i] = count - m.lastCounts[i]
+		total += m.counts[i]
+	}
+	copy(m.lastCounts, h.Counts)
+
+	r.engine.SetAt(now, m.name+".p50", histogramQuantile(h.Buckets, m.counts, total, 0.50))
+	r.engine.SetAt(now, m.name+".p90", histogramQuantile(h.Buckets, m.counts, total, 0.90))
+	r.engine.SetAt(now, m.name+".p99", histogramQuantile(h.Buckets, m.counts, total, 0.99))
+	r.engine.SetAt(now, m.name+".max", histogramQuantile(h.Buckets, m.counts, total, 1))
+}
+
+// histogramQuantile returns the upper bound of the bucket that contains the q
+// quantile of the values counted in counts, or the lower bound when the
+// bucket is unbounded.
+func histogramQuantile(buckets []float64, counts []uint64, total uint64, q float64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	sum := uint64(0)
+	for i, count := range counts {
+		if sum += count; sum >= rank {
+			if upper := buckets[i+1]; !math.IsInf(upper, +1) {
+				return upper
+			}
+			return buckets[i]
+		}
+	}
+
+	return 0
+}
+
+// runtimeMetricName converts a runtime metric name to a measure and field name,
+// for example "/sched/latencies:seconds" becomes "go.sched:latencies.seconds".
+func runtimeMetricName(name string) string {
+	path, unit := name, ""
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		path, unit = name[:i], name[i+1:]
+	}
+
+	dir, base := "", path
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		dir, base = path[:i], path[i+1:]
+	}
+
+	replacer := strings.NewReplacer("/", ".", "-", "_", ":", "_", "*", "_")
+	measure := "go" + replacer.Replace(dir)
+	field := replacer.Replace(base)
+
+	if unit != "" {
+		field += "." + replacer.Replace(unit)
+	}
+
+	return measure + ":" + field
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestRuntimeMetrics(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	r := NewRuntimeMetricsWith(e,
+		"/gc/cycles/total:gc-cycles",
+		"/sched/goroutines:goroutines",
+		"/sched/latencies:seconds",
+		"/not/a/metric:bytes",
+	)
+
+	r.Collect()
+	runtime.GC()
+	h.Clear()
+	r.Collect()
+
+	found := map[string]bool{}
+
+	for _, m := range h.Measures() {
+		found[m.Name+":"+m.Fields[0].Name] = true
+
+		if m.Name == "go.gc.cycles" && m.Fields[0].Value.Uint() == 0 {
+			t.Errorf("the garbage collection was not counted: %v", m)
+		}
+	}
+
+	for _, name := range []string{
+		"go.gc.cycles:total.gc_cycles",
+		"go.sched:goroutines.goroutines",
+		"go.sched:latencies.seconds.p50",
+		"go.sched:latencies.seconds.p90",
+		"go.sched:latencies.seconds.p99",
+		"go.sched:latencies.seconds.max",
+	} {
+		if !found[name] {
+			t.Errorf("%s was not reported: %v", name, h.Measures())
+		}
+	}
+}
+
+func TestRuntimeMetricName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"/gc/heap/allocs:bytes":                            "go.gc.heap:allocs.bytes",
+		"/cpu/classes/gc/total:cpu-seconds":                "go.cpu.classes.gc:total.cpu_seconds",
+		"/godebug/non-default-behavior/http2client:events": "go.godebug.non_default_behavior:http2client.events",
+	} {
+		if s := runtimeMetricName(name); s != expected {
+			t.Errorf("bad name for %s: %s != %s", name, s, expected)
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	buckets := []float64{0, 1, 2, 4, 8}
+	counts := []uint64{5, 3, 1, 1}
+
+	for _, test := range []struct {
+		q float64
+		v float64
+	}{
+		{q: 0.50, v: 1},
+		{q: 0.80, v: 2},
+		{q: 0.90, v: 4},
+		{q: 1, v: 8},
+	} {
+		if v := histogramQuantile(buckets, counts, 10, test.q); v != test.v {
+			t.Errorf("bad quantile %g: %g != %g", test.q, v, test.v)
+		}
+	}
+}
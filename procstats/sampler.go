@@ -0,0 +1,193 @@
+package procstats
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// A Sampler owns the loop that periodically runs a set of collectors.
+//
+// Collectors that panic do not bring down the program, the panic is converted
+// to an error and passed to the sampler's error handler, which by default
+// counts errors on the engine as "procstats:errors.count" tagged with the type
+// of the collector that failed.
+//
+// The type is named Sampler rather than Collector because Collector is the
+// interface implemented by the individual collectors.
+type Sampler struct {
+	engine       *stats.Engine
+	interval     time.Duration
+	collectors   []Collector
+	errorHandler func(Collector, error)
+	kubernetes   bool
+
+	mutex sync.Mutex
+	stop  chan struct{}
+	join  chan struct{}
+}
+
+// A SamplerOption configures a Sampler.
+type SamplerOption func(*Sampler)
+
+// WithInterval sets the time between two runs of the collectors, the default
+// is 15 seconds.
+func WithInterval(interval time.Duration) SamplerOption {
+	return func(s *Sampler) { s.interval = interval }
+}
+
+// WithCollectors sets the collectors run by the sampler. When none are set the
+// sampler runs the process and Go runtime collectors of the current process.
+func WithCollectors(collectors ...Collector) SamplerOption {
+	return func(s *Sampler) { s.collectors = append(s.collectors, collectors...) }
+}
+
+// WithErrorHandler sets the function called when a collector fails.
+func WithErrorHandler(handler func(Collector, error)) SamplerOption {
+	return func(s *Sampler) { s.errorHandler = handler }
+}
+
+// WithKubernetesTags enables or disables tagging the metrics of the default
+// collectors with the pod, namespace, node, and container names when the
+// program runs in kubernetes, the default is enabled.
+//
+// The tags are not applied to collectors set with WithCollectors, which report
+// to the engine they were created with. Programs can use DetectKubernetes to
+// tag these engines.
+func WithKubernetesTags(enable bool) SamplerOption {
+	return func(s *Sampler) { s.kubernetes = enable }
+}
+
+// NewSampler creates a sampler which reports to eng, configured with options.
+// The sampler does nothing until its Start method is called.
+func NewSampler(eng *stats.Engine, options ...SamplerOption) *Sampler {
+	if eng == nil {
+		eng = stats.DefaultEngine
+	}
+
+	s := &Sampler{engine: eng, kubernetes: true}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.kubernetes {
+		if k8s, ok := DetectKubernetes(); ok {
+			eng = eng.WithTags(k8s.Tags()...)
+			s.engine = eng
+		}
+	}
+
+	if s.interval == 0 {
+		s.interval = 15 * time.Second
+	}
+
+	if s.collectors == nil {
+		s.collectors = []Collector{
+			NewProcMetricsWith(eng, os.Getpid()),
+			NewGoMetricsWith(eng),
+		}
+	}
+
+	if s.errorHandler == nil {
+		s.errorHandler = s.countError
+	}
+
+	return s
+}
+
+// Start launches the sampling loop in a background goroutine, the collectors
+// are run once immediately. Calling Start on a sampler that is already
+// running has no effect.
+func (s *Sampler) Start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.join = make(chan struct{})
+	go s.run(s.stop, s.join)
+}
+
+// Stop terminates the sampling loop and waits for the running collection to
+// complete. The sampler can be restarted after being stopped.
+func (s *Sampler) Stop() {
+	s.mutex.Lock()
+	stop, join := s.stop, s.join
+	s.stop, s.join = nil, nil
+	s.mutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-join
+	}
+}
+
+// Close satisfies the io.Closer interface, it calls Stop and always returns a
+// nil error.
+func (s *Sampler) Close() error {
+	s.Stop()
+	return nil
+}
+
+// Collect runs all the collectors of the sampler once, it satisfies the
+// Collector interface.
+func (s *Sampler) Collect() {
+	for _, c := range s.collectors {
+		if err := collect(c); err != nil {
+			s.errorHandler(c, err)
+		}
+	}
+}
+
+func (s *Sampler) run(stop <-chan struct{}, join chan<- struct{}) {
+	// Locks the OS thread, stats collection heavily relies on blocking
+	// syscalls, letting other goroutines execute on the same thread
+	// increases the chance for the Go runtime to detected that the thread
+	// is blocked and schedule a new one.
+	runtime.LockOSThread()
+
+	defer runtime.UnlockOSThread()
+	defer close(join)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Collect()
+	for {
+		select {
+		case <-ticker.C:
+			s.Collect()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *Sampler) countError(c Collector, err error) {
+	s.engine.Incr("procstats:errors.count", stats.T("collector", collectorName(c)))
+}
+
+func collect(c Collector) (err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	c.Collect()
+	return
+}
+
+func collectorName(c Collector) string {
+	t := reflect.TypeOf(c)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if name := t.Name(); name != "" {
+		return name
+	}
+	return t.String()
+}
@@ -0,0 +1,97 @@
+package procstats
+
+import (
+	"os"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+)
+
+// SmapsMetrics is a metric collector that reports the proportional (PSS) and
+// unique (USS) set sizes of a process, which attribute memory more accurately
+// than the resident set size when pages are shared between processes, as well
+// as the amount of its memory backed by transparent huge pages.
+//
+// The values are read from /proc/<pid>/smaps_rollup, or /proc/<pid>/smaps on
+// kernels where the former does not exist. Because the kernel has to walk the
+// page tables of the process to produce them, the files are read at most once
+// every MinInterval; the last values are reported by the collections that
+// happen in between.
+type SmapsMetrics struct {
+	// Minimum amount of time between two reads of the smaps files, defaults
+	// to one minute.
+	MinInterval time.Duration
+
+	engine   *stats.Engine
+	pid      int
+	memory   smapsMemory `metric:"memory"`
+	lastTime time.Time
+	noRollup bool
+	ok       bool
+}
+
+type smapsMemory struct {
+	pss struct { // proportional set size
+		usage uint64 `metric:"usage.bytes" type:"gauge"`
+		typ   string `tag:"type"` // pss
+	}
+	uss struct { // unique set size
+		usage uint64 `metric:"usage.bytes" type:"gauge"`
+		typ   string `tag:"type"` // uss
+	}
+	anonHuge struct { // anonymous memory backed by transparent huge pages
+		usage uint64 `metric:"usage.bytes" type:"gauge"`
+		typ   string `tag:"type"` // anon_huge
+	}
+	swapPss struct { // proportional swap usage
+		usage uint64 `metric:"usage.bytes" type:"gauge"`
+		typ   string `tag:"type"` // swap_pss
+	}
+}
+
+// NewSmapsMetrics collects the proportional and unique set sizes of the
+// current process and reports them to the default stats engine.
+func NewSmapsMetrics() *SmapsMetrics {
+	return NewSmapsMetricsWith(stats.DefaultEngine, os.Getpid())
+}
+
+// NewSmapsMetricsWith collects the proportional and unique set sizes of the
+// process identified by pid and reports them to eng.
+func NewSmapsMetricsWith(eng *stats.Engine, pid int) *SmapsMetrics {
+	s := &SmapsMetrics{MinInterval: time.Minute, engine: eng, pid: pid}
+	s.memory.pss.typ = "pss"
+	s.memory.uss.typ = "uss"
+	s.memory.anonHuge.typ = "anon_huge"
+	s.memory.swapPss.typ = "swap_pss"
+	return s
+}
+
+// Collect satisfies the Collector interface.
+func (s *SmapsMetrics) Collect() {
+	if now := time.Now(); now.Sub(s.lastTime) >= s.MinInterval {
+		if smaps, err := s.read(); err == nil {
+			s.memory.pss.usage = smaps.Pss
+			s.memory.uss.usage = smaps.Uss()
+			s.memory.anonHuge.usage = smaps.AnonHugePages
+			s.memory.swapPss.usage = smaps.SwapPss
+			s.ok = true
+		}
+		s.lastTime = now
+	}
+
+	if s.ok {
+		s.engine.Report(s)
+	}
+}
+
+func (s *SmapsMetrics) read() (linux.ProcSmaps, error) {
+	if !s.noRollup {
+		smaps, err := linux.ReadProcSmapsRollup(s.pid)
+		if err == nil || !os.IsNotExist(err) {
+			return smaps, err
+		}
+		s.noRollup = true
+	}
+	return linux.ReadProcSmaps(s.pid)
+}
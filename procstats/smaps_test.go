@@ -0,0 +1,47 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"os"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestSmapsMetrics(t *testing.T) {
+	if _, err := linux.ReadProcSmaps(os.Getpid()); err != nil {
+		t.Skip("smaps are not available:", err)
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	smaps := NewSmapsMetricsWith(e, os.Getpid())
+	smaps.Collect()
+	first := smaps.lastTime
+
+	// The second collection happens within the minimum interval, it must not
+	// read the smaps again but still report the values.
+	smaps.Collect()
+
+	if smaps.lastTime != first {
+		t.Error("smaps were read twice within the minimum interval")
+	}
+
+	measures := h.Measures()
+	if len(measures) != 8 {
+		t.Fatalf("bad number of measures: %v", measures)
+	}
+
+	for _, m := range measures {
+		if m.Name != "memory" || len(m.Fields) != 1 || m.Fields[0].Name != "usage.bytes" {
+			t.Errorf("bad measure: %v", m)
+		}
+		if m.Tags[0] == stats.T("type", "pss") && m.Fields[0].Value.Uint() == 0 {
+			t.Error("the proportional set size was not reported")
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package procstats
+
+import (
+	"os"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+)
+
+// PortRange represents a named range of local ports, used to tag the sockets
+// reported by SocketMetrics.
+type PortRange struct {
+	Name string
+	Min  uint16
+	Max  uint16
+}
+
+// SocketMetrics is a metric collector that reports the number of TCP and UDP
+// sockets of the network namespace that a process belongs to, tagged by
+// protocol, state, and range of the local port, as well as the number of
+// connections dropped because of listen queue overflows.
+type SocketMetrics struct {
+	engine  *stats.Engine
+	pid     int
+	ranges  []PortRange
+	sockets map[socketKey]*socketGroup
+	listen  socketListen `metric:"tcp"`
+	last    linux.NetStat
+}
+
+type socketKey struct {
+	protocol string
+	state    linux.SocketState
+	port     string
+}
+
+type socketGroup struct {
+	sockets struct {
+		count uint64 `metric:"count" type:"gauge"`
+	} `metric:"socket"`
+	protocol string `tag:"protocol"` // tcp | udp
+	state    string `tag:"state"`
+	port     string `tag:"port"`
+}
+
+type socketListen struct {
+	overflows uint64 `metric:"listen_overflows.count" type:"counter"` // connections dropped because the accept queue was full
+	drops     uint64 `metric:"listen_drops.count"     type:"counter"` // connections dropped for any reason while in the listen state
+}
+
+// NewSocketMetrics collects socket metrics of the network namespace of the
+// current process and reports them to the default stats engine.
+func NewSocketMetrics() *SocketMetrics {
+	return NewSocketMetricsWith(stats.DefaultEngine, os.Getpid())
+}
+
+// NewSocketMetricsWith collects socket metrics of the network namespace of the
+// process identified by pid and reports them to eng.
+//
+// Sockets are tagged with the name of the first range that their local port
+// belongs to, or "other" if it doesn't belong to any. When no ranges are
+// given, the ports are split between "system" (below 1024), "ephemeral"
+// (the range of /proc/sys/net/ipv4/ip_local_port_range), and "user".
+func NewSocketMetricsWith(eng *stats.Engine, pid int, ranges ...PortRange) *SocketMetrics {
+	if len(ranges) == 0 {
+		ranges = defaultPortRanges()
+	}
+	return &SocketMetrics{
+		engine:  eng,
+		pid:     pid,
+		ranges:  ranges,
+		sockets: make(map[socketKey]*socketGroup),
+	}
+}
+
+func defaultPortRanges() []PortRange {
+	min, max, err := linux.ReadLocalPortRange()
+	if err != nil {
+		min, max = 32768, 60999 // linux default
+	}
+	return []PortRange{
+		{Name: "system", Min: 0, Max: 1023},
+		{Name: "ephemeral", Min: min, Max: max},
+		{Name: "user", Min: 1024, Max: 65535},
+	}
+}
+
+// Collect satisfies the Collector interface.
+func (s *SocketMetrics) Collect() {
+	for _, group := range s.sockets {
+		group.sockets.count = 0
+	}
+
+	for _, file := range [...]struct{ name, protocol string }{
+		{"tcp", "tcp"},
+		{"tcp6", "tcp"},
+		{"udp", "udp"},
+		{"udp6", "udp"},
+	} {
+		sockets, err := linux.ReadNetSockets(s.pid, file.name)
+		if err != nil {
+			continue
+		}
+
+		for _, socket := range sockets {
+			key := socketKey{
+				protocol: file.protocol,
+				state:    socket.State,
+				port:     s.portRange(socket.LocalPort),
+			}
+
+			group := s.sockets[key]
+			if group == nil {
+				group = &socketGroup{
+					protocol: key.protocol,
+					state:    key.state.String(),
+					port:     key.port,
+				}
+				s.sockets[key] = group
+			}
+
+			group.sockets.count++
+		}
+	}
+
+	// Groups that have no sockets are reported one last time so the gauges
+	// drop to zero, then forgotten.
+	for key, group := range s.sockets {
+		s.engine.Report(group)
+
+		if group.sockets.count == 0 {
+			delete(s.sockets, key)
+		}
+	}
+
+	if stat, err := linux.ReadNetStat(s.pid); err == nil {
+		// The first collection only initializes the baseline of the counters.
+		if s.last != nil {
+			s.listen.overflows = uint64(stat["TcpExt.ListenOverflows"] - s.last["TcpExt.ListenOverflows"])
+			s.listen.drops = uint64(stat["TcpExt.ListenDrops"] - s.last["TcpExt.ListenDrops"])
+			s.engine.Report(s)
+		}
+		s.last = stat
+	}
+}
+
+func (s *SocketMetrics) portRange(port uint16) string {
+	for _, r := range s.ranges {
+		if port >= r.Min && port <= r.Max {
+			return r.Name
+		}
+	}
+	return "other"
+}
@@ -0,0 +1,49 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestSocketMetrics(t *testing.T) {
+	if _, err := linux.ReadNetSockets(os.Getpid(), "tcp"); err != nil {
+		t.Skip("socket statistics are not available:", err)
+	}
+
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	port := uint16(l.Addr().(*net.TCPAddr).Port)
+	sockets := NewSocketMetricsWith(e, os.Getpid(), PortRange{Name: "test", Min: port, Max: port})
+	sockets.Collect()
+
+	found := false
+
+	for _, m := range h.Measures() {
+		if m.Name != "socket" {
+			continue
+		}
+		if m.Tags[1] == stats.T("protocol", "tcp") &&
+			m.Tags[2] == stats.T("state", "listen") &&
+			m.Tags[0] == stats.T("port", "test") {
+			found = m.Fields[0].Value.Uint() == 1
+		}
+	}
+
+	if !found {
+		t.Errorf("the listening socket was not reported: %v", h.Measures())
+	}
+}
@@ -0,0 +1,59 @@
+package procstats
+
+import (
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+)
+
+// SystemMetrics is a metric collector that reports system-wide interrupts,
+// context switches, and process creations, which help diagnose syscall-heavy
+// workloads or noisy neighbors.
+type SystemMetrics struct {
+	engine *stats.Engine
+	system systemCounters `metric:"system"`
+	last   linux.SystemStat
+	ok     bool
+}
+
+type systemCounters struct {
+	interrupts uint64 `metric:"interrupts.count"       type:"counter"`
+	softirqs   uint64 `metric:"softirqs.count"         type:"counter"`
+	switches   uint64 `metric:"context_switches.count" type:"counter"`
+	forks      uint64 `metric:"forks.count"            type:"counter"`
+	running    uint64 `metric:"procs_running.count"    type:"gauge"`
+	blocked    uint64 `metric:"procs_blocked.count"    type:"gauge"` // processes waiting for I/O
+}
+
+// NewSystemMetrics collects system-wide interrupts and context switches and
+// reports them to the default stats engine.
+func NewSystemMetrics() *SystemMetrics {
+	return NewSystemMetricsWith(stats.DefaultEngine)
+}
+
+// NewSystemMetricsWith collects system-wide interrupts and context switches
+// and reports them to eng.
+func NewSystemMetricsWith(eng *stats.Engine) *SystemMetrics {
+	return &SystemMetrics{engine: eng}
+}
+
+// Collect satisfies the Collector interface.
+func (s *SystemMetrics) Collect() {
+	stat, err := linux.ReadSystemStat()
+	if err != nil {
+		return
+	}
+
+	s.system.interrupts = stat.Interrupts - s.last.Interrupts
+	s.system.softirqs = stat.SoftIRQs - s.last.SoftIRQs
+	s.system.switches = stat.ContextSwitches - s.last.ContextSwitches
+	s.system.forks = stat.Processes - s.last.Processes
+	s.system.running = stat.ProcsRunning
+	s.system.blocked = stat.ProcsBlocked
+	s.last = stat
+
+	// The first collection only initializes the baseline of the counters.
+	if s.ok {
+		s.engine.Report(s)
+	}
+	s.ok = true
+}
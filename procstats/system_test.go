@@ -0,0 +1,38 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestSystemMetrics(t *testing.T) {
+	if _, err := linux.ReadSystemStat(); err != nil {
+		t.Skip("system statistics are not available:", err)
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	system := NewSystemMetricsWith(e)
+	system.Collect()
+
+	if n := len(h.Measures()); n != 0 {
+		t.Error("measures were reported by the first collection:", n)
+	}
+
+	system.Collect()
+
+	measures := h.Measures()
+	if len(measures) != 1 {
+		t.Fatalf("bad number of measures: %v", measures)
+	}
+
+	if m := measures[0]; m.Name != "system" || len(m.Fields) != 6 {
+		t.Errorf("bad measure: %v", m)
+	}
+}
@@ -0,0 +1,124 @@
+package procstats
+
+import (
+	"os"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// ThreadMetrics is a metric collector that reports the CPU time used by the
+// threads of a process, aggregated by thread name.
+//
+// The collector is not part of ProcMetrics because processes may run a large
+// number of threads, it is meant to be enabled explicitly when CPU usage needs
+// to be attributed to cgo code, the garbage collector, or OS threads created
+// by the program.
+type ThreadMetrics struct {
+	engine  *stats.Engine
+	pid     int
+	threads map[string]*threadCPU
+	last    map[int]ThreadCPUInfo
+	ok      bool
+}
+
+type threadCPU struct {
+	user struct {
+		time time.Duration `metric:"cpu.seconds" type:"counter"`
+		typ  string        `tag:"type"` // user
+	} `metric:"thread"`
+	system struct {
+		time time.Duration `metric:"cpu.seconds" type:"counter"`
+		typ  string        `tag:"type"` // system
+	} `metric:"thread"`
+	threads struct {
+		count uint64 `metric:"count" type:"gauge"` // number of threads with this name
+	} `metric:"thread"`
+	name string `tag:"name"`
+}
+
+// NewThreadMetrics collects the CPU time used by the threads of the current
+// process and reports it to the default stats engine.
+func NewThreadMetrics() *ThreadMetrics {
+	return NewThreadMetricsWith(stats.DefaultEngine, os.Getpid())
+}
+
+// NewThreadMetricsWith collects the CPU time used by the threads of the
+// process identified by pid and reports it to eng.
+func NewThreadMetricsWith(eng *stats.Engine, pid int) *ThreadMetrics {
+	return &ThreadMetrics{
+		engine:  eng,
+		pid:     pid,
+		threads: make(map[string]*threadCPU),
+		last:    make(map[int]ThreadCPUInfo),
+	}
+}
+
+// Collect satisfies the Collector interface.
+func (t *ThreadMetrics) Collect() {
+	info, err := CollectThreadCPUInfo(t.pid)
+	if err != nil {
+		return
+	}
+
+	for _, thread := range t.threads {
+		thread.user.time = 0
+		thread.system.time = 0
+		thread.threads.count = 0
+	}
+
+	last := make(map[int]ThreadCPUInfo, len(info))
+
+	for _, i := range info {
+		thread := t.threads[i.Name]
+
+		if thread == nil {
+			thread = &threadCPU{name: i.Name}
+			thread.user.typ = "user"
+			thread.system.typ = "system"
+			t.threads[i.Name] = thread
+		}
+
+		// Threads that were not seen on the previous collection were created
+		// since, all their CPU time was used during the interval.
+		prev := t.last[i.ID]
+		if prev.Name != i.Name {
+			prev = ThreadCPUInfo{}
+		}
+
+		thread.user.time += i.User - prev.User
+		thread.system.time += i.Sys - prev.Sys
+		thread.threads.count++
+		last[i.ID] = i
+	}
+
+	t.last = last
+
+	for name, thread := range t.threads {
+		if thread.threads.count == 0 {
+			delete(t.threads, name)
+		} else if t.ok {
+			// The first collection only initializes the baseline of the
+			// counters.
+			t.engine.Report(thread)
+		}
+	}
+
+	t.ok = true
+}
+
+// ThreadCPUInfo represents the CPU time used by a thread.
+type ThreadCPUInfo struct {
+	ID   int           // thread id
+	Name string        // thread name
+	User time.Duration // user cpu time used by the thread
+	Sys  time.Duration // system cpu time used by the thread
+}
+
+// CollectThreadCPUInfo returns the CPU time used by each thread of the process
+// identified by pid.
+func CollectThreadCPUInfo(pid int) (info []ThreadCPUInfo, err error) {
+	defer func() { err = convertPanicToError(recover()) }()
+	info = collectThreadCPUInfo(pid)
+	return
+}
@@ -0,0 +1,8 @@
+//go:build freebsd || openbsd
+
+package procstats
+
+func collectThreadCPUInfo(pid int) []ThreadCPUInfo {
+	// TODO
+	return nil
+}
@@ -0,0 +1,6 @@
+package procstats
+
+func collectThreadCPUInfo(pid int) []ThreadCPUInfo {
+	// TODO
+	return nil
+}
@@ -0,0 +1,21 @@
+package procstats
+
+import "github.com/segmentio/stats/procstats/linux"
+
+func collectThreadCPUInfo(pid int) []ThreadCPUInfo {
+	tasks, err := linux.ReadProcTaskStats(pid)
+	check(err)
+
+	info := make([]ThreadCPUInfo, len(tasks))
+
+	for i, task := range tasks {
+		info[i] = ThreadCPUInfo{
+			ID:   task.Tid,
+			Name: task.Comm,
+			User: clockTicksToDuration(task.Utime),
+			Sys:  clockTicksToDuration(task.Stime),
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,51 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestThreadMetrics(t *testing.T) {
+	if info, err := CollectThreadCPUInfo(os.Getpid()); err != nil || len(info) == 0 {
+		t.Skip("thread statistics are not available:", err)
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	threads := NewThreadMetricsWith(e, os.Getpid())
+	threads.Collect()
+
+	if n := len(h.Measures()); n != 0 {
+		t.Error("measures were reported by the first collection:", n)
+	}
+
+	// Burn some CPU so the counters move.
+	for i := 0; i != 1e6; i++ {
+		runtime.Gosched()
+	}
+
+	threads.Collect()
+
+	var count uint64
+
+	for _, m := range h.Measures() {
+		if m.Name != "thread" || len(m.Fields) != 1 {
+			t.Errorf("bad measure: %v", m)
+			continue
+		}
+		if m.Fields[0].Name == "count" {
+			count += m.Fields[0].Value.Uint()
+		}
+	}
+
+	if count == 0 {
+		t.Error("no threads were reported")
+	}
+}
@@ -0,0 +1,128 @@
+package procstats
+
+import (
+	"os"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+)
+
+// VMStatMetrics is a metric collector that reports host-level memory metrics
+// read from /proc/meminfo and /proc/vmstat, which help detect memory pressure
+// on the host that affects the processes running on it.
+type VMStatMetrics struct {
+	engine *stats.Engine
+	vm     vmMetrics `metric:"vm"`
+	last   linux.VMStat
+}
+
+type vmMetrics struct {
+	// Memory
+	total     uint64 `metric:"memory_total.bytes"     type:"gauge"` // usable RAM
+	available uint64 `metric:"memory_available.bytes" type:"gauge"` // RAM available to start new applications without swapping
+	free      uint64 `metric:"memory_free.bytes"      type:"gauge"` // RAM left unused
+	dirty     uint64 `metric:"dirty.bytes"            type:"gauge"` // memory waiting to be written back to disk
+	writeback uint64 `metric:"writeback.bytes"        type:"gauge"` // memory actively being written back to disk
+	swapTotal uint64 `metric:"swap_total.bytes"       type:"gauge"` // swap space available
+	swapFree  uint64 `metric:"swap_free.bytes"        type:"gauge"` // swap space left unused
+
+	// Page faults
+	pagefault struct {
+		major struct {
+			count uint64 `metric:"count" type:"counter"`
+			typ   string `tag:"type"` // major
+		}
+		minor struct {
+			count uint64 `metric:"count" type:"counter"`
+			typ   string `tag:"type"` // minor
+		}
+	} `metric:"pagefault"`
+
+	// Page reclaim, by kswapd in the background or directly by allocating
+	// processes
+	reclaim struct {
+		kswapd struct {
+			scan  uint64 `metric:"scan.count"  type:"counter"` // pages scanned
+			steal uint64 `metric:"steal.count" type:"counter"` // pages reclaimed
+			typ   string `tag:"type"`                          // kswapd
+		}
+		direct struct {
+			scan  uint64 `metric:"scan.count"  type:"counter"` // pages scanned
+			steal uint64 `metric:"steal.count" type:"counter"` // pages reclaimed
+			typ   string `tag:"type"`                          // direct
+		}
+	} `metric:"reclaim"`
+
+	// Swap
+	swap struct {
+		in struct {
+			bytes uint64 `metric:"bytes" type:"counter"`
+			dir   string `tag:"direction"` // in
+		}
+		out struct {
+			bytes uint64 `metric:"bytes" type:"counter"`
+			dir   string `tag:"direction"` // out
+		}
+	} `metric:"swap"`
+}
+
+// NewVMStatMetrics collects host-level memory metrics and reports them to the
+// default stats engine.
+func NewVMStatMetrics() *VMStatMetrics {
+	return NewVMStatMetricsWith(stats.DefaultEngine)
+}
+
+// NewVMStatMetricsWith collects host-level memory metrics and reports them to
+// eng.
+func NewVMStatMetricsWith(eng *stats.Engine) *VMStatMetrics {
+	v := &VMStatMetrics{engine: eng}
+	v.vm.pagefault.major.typ = "major"
+	v.vm.pagefault.minor.typ = "minor"
+	v.vm.reclaim.kswapd.typ = "kswapd"
+	v.vm.reclaim.direct.typ = "direct"
+	v.vm.swap.in.dir = "in"
+	v.vm.swap.out.dir = "out"
+	return v
+}
+
+// Collect satisfies the Collector interface.
+func (v *VMStatMetrics) Collect() {
+	info, err := linux.ReadMemInfo()
+	if err != nil {
+		return
+	}
+
+	stat, err := linux.ReadVMStat()
+	if err != nil {
+		return
+	}
+
+	pagesize := uint64(os.Getpagesize())
+	last := v.last
+
+	v.vm.total = info["MemTotal"]
+	v.vm.available = info["MemAvailable"]
+	v.vm.free = info["MemFree"]
+	v.vm.dirty = info["Dirty"]
+	v.vm.writeback = info["Writeback"]
+	v.vm.swapTotal = info["SwapTotal"]
+	v.vm.swapFree = info["SwapFree"]
+
+	v.vm.pagefault.major.count = stat["pgmajfault"] - last["pgmajfault"]
+	v.vm.pagefault.minor.count = (stat["pgfault"] - stat["pgmajfault"]) - (last["pgfault"] - last["pgmajfault"])
+
+	v.vm.reclaim.kswapd.scan = stat.Sum("pgscan_kswapd") - last.Sum("pgscan_kswapd")
+	v.vm.reclaim.kswapd.steal = stat.Sum("pgsteal_kswapd") - last.Sum("pgsteal_kswapd")
+	v.vm.reclaim.direct.scan = stat.Sum("pgscan_direct", "pgscan_direct_throttle") - last.Sum("pgscan_direct", "pgscan_direct_throttle")
+	v.vm.reclaim.direct.steal = stat.Sum("pgsteal_direct") - last.Sum("pgsteal_direct")
+
+	v.vm.swap.in.bytes = pagesize * (stat["pswpin"] - last["pswpin"])
+	v.vm.swap.out.bytes = pagesize * (stat["pswpout"] - last["pswpout"])
+
+	v.last = stat
+
+	// The first collection only initializes the baseline of the counters.
+	if last != nil {
+		v.engine.Report(v)
+	}
+}
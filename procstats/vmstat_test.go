@@ -0,0 +1,41 @@
+//go:build !statsoff
+
+package procstats
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/procstats/linux"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestVMStatMetrics(t *testing.T) {
+	if _, err := linux.ReadVMStat(); err != nil {
+		t.Skip("vmstat is not available:", err)
+	}
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("", h)
+
+	vm := NewVMStatMetricsWith(e)
+	vm.Collect()
+
+	if n := len(h.Measures()); n != 0 {
+		t.Error("measures were reported by the first collection:", n)
+	}
+
+	vm.Collect()
+
+	var total uint64
+
+	for _, m := range h.Measures() {
+		if m.Name == "vm" {
+			total = m.Fields[0].Value.Uint()
+		}
+	}
+
+	if total == 0 {
+		t.Errorf("the total memory was not reported: %v", h.Measures())
+	}
+}
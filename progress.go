@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress tracks the completion of long running operations, like migrations or
+// backfills, which process a known number of items.
+//
+// Until Done is called, a "progress" measure tagged with the name of the
+// operation is reported each time the engine is flushed, with the following
+// fields:
+//
+//	completed     number of items processed
+//	total         number of items to process
+//	percent       completion percentage, between 0 and 100
+//	throughput    average number of items processed per second
+//	eta.seconds   estimated number of seconds until completion
+//
+// The percent and eta.seconds fields are only reported when the total is known
+// (greater than zero), and the ETA once some items were processed.
+//
+// Progress values are safe to use concurrently from multiple goroutines.
+type Progress struct {
+	// Accessed atomically, they are first to be 64 bits aligned on 32 bits
+	// platforms.
+	total     int64
+	completed int64
+
+	eng   *Engine
+	state *engineState
+	start time.Time
+	once  sync.Once
+}
+
+// StartProgress starts tracking the progress of the operation identified by
+// name, which has total items to process. The total may be zero if it is not
+// known yet, see SetTotal.
+func (eng *Engine) StartProgress(name string, total int64, tags ...Tag) *Progress {
+	p := &Progress{
+		eng:   eng.WithTags(append([]Tag{{Name: "job", Value: name}}, tags...)...),
+		start: eng.Now(),
+		total: total,
+	}
+
+	if Enabled {
+		p.state = eng.state()
+		p.state.samplers.add(p)
+	}
+
+	return p
+}
+
+// StartProgress starts tracking the progress of an operation on the default
+// engine.
+func StartProgress(name string, total int64, tags ...Tag) *Progress {
+	return DefaultEngine.StartProgress(name, total, tags...)
+}
+
+// Advance records that n more items were processed.
+func (p *Progress) Advance(n int64) {
+	atomic.AddInt64(&p.completed, n)
+}
+
+// SetTotal sets the number of items to process.
+func (p *Progress) SetTotal(total int64) {
+	atomic.StoreInt64(&p.total, total)
+}
+
+// Completed returns the number of items processed.
+func (p *Progress) Completed() int64 {
+	return atomic.LoadInt64(&p.completed)
+}
+
+// Done reports the final progress of the operation, and stops reporting it
+// when the engine is flushed. Calling Done more than once has no effect.
+func (p *Progress) Done() {
+	p.once.Do(func() {
+		if p.state != nil {
+			p.state.samplers.remove(p)
+		}
+		p.sample(p.eng.Now())
+	})
+}
+
+// sample reports the progress of the operation at time t.
+func (p *Progress) sample(t time.Time) {
+	total := atomic.LoadInt64(&p.total)
+	completed := atomic.LoadInt64(&p.completed)
+	seconds := t.Sub(p.start).Seconds()
+
+	b := p.eng.Batch()
+	b.Set("progress:completed", completed)
+	b.Set("progress:total", total)
+
+	var throughput float64
+	if seconds > 0 {
+		throughput = float64(completed) / seconds
+		b.Set("progress:throughput", throughput)
+	}
+
+	if total > 0 {
+		b.Set("progress:percent", 100*float64(completed)/float64(total))
+
+		if throughput > 0 {
+			remaining := total - completed
+			if remaining < 0 {
+				remaining = 0
+			}
+			b.Set("progress:eta.seconds", float64(remaining)/throughput)
+		}
+	}
+
+	b.CommitAt(t)
+}
@@ -0,0 +1,89 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestProgress(t *testing.T) {
+	h := &statstest.Handler{}
+	clock := newManualClock(time.Date(2017, 6, 4, 22, 12, 0, 0, time.UTC))
+	eng := stats.NewEngine("test", h)
+	eng.ClockSource = clock
+
+	advance := func(d time.Duration) {
+		clock.mutex.Lock()
+		clock.now = clock.now.Add(d)
+		clock.mutex.Unlock()
+	}
+
+	fields := func() map[string]float64 {
+		values := map[string]float64{}
+		for _, m := range h.Measures() {
+			if m.Name != "test.progress" || len(m.Tags) != 1 || m.Tags[0] != stats.T("job", "backfill") {
+				t.Errorf("bad measure: %v", m)
+			}
+			for _, f := range m.Fields {
+				if f.Type() != stats.Gauge {
+					t.Errorf("bad field type: %v", f)
+				}
+				switch f.Value.Type() {
+				case stats.Int:
+					values[f.Name] = float64(f.Value.Int())
+				default:
+					values[f.Name] = f.Value.Float()
+				}
+			}
+		}
+		h.Clear()
+		return values
+	}
+
+	p := eng.StartProgress("backfill", 0)
+	eng.Flush()
+
+	if values := fields(); !reflect.DeepEqual(values, map[string]float64{"completed": 0, "total": 0}) {
+		t.Errorf("bad progress before starting: %v", values)
+	}
+
+	p.SetTotal(100)
+	p.Advance(20)
+	advance(10 * time.Second)
+	eng.Flush()
+
+	expected := map[string]float64{
+		"completed":   20,
+		"total":       100,
+		"percent":     20,
+		"throughput":  2,
+		"eta.seconds": 40,
+	}
+
+	if values := fields(); !reflect.DeepEqual(values, expected) {
+		t.Errorf("bad progress:\nexpected: %v\nfound:    %v", expected, values)
+	}
+
+	p.Advance(80)
+	advance(10 * time.Second)
+	p.Done()
+	p.Done()
+	eng.Flush()
+
+	expected = map[string]float64{
+		"completed":   100,
+		"total":       100,
+		"percent":     100,
+		"throughput":  5,
+		"eta.seconds": 0,
+	}
+
+	if values := fields(); !reflect.DeepEqual(values, expected) {
+		t.Errorf("bad final progress:\nexpected: %v\nfound:    %v", expected, values)
+	}
+}
@@ -3,6 +3,9 @@ package prometheus
 import (
 	"testing"
 	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
 )
 
 var testMetrics = []struct {
@@ -91,3 +94,23 @@ func BenchmarkAppendMetric(b *testing.B) {
 		})
 	}
 }
+
+func TestAppendMetricHostile(t *testing.T) {
+	appendMeasure := func(b []byte, m stats.Measure) []byte {
+		tags := labels(nil).appendTags(m.Tags...)
+
+		for _, f := range m.Fields {
+			b = appendMetric(b, metric{scope: m.Name, name: f.Name, value: valueOf(f.Value), labels: tags})
+		}
+
+		return b
+	}
+
+	// Measures are not sanitized, the prometheus format escapes the special
+	// characters.
+	for _, m := range statstest.HostileMeasures() {
+		if err := statstest.CheckRecords(appendMeasure, m, len(m.Fields)); err != nil {
+			t.Error(err)
+		}
+	}
+}
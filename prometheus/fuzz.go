@@ -0,0 +1,38 @@
+//go:build gofuzz
+
+package prometheus
+
+import (
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+// Fuzz is the entry point for go-fuzz, it verifies that measures built from
+// the fuzzer data cannot inject records in the prometheus exposition format.
+//
+// Unlike the other protocols, metric names, label names, and label values are
+// escaped when they are serialized, so the measures are not sanitized.
+func Fuzz(data []byte) int {
+	m := statstest.FuzzMeasure(data)
+
+	if err := statstest.CheckRecords(appendMeasure, m, len(m.Fields)); err != nil {
+		panic(err)
+	}
+
+	return 1
+}
+
+func appendMeasure(b []byte, m stats.Measure) []byte {
+	tags := labels(nil).appendTags(m.Tags...)
+
+	for _, f := range m.Fields {
+		b = appendMetric(b, metric{
+			scope:  m.Name,
+			name:   f.Name,
+			value:  valueOf(f.Value),
+			labels: tags,
+		})
+	}
+
+	return b
+}
@@ -45,6 +45,17 @@ type Handler struct {
 	// If nil, stats.Buckets is used instead.
 	Buckets stats.HistogramBuckets
 
+	// Units is the registry of metric units used by the handler, if nil
+	// stats.Units is used instead. Following the conventions of Prometheus,
+	// the units of the metrics are appended to their names (for example
+	// "rtt" becomes "rtt_seconds"), unless the names already end with them.
+	Units *stats.UnitRegistry
+
+	// Descriptions is the registry of metric descriptions used by the handler
+	// to produce the "# HELP" lines of the metrics, if nil stats.Descriptions
+	// is used instead.
+	Descriptions *stats.DescriptionRegistry
+
 	opcount uint64
 	metrics metricStore
 }
@@ -76,8 +87,10 @@ func (h *Handler) HandleMeasures(mtime time.Time, measures ...stats.Measure) {
 			h.metrics.update(metric{
 				mtype:  mtype,
 				scope:  scope,
-				name:   f.Name,
+				name:   h.metricName(m.Name, f.Name),
+				help:   h.metricHelp(m.Name, f.Name),
 				value:  valueOf(f.Value),
+				rate:   m.SampleRate,
 				time:   mtime,
 				labels: cache.labels,
 			}, buckets)
@@ -98,6 +111,54 @@ func (h *Handler) HandleMeasures(mtime time.Time, measures ...stats.Measure) {
 	}
 }
 
+// ObserveBucketed satisfies the stats.BucketedHandler interface, the
+// distributions are merged into the histograms exposed by the handler.
+func (h *Handler) ObserveBucketed(mtime time.Time, name string, field string, tags []stats.Tag, dist *stats.Distribution) {
+	cache := handleMetricPool.Get().(*handleMetricCache)
+	cache.labels = cache.labels[:0].appendTags(tags...)
+
+	h.metrics.merge(metric{
+		mtype:  histogram,
+		scope:  h.trimPrefix(name),
+		name:   h.metricName(name, field),
+		help:   h.metricHelp(name, field),
+		time:   mtime,
+		labels: cache.labels,
+	}, dist)
+
+	for i := range cache.labels {
+		cache.labels[i] = label{}
+	}
+
+	handleMetricPool.Put(cache)
+}
+
+func (h *Handler) metricHelp(measure string, field string) string {
+	if h.Descriptions != nil {
+		return h.Descriptions.Help(measure, field)
+	}
+	return stats.Descriptions.Help(measure, field)
+}
+
+func (h *Handler) metricName(measure string, field string) string {
+	units := h.Units
+	if units == nil {
+		units = stats.Units
+	}
+
+	unit := string(units.Lookup(measure, field))
+
+	if len(unit) == 0 || field == unit || strings.HasSuffix(field, "_"+unit) || strings.HasSuffix(field, "."+unit) {
+		return field
+	}
+
+	if len(field) == 0 {
+		return unit
+	}
+
+	return field + "_" + unit
+}
+
 func (h *Handler) trimPrefix(s string) string {
 	s = strings.TrimPrefix(s, h.TrimPrefix)
 	if len(s) != 0 && s[0] == '.' {
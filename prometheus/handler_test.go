@@ -1,10 +1,12 @@
 package prometheus
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -157,3 +159,123 @@ func BenchmarkHandleMetric(b *testing.B) {
 		})
 	}
 }
+
+func TestObserveBucketed(t *testing.T) {
+	handler := &Handler{}
+
+	dist := stats.NewDistribution(0.5, 1)
+	dist.Observe(0.1)
+	dist.Observe(0.75)
+	dist.Observe(2)
+
+	handler.ObserveBucketed(time.Now(), "test", "rtt", []stats.Tag{stats.T("a", "1")}, dist)
+	handler.ObserveBucketed(time.Now(), "test", "rtt", []stats.Tag{stats.T("a", "1")}, dist)
+
+	b := &bytes.Buffer{}
+	handler.WriteStats(b)
+	out := b.String()
+
+	for _, line := range []string{
+		`test_rtt_bucket{a="1",le="0.5"} 2`,
+		`test_rtt_bucket{a="1",le="1"} 4`,
+		`test_rtt_count{a="1"} 6`,
+		`test_rtt_sum{a="1"} 5.7`,
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("missing line in the output: %s\n%s", line, out)
+		}
+	}
+}
+
+func TestHandlerUnits(t *testing.T) {
+	units := &stats.UnitRegistry{}
+	units.Set("http:rtt", stats.Seconds)
+	units.Set("http:size_bytes", stats.Bytes)
+	units.Set("queue", stats.Requests)
+
+	handler := &Handler{Units: units}
+	now := time.Now()
+
+	handler.HandleMeasures(now,
+		stats.Measure{Name: "http", Fields: []stats.Field{
+			stats.MakeField("rtt", 1, stats.Gauge),
+			stats.MakeField("size_bytes", 2, stats.Gauge),
+		}},
+		stats.Measure{Name: "queue", Fields: []stats.Field{stats.MakeField("", 3, stats.Gauge)}},
+	)
+
+	b := &bytes.Buffer{}
+	handler.WriteStats(b)
+	out := b.String()
+
+	for _, name := range []string{"http_rtt_seconds ", "http_size_bytes ", "queue_requests "} {
+		if !strings.Contains(out, name) {
+			t.Errorf("missing metric in the output: %s\n%s", name, out)
+		}
+	}
+}
+
+func TestHandlerDescriptions(t *testing.T) {
+	descriptions := &stats.DescriptionRegistry{}
+	descriptions.Set("http:requests", stats.Description{Help: "Number of requests.", Type: stats.Counter})
+
+	handler := &Handler{Descriptions: descriptions}
+
+	handler.HandleMeasures(time.Time{},
+		stats.Measure{Name: "http", Fields: []stats.Field{
+			stats.MakeField("requests", 1, stats.Counter),
+			stats.MakeField("errors", 1, stats.Counter),
+		}},
+	)
+
+	b := &bytes.Buffer{}
+	handler.WriteStats(b)
+
+	expected := `# TYPE http_errors counter
+http_errors 1
+
+# HELP http_requests Number of requests.
+# TYPE http_requests counter
+http_requests 1
+`
+
+	if out := b.String(); out != expected {
+		t.Errorf("bad output:\n%s", out)
+	}
+}
+
+func TestHandlerSampleRate(t *testing.T) {
+	handler := &Handler{Buckets: map[stats.Key][]stats.Value{
+		{Measure: "http", Field: "rtt"}: {stats.ValueOf(1)},
+	}}
+
+	handler.HandleMeasures(time.Time{},
+		stats.Measure{
+			Name: "http",
+			Fields: []stats.Field{
+				stats.MakeField("requests", 1, stats.Counter),
+				stats.MakeField("rtt", 0.5, stats.Histogram),
+			},
+			SampleRate: 0.25,
+		},
+		stats.Measure{
+			Name:   "http",
+			Fields: []stats.Field{stats.MakeField("requests", 1, stats.Counter)},
+		},
+	)
+
+	b := &bytes.Buffer{}
+	handler.WriteStats(b)
+	out := b.String()
+
+	for _, line := range []string{
+		"http_requests 5\n",
+		`http_rtt_bucket{le="1"} 4`,
+		"http_rtt_count 4\n",
+		"http_rtt_sum 2\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("missing line in the output: %s\n%s", line, out)
+		}
+	}
+}
@@ -1,12 +1,11 @@
 package prometheus
 
 import (
-	"reflect"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
-	"unsafe"
 
 	"github.com/segmentio/stats"
 )
@@ -49,6 +48,7 @@ type metric struct {
 	name   string
 	help   string
 	value  float64
+	rate   float64 // sample rate of the value, zero if it was not sampled
 	time   time.Time
 	labels labels
 }
@@ -98,7 +98,13 @@ func (store *metricStore) lookup(mtype metricType, key metricKey, help string) *
 func (store *metricStore) update(metric metric, buckets []stats.Value) {
 	entry := store.lookup(metric.mtype, metric.key(), metric.help)
 	state := entry.lookup(metric.labels)
-	state.update(metric.mtype, metric.value, metric.time, buckets)
+	state.update(metric.mtype, metric.value, metric.rate, metric.time, buckets)
+}
+
+func (store *metricStore) merge(metric metric, dist *stats.Distribution) {
+	entry := store.lookup(metric.mtype, metric.key(), metric.help)
+	state := entry.lookup(metric.labels)
+	state.merge(dist, metric.time)
 }
 
 func (store *metricStore) collect(metrics []metric) []metric {
@@ -252,11 +258,14 @@ func newMetricState(labels labels) *metricState {
 	}
 }
 
-func (state *metricState) update(mtype metricType, value float64, time time.Time, buckets []stats.Value) {
+func (state *metricState) update(mtype metricType, value float64, rate float64, time time.Time, buckets []stats.Value) {
 	state.mutex.Lock()
 
 	switch mtype {
 	case counter:
+		if rate > 0 && rate < 1 {
+			value /= rate
+		}
 		state.value += value
 
 	case gauge:
@@ -266,11 +275,37 @@ func (state *metricState) update(mtype metricType, value float64, time time.Time
 		if len(state.buckets) != len(buckets) {
 			state.buckets = makeMetricBuckets(buckets, state.labels)
 		}
-		state.buckets.update(value)
-		state.sum += value
-		state.count++
+		n := observations(rate)
+		state.buckets.update(value, n)
+		state.sum += value * float64(n)
+		state.count += n
+	}
+
+	state.time = time
+	state.mutex.Unlock()
+}
+
+func (state *metricState) merge(dist *stats.Distribution, time time.Time) {
+	state.mutex.Lock()
+
+	if !state.buckets.match(dist.Bounds) {
+		bounds := make([]stats.Value, len(dist.Bounds))
+		for i, b := range dist.Bounds {
+			bounds[i] = stats.ValueOf(b)
+		}
+		state.buckets = makeMetricBuckets(bounds, state.labels)
+	}
+
+	// Values greater than the last bound are only accounted for in the count,
+	// like the values observed by update.
+	for i := range state.buckets {
+		if i < len(dist.Counts) {
+			state.buckets[i].count += dist.Counts[i]
+		}
 	}
 
+	state.sum += dist.Sum
+	state.count += dist.Count
 	state.time = time
 	state.mutex.Unlock()
 }
@@ -373,15 +408,37 @@ func makeMetricBuckets(buckets []stats.Value, labels labels) metricBuckets {
 	return b
 }
 
-func (m metricBuckets) update(value float64) {
+func (m metricBuckets) update(value float64, n uint64) {
 	for i := range m {
 		if value <= m[i].limit {
-			m[i].count++
+			m[i].count += n
 			break
 		}
 	}
 }
 
+// observations returns the number of observations that a histogram value
+// sampled at rate stands for, rounded to the nearest integer since the counts
+// of histograms are integers.
+func observations(rate float64) uint64 {
+	if rate <= 0 || rate >= 1 {
+		return 1
+	}
+	return uint64(math.Round(1 / rate))
+}
+
+func (m metricBuckets) match(bounds []float64) bool {
+	if len(m) != len(bounds) {
+		return false
+	}
+	for i := range m {
+		if m[i].limit != bounds[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // This function builds a string of column-separated float representations of
 // the given list of buckets, which is then split by calls to nextLe to generate
 // the values of the "le" label for each bucket of a histogram.
@@ -402,10 +459,9 @@ func le(buckets []stats.Value) string {
 		b = appendFloat(b, valueOf(v))
 	}
 
-	return *(*string)(unsafe.Pointer(&reflect.StringHeader{
-		Data: uintptr(unsafe.Pointer(&b[0])),
-		Len:  len(b),
-	}))
+	// The bytes are copied, the labels retain substrings of the result after
+	// the buffer was released.
+	return string(b)
 }
 
 func nextLe(s string) (head string, tail string) {
@@ -0,0 +1,233 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Snapshot writes the state of all metrics exposed by the handler to w. The
+// snapshot can be loaded by Restore, which allows a program to keep its
+// counters and histograms monotonic across restarts.
+func (h *Handler) Snapshot(w io.Writer) error {
+	return json.NewEncoder(w).Encode(h.metrics.snapshot())
+}
+
+// Restore loads a snapshot produced by Snapshot into the handler. Metrics that
+// were already updated by the program are merged with the restored state.
+//
+// Restored metrics are considered to have been updated at the time they were
+// snapshotted, and therefore expire after MetricTimeout if the program does
+// not update them anymore.
+func (h *Handler) Restore(r io.Reader) error {
+	var s snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return err
+	}
+	h.metrics.restore(s)
+	return nil
+}
+
+// SnapshotFile atomically writes a snapshot of the handler to the file at path.
+func (h *Handler) SnapshotFile(path string) error {
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+
+	if err = h.Snapshot(f); err == nil {
+		err = f.Sync()
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), filepath.Clean(path))
+}
+
+// RestoreFile loads a snapshot from the file at path. No error is returned if
+// the file does not exist.
+func (h *Handler) RestoreFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return err
+	}
+	defer f.Close()
+	return h.Restore(f)
+}
+
+type snapshot struct {
+	Version int              `json:"version"`
+	Metrics []snapshotMetric `json:"metrics"`
+}
+
+type snapshotMetric struct {
+	Type    string           `json:"type"`
+	Scope   string           `json:"scope"`
+	Name    string           `json:"name"`
+	Help    string           `json:"help,omitempty"`
+	Labels  [][2]string      `json:"labels,omitempty"`
+	Value   snapshotFloat    `json:"value,omitempty"`
+	Sum     snapshotFloat    `json:"sum,omitempty"`
+	Count   uint64           `json:"count,omitempty"`
+	Buckets []snapshotBucket `json:"buckets,omitempty"`
+	Time    time.Time        `json:"time"`
+}
+
+type snapshotBucket struct {
+	Limit snapshotFloat `json:"le"`
+	Count uint64        `json:"count"`
+}
+
+// snapshotFloat is a float64 encoded as a JSON string, the way values are
+// written in the Prometheus text format, because JSON numbers cannot represent
+// NaN and infinities (the last bucket of histograms is +Inf). Numbers are also
+// accepted when decoding.
+type snapshotFloat float64
+
+func (f snapshotFloat) MarshalJSON() ([]byte, error) {
+	b := make([]byte, 0, 32)
+	b = append(b, '"')
+	b = appendFloat(b, float64(f))
+	b = append(b, '"')
+	return b, nil
+}
+
+func (f *snapshotFloat) UnmarshalJSON(b []byte) error {
+	if n := len(b); n >= 2 && b[0] == '"' && b[n-1] == '"' {
+		b = b[1 : n-1]
+	}
+
+	v, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return err
+	}
+
+	*f = snapshotFloat(v)
+	return nil
+}
+
+func (store *metricStore) snapshot() snapshot {
+	s := snapshot{Version: 1}
+
+	store.mutex.RLock()
+
+	for _, entry := range store.entries {
+		entry.mutex.RLock()
+
+		for _, states := range entry.states {
+			for _, state := range states {
+				s.Metrics = append(s.Metrics, state.snapshot(entry))
+			}
+		}
+
+		entry.mutex.RUnlock()
+	}
+
+	store.mutex.RUnlock()
+	return s
+}
+
+func (store *metricStore) restore(s snapshot) {
+	for _, m := range s.Metrics {
+		mtype := parseMetricType(m.Type)
+		if mtype == untyped {
+			continue
+		}
+
+		labels := make(labels, len(m.Labels))
+		for i, l := range m.Labels {
+			labels[i] = label{name: l[0], value: l[1]}
+		}
+
+		entry := store.lookup(mtype, metricKey{scope: m.Scope, name: m.Name}, m.Help)
+		entry.lookup(labels).restore(mtype, m)
+	}
+}
+
+func (state *metricState) snapshot(entry *metricEntry) snapshotMetric {
+	state.mutex.Lock()
+
+	m := snapshotMetric{
+		Type:  entry.mtype.String(),
+		Scope: entry.scope,
+		Name:  entry.name,
+		Help:  entry.help,
+		Value: snapshotFloat(state.value),
+		Sum:   snapshotFloat(state.sum),
+		Count: state.count,
+		Time:  state.time,
+	}
+
+	for _, l := range state.labels {
+		m.Labels = append(m.Labels, [2]string{l.name, l.value})
+	}
+
+	for _, b := range state.buckets {
+		m.Buckets = append(m.Buckets, snapshotBucket{Limit: snapshotFloat(b.limit), Count: b.count})
+	}
+
+	state.mutex.Unlock()
+	return m
+}
+
+func (state *metricState) restore(mtype metricType, m snapshotMetric) {
+	state.mutex.Lock()
+
+	switch mtype {
+	case counter:
+		state.value += float64(m.Value)
+
+	case gauge:
+		if state.time.IsZero() {
+			state.value = float64(m.Value)
+		}
+
+	case histogram:
+		if len(state.buckets) == 0 {
+			state.buckets = make(metricBuckets, len(m.Buckets))
+			for i, b := range m.Buckets {
+				state.buckets[i].limit = float64(b.Limit)
+				state.buckets[i].labels = state.labels.copyAppend(label{"le", string(appendFloat(nil, float64(b.Limit)))})
+			}
+		}
+		if len(state.buckets) == len(m.Buckets) {
+			for i, b := range m.Buckets {
+				state.buckets[i].count += b.Count
+			}
+			state.sum += float64(m.Sum)
+			state.count += m.Count
+		}
+	}
+
+	if state.time.IsZero() {
+		state.time = m.Time
+	}
+
+	state.mutex.Unlock()
+}
+
+func parseMetricType(s string) metricType {
+	switch s {
+	case "counter":
+		return counter
+	case "gauge":
+		return gauge
+	case "histogram":
+		return histogram
+	default:
+		return untyped
+	}
+}
@@ -0,0 +1,146 @@
+package prometheus
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	now := time.Date(2017, 6, 4, 22, 12, 0, 0, time.UTC)
+
+	h1 := &Handler{
+		Buckets: map[stats.Key][]stats.Value{
+			stats.Key{Field: "C"}: []stats.Value{
+				stats.ValueOf(0.5),
+				stats.ValueOf(1.0),
+			},
+		},
+	}
+
+	h1.HandleMeasures(now,
+		stats.Measure{Fields: []stats.Field{stats.MakeField("A", 1, stats.Counter)}},
+		stats.Measure{Fields: []stats.Field{stats.MakeField("A", 2, stats.Counter)}, Tags: []stats.Tag{stats.T("id", "123")}},
+		stats.Measure{Fields: []stats.Field{stats.MakeField("B", 42, stats.Gauge)}},
+		stats.Measure{Fields: []stats.Field{stats.MakeField("C", 0.1, stats.Histogram)}},
+		stats.Measure{Fields: []stats.Field{stats.MakeField("C", 0.7, stats.Histogram)}},
+	)
+
+	b := &bytes.Buffer{}
+
+	if err := h1.Snapshot(b); err != nil {
+		t.Fatal(err)
+	}
+
+	h2 := &Handler{}
+
+	if err := h2.Restore(b); err != nil {
+		t.Fatal(err)
+	}
+
+	s1 := sortedSnapshot(h1)
+	s2 := sortedSnapshot(h2)
+
+	if !reflect.DeepEqual(s1, s2) {
+		t.Errorf("snapshots mismatch:\n%+v\n%+v", s1, s2)
+	}
+
+	// Restoring a second time accumulates counters.
+	h2.metrics.restore(h1.metrics.snapshot())
+
+	for _, m := range sortedSnapshot(h2) {
+		if m.Name == "A" && len(m.Labels) == 0 && m.Value != 2 {
+			t.Error("bad counter value after restoring twice:", m.Value)
+		}
+	}
+}
+
+func TestSnapshotRestoreInf(t *testing.T) {
+	now := time.Date(2017, 6, 4, 22, 12, 0, 0, time.UTC)
+
+	h1 := &Handler{
+		Buckets: map[stats.Key][]stats.Value{
+			stats.Key{Field: "C"}: []stats.Value{
+				stats.ValueOf(1.0),
+				stats.ValueOf(math.Inf(+1)),
+			},
+		},
+	}
+
+	h1.HandleMeasures(now,
+		stats.Measure{Fields: []stats.Field{stats.MakeField("B", math.Inf(-1), stats.Gauge)}},
+		stats.Measure{Fields: []stats.Field{stats.MakeField("C", 0.5, stats.Histogram)}},
+		stats.Measure{Fields: []stats.Field{stats.MakeField("C", 2.0, stats.Histogram)}},
+	)
+
+	b := &bytes.Buffer{}
+
+	if err := h1.Snapshot(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(b.String(), `"+Inf"`) {
+		t.Error("the +Inf bucket was not found in the snapshot:", b.String())
+	}
+
+	h2 := &Handler{}
+
+	if err := h2.Restore(b); err != nil {
+		t.Fatal(err)
+	}
+
+	s1 := sortedSnapshot(h1)
+	s2 := sortedSnapshot(h2)
+
+	if !reflect.DeepEqual(s1, s2) {
+		t.Errorf("snapshots mismatch:\n%+v\n%+v", s1, s2)
+	}
+}
+
+func TestSnapshotFloat(t *testing.T) {
+	for _, test := range []struct {
+		in  string
+		out float64
+	}{
+		{in: `"1.5"`, out: 1.5},
+		{in: `1.5`, out: 1.5},
+		{in: `"+Inf"`, out: math.Inf(+1)},
+		{in: `"-Inf"`, out: math.Inf(-1)},
+	} {
+		var f snapshotFloat
+
+		if err := json.Unmarshal([]byte(test.in), &f); err != nil {
+			t.Errorf("%s: %s", test.in, err)
+		} else if float64(f) != test.out {
+			t.Errorf("%s: bad value: %g", test.in, f)
+		}
+	}
+
+	var f snapshotFloat
+
+	if err := json.Unmarshal([]byte(`"NaN"`), &f); err != nil || !math.IsNaN(float64(f)) {
+		t.Error("bad NaN value:", f, err)
+	}
+
+	if b, err := json.Marshal(snapshotFloat(math.NaN())); err != nil || string(b) != `"NaN"` {
+		t.Error("bad NaN encoding:", string(b), err)
+	}
+}
+
+func sortedSnapshot(h *Handler) []snapshotMetric {
+	s := h.metrics.snapshot().Metrics
+	sort.Slice(s, func(i int, j int) bool {
+		return s[i].Name < s[j].Name || (s[i].Name == s[j].Name && len(s[i].Labels) < len(s[j].Labels))
+	})
+	for i := range s {
+		s[i].Time = s[i].Time.UTC()
+	}
+	return s
+}
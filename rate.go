@@ -0,0 +1,152 @@
+package stats
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// CounterRates is a measure handler which converts counters to per-second
+// rates, for backends that are unable to compute rates themselves (CSV files
+// or plain Graphite dashboards for example).
+//
+// The increments of each counter series (measure name, field name, and tags)
+// are summed until the handler is flushed, at which point the handler forwards
+// the rates of the counters over the flush window as gauges, named after the
+// counter fields with a ".rate" suffix. The window starts when the handler was
+// last flushed, or when it received its first measure.
+//
+// Counters are forwarded to the base handler unless Replace is true, other
+// measures are always forwarded unchanged.
+type CounterRates struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// When true, counters are only exported as rates.
+	Replace bool
+
+	// The source of the current time used to compute the rates when the
+	// handler is flushed. Defaults to SystemClock.
+	ClockSource ClockSource
+
+	mutex  sync.Mutex
+	start  time.Time
+	series map[string]*rateSeries
+	keys   []byte
+}
+
+type rateSeries struct {
+	name  string
+	field string
+	tags  []Tag
+	sum   float64
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (r *CounterRates) HandleMeasures(t time.Time, measures ...Measure) {
+	passthrough := measurePool.Get().(*measuresBuffer)
+	ms := passthrough.measures[:0]
+
+	r.mutex.Lock()
+
+	if r.start.IsZero() {
+		r.start = t
+	}
+
+	for _, m := range measures {
+		// The list of fields is only copied when counters were removed.
+		var fields []Field
+
+		for i, f := range m.Fields {
+			if f.Type() != Counter {
+				if fields != nil {
+					fields = append(fields, f)
+				}
+				continue
+			}
+
+			r.add(m, f)
+
+			if r.Replace && fields == nil {
+				fields = append(make([]Field, 0, len(m.Fields)), m.Fields[:i]...)
+			}
+		}
+
+		switch {
+		case fields == nil:
+			ms = append(ms, m)
+		case len(fields) != 0:
+			ms = append(ms, Measure{Name: m.Name, Fields: fields, Tags: m.Tags, Const: m.Const, SampleRate: m.SampleRate})
+		}
+	}
+
+	r.mutex.Unlock()
+
+	if len(ms) != 0 {
+		r.Handler.HandleMeasures(t, ms...)
+	}
+
+	for i := range ms {
+		ms[i] = Measure{}
+	}
+
+	passthrough.measures = ms[:0]
+	measurePool.Put(passthrough)
+}
+
+// Flush satisfies the Flusher interface, it forwards the rates of the counters
+// over the window that ends now to the base handler, then flushes it.
+func (r *CounterRates) Flush() {
+	now := clockNow(r.ClockSource)
+
+	r.mutex.Lock()
+	series, start := r.series, r.start
+	r.series, r.start = nil, now
+	r.mutex.Unlock()
+
+	if seconds := now.Sub(start).Seconds(); len(series) != 0 && seconds > 0 {
+		measures := make([]Measure, 0, len(series))
+
+		for _, s := range series {
+			measures = append(measures, Measure{
+				Name:   s.name,
+				Fields: []Field{MakeField(s.fieldName(), s.sum/seconds, Gauge)},
+				Tags:   s.tags,
+			})
+		}
+
+		sort.Slice(measures, func(i, j int) bool {
+			return measures[i].Name < measures[j].Name
+		})
+
+		r.Handler.HandleMeasures(now, measures...)
+	}
+
+	flush(r.Handler)
+}
+
+func (r *CounterRates) add(m Measure, f Field) {
+	r.keys = appendSeriesKey(r.keys[:0], m.Name, f.Name, m.Tags)
+	s := r.series[string(r.keys)]
+
+	if s == nil {
+		if r.series == nil {
+			r.series = make(map[string]*rateSeries)
+		}
+		s = &rateSeries{
+			name:  m.Name,
+			field: f.Name,
+			tags:  copyTags(m.Tags),
+		}
+		r.series[string(r.keys)] = s
+	}
+
+	s.sum += valueToFloat(unsample(f.Value, m.SampleRate))
+}
+
+func (s *rateSeries) fieldName() string {
+	if len(s.field) == 0 {
+		return "rate"
+	}
+	return s.field + ".rate"
+}
@@ -0,0 +1,52 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestCounterRates(t *testing.T) {
+	for _, replace := range []bool{false, true} {
+		h := &statstest.Handler{}
+		r := &stats.CounterRates{Handler: h, Replace: replace}
+
+		start := time.Now().Add(-10 * time.Second)
+		r.HandleMeasures(start, stats.Measure{
+			Name: "test.http",
+			Fields: []stats.Field{
+				stats.MakeField("requests", 100, stats.Counter),
+				stats.MakeField("rtt", time.Second, stats.Histogram),
+			},
+		})
+		r.HandleMeasures(start, stats.Measure{
+			Name:   "test.http",
+			Fields: []stats.Field{stats.MakeField("requests", 100, stats.Counter)},
+		})
+
+		forwarded := len(h.Measures())
+		if (replace && forwarded != 1) || (!replace && forwarded != 2) {
+			t.Errorf("replace=%t: bad number of forwarded measures: %d", replace, forwarded)
+		}
+
+		if fields := h.Measures()[0].Fields; replace && (len(fields) != 1 || fields[0].Name != "rtt") {
+			t.Errorf("replace=%t: counters were not removed: %v", replace, fields)
+		}
+
+		h.Clear()
+		r.Flush()
+
+		found := h.Measures()
+		if len(found) != 1 {
+			t.Fatalf("replace=%t: bad number of rates: %v", replace, found)
+		}
+
+		f := found[0].Fields[0]
+		if f.Name != "requests.rate" || f.Type() != stats.Gauge || math.Abs(f.Value.Float()-20) > 0.5 {
+			t.Errorf("replace=%t: bad rate: %v", replace, f)
+		}
+	}
+}
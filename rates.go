@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// Rate is a metric which counts events and is reported as a number of events
+// per second when its engine is flushed. The rate is computed over the time
+// elapsed since the previous flush (or since the rate was created), and
+// reported as a gauge, so all backends expose the same values whether they
+// are able to compute rates or not.
+//
+// Rate values are safe to use concurrently from multiple goroutines.
+type Rate struct {
+	eng   *Engine
+	name  string
+	tags  []Tag
+	state *engineState
+
+	mutex sync.Mutex
+	start time.Time
+	count float64
+}
+
+// Rate returns a new rate identified by name and tags, which is reported each
+// time eng (or one of the engines derived from it) is flushed, until Stop is
+// called.
+func (eng *Engine) Rate(name string, tags ...Tag) *Rate {
+	r := &Rate{
+		eng:   eng,
+		name:  name,
+		tags:  copyTags(tags),
+		start: eng.Now(),
+	}
+
+	if Enabled {
+		r.state = eng.state()
+		r.state.samplers.add(r)
+	}
+
+	return r
+}
+
+// NewRate returns a new rate reported when the default engine is flushed.
+func NewRate(name string, tags ...Tag) *Rate {
+	return DefaultEngine.Rate(name, tags...)
+}
+
+// Incr counts one event.
+func (r *Rate) Incr() {
+	r.Add(1)
+}
+
+// Add counts n events.
+func (r *Rate) Add(n float64) {
+	r.mutex.Lock()
+	r.count += n
+	r.mutex.Unlock()
+}
+
+// Stop unregisters r from its engine, the events counted since the last flush
+// are discarded.
+func (r *Rate) Stop() {
+	if r.state != nil {
+		r.state.samplers.remove(r)
+	}
+}
+
+// reset returns the number of events per second since the last call to reset,
+// and starts a new window at t. The second return value is false if no time
+// elapsed since the window started.
+func (r *Rate) reset(t time.Time) (float64, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	seconds := t.Sub(r.start).Seconds()
+	if seconds <= 0 {
+		return 0, false
+	}
+
+	rate := r.count / seconds
+	r.count, r.start = 0, t
+	return rate, true
+}
+
+// sample reports the value of the rate over the window ending at time t, and
+// starts a new window.
+func (r *Rate) sample(t time.Time) {
+	if v, ok := r.reset(t); ok {
+		r.eng.SetAt(t, r.name, v, r.tags...)
+	}
+}
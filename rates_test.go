@@ -0,0 +1,60 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestEngineRate(t *testing.T) {
+	h := &statstest.Handler{}
+	clock := newManualClock(time.Date(2017, 6, 4, 22, 12, 0, 0, time.UTC))
+	eng := stats.NewEngine("test", h)
+	eng.ClockSource = clock
+
+	advance := func(d time.Duration) {
+		clock.mutex.Lock()
+		clock.now = clock.now.Add(d)
+		clock.mutex.Unlock()
+	}
+
+	r := eng.Rate("requests", stats.T("path", "/"))
+
+	for i := 0; i != 20; i++ {
+		r.Incr()
+	}
+
+	advance(10 * time.Second)
+	eng.Flush()
+
+	r.Add(15)
+	advance(5 * time.Second)
+	eng.WithPrefix("other").Flush()
+
+	r.Stop()
+	r.Add(100)
+	advance(5 * time.Second)
+	eng.Flush()
+
+	measures := h.Measures()
+
+	if len(measures) != 2 {
+		t.Fatalf("bad measures: %v", measures)
+	}
+
+	for i, expected := range []float64{2, 3} {
+		m := measures[i]
+
+		if m.Name != "test.requests" || len(m.Tags) != 1 || m.Tags[0] != stats.T("path", "/") {
+			t.Errorf("bad measure #%d: %v", i, m)
+		}
+
+		if f := m.Fields[0]; f.Type() != stats.Gauge || f.Value.Float() != expected {
+			t.Errorf("bad rate #%d: %v (expected %g)", i, f, expected)
+		}
+	}
+}
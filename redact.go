@@ -0,0 +1,132 @@
+package stats
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// RedactMode is an enumeration of the ways tag values can be redacted.
+type RedactMode int
+
+const (
+	// RedactMask replaces tag values with a fixed mask.
+	RedactMask RedactMode = iota
+
+	// RedactHash replaces tag values with a truncated hash of the original
+	// value, which keeps the cardinality of the tag but hides its content.
+	RedactHash
+)
+
+// RedactedValue is the value set on tags redacted with RedactMask.
+const RedactedValue = "redacted"
+
+// Redactor is a measure handler which rewrites the values of sensitive tags
+// before forwarding measures to its base handler, this is useful to prevent
+// personal information like emails or access tokens from being sent to metric
+// collection systems.
+type Redactor struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// Names of the tags which have their values redacted.
+	Tags []string
+
+	// List of tags that are forwarded unchanged even if their name appears in
+	// Tags (for example user_id=anonymous).
+	Allow []Tag
+
+	// Mode configures how tag values are redacted, defaults to RedactMask.
+	Mode RedactMode
+
+	// When Mode is RedactHash, Salt is prepended to tag values before they
+	// are hashed.
+	Salt string
+
+	once  sync.Once
+	tags  map[string]struct{}
+	allow map[Tag]struct{}
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (r *Redactor) HandleMeasures(t time.Time, measures ...Measure) {
+	r.once.Do(r.init)
+
+	b := measurePool.Get().(*measuresBuffer)
+	ms := b.measures[:0]
+
+	for _, m := range measures {
+		if r.redacted(m.Tags) {
+			// The cached encoding of the constant tags carries the values
+			// that were redacted, it must not be used by the handler.
+			m.Tags = r.redact(m.Tags)
+			m.Const = nil
+		}
+		ms = append(ms, m)
+	}
+
+	r.Handler.HandleMeasures(t, ms...)
+
+	for i := range ms {
+		ms[i] = Measure{}
+	}
+
+	b.measures = ms[:0]
+	measurePool.Put(b)
+}
+
+// Flush satisfies the Flusher interface.
+func (r *Redactor) Flush() {
+	flush(r.Handler)
+}
+
+func (r *Redactor) init() {
+	r.tags = make(map[string]struct{}, len(r.Tags))
+	r.allow = make(map[Tag]struct{}, len(r.Allow))
+
+	for _, name := range r.Tags {
+		r.tags[name] = struct{}{}
+	}
+
+	for _, tag := range r.Allow {
+		r.allow[tag] = struct{}{}
+	}
+}
+
+func (r *Redactor) redacted(tags []Tag) bool {
+	for _, t := range tags {
+		if r.match(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) match(t Tag) bool {
+	if _, ok := r.tags[t.Name]; !ok {
+		return false
+	}
+	_, ok := r.allow[t]
+	return !ok
+}
+
+func (r *Redactor) redact(tags []Tag) []Tag {
+	tags = copyTags(tags)
+
+	for i, t := range tags {
+		if r.match(t) {
+			tags[i].Value = r.redactValue(t.Value)
+		}
+	}
+
+	return tags
+}
+
+func (r *Redactor) redactValue(v string) string {
+	if r.Mode != RedactHash {
+		return RedactedValue
+	}
+	sum := sha256.Sum256([]byte(r.Salt + v))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,64 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestRedactor(t *testing.T) {
+	tests := []struct {
+		scenario string
+		redactor *stats.Redactor
+		tags     []stats.Tag
+		expect   []stats.Tag
+	}{
+		{
+			scenario: "tags that are not configured are left unchanged",
+			redactor: &stats.Redactor{Tags: []string{"email"}},
+			tags:     []stats.Tag{stats.T("host", "localhost")},
+			expect:   []stats.Tag{stats.T("host", "localhost")},
+		},
+		{
+			scenario: "configured tags are masked",
+			redactor: &stats.Redactor{Tags: []string{"email"}},
+			tags:     []stats.Tag{stats.T("email", "me@example.com"), stats.T("host", "localhost")},
+			expect:   []stats.Tag{stats.T("email", stats.RedactedValue), stats.T("host", "localhost")},
+		},
+		{
+			scenario: "allowed tags are left unchanged",
+			redactor: &stats.Redactor{Tags: []string{"user_id"}, Allow: []stats.Tag{stats.T("user_id", "anonymous")}},
+			tags:     []stats.Tag{stats.T("user_id", "anonymous")},
+			expect:   []stats.Tag{stats.T("user_id", "anonymous")},
+		},
+		{
+			scenario: "configured tags are hashed",
+			redactor: &stats.Redactor{Tags: []string{"token"}, Mode: stats.RedactHash},
+			tags:     []stats.Tag{stats.T("token", "secret")},
+			expect:   []stats.Tag{stats.T("token", "2bb80d537b1da3e3")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			h := &statstest.Handler{}
+			test.redactor.Handler = h
+
+			eng := stats.NewEngine("", test.redactor)
+			eng.Incr("calls", test.tags...)
+
+			m := h.Measures()
+			if len(m) != 1 {
+				t.Fatal("bad number of measures:", len(m))
+			}
+
+			if !reflect.DeepEqual(m[0].Tags, test.expect) {
+				t.Errorf("bad tags:\nexpected: %v\nfound:    %v", test.expect, m[0].Tags)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package stats
+
+import (
+	"strings"
+	"time"
+)
+
+// RelabelFunc is the signature of functions used by a Relabeler to rewrite the
+// measures before they reach its base handler. The function may modify the
+// name, fields, and tags of the measure in place (it always receives a copy),
+// and returns false to drop it.
+type RelabelFunc func(m *Measure) bool
+
+// Relabeler is a measure handler which passes the measures through a chain of
+// relabeling functions before forwarding them to its base handler. It is useful
+// to normalize the metrics produced by third-party libraries, for example to
+// rename measures or tags which don't follow the conventions of the program
+// ("statuscode" vs "status_code"), or to drop the metrics it does not need.
+//
+// Measures that have no fields left after being relabeled are dropped. The
+// tags are sorted again after the functions were applied, so they do not have
+// to preserve their order.
+type Relabeler struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// The relabeling functions, applied in order.
+	Funcs []RelabelFunc
+}
+
+// Relabel inserts a Relabeler applying funcs in front of the handler of eng.
+//
+// The method must be called before eng is used to produce measures, and only
+// affects the engines derived from eng after it was called.
+func (eng *Engine) Relabel(funcs ...RelabelFunc) {
+	if r, ok := eng.Handler.(*Relabeler); ok {
+		eng.Handler = &Relabeler{
+			Handler: r.Handler,
+			Funcs:   append(append([]RelabelFunc(nil), r.Funcs...), funcs...),
+		}
+		return
+	}
+	eng.Handler = &Relabeler{Handler: eng.Handler, Funcs: funcs}
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (r *Relabeler) HandleMeasures(t time.Time, measures ...Measure) {
+	b := measurePool.Get().(*measuresBuffer)
+	ms := b.measures[:0]
+
+	for _, m := range measures {
+		if m, ok := r.relabel(m); ok {
+			ms = append(ms, m)
+		}
+	}
+
+	if len(ms) != 0 {
+		r.Handler.HandleMeasures(t, ms...)
+	}
+
+	for i := range ms {
+		ms[i] = Measure{}
+	}
+
+	b.measures = ms[:0]
+	measurePool.Put(b)
+}
+
+// Flush satisfies the Flusher interface.
+func (r *Relabeler) Flush() {
+	flush(r.Handler)
+}
+
+func (r *Relabeler) relabel(m Measure) (Measure, bool) {
+	if len(r.Funcs) == 0 {
+		return m, true
+	}
+
+	tags := m.Tags
+	m.Fields = copyFields(m.Fields)
+	m.Tags = copyTags(m.Tags)
+
+	for _, fn := range r.Funcs {
+		if !fn(&m) || len(m.Fields) == 0 {
+			return Measure{}, false
+		}
+	}
+
+	if !TagsAreSorted(m.Tags) {
+		SortTags(m.Tags)
+	}
+
+	// The constant tags of the measure may not be part of its tags anymore.
+	if m.Const != nil && !tagsEqual(tags, m.Tags) {
+		m.Const = nil
+	}
+
+	return m, true
+}
+
+// RenameMeasure returns a relabeling function which renames the measures named
+// from to the name to.
+func RenameMeasure(from string, to string) RelabelFunc {
+	return func(m *Measure) bool {
+		if m.Name == from {
+			m.Name = to
+		}
+		return true
+	}
+}
+
+// RenameTag returns a relabeling function which renames the tags named from to
+// the name to. If the measure already has a tag named to, the tag named from is
+// removed instead.
+func RenameTag(from string, to string) RelabelFunc {
+	return func(m *Measure) bool {
+		i := tagIndex(m.Tags, from)
+		if i < 0 {
+			return true
+		}
+		if tagIndex(m.Tags, to) < 0 {
+			m.Tags[i].Name = to
+		} else {
+			m.Tags = append(m.Tags[:i], m.Tags[i+1:]...)
+		}
+		return true
+	}
+}
+
+// DropTag returns a relabeling function which removes the tags with the given
+// name from the measures.
+func DropTag(name string) RelabelFunc {
+	return func(m *Measure) bool {
+		if i := tagIndex(m.Tags, name); i >= 0 {
+			m.Tags = append(m.Tags[:i], m.Tags[i+1:]...)
+		}
+		return true
+	}
+}
+
+// DropMeasures returns a relabeling function which drops the measures whose
+// names start with prefix.
+func DropMeasures(prefix string) RelabelFunc {
+	return func(m *Measure) bool {
+		return !strings.HasPrefix(m.Name, prefix)
+	}
+}
+
+func tagIndex(tags []Tag, name string) int {
+	for i, t := range tags {
+		if t.Name == name {
+			return i
+		}
+	}
+	return -1
+}
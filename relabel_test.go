@@ -0,0 +1,74 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestRelabel(t *testing.T) {
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("", h)
+	eng.Relabel(
+		stats.RenameTag("statuscode", "status_code"),
+		stats.RenameMeasure("lib.req", "http.requests"),
+	)
+	eng.Relabel(
+		stats.DropTag("debug"),
+		stats.DropMeasures("lib.internal"),
+	)
+
+	eng.Incr("lib.req", stats.T("statuscode", "200"), stats.T("debug", "1"), stats.T("host", "a"))
+	eng.Incr("lib.req", stats.T("statuscode", "500"), stats.T("status_code", "200"))
+	eng.Incr("lib.internal.gc")
+	eng.Incr("other")
+
+	found := []string{}
+	for _, m := range h.Measures() {
+		tags := make([]string, len(m.Tags))
+		for i, tag := range m.Tags {
+			tags[i] = tag.String()
+		}
+		found = append(found, m.Name+" "+strings.Join(tags, ","))
+	}
+
+	expected := []string{
+		"http.requests host=a,status_code=200",
+		"http.requests status_code=200",
+		"other ",
+	}
+
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("bad measures:\nexpected: %q\nfound:    %q", expected, found)
+	}
+}
+
+func TestRelabelerDropsEmptyMeasures(t *testing.T) {
+	h := &statstest.Handler{}
+	r := &stats.Relabeler{
+		Handler: h,
+		Funcs: []stats.RelabelFunc{func(m *stats.Measure) bool {
+			fields := m.Fields[:0]
+			for _, f := range m.Fields {
+				if f.Name != "debug" {
+					fields = append(fields, f)
+				}
+			}
+			m.Fields = fields
+			return true
+		}},
+	}
+
+	eng := stats.NewEngine("", r)
+	eng.Incr("a:debug")
+	eng.Incr("b:count")
+
+	if measures := h.Measures(); len(measures) != 1 || measures[0].Name != "b" {
+		t.Errorf("bad measures: %v", measures)
+	}
+}
@@ -0,0 +1,149 @@
+// Package relay implements an HTTP receiver for the stream of measures produced
+// by the JSON writer handlers of the stats package, so edge processes can relay
+// their metrics to a central aggregator built with the same package:
+//
+//	// On the edge processes, batches of measures are POSTed to the aggregator.
+//	stats.Register(stats.NewWriterHandler(w, stats.JSONEncoder{}))
+//
+//	// On the aggregator, the measures are fed into a local engine.
+//	http.Handle("/measures", relay.NewHandler(stats.NewEngine("", handler)))
+package relay
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// DefaultMaxBodySize is the default limit of the size of the request bodies
+// accepted by relay handlers.
+const DefaultMaxBodySize = 16 * 1024 * 1024
+
+// Handler is an HTTP handler which accepts POST requests with bodies made of
+// measures encoded by stats.JSONEncoder (newline-delimited JSON), and reports
+// them on an engine with the times they were taken at.
+//
+// The fields of the measures are reported individually on the engine, so the
+// engine's prefix is added to the measure names, its tags are merged with the
+// tags of the measures, and its handler receives the measures after they went
+// through the sampling, cardinality limits, and tag allowlists configured on
+// the engine. An engine with no prefix should be used to preserve the names of
+// the measures.
+//
+// Request bodies may be gzip-compressed, in which case the requests must carry
+// a "Content-Encoding: gzip" header. The handler responds with 204 when all the
+// measures of the body were received, and 400 if the body was malformed (the
+// measures decoded before the error was found are still reported).
+type Handler struct {
+	// The engine that relayed measures are reported on, defaults to
+	// stats.DefaultEngine.
+	Engine *stats.Engine
+
+	// Limit of the size of request bodies, defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+}
+
+// NewHandler returns a new relay handler reporting measures on eng.
+func NewHandler(eng *stats.Engine) *Handler {
+	return &Handler{Engine: eng}
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (h *Handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		res.Header().Set("Allow", "POST")
+		res.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body io.Reader = http.MaxBytesReader(res, req.Body, h.maxBodySize())
+
+	switch encoding := strings.TrimSpace(req.Header.Get("Content-Encoding")); encoding {
+	case "", "identity":
+	case "gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer zr.Close()
+		body = zr
+	default:
+		http.Error(res, "unsupported content encoding: "+encoding, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	eng := h.engine()
+	dec := stats.NewJSONDecoder(body)
+
+	for {
+		t, m, err := dec.Decode()
+
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		report(eng, t, m)
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) engine() *stats.Engine {
+	if h.Engine != nil {
+		return h.Engine
+	}
+	return stats.DefaultEngine
+}
+
+func (h *Handler) maxBodySize() int64 {
+	if h.MaxBodySize > 0 {
+		return h.MaxBodySize
+	}
+	return DefaultMaxBodySize
+}
+
+func report(eng *stats.Engine, t time.Time, m stats.Measure) {
+	for _, f := range m.Fields {
+		name := m.Name
+		if len(f.Name) != 0 {
+			name += ":" + f.Name
+		}
+
+		value := f.Value.Interface()
+
+		switch f.Type() {
+		case stats.Counter:
+			if m.SampleRate > 0 && m.SampleRate < 1 {
+				// The engine reports the increments of the counters, so the
+				// values of sampled counters are scaled to their estimated totals.
+				value = floatOf(f.Value) / m.SampleRate
+			}
+			eng.AddAt(t, name, value, m.Tags...)
+		case stats.Gauge:
+			eng.SetAt(t, name, value, m.Tags...)
+		case stats.Histogram:
+			eng.ObserveAt(t, name, value, m.Tags...)
+		}
+	}
+}
+
+func floatOf(v stats.Value) float64 {
+	switch v.Type() {
+	case stats.Int:
+		return float64(v.Int())
+	case stats.Uint:
+		return float64(v.Uint())
+	case stats.Float:
+		return v.Float()
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+//go:build !statsoff
+
+package relay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestHandler(t *testing.T) {
+	now := time.Date(2017, 6, 4, 22, 12, 0, 0, time.UTC)
+
+	body := stats.JSONEncoder{}.AppendMeasures(nil, now,
+		stats.Measure{
+			Name: "http",
+			Fields: []stats.Field{
+				stats.MakeField("requests", 2, stats.Counter),
+				stats.MakeField("rtt", 100*time.Millisecond, stats.Histogram),
+			},
+			Tags: []stats.Tag{stats.T("host", "a")},
+		},
+		stats.Measure{
+			Name:   "queue",
+			Fields: []stats.Field{stats.MakeField("", 42, stats.Gauge)},
+		},
+	)
+
+	for _, gzipped := range []bool{false, true} {
+		h := &statstest.Handler{}
+		eng := stats.NewEngine("", h, stats.T("relay", "edge"))
+
+		req := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+
+		if gzipped {
+			b := &bytes.Buffer{}
+			zw := gzip.NewWriter(b)
+			zw.Write(body)
+			zw.Close()
+			req = httptest.NewRequest("POST", "/", b)
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+
+		res := httptest.NewRecorder()
+		NewHandler(eng).ServeHTTP(res, req)
+
+		if res.Code != http.StatusNoContent {
+			t.Fatalf("bad status: %d: %s", res.Code, res.Body.String())
+		}
+
+		measures := h.Measures()
+
+		if len(measures) != 3 {
+			t.Fatalf("bad measures: %v", measures)
+		}
+
+		expected := []string{
+			"counter:requests=2",
+			"histogram:rtt=0.1",
+			"gauge:=42",
+		}
+
+		for i, m := range measures {
+			if s := m.Fields[0].String(); s != expected[i] {
+				t.Errorf("bad field #%d: %s (expected %s)", i, s, expected[i])
+			}
+		}
+
+		if m := measures[0]; m.Name != "http" || len(m.Tags) != 2 || m.Tags[0] != stats.T("host", "a") || m.Tags[1] != stats.T("relay", "edge") {
+			t.Errorf("bad measure: %v", m)
+		}
+
+		if m := measures[2]; m.Name != "queue" {
+			t.Errorf("bad measure: %v", m)
+		}
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	tests := []struct {
+		method string
+		body   string
+		status int
+	}{
+		{method: "GET", status: http.StatusMethodNotAllowed},
+		{method: "POST", body: `{"name":"a","fields":[{"name":"","type":"rate","value":1}]}`, status: http.StatusBadRequest},
+		{method: "POST", body: `{"name":`, status: http.StatusBadRequest},
+		{method: "POST", body: ``, status: http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/", bytes.NewReader([]byte(test.body)))
+		res := httptest.NewRecorder()
+		NewHandler(stats.NewEngine("", &statstest.Handler{})).ServeHTTP(res, req)
+
+		if res.Code != test.status {
+			t.Errorf("%s %q: bad status: %d (expected %d)", test.method, test.body, res.Code, test.status)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package stats
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures how the handlers which send measures over the
+// network retry the operations that failed.
+//
+// The zero value is a valid policy, it makes up to 10 attempts, waiting 100ms
+// after the first failure and doubling the delay after each following one, up
+// to 10s.
+type RetryPolicy struct {
+	// Maximum number of attempts, including the first one. Defaults to 10,
+	// negative values remove the limit.
+	MaxAttempts int
+
+	// Delay between the first and second attempts, which is doubled after
+	// each failed attempt. Defaults to 100ms.
+	MinBackoff time.Duration
+
+	// Maximum delay between two attempts. Defaults to 10s.
+	MaxBackoff time.Duration
+
+	// Fraction of the delays which is randomized, between 0 and 1. With a
+	// jitter of 0.2, the delays are chosen between 80% and 100% of their
+	// nominal value, which spreads the retries of programs that failed at the
+	// same time.
+	Jitter float64
+
+	// Retryable classifies the errors, operations which failed with an error
+	// for which the function returns false are not retried. When nil, all
+	// errors are retryable.
+	Retryable func(error) bool
+
+	// The clock source used to wait between attempts. Defaults to
+	// SystemClock.
+	ClockSource ClockSource
+}
+
+// Backoff returns the delay to wait for before making the given attempt,
+// starting at 1 for the first retry.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		return 0
+	}
+
+	delay, maxDelay := p.minBackoff(), p.maxBackoff()
+
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	if jitter := p.jitter(); jitter != 0 {
+		delay -= time.Duration(jitter * rand.Float64() * float64(delay))
+	}
+
+	return delay
+}
+
+// Do calls op until it succeeds, fails with an error that is not retryable, or
+// the maximum number of attempts is reached. Do stops waiting for the next
+// attempt when done is closed.
+//
+// The returned error is the one of the last attempt.
+func (p RetryPolicy) Do(done <-chan struct{}, op func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+
+		if err == nil || !p.retryable(err) {
+			return err
+		}
+
+		if max := p.maxAttempts(); max > 0 && attempt >= max {
+			return err
+		}
+
+		// The ticker is stopped after its first tick, it acts as a timer.
+		ticker := p.clock().NewTicker(p.Backoff(attempt))
+
+		select {
+		case <-ticker.C():
+			ticker.Stop()
+		case <-done:
+			ticker.Stop()
+			return err
+		}
+	}
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+func (p RetryPolicy) clock() ClockSource {
+	if p.ClockSource != nil {
+		return p.ClockSource
+	}
+	return SystemClock
+}
+
+func (p RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts != 0 {
+		return p.MaxAttempts
+	}
+	return 10
+}
+
+func (p RetryPolicy) minBackoff() time.Duration {
+	if p.MinBackoff > 0 {
+		return p.MinBackoff
+	}
+	return 100 * time.Millisecond
+}
+
+func (p RetryPolicy) maxBackoff() time.Duration {
+	if p.MaxBackoff > 0 {
+		return p.MaxBackoff
+	}
+	return 10 * time.Second
+}
+
+func (p RetryPolicy) jitter() float64 {
+	switch {
+	case p.Jitter < 0:
+		return 0
+	case p.Jitter > 1:
+		return 1
+	default:
+		return p.Jitter
+	}
+}
@@ -0,0 +1,103 @@
+package stats_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := stats.RetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	for attempt, delay := range []time.Duration{0, 1 * time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if d := p.Backoff(attempt); d != delay {
+			t.Errorf("bad backoff for attempt %d: %s != %s", attempt, d, delay)
+		}
+	}
+
+	p.Jitter = 0.5
+
+	for i := 0; i != 100; i++ {
+		if d := p.Backoff(2); d < time.Second || d > 2*time.Second {
+			t.Fatal("backoff out of the jitter range:", d)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	errPermanent := errors.New("permanent")
+
+	tests := []struct {
+		scenario string
+		errors   []error
+		attempts int
+		err      error
+	}{
+		{
+			scenario: "successful operations are not retried",
+			errors:   []error{nil},
+			attempts: 1,
+		},
+		{
+			scenario: "failed operations are retried until they succeed",
+			errors:   []error{errTemporary, errTemporary, nil},
+			attempts: 3,
+		},
+		{
+			scenario: "operations failing with errors that are not retryable are not retried",
+			errors:   []error{errTemporary, errPermanent, nil},
+			attempts: 2,
+			err:      errPermanent,
+		},
+		{
+			scenario: "operations are not retried after the maximum number of attempts",
+			errors:   []error{errTemporary, errTemporary, errTemporary, errTemporary, nil},
+			attempts: 3,
+			err:      errTemporary,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			p := stats.RetryPolicy{
+				MaxAttempts: 3,
+				MinBackoff:  time.Millisecond,
+				Retryable:   func(err error) bool { return err != errPermanent },
+			}
+
+			attempts := 0
+
+			err := p.Do(nil, func() error {
+				attempts++
+				return test.errors[attempts-1]
+			})
+
+			if err != test.err {
+				t.Errorf("bad error: %v != %v", err, test.err)
+			}
+
+			if attempts != test.attempts {
+				t.Errorf("bad number of attempts: %d != %d", attempts, test.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDoCanceled(t *testing.T) {
+	done := make(chan struct{})
+	close(done)
+
+	attempts := 0
+
+	stats.RetryPolicy{MaxAttempts: -1}.Do(done, func() error {
+		attempts++
+		return errors.New("oops")
+	})
+
+	if attempts != 1 {
+		t.Error("the operation was retried after done was closed:", attempts)
+	}
+}
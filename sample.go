@@ -0,0 +1,53 @@
+package stats
+
+import "math/rand"
+
+// WithSampleRate returns a copy of the engine which only forwards a fraction of
+// the counter increments and histogram observations it produces, see the
+// SampleRate field of Engine for details.
+//
+// Programs which need to sample a single metric should create a sampled engine
+// (or a handle from it) once and reuse it:
+//
+//	rtt := stats.WithSampleRate(0.1).Handle("rpc.rtt", stats.Histogram)
+//	...
+//	rtt.Measure(time.Since(start))
+func (eng *Engine) WithSampleRate(rate float64) *Engine {
+	e := eng.WithPrefix("")
+	e.SampleRate = rate
+	return e
+}
+
+// WithSampleRate returns a copy of the default engine with the given sample
+// rate.
+func WithSampleRate(rate float64) *Engine {
+	return DefaultEngine.WithSampleRate(rate)
+}
+
+// sampling returns true if measures produced with rate must be sampled.
+func sampling(rate float64) bool {
+	return rate > 0 && rate < 1
+}
+
+// sampled randomly decides whether a measure produced with rate is kept.
+func sampled(rate float64) bool {
+	return rand.Float64() < rate
+}
+
+// sampleWeight returns the number of measures that a measure sampled at rate
+// stands for, which is one if the measure was not sampled.
+func sampleWeight(rate float64) float64 {
+	if !sampling(rate) {
+		return 1
+	}
+	return 1 / rate
+}
+
+// unsample returns the value of a counter increment sampled at rate, scaled to
+// estimate the total of the increments that it stands for.
+func unsample(v Value, rate float64) Value {
+	if !sampling(rate) {
+		return v
+	}
+	return float64Value(valueToFloat(v) / rate)
+}
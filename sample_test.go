@@ -0,0 +1,110 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestEngineSampleRate(t *testing.T) {
+	const calls = 10000
+
+	h := &statstest.Handler{}
+	e := stats.NewEngine("test", h, stats.T("service", "test")).WithSampleRate(0.25)
+
+	handle := e.Handle("D", stats.Histogram)
+
+	for i := 0; i != calls; i++ {
+		e.Incr("A")
+		e.Observe("B", 1, stats.T("a", "b"))
+		e.Set("C", 1)
+		handle.Measure(1)
+	}
+
+	counts := map[string]int{}
+
+	for _, m := range h.Measures() {
+		counts[m.Name]++
+
+		if m.Name == "test.C" {
+			if m.SampleRate != 0 {
+				t.Errorf("gauge was sampled: %v", m)
+			}
+		} else if m.SampleRate != 0.25 {
+			t.Errorf("bad sample rate on %v: %g", m, m.SampleRate)
+		}
+
+		for _, tag := range m.Tags {
+			if tag.Name == "sample_rate" {
+				t.Errorf("sample rate reported as a tag on %v", m)
+			}
+		}
+
+		if !stats.TagsAreSorted(m.Tags) {
+			t.Errorf("tags are not sorted: %v", m.Tags)
+		}
+	}
+
+	for _, name := range []string{"test.A", "test.B", "test.D"} {
+		if n := counts[name]; n < calls/8 || n > calls/2 {
+			t.Errorf("%s: bad number of sampled measures: %d", name, n)
+		}
+	}
+
+	if n := counts["test.C"]; n != calls {
+		t.Errorf("bad number of gauges: %d", n)
+	}
+}
+
+func TestEngineWithoutSampleRate(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("test", h)
+
+	for i := 0; i != 100; i++ {
+		e.Incr("A")
+	}
+
+	if n := len(h.Measures()); n != 100 {
+		t.Errorf("bad number of measures: %d", n)
+	}
+
+	if m := h.Measures()[0]; len(m.Tags) != 0 {
+		t.Errorf("bad tags: %v", m.Tags)
+	}
+}
+
+func TestCounterAggregatorSampleRate(t *testing.T) {
+	h := &statstest.Handler{}
+	c := &stats.CounterAggregator{Handler: h}
+
+	sampled := []stats.Measure{
+		{
+			Name:       "test.A",
+			Fields:     []stats.Field{stats.MakeField("", 1, stats.Counter)},
+			SampleRate: 0.25,
+		},
+		{
+			Name:   "test.A",
+			Fields: []stats.Field{stats.MakeField("", 1, stats.Counter)},
+		},
+	}
+
+	c.HandleMeasures(time.Now(), sampled...)
+	c.Flush()
+
+	for _, m := range h.Measures() {
+		if m.Name != "test.A" {
+			continue
+		}
+		if v := m.Fields[0].Value.Float(); v != 5 {
+			t.Errorf("bad value of sampled counter: %g", v)
+		}
+		return
+	}
+
+	t.Error("counter not found in the flushed measures:", h.Measures())
+}
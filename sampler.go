@@ -0,0 +1,216 @@
+package stats
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AdaptiveSampler is a measure handler which reduces the number of histogram
+// observations forwarded to its base handler when the base handler becomes
+// slow to accept measures.
+//
+// The sampler tracks the average time spent in the base handler over periods
+// of AdjustInterval. When the average exceeds Threshold the sampler doubles the
+// fraction of histogram observations that it drops (up to 1 - MinRate), and
+// halves it again once the pressure subsides. Observations are dropped in a
+// deterministic way: for a given measure name and sample rate of 1/N, one in N
+// observations is forwarded.
+//
+// Counters and gauges are never dropped, the sampler prefers degrading the
+// precision of distributions over losing increments. Sampled histogram fields
+// are forwarded in a separate measure with the sample rate set, so handlers can
+// scale the values accordingly.
+type AdaptiveSampler struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// Average time spent in the base handler above which the sampler
+	// considers it is under pressure. Defaults to 1ms.
+	Threshold time.Duration
+
+	// Period over which the base handler latency is averaged before the
+	// sample rate gets adjusted. Defaults to 1s.
+	AdjustInterval time.Duration
+
+	// Lower bound for the sample rate. Defaults to 1/64.
+	MinRate float64
+
+	stride  uint64 // 1/rate, always a power of two
+	elapsed int64  // nanoseconds spent in the base handler in this period
+	calls   int64  // number of calls to the base handler in this period
+	adjust  int64  // unix nano time of the last adjustment
+	lock    int32  // set while a goroutine adjusts the sample rate
+	counts  [64]uint64
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (s *AdaptiveSampler) HandleMeasures(t time.Time, measures ...Measure) {
+	stride := s.loadStride()
+
+	if stride > 1 {
+		b := samplerPool.Get().(*samplerBuffer)
+		b.measures = s.sample(b.measures[:0], stride, measures)
+		s.handleMeasures(t, b.measures...)
+		b.reset()
+		samplerPool.Put(b)
+	} else {
+		s.handleMeasures(t, measures...)
+	}
+}
+
+// Flush satisfies the Flusher interface.
+func (s *AdaptiveSampler) Flush() {
+	flush(s.Handler)
+}
+
+// Rate returns the fraction of histogram observations currently forwarded to
+// the base handler, a value between MinRate and 1.
+func (s *AdaptiveSampler) Rate() float64 {
+	return 1 / float64(s.loadStride())
+}
+
+func (s *AdaptiveSampler) handleMeasures(t time.Time, measures ...Measure) {
+	if len(measures) == 0 {
+		return
+	}
+
+	start := time.Now()
+	s.Handler.HandleMeasures(t, measures...)
+	now := time.Now()
+
+	atomic.AddInt64(&s.elapsed, int64(now.Sub(start)))
+	atomic.AddInt64(&s.calls, 1)
+
+	if last := atomic.LoadInt64(&s.adjust); last == 0 {
+		atomic.CompareAndSwapInt64(&s.adjust, 0, now.UnixNano())
+	} else if time.Duration(now.UnixNano()-last) >= s.adjustInterval() {
+		if atomic.CompareAndSwapInt32(&s.lock, 0, 1) {
+			s.adjustStride(now)
+			atomic.StoreInt32(&s.lock, 0)
+		}
+	}
+}
+
+func (s *AdaptiveSampler) adjustStride(now time.Time) {
+	elapsed := atomic.SwapInt64(&s.elapsed, 0)
+	calls := atomic.SwapInt64(&s.calls, 0)
+	atomic.StoreInt64(&s.adjust, now.UnixNano())
+
+	if calls == 0 {
+		return
+	}
+
+	stride := s.loadStride()
+
+	switch avg := time.Duration(elapsed / calls); {
+	case avg > s.threshold():
+		if float64(2*stride) <= 1/s.minRate() {
+			stride *= 2
+		}
+	case avg < s.threshold()/2:
+		if stride > 1 {
+			stride /= 2
+		}
+	}
+
+	atomic.StoreUint64(&s.stride, stride)
+}
+
+func (s *AdaptiveSampler) sample(buf []Measure, stride uint64, measures []Measure) []Measure {
+	for _, m := range measures {
+		if !hasHistogram(m.Fields) {
+			buf = append(buf, m)
+			continue
+		}
+
+		kept, sampled := m, Measure{Name: m.Name, Tags: m.Tags, Const: m.Const}
+		kept.Fields = nil
+		selected := s.selected(m.Name, stride)
+
+		for _, f := range m.Fields {
+			if f.Type() != Histogram {
+				kept.Fields = append(kept.Fields, f)
+			} else if selected {
+				sampled.Fields = append(sampled.Fields, f)
+			}
+		}
+
+		if len(kept.Fields) != 0 {
+			buf = append(buf, kept)
+		}
+
+		if len(sampled.Fields) != 0 {
+			// Measures which were already sampled by the engine are sampled
+			// again, so the rates multiply.
+			sampled.SampleRate = 1 / float64(stride)
+			if sampling(m.SampleRate) {
+				sampled.SampleRate *= m.SampleRate
+			}
+			buf = append(buf, sampled)
+		}
+	}
+
+	return buf
+}
+
+func (s *AdaptiveSampler) selected(name string, stride uint64) bool {
+	h := uint64(14695981039346656037)
+	for i := 0; i != len(name); i++ {
+		h = (h ^ uint64(name[i])) * 1099511628211
+	}
+	n := atomic.AddUint64(&s.counts[h%uint64(len(s.counts))], 1)
+	return (n % stride) == 0
+}
+
+func hasHistogram(fields []Field) bool {
+	for _, f := range fields {
+		if f.Type() == Histogram {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AdaptiveSampler) loadStride() uint64 {
+	if stride := atomic.LoadUint64(&s.stride); stride != 0 {
+		return stride
+	}
+	return 1
+}
+
+func (s *AdaptiveSampler) threshold() time.Duration {
+	if s.Threshold != 0 {
+		return s.Threshold
+	}
+	return 1 * time.Millisecond
+}
+
+func (s *AdaptiveSampler) adjustInterval() time.Duration {
+	if s.AdjustInterval != 0 {
+		return s.AdjustInterval
+	}
+	return 1 * time.Second
+}
+
+func (s *AdaptiveSampler) minRate() float64 {
+	if s.MinRate > 0 && s.MinRate <= 1 {
+		return s.MinRate
+	}
+	return 1.0 / 64
+}
+
+type samplerBuffer struct {
+	measures []Measure
+}
+
+func (b *samplerBuffer) reset() {
+	for i := range b.measures {
+		b.measures[i] = Measure{}
+	}
+	b.measures = b.measures[:0]
+}
+
+var samplerPool = sync.Pool{
+	New: func() interface{} { return &samplerBuffer{measures: make([]Measure, 0, 32)} },
+}
@@ -0,0 +1,85 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestAdaptiveSampler(t *testing.T) {
+	t.Run("the sampler forwards all measures when the handler is fast", func(t *testing.T) {
+		h := &statstest.Handler{}
+		s := &stats.AdaptiveSampler{Handler: h, AdjustInterval: time.Nanosecond}
+		e := stats.NewEngine("test", s)
+
+		for i := 0; i != 10; i++ {
+			e.Observe("size", i)
+		}
+
+		if n := len(h.Measures()); n != 10 {
+			t.Error("bad number of measures:", n)
+		}
+
+		if r := s.Rate(); r != 1 {
+			t.Error("bad sample rate:", r)
+		}
+	})
+
+	t.Run("the sampler drops histogram observations but not counters when the handler is slow", func(t *testing.T) {
+		h := &statstest.Handler{}
+		s := &stats.AdaptiveSampler{
+			Handler: stats.HandlerFunc(func(t time.Time, measures ...stats.Measure) {
+				time.Sleep(2 * time.Millisecond)
+				h.HandleMeasures(t, measures...)
+			}),
+			Threshold:      time.Millisecond,
+			AdjustInterval: time.Nanosecond,
+			MinRate:        0.25,
+		}
+		e := stats.NewEngine("test", s)
+
+		for i := 0; i != 5; i++ {
+			e.Incr("calls")
+		}
+
+		if r := s.Rate(); r != 0.25 {
+			t.Fatal("bad sample rate:", r)
+		}
+
+		h.Clear()
+
+		for i := 0; i != 8; i++ {
+			e.Incr("calls")
+			e.Observe("size", i)
+		}
+
+		counters, histograms := 0, 0
+
+		for _, m := range h.Measures() {
+			switch m.Fields[0].Type() {
+			case stats.Counter:
+				counters++
+				if len(m.Tags) != 0 || m.SampleRate != 0 {
+					t.Error("unexpected tags or sample rate on counter:", m)
+				}
+			case stats.Histogram:
+				histograms++
+				if len(m.Tags) != 0 || m.SampleRate != 0.25 {
+					t.Error("bad sampled histogram:", m, m.SampleRate)
+				}
+			}
+		}
+
+		if counters != 8 {
+			t.Error("bad number of counters:", counters)
+		}
+
+		if histograms != 2 {
+			t.Error("bad number of histograms:", histograms)
+		}
+	})
+}
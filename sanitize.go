@@ -0,0 +1,168 @@
+package stats
+
+import (
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SanitizePolicy is an enumeration of the behaviors a Sanitizer may have when
+// it finds invalid measures.
+type SanitizePolicy int
+
+const (
+	// SanitizeReplace replaces invalid UTF-8 sequences and control characters
+	// with the configured replacement string.
+	SanitizeReplace SanitizePolicy = iota
+
+	// SanitizeReject drops measures that contain invalid UTF-8 sequences or
+	// control characters.
+	SanitizeReject
+)
+
+// Sanitizer is a measure handler which validates that measure names, field
+// names, and tags are made of valid UTF-8 sequences with no control characters
+// before forwarding them to its base handler.
+//
+// Most wire protocols supported by the stats package are text-based and break
+// when raw bytes (coming from request data for example) end up in the metrics.
+type Sanitizer struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// Policy configures how invalid measures are handled, the default is to
+	// replace invalid characters.
+	Policy SanitizePolicy
+
+	// Replacement is the string used to replace invalid characters when the
+	// policy is SanitizeReplace, defaults to the unicode replacement character.
+	Replacement string
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (s *Sanitizer) HandleMeasures(t time.Time, measures ...Measure) {
+	b := measurePool.Get().(*measuresBuffer)
+	ms := b.measures[:0]
+
+	for _, m := range measures {
+		if !validMeasure(m) {
+			if s.Policy == SanitizeReject {
+				continue
+			}
+			m = s.sanitize(m)
+		}
+		ms = append(ms, m)
+	}
+
+	if len(ms) != 0 {
+		s.Handler.HandleMeasures(t, ms...)
+	}
+
+	for i := range ms {
+		ms[i] = Measure{}
+	}
+
+	b.measures = ms[:0]
+	measurePool.Put(b)
+}
+
+// Flush satisfies the Flusher interface.
+func (s *Sanitizer) Flush() {
+	flush(s.Handler)
+}
+
+func (s *Sanitizer) sanitize(m Measure) Measure {
+	tags := m.Tags
+	m.Name = s.sanitizeString(m.Name)
+	m.Fields = copyFields(m.Fields)
+	m.Tags = copyTags(m.Tags)
+
+	for i := range m.Fields {
+		m.Fields[i].Name = s.sanitizeString(m.Fields[i].Name)
+	}
+
+	for i := range m.Tags {
+		m.Tags[i].Name = s.sanitizeString(m.Tags[i].Name)
+		m.Tags[i].Value = s.sanitizeString(m.Tags[i].Value)
+	}
+
+	// Replacing characters in tag names may have changed their order.
+	if !TagsAreSorted(m.Tags) {
+		SortTags(m.Tags)
+	}
+
+	// The cached encoding of the constant tags carries the invalid strings.
+	if m.Const != nil && !tagsEqual(tags, m.Tags) {
+		m.Const = nil
+	}
+
+	return m
+}
+
+func (s *Sanitizer) sanitizeString(str string) string {
+	if validString(str) {
+		return str
+	}
+
+	repl := s.Replacement
+	if len(repl) == 0 {
+		repl = string(utf8.RuneError)
+	}
+
+	b := make([]byte, 0, len(str))
+
+	for i := 0; i < len(str); {
+		r, n := utf8.DecodeRuneInString(str[i:])
+
+		if (r == utf8.RuneError && n == 1) || unicode.IsControl(r) {
+			b = append(b, repl...)
+		} else {
+			b = append(b, str[i:i+n]...)
+		}
+
+		i += n
+	}
+
+	return string(b)
+}
+
+func validMeasure(m Measure) bool {
+	if !validString(m.Name) {
+		return false
+	}
+
+	for _, f := range m.Fields {
+		if !validString(f.Name) {
+			return false
+		}
+	}
+
+	for _, t := range m.Tags {
+		if !validString(t.Name) || !validString(t.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validString(s string) bool {
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c < 0x20 || c == 0x7F {
+				return false
+			}
+			i++
+			continue
+		}
+
+		r, n := utf8.DecodeRuneInString(s[i:])
+
+		if (r == utf8.RuneError && n == 1) || unicode.IsControl(r) {
+			return false
+		}
+
+		i += n
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSanitizer(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{in: "", out: ""},
+		{in: "hello", out: "hello"},
+		{in: "héllo wörld", out: "héllo wörld"},
+		{in: "hello\nworld", out: "hello_world"},
+		{in: "hello\xffworld", out: "hello_world"},
+		{in: "\x00\x7f", out: "__"},
+	}
+
+	s := &Sanitizer{Replacement: "_"}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			if v := validString(test.in); v != (test.in == test.out) {
+				t.Errorf("validString(%q) => %t", test.in, v)
+			}
+
+			if s := s.sanitizeString(test.in); s != test.out {
+				t.Errorf("sanitizeString(%q) => %q != %q", test.in, s, test.out)
+			}
+		})
+	}
+}
+
+func TestSanitizerReject(t *testing.T) {
+	n := 0
+	s := &Sanitizer{
+		Policy:  SanitizeReject,
+		Handler: HandlerFunc(func(time time.Time, measures ...Measure) { n += len(measures) }),
+	}
+
+	s.HandleMeasures(time.Now(),
+		Measure{Name: "valid", Tags: []Tag{T("a", "1")}},
+		Measure{Name: "invalid", Tags: []Tag{T("a", "\xff")}},
+	)
+
+	if n != 1 {
+		t.Error("bad number of measures forwarded:", n)
+	}
+}
@@ -0,0 +1,232 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Schema describes the constraints that measures of a given name must satisfy.
+type Schema struct {
+	// Names of the tags that must be set on the measures.
+	RequiredTags []string
+
+	// If not empty, the list of tag names that may be set on the measures (in
+	// addition to the required tags).
+	AllowedTags []string
+
+	// Lists of allowed values for tags, indexed by tag name.
+	AllowedValues map[string][]string
+
+	// Bounds of the range of values accepted for the measure fields, a nil
+	// bound leaves the range open on that side.
+	Min *float64
+	Max *float64
+}
+
+// SchemaError is the error type reported by SchemaValidator when a measure
+// does not satisfy its schema.
+type SchemaError struct {
+	Measure string
+	Field   string
+	Reason  string
+}
+
+// Error satisfies the error interface.
+func (e *SchemaError) Error() string {
+	name := e.Measure
+	if len(e.Field) != 0 {
+		name += ":" + e.Field
+	}
+	return "stats: schema violation on " + name + ": " + e.Reason
+}
+
+// SchemaValidator is a measure handler which checks measures against schemas
+// before forwarding them to its base handler, it helps catching instrumentation
+// bugs before they end up on dashboards.
+type SchemaValidator struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// Schemas indexed by metric name. Names may either identify a whole
+	// measure (e.g. "http.requests") or a single field using the same syntax
+	// as HistogramBuckets (e.g. "http.requests:time"). Names include the
+	// engine prefix.
+	Schemas map[string]Schema
+
+	// Fail is called with a *SchemaError for every violation, it may be nil.
+	Fail func(error)
+
+	// When Drop is true measures that violate their schema are not forwarded
+	// to the base handler.
+	Drop bool
+
+	once    sync.Once
+	schemas map[Key]*compiledSchema
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (v *SchemaValidator) HandleMeasures(t time.Time, measures ...Measure) {
+	v.once.Do(v.init)
+
+	if !v.Drop {
+		for _, m := range measures {
+			v.validate(m)
+		}
+		v.Handler.HandleMeasures(t, measures...)
+		return
+	}
+
+	b := measurePool.Get().(*measuresBuffer)
+	ms := b.measures[:0]
+
+	for _, m := range measures {
+		if v.validate(m) {
+			ms = append(ms, m)
+		}
+	}
+
+	if len(ms) != 0 {
+		v.Handler.HandleMeasures(t, ms...)
+	}
+
+	for i := range ms {
+		ms[i] = Measure{}
+	}
+
+	b.measures = ms[:0]
+	measurePool.Put(b)
+}
+
+// Flush satisfies the Flusher interface.
+func (v *SchemaValidator) Flush() {
+	flush(v.Handler)
+}
+
+func (v *SchemaValidator) init() {
+	v.schemas = make(map[Key]*compiledSchema, len(v.Schemas))
+
+	for name, s := range v.Schemas {
+		v.schemas[makeKey(name)] = compileSchema(s)
+	}
+}
+
+func (v *SchemaValidator) validate(m Measure) bool {
+	valid := true
+
+	if s := v.schemas[Key{Measure: m.Name}]; s != nil {
+		valid = v.check(s.validateTags(m), m.Name, "") && valid
+		for _, f := range m.Fields {
+			valid = v.check(s.validateValue(f.Value), m.Name, f.Name) && valid
+		}
+	}
+
+	for _, f := range m.Fields {
+		if len(f.Name) == 0 {
+			continue // already validated by the measure schema
+		}
+		if s := v.schemas[Key{Measure: m.Name, Field: f.Name}]; s != nil {
+			valid = v.check(s.validateTags(m), m.Name, f.Name) && valid
+			valid = v.check(s.validateValue(f.Value), m.Name, f.Name) && valid
+		}
+	}
+
+	return valid
+}
+
+func (v *SchemaValidator) check(reason string, measure string, field string) bool {
+	if len(reason) == 0 {
+		return true
+	}
+	if v.Fail != nil {
+		v.Fail(&SchemaError{Measure: measure, Field: field, Reason: reason})
+	}
+	return false
+}
+
+type compiledSchema struct {
+	required []string
+	allowed  map[string]struct{}
+	values   map[string]map[string]struct{}
+	min      float64
+	max      float64
+}
+
+func compileBound(b *float64, unbounded float64) float64 {
+	if b == nil {
+		return unbounded
+	}
+	return *b
+}
+
+func compileSchema(s Schema) *compiledSchema {
+	c := &compiledSchema{
+		required: s.RequiredTags,
+		values:   make(map[string]map[string]struct{}, len(s.AllowedValues)),
+		min:      compileBound(s.Min, math.Inf(-1)),
+		max:      compileBound(s.Max, math.Inf(+1)),
+	}
+
+	if len(s.AllowedTags) != 0 {
+		c.allowed = make(map[string]struct{}, len(s.AllowedTags)+len(s.RequiredTags))
+		for _, name := range s.AllowedTags {
+			c.allowed[name] = struct{}{}
+		}
+		for _, name := range s.RequiredTags {
+			c.allowed[name] = struct{}{}
+		}
+	}
+
+	for name, values := range s.AllowedValues {
+		set := make(map[string]struct{}, len(values))
+		for _, value := range values {
+			set[value] = struct{}{}
+		}
+		c.values[name] = set
+	}
+
+	return c
+}
+
+func (s *compiledSchema) validateTags(m Measure) string {
+	for _, name := range s.required {
+		if !hasTag(m.Tags, name) {
+			return "missing required tag " + name
+		}
+	}
+
+	for _, t := range m.Tags {
+		if s.allowed != nil {
+			if _, ok := s.allowed[t.Name]; !ok {
+				return "tag " + t.Name + " is not allowed"
+			}
+		}
+		if values := s.values[t.Name]; values != nil {
+			if _, ok := values[t.Value]; !ok {
+				return "value " + t.Value + " is not allowed for tag " + t.Name
+			}
+		}
+	}
+
+	return ""
+}
+
+func (s *compiledSchema) validateValue(v Value) string {
+	x := valueToFloat(v)
+
+	if x < s.min || x > s.max {
+		return fmt.Sprintf("value %v is out of range [%v, %v]", x, s.min, s.max)
+	}
+
+	return ""
+}
+
+func hasTag(tags []Tag, name string) bool {
+	for _, t := range tags {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestSchemaValidator(t *testing.T) {
+	var errs []error
+	var min, max float64 = 0, 1024
+
+	h := &statstest.Handler{}
+	v := &stats.SchemaValidator{
+		Handler: h,
+		Drop:    true,
+		Fail:    func(err error) { errs = append(errs, err) },
+		Schemas: map[string]stats.Schema{
+			"test.requests": {
+				RequiredTags:  []string{"status"},
+				AllowedTags:   []string{"method"},
+				AllowedValues: map[string][]string{"method": {"GET", "POST"}},
+			},
+			"test.requests:size": {
+				Min: &min,
+				Max: &max,
+			},
+		},
+	}
+
+	eng := stats.NewEngine("test", v)
+	eng.Incr("requests", stats.T("status", "200"), stats.T("method", "GET")) // ok
+	eng.Incr("requests", stats.T("method", "GET"))                           // missing status
+	eng.Incr("requests", stats.T("status", "200"), stats.T("path", "/"))     // unknown tag
+	eng.Incr("requests", stats.T("status", "200"), stats.T("method", "PUT")) // bad value
+	eng.Observe("requests:size", 4096, stats.T("status", "200"))             // out of range
+	eng.Observe("requests:size", 512, stats.T("status", "200"))              // ok
+	eng.Incr("other", stats.T("anything", "goes"))                           // no schema
+
+	if n := len(errs); n != 4 {
+		t.Error("bad number of errors:", n, errs)
+	}
+
+	if n := len(h.Measures()); n != 3 {
+		t.Error("bad number of measures:", n)
+	}
+}
+
+func TestSchemaValidatorOpenRange(t *testing.T) {
+	var min, max float64 = 0.5, -1
+
+	tests := []struct {
+		schema stats.Schema
+		value  float64
+		valid  bool
+	}{
+		{schema: stats.Schema{Min: &min}, value: 0.25, valid: false},
+		{schema: stats.Schema{Min: &min}, value: 0.5, valid: true},
+		{schema: stats.Schema{Min: &min}, value: 1e9, valid: true},
+		{schema: stats.Schema{Max: &max}, value: -1e9, valid: true},
+		{schema: stats.Schema{Max: &max}, value: -1, valid: true},
+		{schema: stats.Schema{Max: &max}, value: 0, valid: false},
+		{schema: stats.Schema{}, value: 1e9, valid: true},
+	}
+
+	for _, test := range tests {
+		var errs []error
+
+		v := &stats.SchemaValidator{
+			Handler: &statstest.Handler{},
+			Fail:    func(err error) { errs = append(errs, err) },
+			Schemas: map[string]stats.Schema{"test.value": test.schema},
+		}
+
+		stats.NewEngine("test", v).Set("value", test.value)
+
+		if valid := len(errs) == 0; valid != test.valid {
+			t.Errorf("value %g: expected valid=%t but got errors %v", test.value, test.valid, errs)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// Shadow is a measure handler which forwards measures to a primary handler and
+// duplicates them to a candidate handler, it is intended to be used to validate
+// the migration from a metric collection system to another.
+//
+// The candidate handler is isolated from the program: it receives copies of the
+// measures from a goroutine, through a bounded queue which drops the measures
+// when the candidate is too slow to keep up, and panics that occur while it
+// handles measures are recovered and counted as failures. If the candidate has
+// an Errors method returning a channel of errors (like Buffer), the errors are
+// drained from the channel and counted as failures as well.
+//
+// If Engine is set, the shadow handler reports the following metrics to it:
+//
+//	shadow.calls         (counter)   number of calls to each handler
+//	shadow.failures      (counter)   number of calls that failed on each handler
+//	shadow.latency       (histogram) time spent in each handler
+//	shadow.dropped       (counter)   number of measures dropped from the queue
+//	shadow.divergence    (gauge)     number of measures handled by the primary
+//	                                 handler but not by the candidate
+//
+// Each metric has a "handler" tag set to either "primary" or "candidate". The
+// divergence is computed between two flushes of the shadow handler, a value
+// other than zero means that the candidate lost measures (because they were
+// dropped, or because it failed to handle them). The engine must not forward
+// measures back to the shadow handler.
+type Shadow struct {
+	// The handler that the program relies on.
+	Handler Handler
+
+	// The handler being validated.
+	Candidate Handler
+
+	// Engine where divergence metrics are reported, may be nil.
+	Engine *Engine
+
+	// Maximum number of calls queued for the candidate handler, defaults to
+	// 1000.
+	QueueSize int
+
+	once    sync.Once
+	mutex   sync.Mutex
+	queue   chan shadowCall
+	done    chan struct{}
+	closed  bool
+	primary int // measures passed to the primary handler since the last flush
+	handled int // measures handled by the candidate since the last flush
+}
+
+type shadowCall struct {
+	time     time.Time
+	measures []Measure
+	flush    bool
+	primary  int // measures passed to the primary handler before the flush
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (s *Shadow) HandleMeasures(t time.Time, measures ...Measure) {
+	s.once.Do(s.start)
+
+	start := time.Now()
+	s.Handler.HandleMeasures(t, measures...)
+	s.report(&shadowMetrics{handler: "primary", calls: 1, latency: time.Since(start)})
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.primary += len(measures)
+
+	select {
+	case s.queue <- shadowCall{time: t, measures: cloneMeasures(measures)}:
+	default:
+		s.report(&shadowMetrics{handler: "candidate", dropped: len(measures)})
+	}
+}
+
+// Flush satisfies the Flusher interface, the candidate handler is flushed
+// asynchronously once it handled the measures queued before the call.
+func (s *Shadow) Flush() {
+	s.once.Do(s.start)
+	flush(s.Handler)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.queue <- shadowCall{flush: true, primary: s.primary}:
+		s.primary = 0
+	default:
+		// The divergence is computed at the next flush.
+	}
+}
+
+// Close satisfies the io.Closer interface, it waits for the candidate handler
+// to handle the queued measures and flushes it. Measures received after the
+// shadow handler was closed are only passed to the primary handler.
+func (s *Shadow) Close() error {
+	s.once.Do(s.start)
+	flush(s.Handler)
+
+	s.mutex.Lock()
+	if !s.closed {
+		s.closed = true
+		s.queue <- shadowCall{flush: true, primary: s.primary}
+		s.primary = 0
+		close(s.queue)
+	}
+	s.mutex.Unlock()
+
+	<-s.done
+	return nil
+}
+
+func (s *Shadow) start() {
+	size := s.QueueSize
+	if size <= 0 {
+		size = 1000
+	}
+
+	s.queue = make(chan shadowCall, size)
+	s.done = make(chan struct{})
+
+	if h, ok := s.Candidate.(interface{ Errors() <-chan error }); ok {
+		go s.drain(h.Errors())
+	}
+
+	go s.run()
+}
+
+func (s *Shadow) run() {
+	defer close(s.done)
+
+	for call := range s.queue {
+		if call.flush {
+			s.candidate(func() { flush(s.Candidate) })
+			s.report(&shadowDivergence{handler: "candidate", divergence: call.primary - s.handled})
+			s.handled = 0
+			continue
+		}
+
+		start := time.Now()
+		failed := s.candidate(func() { s.Candidate.HandleMeasures(call.time, call.measures...) })
+		m := shadowMetrics{handler: "candidate", calls: 1, latency: time.Since(start)}
+
+		if failed {
+			m.failures = 1
+		} else {
+			s.handled += len(call.measures)
+		}
+
+		s.report(&m)
+	}
+}
+
+func (s *Shadow) drain(errs <-chan error) {
+	for range errs {
+		s.report(&shadowMetrics{handler: "candidate", failures: 1})
+	}
+}
+
+func (s *Shadow) candidate(f func()) (failed bool) {
+	defer func() {
+		if recover() != nil {
+			failed = true
+		}
+	}()
+	f()
+	return
+}
+
+func (s *Shadow) report(metrics interface{}) {
+	if s.Engine != nil {
+		s.Engine.Report(metrics)
+	}
+}
+
+func cloneMeasures(measures []Measure) []Measure {
+	cloned := make([]Measure, len(measures))
+	for i, m := range measures {
+		cloned[i] = m.Clone()
+	}
+	return cloned
+}
+
+type shadowMetrics struct {
+	handler  string        `tag:"handler"`
+	calls    int           `metric:"shadow.calls"    type:"counter"`
+	failures int           `metric:"shadow.failures" type:"counter"`
+	latency  time.Duration `metric:"shadow.latency"  type:"histogram"`
+	dropped  int           `metric:"shadow.dropped"  type:"counter"`
+}
+
+type shadowDivergence struct {
+	handler    string `tag:"handler"`
+	divergence int    `metric:"shadow.divergence" type:"gauge"`
+}
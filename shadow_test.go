@@ -0,0 +1,131 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestShadow(t *testing.T) {
+	primary := &statstest.Handler{}
+	metrics := &statstest.Handler{}
+
+	shadow := &stats.Shadow{
+		Handler: primary,
+		Candidate: stats.HandlerFunc(func(time.Time, ...stats.Measure) {
+			panic("candidate failure")
+		}),
+		Engine: stats.NewEngine("", metrics),
+	}
+
+	stats.NewEngine("test", shadow).Incr("calls")
+	shadow.Close()
+
+	if n := len(primary.Measures()); n != 1 {
+		t.Error("bad number of measures sent to the primary handler:", n)
+	}
+
+	failures := map[string]int{}
+
+	for _, m := range metrics.Measures() {
+		for _, f := range m.Fields {
+			if f.Name == "shadow.failures" {
+				failures[m.Tags[0].Value] += int(f.Value.Int())
+			}
+		}
+	}
+
+	if failures["primary"] != 0 || failures["candidate"] != 1 {
+		t.Error("bad failure counts:", failures)
+	}
+
+	metrics.ExpectGauge(t, ":shadow.divergence", 1, stats.T("handler", "candidate"))
+}
+
+func TestShadowCopiesMeasures(t *testing.T) {
+	candidate := &statstest.Handler{}
+	shadow := &stats.Shadow{Handler: &statstest.Handler{}, Candidate: candidate}
+
+	m := stats.Measure{Name: "test", Fields: []stats.Field{stats.MakeField("value", 1, stats.Counter)}}
+	shadow.HandleMeasures(time.Now(), m)
+	m.Fields[0].Name = "changed"
+	shadow.Close()
+
+	if ms := candidate.Measures(); len(ms) != 1 || ms[0].Fields[0].Name != "value" {
+		t.Error("bad measures sent to the candidate handler:", ms)
+	}
+}
+
+func TestShadowDrops(t *testing.T) {
+	metrics := &statstest.Handler{}
+	unblock := make(chan struct{})
+
+	shadow := &stats.Shadow{
+		Handler: &statstest.Handler{},
+		Candidate: stats.HandlerFunc(func(time.Time, ...stats.Measure) {
+			<-unblock
+		}),
+		Engine:    stats.NewEngine("", metrics),
+		QueueSize: 1,
+	}
+
+	eng := stats.NewEngine("test", shadow)
+
+	for i := 0; i != 3; i++ {
+		eng.Incr("calls")
+	}
+
+	close(unblock)
+	shadow.Close()
+
+	dropped := 0.0
+	for _, v := range metrics.Values(":shadow.dropped", stats.T("handler", "candidate")) {
+		dropped += v
+	}
+
+	if dropped < 1 {
+		t.Error("no measures were dropped")
+	}
+
+	metrics.ExpectGauge(t, ":shadow.divergence", dropped, stats.T("handler", "candidate"))
+}
+
+func TestShadowErrors(t *testing.T) {
+	metrics := &statstest.Handler{}
+	candidate := &erroringHandler{errs: make(chan error, 1)}
+
+	shadow := &stats.Shadow{
+		Handler:   &statstest.Handler{},
+		Candidate: candidate,
+		Engine:    stats.NewEngine("", metrics),
+	}
+
+	stats.NewEngine("test", shadow).Incr("calls")
+	shadow.Close()
+
+	// The errors are drained asynchronously from the candidate.
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if len(metrics.Values(":shadow.failures", stats.T("handler", "candidate"))) == 2 {
+			break
+		}
+	}
+
+	metrics.ExpectCounter(t, ":shadow.failures", 1, stats.T("handler", "candidate"))
+}
+
+type erroringHandler struct {
+	errs chan error
+}
+
+func (h *erroringHandler) HandleMeasures(time.Time, ...stats.Measure) {
+	h.errs <- errors.New("candidate error")
+}
+
+func (h *erroringHandler) Errors() <-chan error {
+	return h.errs
+}
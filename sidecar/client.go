@@ -0,0 +1,377 @@
+package sidecar
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/httpauth"
+	"golang.org/x/net/http2"
+)
+
+const (
+	// DefaultAddress is the default address of the agent that clients stream
+	// measures to.
+	DefaultAddress = "localhost:9125"
+
+	// DefaultBufferSize is the default size of the batches of measures sent to
+	// the agent.
+	DefaultBufferSize = 16384
+
+	// DefaultQueueSize is the default number of batches that clients hold in
+	// memory when the agent is not able to receive them.
+	DefaultQueueSize = 64
+
+	// DefaultMaxBackoff is the default maximum delay between attempts to
+	// reconnect to the agent.
+	DefaultMaxBackoff = 10 * time.Second
+
+	// DefaultTimeout is the default timeout for connecting to the agent and
+	// terminating streams.
+	DefaultTimeout = 5 * time.Second
+)
+
+// The ClientConfig type is used to configure sidecar clients.
+type ClientConfig struct {
+	// Address of the agent to stream measures to.
+	Address string
+
+	// Maximum size of the batches of measures sent to the agent.
+	BufferSize int
+
+	// Number of batches held in memory while the stream is blocked by flow
+	// control or reconnecting. Batches are dropped when the queue is full.
+	QueueSize int
+
+	// Policy applied to reconnect to the agent. The stream is always reopened,
+	// only the backoff and jitter settings apply.
+	Retry stats.RetryPolicy
+
+	// Timeout for connecting to the agent and terminating streams.
+	Timeout time.Duration
+
+	// Credentials used to authenticate the streams opened to the agent.
+	// Streams cannot be replayed, a stream rejected by the agent is reopened
+	// with the refreshed token.
+	//
+	// When TLS settings are configured, the client connects to the agent over
+	// TLS instead of plain text HTTP/2.
+	Auth httpauth.Config
+}
+
+// Client represents a sidecar client that implements the stats.Handler
+// interface.
+//
+// The client maintains a single stream to the agent, which is reopened with an
+// exponential backoff when it fails. Writes to the stream are subject to HTTP/2
+// flow control, batches are queued while the agent is not consuming them and
+// dropped when the queue is full so the program is never blocked.
+type Client struct {
+	stream *stream
+	buffer stats.Buffer
+}
+
+// NewClient creates and returns a new sidecar client streaming measures to
+// the agent running at addr.
+func NewClient(addr string) *Client {
+	return NewClientWith(ClientConfig{
+		Address: addr,
+	})
+}
+
+// NewClientWith creates and returns a new sidecar client configured with the
+// given config.
+func NewClientWith(config ClientConfig) *Client {
+	if len(config.Address) == 0 {
+		config.Address = DefaultAddress
+	}
+
+	if config.BufferSize == 0 {
+		config.BufferSize = DefaultBufferSize
+	}
+
+	if config.QueueSize == 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+
+	if config.Retry.MaxBackoff == 0 {
+		config.Retry.MaxBackoff = DefaultMaxBackoff
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	scheme := "http"
+	dialTLS := func(network string, addr string, _ *tls.Config) (net.Conn, error) {
+		return net.DialTimeout(network, addr, config.Timeout)
+	}
+
+	if tlsConfig := config.Auth.TLSConfig(); tlsConfig != nil {
+		// The TLS configuration is applied to each connection, so rotated
+		// client certificates are used when the stream is reopened.
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.NextProtos = []string{http2.NextProtoTLS}
+		scheme = "https"
+		dialTLS = func(network string, addr string, _ *tls.Config) (net.Conn, error) {
+			return tls.DialWithDialer(&net.Dialer{Timeout: config.Timeout}, network, addr, tlsConfig)
+		}
+	}
+
+	s := &stream{
+		url:     scheme + "://" + config.Address + "/stats.v1.Agent/Stream",
+		timeout: config.Timeout,
+		retry:   config.Retry,
+		queue:   make(chan []byte, config.QueueSize),
+		done:    make(chan struct{}),
+		exit:    make(chan struct{}),
+		transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS:   dialTLS,
+		},
+	}
+
+	s.roundTripper = s.transport
+
+	if config.Auth.Enabled() {
+		s.roundTripper = httpauth.NewTransport(s.transport, config.Auth)
+	}
+
+	go s.run()
+
+	c := &Client{stream: s}
+	c.buffer.BufferSize = config.BufferSize
+	c.buffer.Serializer = s
+	return c
+}
+
+// HandleMeasures satisfies the stats.Handler interface.
+func (c *Client) HandleMeasures(time time.Time, measures ...stats.Measure) {
+	c.buffer.HandleMeasures(time, measures...)
+}
+
+// Flush satisfies the stats.Flusher interface.
+func (c *Client) Flush() {
+	c.buffer.Flush()
+}
+
+// Errors returns a channel receiving the errors which occur streaming measures
+// to the agent. Once the program called the method, the errors are no longer
+// logged.
+func (c *Client) Errors() <-chan error {
+	return c.stream.errors.C()
+}
+
+// Dropped returns the number of batches that were dropped because the queue
+// was full.
+func (c *Client) Dropped() uint64 {
+	return atomic.LoadUint64(&c.stream.dropped)
+}
+
+// Close flushes and closes the client, satisfies the io.Closer interface.
+//
+// The method waits for the queued batches to be sent and the stream to be
+// terminated by the agent.
+func (c *Client) Close() error {
+	c.Flush()
+	c.stream.once.Do(func() { close(c.stream.done) })
+	<-c.stream.exit
+	return nil
+}
+
+type stream struct {
+	url          string
+	timeout      time.Duration
+	retry        stats.RetryPolicy
+	transport    *http2.Transport
+	roundTripper http.RoundTripper // transport, wrapped to authenticate requests
+	queue        chan []byte
+	dropped      uint64
+	errors       stats.ErrorChannel
+	once         sync.Once
+	done         chan struct{}
+	exit         chan struct{}
+}
+
+func (*stream) AppendMeasures(b []byte, time time.Time, measures ...stats.Measure) []byte {
+	return AppendMeasures(b, time, measures...)
+}
+
+func (s *stream) Write(b []byte) (int, error) {
+	select {
+	case s.queue <- append([]byte(nil), b...):
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+	return len(b), nil
+}
+
+func (s *stream) run() {
+	defer close(s.exit)
+	defer s.transport.CloseIdleConnections()
+
+	attempt := 0
+
+	for {
+		sent, err := s.send(s.open())
+
+		if err == nil {
+			return
+		}
+
+		if !s.errors.Report(err) {
+			log.Print("stats/sidecar: ", err)
+		}
+
+		if sent {
+			attempt = 0
+		}
+		attempt++
+
+		select {
+		case <-time.After(s.retry.Backoff(attempt)):
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// send writes the queued batches to the stream until the client is closed or
+// the stream fails. The returned boolean is true if at least one batch was
+// accepted by the agent.
+func (s *stream) send(c *conn) (sent bool, err error) {
+	stop := make(chan struct{})
+	defer close(stop)
+	defer c.cancel()
+
+	// Writes may be blocked by flow control if the agent stops consuming the
+	// stream, which must not prevent the client from being closed.
+	go func() {
+		select {
+		case <-s.done:
+		case <-stop:
+			return
+		}
+		select {
+		case <-time.After(s.timeout):
+			c.cancel()
+		case <-stop:
+		}
+	}()
+
+	for {
+		select {
+		case b := <-s.queue:
+			if _, err = c.w.Write(b); err != nil {
+				return
+			}
+			sent = true
+
+		case err = <-c.res:
+			return
+
+		case <-s.done:
+			for {
+				select {
+				case b := <-s.queue:
+					if _, err = c.w.Write(b); err != nil {
+						return
+					}
+				default:
+					err = c.close(s.timeout)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *stream) open() *conn {
+	r, w := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req, _ := http.NewRequest("POST", s.url, r)
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/grpc+proto")
+	req.Header.Set("TE", "trailers")
+
+	c := &conn{
+		w:      w,
+		cancel: cancel,
+		res:    make(chan error, 1),
+	}
+
+	go func() {
+		err := roundTrip(s.roundTripper, req)
+		if err == nil {
+			err = errStreamClosed
+		}
+		r.CloseWithError(err)
+		c.res <- err
+	}()
+
+	return c
+}
+
+type conn struct {
+	w      *io.PipeWriter
+	cancel context.CancelFunc
+	res    chan error
+}
+
+// close half-closes the stream and waits for the agent to terminate it.
+func (c *conn) close(timeout time.Duration) error {
+	c.w.Close()
+
+	select {
+	case err := <-c.res:
+		if err == errStreamClosed {
+			err = nil
+		}
+		return err
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+var errStreamClosed = errors.New("stream closed by the agent")
+
+func roundTrip(t http.RoundTripper, req *http.Request) error {
+	res, err := t.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("POST %s: %s", req.URL, res.Status)
+	}
+
+	status := res.Trailer.Get("Grpc-Status")
+	if len(status) == 0 {
+		// Trailers-only responses carry the status in the headers.
+		status = res.Header.Get("Grpc-Status")
+	}
+
+	if len(status) != 0 && status != "0" {
+		message := res.Trailer.Get("Grpc-Message")
+		if len(message) == 0 {
+			message = res.Header.Get("Grpc-Message")
+		}
+		return fmt.Errorf("POST %s: grpc-status %s: %s", req.URL, status, message)
+	}
+
+	return nil
+}
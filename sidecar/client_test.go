@@ -0,0 +1,194 @@
+package sidecar
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestClient(t *testing.T) {
+	var mutex sync.Mutex
+	var measures []stats.Measure
+
+	server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stats.v1.Agent/Stream" {
+			t.Error("bad path:", r.URL.Path)
+		}
+
+		for {
+			var prefix [5]byte
+
+			if _, err := io.ReadFull(r.Body, prefix[:]); err != nil {
+				break
+			}
+
+			b := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+
+			if _, err := io.ReadFull(r.Body, b); err != nil {
+				t.Error(err)
+				break
+			}
+
+			mutex.Lock()
+			measures = append(measures, parseBatch(t, b)...)
+			mutex.Unlock()
+		}
+
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Header().Set("Content-Type", "application/grpc+proto")
+		w.Write([]byte{0, 0, 0, 0, 0})
+		w.Header().Set("Grpc-Status", "0")
+	}), &http2.Server{}))
+	defer server.Close()
+
+	client := NewClient(strings.TrimPrefix(server.URL, "http://"))
+
+	for i := 0; i != 10; i++ {
+		client.HandleMeasures(time.Now(), stats.Measure{
+			Name: "request",
+			Fields: []stats.Field{
+				stats.MakeField("count", 1, stats.Counter),
+				stats.MakeField("rtt", time.Second, stats.Histogram),
+			},
+			Tags:       []stats.Tag{stats.T("answer", "42")},
+			SampleRate: 0.5,
+		})
+	}
+
+	if err := client.Close(); err != nil {
+		t.Error(err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if len(measures) != 10 {
+		t.Fatal("bad number of measures received by the agent:", len(measures))
+	}
+
+	for _, m := range measures {
+		if m.Name != "request" || len(m.Fields) != 2 || len(m.Tags) != 1 {
+			t.Fatalf("bad measure: %#v", m)
+		}
+
+		if f := m.Fields[0]; f.Name != "count" || f.Type() != stats.Counter || f.Value.Float() != 1 {
+			t.Errorf("bad field: %#v", f)
+		}
+
+		if f := m.Fields[1]; f.Name != "rtt" || f.Type() != stats.Histogram || f.Value.Float() != 1 {
+			t.Errorf("bad field: %#v", f)
+		}
+
+		if tag := m.Tags[0]; tag != stats.T("answer", "42") {
+			t.Errorf("bad tag: %#v", tag)
+		}
+
+		if m.SampleRate != 0.5 {
+			t.Errorf("bad sample rate: %g", m.SampleRate)
+		}
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	client := NewClientWith(ClientConfig{
+		Address:   "127.0.0.1:1",
+		QueueSize: 1,
+		Retry:     stats.RetryPolicy{MaxBackoff: 10 * time.Millisecond},
+		Timeout:   10 * time.Millisecond,
+	})
+
+	for i := 0; i != 100; i++ {
+		client.HandleMeasures(time.Now(), stats.Measure{
+			Name:   "request",
+			Fields: []stats.Field{stats.MakeField("count", 1, stats.Counter)},
+		})
+		client.Flush()
+	}
+
+	if client.Dropped() == 0 {
+		t.Error("no batches were dropped while the agent was unavailable")
+	}
+
+	client.Close()
+}
+
+func parseBatch(t *testing.T, b []byte) (measures []stats.Measure) {
+	forEachField(t, b, func(key uint64, v uint64, b []byte) {
+		if key == batchMeasuresKey {
+			measures = append(measures, parseMeasure(t, b))
+		}
+	})
+	return
+}
+
+func parseMeasure(t *testing.T, b []byte) (m stats.Measure) {
+	forEachField(t, b, func(key uint64, v uint64, b []byte) {
+		switch key {
+		case measureNameKey:
+			m.Name = string(b)
+		case measureFieldsKey:
+			var name string
+			var ftype stats.FieldType
+			var value float64
+			forEachField(t, b, func(key uint64, v uint64, b []byte) {
+				switch key {
+				case fieldNameKey:
+					name = string(b)
+				case fieldTypeKey:
+					ftype = stats.FieldType(v)
+				case fieldValueKey:
+					value = math.Float64frombits(v)
+				}
+			})
+			m.Fields = append(m.Fields, stats.MakeField(name, value, ftype))
+		case measureTagsKey:
+			var tag stats.Tag
+			forEachField(t, b, func(key uint64, v uint64, b []byte) {
+				switch key {
+				case tagNameKey:
+					tag.Name = string(b)
+				case tagValueKey:
+					tag.Value = string(b)
+				}
+			})
+			m.Tags = append(m.Tags, tag)
+		case measureRateKey:
+			m.SampleRate = math.Float64frombits(v)
+		}
+	})
+	return
+}
+
+func forEachField(t *testing.T, b []byte, f func(key uint64, v uint64, b []byte)) {
+	for len(b) != 0 {
+		key, n := binary.Uvarint(b)
+		b = b[n:]
+
+		switch key & 7 {
+		case 0:
+			v, n := binary.Uvarint(b)
+			f(key, v, nil)
+			b = b[n:]
+		case 1:
+			f(key, binary.LittleEndian.Uint64(b), nil)
+			b = b[8:]
+		case 2:
+			size, n := binary.Uvarint(b)
+			b = b[n:]
+			f(key, 0, b[:size])
+			b = b[size:]
+		default:
+			t.Fatal("unexpected wire type:", key&7)
+		}
+	}
+}
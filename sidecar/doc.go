@@ -0,0 +1,48 @@
+// Package sidecar implements a stats handler which streams measures to a local
+// agent over a persistent gRPC client stream.
+//
+// The client calls the stats.v1.Agent/Stream method, sending a Batch message
+// for each group of measures reported together. Agents may be implemented with
+// any gRPC framework using the following protobuf schema:
+//
+//	syntax = "proto3";
+//
+//	package stats.v1;
+//
+//	service Agent {
+//	  rpc Stream(stream Batch) returns (Empty);
+//	}
+//
+//	message Empty {}
+//
+//	message Batch {
+//	  int64            time_unix_nano = 1;
+//	  repeated Measure measures       = 2;
+//	}
+//
+//	message Measure {
+//	  string         name        = 1;
+//	  repeated Field fields      = 2;
+//	  repeated Tag   tags        = 3;
+//	  double         sample_rate = 4;
+//	}
+//
+//	message Field {
+//	  enum Type {
+//	    COUNTER   = 0;
+//	    GAUGE     = 1;
+//	    HISTOGRAM = 2;
+//	  }
+//	  string name  = 1;
+//	  Type   type  = 2;
+//	  double value = 3;
+//	}
+//
+//	message Tag {
+//	  string name  = 1;
+//	  string value = 2;
+//	}
+//
+// Durations are sent as floating point numbers of seconds, and booleans as 0
+// or 1. The sample rate is only set on measures which were sampled.
+package sidecar
@@ -0,0 +1,55 @@
+package sidecar
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+func init() {
+	stats.RegisterScheme("sidecar", openURL)
+}
+
+// openURL constructs a sidecar client from a URL of the form:
+//
+//	sidecar://host:port?buffer=16384&queue=64&backoff=10s&timeout=5s
+func openURL(u *url.URL) (stats.Handler, error) {
+	config := ClientConfig{Address: u.Host}
+	query := u.Query()
+
+	if s := query.Get("buffer"); len(s) != 0 {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+		config.BufferSize = n
+	}
+
+	if s := query.Get("queue"); len(s) != 0 {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+		config.QueueSize = n
+	}
+
+	if s := query.Get("backoff"); len(s) != 0 {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		config.Retry.MaxBackoff = d
+	}
+
+	if s := query.Get("timeout"); len(s) != 0 {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		config.Timeout = d
+	}
+
+	return NewClientWith(config), nil
+}
@@ -0,0 +1,171 @@
+package sidecar
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// Protobuf field keys of the messages sent on the stream, combining the field
+// numbers and wire types declared in the package documentation.
+const (
+	batchTimeKey     = 1<<3 | 0 // varint
+	batchMeasuresKey = 2<<3 | 2 // length-delimited
+
+	measureNameKey   = 1<<3 | 2
+	measureFieldsKey = 2<<3 | 2
+	measureTagsKey   = 3<<3 | 2
+	measureRateKey   = 4<<3 | 1 // 64 bits
+
+	fieldNameKey  = 1<<3 | 2
+	fieldTypeKey  = 2<<3 | 0
+	fieldValueKey = 3<<3 | 1 // 64 bits
+
+	tagNameKey  = 1<<3 | 2
+	tagValueKey = 2<<3 | 2
+)
+
+// AppendMeasures appends a gRPC message frame carrying a Batch of the given
+// measures to b.
+func AppendMeasures(b []byte, time time.Time, measures ...stats.Measure) []byte {
+	n := sizeBatch(time, measures)
+
+	b = append(b, 0) // uncompressed
+	b = appendUint32(b, uint32(n))
+	b = appendVarintField(b, batchTimeKey, uint64(time.UnixNano()))
+
+	for _, m := range measures {
+		b = appendMessageHeader(b, batchMeasuresKey, sizeMeasure(m))
+		b = appendMeasure(b, m)
+	}
+
+	return b
+}
+
+func appendMeasure(b []byte, m stats.Measure) []byte {
+	b = appendStringField(b, measureNameKey, m.Name)
+
+	for _, f := range m.Fields {
+		b = appendMessageHeader(b, measureFieldsKey, sizeField(f))
+		b = appendStringField(b, fieldNameKey, f.Name)
+		b = appendVarintField(b, fieldTypeKey, uint64(f.Type()))
+		b = appendVarint(b, fieldValueKey)
+		b = appendUint64(b, math.Float64bits(valueOf(f.Value)))
+	}
+
+	for _, t := range m.Tags {
+		b = appendMessageHeader(b, measureTagsKey, sizeTag(t))
+		b = appendStringField(b, tagNameKey, t.Name)
+		b = appendStringField(b, tagValueKey, t.Value)
+	}
+
+	if sampled(m) {
+		b = appendVarint(b, measureRateKey)
+		b = appendUint64(b, math.Float64bits(m.SampleRate))
+	}
+
+	return b
+}
+
+func sizeBatch(time time.Time, measures []stats.Measure) int {
+	n := 1 + sizeVarint(uint64(time.UnixNano()))
+	for _, m := range measures {
+		n += sizeMessage(sizeMeasure(m))
+	}
+	return n
+}
+
+func sizeMeasure(m stats.Measure) int {
+	n := sizeString(m.Name)
+	for _, f := range m.Fields {
+		n += sizeMessage(sizeField(f))
+	}
+	for _, t := range m.Tags {
+		n += sizeMessage(sizeTag(t))
+	}
+	if sampled(m) {
+		n += 1 + 8
+	}
+	return n
+}
+
+func sampled(m stats.Measure) bool {
+	return m.SampleRate > 0 && m.SampleRate < 1
+}
+
+func sizeField(f stats.Field) int {
+	return sizeString(f.Name) + 1 + sizeVarint(uint64(f.Type())) + 1 + 8
+}
+
+func sizeTag(t stats.Tag) int {
+	return sizeString(t.Name) + sizeString(t.Value)
+}
+
+func sizeString(s string) int {
+	return sizeMessage(len(s))
+}
+
+func sizeMessage(n int) int {
+	return 1 + sizeVarint(uint64(n)) + n
+}
+
+func sizeVarint(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+func appendStringField(b []byte, key uint64, s string) []byte {
+	b = appendMessageHeader(b, key, len(s))
+	return append(b, s...)
+}
+
+func appendMessageHeader(b []byte, key uint64, size int) []byte {
+	b = appendVarint(b, key)
+	return appendVarint(b, uint64(size))
+}
+
+func appendVarintField(b []byte, key uint64, v uint64) []byte {
+	b = appendVarint(b, key)
+	return appendVarint(b, v)
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	var a [binary.MaxVarintLen64]byte
+	return append(b, a[:binary.PutUvarint(a[:], v)]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var a [4]byte
+	binary.BigEndian.PutUint32(a[:], v)
+	return append(b, a[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var a [8]byte
+	binary.LittleEndian.PutUint64(a[:], v)
+	return append(b, a[:]...)
+}
+
+func valueOf(v stats.Value) float64 {
+	switch v.Type() {
+	case stats.Bool:
+		if v.Bool() {
+			return 1
+		}
+	case stats.Int:
+		return float64(v.Int())
+	case stats.Uint:
+		return float64(v.Uint())
+	case stats.Float:
+		return v.Float()
+	case stats.Duration:
+		return v.Duration().Seconds()
+	}
+	return 0
+}
@@ -0,0 +1,134 @@
+package stats
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// engineState carries the mutable state shared by an engine and all the
+// engines derived from it.
+type engineState struct {
+	inflight int64 // number of measures being passed to the handler
+	stopped  int32 // set to 1 when the engine stopped accepting measures
+
+	subscriptions subscriptions
+	samplers      samplers
+}
+
+// acquire registers n measures as being handled, it returns false if the
+// engine stopped accepting measures, in which case they must be discarded.
+func (s *engineState) acquire(n int) bool {
+	atomic.AddInt64(&s.inflight, int64(n))
+	if s.closed() {
+		atomic.AddInt64(&s.inflight, -int64(n))
+		return false
+	}
+	return true
+}
+
+func (s *engineState) release(n int) {
+	atomic.AddInt64(&s.inflight, -int64(n))
+}
+
+func (s *engineState) pending() int {
+	return int(atomic.LoadInt64(&s.inflight))
+}
+
+func (s *engineState) close() {
+	atomic.StoreInt32(&s.stopped, 1)
+}
+
+func (s *engineState) closed() bool {
+	return atomic.LoadInt32(&s.stopped) != 0
+}
+
+func (s *engineState) subscribe(sub *Subscription) {
+	s.subscriptions.add(sub)
+}
+
+func (s *engineState) unsubscribe(sub *Subscription) {
+	s.subscriptions.remove(sub)
+}
+
+// publish delivers measures to the subscriptions.
+func (s *engineState) publish(measures []Measure) {
+	for _, sub := range s.subscriptions.load() {
+		sub.deliver(measures)
+	}
+}
+
+// sampler is the interface of the values reported each time an engine is
+// flushed, like registered gauges, rates, and the progress of operations.
+type sampler interface {
+	sample(t time.Time)
+}
+
+// samplers is the registry of the samplers of an engine state.
+type samplers struct {
+	mutex    sync.Mutex
+	samplers []sampler
+}
+
+func (r *samplers) add(s sampler) {
+	r.mutex.Lock()
+	r.samplers = append(r.samplers, s)
+	r.mutex.Unlock()
+}
+
+func (r *samplers) remove(s sampler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, x := range r.samplers {
+		if x == s {
+			r.samplers = append(r.samplers[:i], r.samplers[i+1:]...)
+			return
+		}
+	}
+}
+
+// sample calls the registered samplers with time t, they are not called while
+// holding the lock so they may register or unregister samplers.
+func (r *samplers) sample(t time.Time) {
+	r.mutex.Lock()
+	samplers := append([]sampler(nil), r.samplers...)
+	r.mutex.Unlock()
+
+	for _, s := range samplers {
+		s.sample(t)
+	}
+}
+
+// state returns the state shared by eng and the engines derived from it,
+// creating it if eng was not constructed by NewEngine.
+func (eng *Engine) state() *engineState {
+	for {
+		if s := eng.shared.load(); s != nil {
+			return s
+		}
+		if s := new(engineState); eng.shared.compareAndSwap(nil, s) {
+			return s
+		}
+	}
+}
+
+type engineStatePointer struct {
+	ptr unsafe.Pointer
+}
+
+func (p *engineStatePointer) load() *engineState {
+	return (*engineState)(atomic.LoadPointer(&p.ptr))
+}
+
+func (p *engineStatePointer) store(s *engineState) {
+	atomic.StorePointer(&p.ptr, unsafe.Pointer(s))
+}
+
+func (p *engineStatePointer) compareAndSwap(old *engineState, new *engineState) bool {
+	return atomic.CompareAndSwapPointer(&p.ptr,
+		unsafe.Pointer(old),
+		unsafe.Pointer(new),
+	)
+}
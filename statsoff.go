@@ -0,0 +1,7 @@
+//go:build statsoff
+
+package stats
+
+// Enabled is false because the program was compiled with the statsoff build
+// tag, see the documentation of the constant in builds without the tag.
+const Enabled = false
@@ -0,0 +1,35 @@
+//go:build statsoff
+
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestStatsOff(t *testing.T) {
+	h := &statstest.Handler{}
+	e := stats.NewEngine("test", h, stats.T("service", "test"))
+
+	e.Incr("A")
+	e.Set("B", 1)
+	e.Observe("C", time.Second)
+	e.Report(struct {
+		D int `metric:"D" type:"counter"`
+	}{1})
+	e.Handle("E", stats.Counter).Measure(1)
+	e.Clock("F").Stop()
+	e.RegisterGauge("G", func() float64 { return 1 })
+	e.Flush()
+
+	if measures := h.Measures(); len(measures) != 0 {
+		t.Error("measures were produced:", measures)
+	}
+
+	if n := h.FlushCalls(); n != 0 {
+		t.Error("the handler was flushed:", n)
+	}
+}
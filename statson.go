@@ -0,0 +1,17 @@
+//go:build !statsoff
+
+package stats
+
+// Enabled is true unless the program was compiled with the statsoff build tag.
+//
+// When compiled with the statsoff build tag, engines, handles, clocks, and
+// registered gauges never produce measures, and flushing engines does nothing.
+// The checks are made against this constant so the compiler removes the
+// instrumentation code entirely, leaving only the calls to the methods.
+// Programs may also test the constant to skip computing the values of their
+// metrics:
+//
+//	if stats.Enabled {
+//		stats.Set("queue.size", q.size())
+//	}
+const Enabled = true
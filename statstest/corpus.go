@@ -0,0 +1,128 @@
+package statstest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// HostileStrings is a corpus of strings which are likely to break the wire
+// protocols of metric collection systems when they appear in measure names,
+// field names, or tags and are not escaped properly.
+var HostileStrings = []string{
+	"",
+	" ",
+	"\n",
+	"\r\n",
+	"\t",
+	"\x00",
+	"\x7f",
+	"a\nb:1|c",
+	"a|b",
+	"a:b",
+	"a,b",
+	"a=b",
+	"a b",
+	"a#b",
+	"a@b",
+	"a.b",
+	"a\\b",
+	"a\\",
+	`a"b`,
+	`"a"`,
+	"a'b",
+	"a{b}",
+	"a=\"b\"}",
+	"a\\nb",
+	"_e{1,1}:a|b",
+	"# TYPE a counter",
+	"été",
+	"日本語",
+	"\u2028",
+	"\ufeff",
+	"\xff\xfe",
+	"\xc3",
+	strings.Repeat("a", 1024),
+}
+
+// HostileMeasures returns a list of measures built from the HostileStrings
+// corpus, each string is used in the measure name, field name, tag name, and
+// tag value of a measure.
+func HostileMeasures() []stats.Measure {
+	measures := make([]stats.Measure, 0, len(HostileStrings))
+
+	for _, s := range HostileStrings {
+		measures = append(measures, stats.Measure{
+			Name: "measure" + s,
+			Fields: []stats.Field{
+				stats.MakeField("field"+s, 1, stats.Counter),
+				stats.MakeField(s, 2.5, stats.Gauge),
+			},
+			Tags: stats.SortTags([]stats.Tag{
+				stats.T("tag"+s, s),
+				stats.T("name", "value"+s),
+			}),
+		})
+	}
+
+	return measures
+}
+
+// FuzzMeasure builds a measure from arbitrary data, it is intended to be used
+// in fuzzing entry points. The data is split on zero bytes, the first part is
+// the measure name, the second is the field name, and the following ones are
+// pairs of tag names and values.
+func FuzzMeasure(data []byte) stats.Measure {
+	parts := strings.Split(string(data), "\x00")
+	field := ""
+
+	if len(parts) > 1 {
+		field = parts[1]
+	}
+
+	m := stats.Measure{
+		Name:   parts[0],
+		Fields: []stats.Field{stats.MakeField(field, len(data), stats.Counter)},
+	}
+
+	for i := 2; i+1 < len(parts); i += 2 {
+		m.Tags = append(m.Tags, stats.T(parts[i], parts[i+1]))
+	}
+
+	stats.SortTags(m.Tags)
+	return m
+}
+
+// AppendFunc is the signature of functions appending the representation of
+// measures in a wire protocol to a buffer.
+type AppendFunc func(b []byte, m stats.Measure) []byte
+
+// CheckRecords serializes m with appendMeasure and returns an error if the
+// output is not made of exactly records lines, which happens when special
+// characters are not escaped and allow the content of the measure to inject new
+// records.
+//
+// Protocols which rely on a stats.Sanitizer to remove control characters from
+// the measures should pass the measures through it before calling
+// CheckRecords.
+func CheckRecords(appendMeasure AppendFunc, m stats.Measure, records int) error {
+	b := appendMeasure(nil, m)
+
+	if n := bytes.Count(b, []byte{'\n'}); n != records || (len(b) != 0 && b[len(b)-1] != '\n') {
+		return fmt.Errorf("%s: expected %d records but found %d: %q", m, records, n, b)
+	}
+
+	return nil
+}
+
+// Sanitize passes m through a stats.Sanitizer replacing invalid characters,
+// and returns the result.
+func Sanitize(m stats.Measure) stats.Measure {
+	h := &Handler{}
+	s := &stats.Sanitizer{Handler: h}
+	s.HandleMeasures(time.Time{}, m)
+	return h.Measures()[0]
+}
@@ -0,0 +1,170 @@
+package statstest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// The methods of this file help tests verify the metrics produced by the code
+// they exercise. Metrics are identified by the name of their measure and field
+// separated by a colon (or the name of the measure only for fields with no
+// names), the same way they are named when produced by an engine. The names
+// include the prefix of the engine that produced the measures.
+//
+// Tags passed to the methods select the measures carrying at least these tags,
+// other tags of the measures are ignored.
+
+// Values returns the values recorded for the metric identified by name and
+// tags, in the order they were received. Durations are converted to seconds.
+func (h *Handler) Values(name string, tags ...stats.Tag) []float64 {
+	var values []float64
+	h.each(name, tags, func(f stats.Field) {
+		values = append(values, valueOf(f.Value))
+	})
+	return values
+}
+
+// ExpectCounter reports an error on t if the sum of the increments of the
+// counter identified by name and tags is not value.
+func (h *Handler) ExpectCounter(t testing.TB, name string, value float64, tags ...stats.Tag) {
+	t.Helper()
+	sum, found := 0.0, false
+
+	h.each(name, tags, func(f stats.Field) {
+		if f.Type() == stats.Counter {
+			sum += valueOf(f.Value)
+			found = true
+		}
+	})
+
+	switch {
+	case !found:
+		t.Errorf("counter %s%v was not recorded", name, tags)
+	case sum != value:
+		t.Errorf("counter %s%v: bad value: %g != %g", name, tags, sum, value)
+	}
+}
+
+// ExpectGauge reports an error on t if the last value of the gauge identified
+// by name and tags is not value.
+func (h *Handler) ExpectGauge(t testing.TB, name string, value float64, tags ...stats.Tag) {
+	t.Helper()
+	last, found := 0.0, false
+
+	h.each(name, tags, func(f stats.Field) {
+		if f.Type() == stats.Gauge {
+			last = valueOf(f.Value)
+			found = true
+		}
+	})
+
+	switch {
+	case !found:
+		t.Errorf("gauge %s%v was not recorded", name, tags)
+	case last != value:
+		t.Errorf("gauge %s%v: bad value: %g != %g", name, tags, last, value)
+	}
+}
+
+// ExpectHistogram reports an error on t if the observations of the histogram
+// identified by name and tags are not values, in order.
+func (h *Handler) ExpectHistogram(t testing.TB, name string, values []float64, tags ...stats.Tag) {
+	t.Helper()
+	var observed []float64
+
+	h.each(name, tags, func(f stats.Field) {
+		if f.Type() == stats.Histogram {
+			observed = append(observed, valueOf(f.Value))
+		}
+	})
+
+	if len(observed) != len(values) {
+		t.Errorf("histogram %s%v: bad observations: %v != %v", name, tags, observed, values)
+		return
+	}
+
+	for i := range values {
+		if observed[i] != values[i] {
+			t.Errorf("histogram %s%v: bad observations: %v != %v", name, tags, observed, values)
+			return
+		}
+	}
+}
+
+// WaitForMetric waits until a value was recorded for the metric identified by
+// name and tags, it returns false if none was recorded within timeout. It is
+// useful to test code producing metrics asynchronously.
+func (h *Handler) WaitForMetric(name string, timeout time.Duration, tags ...stats.Tag) bool {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if len(h.Values(name, tags...)) != 0 {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// each calls fn with the fields of the recorded measures that match the metric
+// identified by name and tags.
+func (h *Handler) each(name string, tags []stats.Tag, fn func(stats.Field)) {
+	measure, field := name, ""
+
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		measure, field = name[:i], name[i+1:]
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	for _, m := range h.measures {
+		if m.Name != measure || !hasTags(m.Tags, tags) {
+			continue
+		}
+		for _, f := range m.Fields {
+			if f.Name == field {
+				fn(f)
+			}
+		}
+	}
+}
+
+func hasTags(tags []stats.Tag, subset []stats.Tag) bool {
+	for _, s := range subset {
+		found := false
+		for _, t := range tags {
+			if t == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func valueOf(v stats.Value) float64 {
+	switch v.Type() {
+	case stats.Bool:
+		if v.Bool() {
+			return 1
+		}
+	case stats.Int:
+		return float64(v.Int())
+	case stats.Uint:
+		return float64(v.Uint())
+	case stats.Float:
+		return v.Float()
+	case stats.Duration:
+		return v.Duration().Seconds()
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+//go:build !statsoff
+
+package statstest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+func TestHandlerExpect(t *testing.T) {
+	h := &Handler{}
+	eng := stats.NewEngine("test", h, stats.T("service", "api"))
+
+	eng.Incr("requests", stats.T("status", "200"))
+	eng.Add("requests", 2, stats.T("status", "200"))
+	eng.Incr("requests", stats.T("status", "500"))
+	eng.Set("queue:size", 3)
+	eng.Set("queue:size", 4)
+	eng.Observe("rtt", time.Second)
+	eng.Observe("rtt", 2*time.Second)
+
+	h.ExpectCounter(t, "test.requests", 3, stats.T("status", "200"))
+	h.ExpectCounter(t, "test.requests", 4)
+	h.ExpectGauge(t, "test.queue:size", 4, stats.T("service", "api"))
+	h.ExpectHistogram(t, "test.rtt", []float64{1, 2})
+
+	if values := h.Values("test.requests", stats.T("status", "404")); len(values) != 0 {
+		t.Error("unexpected values:", values)
+	}
+}
+
+func TestHandlerWaitForMetric(t *testing.T) {
+	h := &Handler{}
+	eng := stats.NewEngine("test", h)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		eng.Incr("done")
+	}()
+
+	if !h.WaitForMetric("test.done", time.Second) {
+		t.Error("the metric was not recorded")
+	}
+
+	if h.WaitForMetric("test.other", 10*time.Millisecond) {
+		t.Error("unexpected metric")
+	}
+}
@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// Subscription represents a function receiving the measures produced by an
+// engine, see Engine.Subscribe.
+type Subscription struct {
+	state    *engineState
+	rate     float64
+	fn       func(Measure)
+	canceled int32
+}
+
+// Cancel stops the delivery of measures to the subscription. Calls to the
+// subscription's function which are already in progress may complete after
+// Cancel returned.
+func (s *Subscription) Cancel() {
+	if atomic.CompareAndSwapInt32(&s.canceled, 0, 1) {
+		s.state.unsubscribe(s)
+	}
+}
+
+func (s *Subscription) deliver(measures []Measure) {
+	for _, m := range measures {
+		if atomic.LoadInt32(&s.canceled) != 0 {
+			return
+		}
+		if s.rate < 1 && rand.Float64() >= s.rate {
+			continue
+		}
+		s.fn(m.Clone())
+	}
+}
+
+// Subscribe registers fn to receive a copy of every measure produced by eng
+// and the engines sharing its state (the ones derived from it with WithPrefix
+// and WithTags), in addition to the engine's handler.
+//
+// The function is called synchronously by the goroutines producing the
+// measures, it must be safe to use concurrently and should not block. The
+// measures are copies that fn may retain.
+func (eng *Engine) Subscribe(fn func(Measure)) *Subscription {
+	return eng.SubscribeSampled(1, fn)
+}
+
+// SubscribeSampled is like Subscribe but fn only receives a random sample of
+// the measures, rate is the fraction of the measures that fn receives.
+func (eng *Engine) SubscribeSampled(rate float64, fn func(Measure)) *Subscription {
+	s := &Subscription{
+		state: eng.state(),
+		rate:  rate,
+		fn:    fn,
+	}
+	s.state.subscribe(s)
+	return s
+}
+
+// subscriptions is a copy-on-write list of subscriptions, which lets engines
+// publish measures without synchronizing with the goroutines subscribing or
+// canceling subscriptions.
+type subscriptions struct {
+	mutex sync.Mutex
+	value atomic.Value // []*Subscription
+}
+
+func (s *subscriptions) load() []*Subscription {
+	subs, _ := s.value.Load().([]*Subscription)
+	return subs
+}
+
+func (s *subscriptions) add(sub *Subscription) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	subs := s.load()
+	s.value.Store(append(subs[:len(subs):len(subs)], sub))
+}
+
+func (s *subscriptions) remove(sub *Subscription) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	subs := s.load()
+	list := make([]*Subscription, 0, len(subs))
+
+	for _, x := range subs {
+		if x != sub {
+			list = append(list, x)
+		}
+	}
+
+	s.value.Store(list)
+}
@@ -0,0 +1,82 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/segmentio/stats"
+)
+
+func TestEngineSubscribe(t *testing.T) {
+	var mutex sync.Mutex
+	var found []stats.Measure
+
+	eng := stats.NewEngine("test", stats.Discard)
+	sub := eng.Subscribe(func(m stats.Measure) {
+		mutex.Lock()
+		found = append(found, m)
+		mutex.Unlock()
+	})
+
+	eng.WithTags(stats.T("a", "b")).Incr("requests")
+	eng.Report(struct {
+		Size int `metric:"size" type:"gauge"`
+	}{42})
+
+	sub.Cancel()
+	eng.Incr("requests")
+
+	expected := []stats.Measure{
+		{
+			Name:   "test.requests",
+			Fields: []stats.Field{stats.MakeField("", 1, stats.Counter)},
+			Tags:   []stats.Tag{stats.T("a", "b")},
+		},
+		{
+			Name:   "test",
+			Fields: []stats.Field{stats.MakeField("size", 42, stats.Gauge)},
+		},
+	}
+
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("bad measures:\nexpected: %v\nfound:    %v", expected, found)
+	}
+}
+
+func TestEngineSubscribeHandle(t *testing.T) {
+	var found []stats.Measure
+
+	eng := stats.NewEngine("test", stats.Discard)
+	eng.Subscribe(func(m stats.Measure) { found = append(found, m) })
+
+	h := eng.Handle("requests", stats.Counter, stats.T("a", "b"))
+	h.Measure(1)
+	h.Measure(2, stats.T("c", "d"))
+
+	if len(found) != 2 {
+		t.Fatal("bad number of measures delivered to the subscription:", len(found))
+	}
+
+	if v := found[1].Fields[0].Value.Int(); v != 2 || len(found[1].Tags) != 2 {
+		t.Error("bad measure delivered to the subscription:", found[1])
+	}
+}
+
+func TestEngineSubscribeSampled(t *testing.T) {
+	all, none := 0, 0
+
+	eng := stats.NewEngine("test", stats.Discard)
+	eng.SubscribeSampled(1, func(stats.Measure) { all++ })
+	eng.SubscribeSampled(0, func(stats.Measure) { none++ })
+
+	for i := 0; i != 100; i++ {
+		eng.Incr("requests")
+	}
+
+	if all != 100 || none != 0 {
+		t.Errorf("bad number of sampled measures: all=%d none=%d", all, none)
+	}
+}
@@ -0,0 +1,374 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Summaries computes quantiles of the histogram values produced by an engine
+// over a sliding window, which lets programs get latency percentiles without
+// shipping every observation to a remote aggregator.
+//
+// Summaries are enabled by setting the Summaries field of an engine, the
+// engines derived from it with WithPrefix and WithTags share the summaries.
+// The current quantiles are exposed by Engine.State, and passed as gauges to
+// the engine's handler when the engine is flushed. For each histogram series
+// (measure name, field name, and tags), the gauges are named after the field
+// name with a suffix for each quantile (p50, p90, p99, ...).
+//
+// The histogram values are still passed to the engine's handler.
+//
+// The counters and gauges are tracked over the same window so Engine.State can
+// report them as well, the sum of the increments of each counter series and the
+// last value of each gauge series are returned in the Value field of their
+// summaries. They are not passed to the handler when the engine is flushed.
+type Summaries struct {
+	// The quantiles computed for each histogram, between 0 and 1. Defaults to
+	// 0.5, 0.9, and 0.99.
+	Quantiles []float64
+
+	// Duration of the sliding window, observations older than the window are
+	// discarded. Defaults to 1 minute.
+	Window time.Duration
+
+	// Maximum number of observations retained for each series, the oldest
+	// observations are discarded first when the limit is reached. Defaults to
+	// 1024.
+	Size int
+
+	mutex  sync.Mutex
+	series map[string]*summarySeries
+	keys   []byte
+}
+
+// Summary is a snapshot of a series in the window of the summaries. Quantiles
+// are only computed for histograms, Value is only set for counters and gauges.
+type Summary struct {
+	Measure   string
+	Field     string
+	Type      FieldType
+	Tags      []Tag
+	Count     int        // number of observations in the window
+	Value     float64    // sum of the counter, or last value of the gauge
+	Quantiles []Quantile // sorted by ascending quantile
+}
+
+// Quantile is the value of a quantile of a summary.
+type Quantile struct {
+	Q     float64
+	Value float64
+}
+
+type summarySeries struct {
+	name    string
+	field   string
+	ftype   FieldType
+	tags    []Tag
+	samples []summarySample
+	head    int
+}
+
+type summarySample struct {
+	time   time.Time
+	value  float64
+	weight float64 // number of observations the sample stands for
+}
+
+// StateFilter selects summaries returned by Engine.State. The zero value
+// matches all summaries.
+type StateFilter struct {
+	// Prefix that the measure names must start with.
+	Prefix string
+
+	// Name of the field, when not empty the summaries of other fields are
+	// not matched.
+	Field string
+
+	// Types of the fields, when not empty the summaries of fields of other
+	// types are not matched.
+	Types []FieldType
+
+	// Tags that the series must carry, a tag with an empty value matches any
+	// value of the tag.
+	Tags []Tag
+}
+
+// State returns the summaries of the counters, gauges, and histograms produced
+// by eng and the engines derived from it, or nil if eng has no summaries
+// configured.
+//
+// When filters are passed, only the summaries matching at least one of them
+// are returned. The summaries are sorted by measure name, field name, type, and
+// tags.
+func (eng *Engine) State(filters ...StateFilter) []Summary {
+	if eng.Summaries == nil {
+		return nil
+	}
+
+	summaries := eng.Summaries.snapshot(eng.Now())
+
+	if len(filters) != 0 {
+		matched := summaries[:0]
+
+		for _, s := range summaries {
+			for _, f := range filters {
+				if f.match(s) {
+					matched = append(matched, s)
+					break
+				}
+			}
+		}
+
+		summaries = matched
+	}
+
+	return summaries
+}
+
+func (f StateFilter) match(s Summary) bool {
+	if !strings.HasPrefix(s.Measure, f.Prefix) {
+		return false
+	}
+
+	if len(f.Field) != 0 && s.Field != f.Field {
+		return false
+	}
+
+	if len(f.Types) != 0 && !containsType(f.Types, s.Type) {
+		return false
+	}
+
+search:
+	for _, t := range f.Tags {
+		for _, x := range s.Tags {
+			if x.Name == t.Name && (len(t.Value) == 0 || x.Value == t.Value) {
+				continue search
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func containsType(types []FieldType, t FieldType) bool {
+	for _, x := range types {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Summaries) observe(t time.Time, measures []Measure) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, m := range measures {
+		for _, f := range m.Fields {
+			s.add(t, m, f)
+		}
+	}
+}
+
+func (s *Summaries) add(t time.Time, m Measure, f Field) {
+	s.keys = appendSeriesKey(s.keys[:0], m.Name, f.Name, m.Tags)
+	s.keys = append(s.keys, byte(f.Type()))
+	series := s.series[string(s.keys)]
+
+	if series == nil {
+		if s.series == nil {
+			s.series = make(map[string]*summarySeries)
+		}
+		series = &summarySeries{
+			name:  m.Name,
+			field: f.Name,
+			ftype: f.Type(),
+			tags:  copyTags(m.Tags),
+		}
+		s.series[string(s.keys)] = series
+	}
+
+	if series.len() >= s.size() {
+		series.samples[series.head] = summarySample{}
+		series.head++
+		series.compact()
+	}
+
+	series.samples = append(series.samples, summarySample{
+		time:   t,
+		value:  valueToFloat(f.Value),
+		weight: sampleWeight(m.SampleRate),
+	})
+}
+
+// snapshot computes the summaries of the observations made in the window that
+// ends at now, series without observations in the window are discarded.
+func (s *Summaries) snapshot(now time.Time) []Summary {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	quantiles := s.quantiles()
+	summaries := make([]Summary, 0, len(s.series))
+	values := []float64{}
+
+	for key, series := range s.series {
+		series.expire(now.Add(-s.window()))
+
+		if series.len() == 0 {
+			delete(s.series, key)
+			continue
+		}
+
+		values = values[:0]
+		count, sum := 0.0, 0.0
+		for _, sample := range series.samples[series.head:] {
+			values = append(values, sample.value)
+			count += sample.weight
+			sum += sample.value * sample.weight
+		}
+
+		summary := Summary{
+			Measure: series.name,
+			Field:   series.field,
+			Type:    series.ftype,
+			Tags:    series.tags,
+			Count:   int(math.Round(count)),
+		}
+
+		switch series.ftype {
+		case Counter:
+			summary.Value = sum
+		case Gauge:
+			summary.Value = values[len(values)-1]
+		default:
+			sort.Float64s(values)
+			summary.Quantiles = make([]Quantile, len(quantiles))
+
+			for i, q := range quantiles {
+				summary.Quantiles[i] = Quantile{Q: q, Value: quantile(values, q)}
+			}
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].less(summaries[j])
+	})
+
+	return summaries
+}
+
+// measures returns the gauges reporting the quantiles of the histograms in the
+// window that ends at now.
+func (s *Summaries) measures(now time.Time) []Measure {
+	summaries := s.snapshot(now)
+	measures := make([]Measure, 0, len(summaries))
+
+	for _, summary := range summaries {
+		if summary.Type != Histogram {
+			continue
+		}
+
+		fields := make([]Field, len(summary.Quantiles))
+
+		for j, q := range summary.Quantiles {
+			fields[j] = MakeField(summary.fieldName(q.Q), q.Value, Gauge)
+		}
+
+		measures = append(measures, Measure{
+			Name:   summary.Measure,
+			Fields: fields,
+			Tags:   summary.Tags,
+		})
+	}
+
+	return measures
+}
+
+func (s *Summaries) quantiles() []float64 {
+	if len(s.Quantiles) != 0 {
+		q := append([]float64(nil), s.Quantiles...)
+		sort.Float64s(q)
+		return q
+	}
+	return []float64{0.5, 0.9, 0.99}
+}
+
+func (s *Summaries) window() time.Duration {
+	if s.Window != 0 {
+		return s.Window
+	}
+	return time.Minute
+}
+
+func (s *Summaries) size() int {
+	if s.Size > 0 {
+		return s.Size
+	}
+	return 1024
+}
+
+func (series *summarySeries) len() int {
+	return len(series.samples) - series.head
+}
+
+// expire discards the observations made before the given time, the samples
+// are kept in the order they were received which is assumed to be increasing.
+func (series *summarySeries) expire(before time.Time) {
+	for series.head < len(series.samples) && series.samples[series.head].time.Before(before) {
+		series.samples[series.head] = summarySample{}
+		series.head++
+	}
+
+	series.compact()
+}
+
+func (series *summarySeries) compact() {
+	// Reclaim the space of discarded samples once they make up half of the
+	// buffer, so the memory used by a series stays proportional to its size.
+	if series.head != 0 && series.head >= len(series.samples)/2 {
+		n := copy(series.samples, series.samples[series.head:])
+		series.samples, series.head = series.samples[:n], 0
+	}
+}
+
+// quantile returns the value of the q quantile of values using the nearest
+// rank method, values must be sorted.
+func quantile(values []float64, q float64) float64 {
+	i := int(math.Ceil(q*float64(len(values)))) - 1
+	switch {
+	case i < 0:
+		i = 0
+	case i >= len(values):
+		i = len(values) - 1
+	}
+	return values[i]
+}
+
+func (s Summary) fieldName(q float64) string {
+	// Rounding to 4 decimals avoids names like p99.89999999999999 for 0.999.
+	name := "p" + strconv.FormatFloat(math.Round(q*1e6)/1e4, 'f', -1, 64)
+	if len(s.Field) == 0 {
+		return name
+	}
+	return s.Field + "." + name
+}
+
+func (s Summary) less(other Summary) bool {
+	if s.Measure != other.Measure {
+		return s.Measure < other.Measure
+	}
+	if s.Field != other.Field {
+		return s.Field < other.Field
+	}
+	if s.Type != other.Type {
+		return s.Type < other.Type
+	}
+	return string(appendSeriesKey(nil, "", "", s.Tags)) < string(appendSeriesKey(nil, "", "", other.Tags))
+}
@@ -0,0 +1,198 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestEngineSummaries(t *testing.T) {
+	now := time.Now()
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", h)
+	eng.Summaries = &stats.Summaries{Window: time.Minute}
+
+	rpc := eng.WithTags(stats.T("service", "api"))
+
+	// Observations made before the window are not part of the summaries.
+	rpc.ObserveAt(now.Add(-2*time.Minute), "rpc:latency", 1000)
+
+	for i := 1; i <= 100; i++ {
+		rpc.ObserveAt(now, "rpc:latency", i)
+	}
+
+	eng.Set("queue:size", 10)
+
+	expected := []stats.Summary{{
+		Measure: "test.queue",
+		Field:   "size",
+		Type:    stats.Gauge,
+		Count:   1,
+		Value:   10,
+	}, {
+		Measure: "test.rpc",
+		Field:   "latency",
+		Type:    stats.Histogram,
+		Tags:    []stats.Tag{stats.T("service", "api")},
+		Count:   100,
+		Quantiles: []stats.Quantile{
+			{Q: 0.5, Value: 50},
+			{Q: 0.9, Value: 90},
+			{Q: 0.99, Value: 99},
+		},
+	}}
+
+	if state := eng.State(); !reflect.DeepEqual(state, expected) {
+		t.Errorf("bad state:\nexpected: %#v\nfound:    %#v", expected, state)
+	}
+
+	h.Clear()
+	eng.Flush()
+
+	measures := h.Measures()
+	if len(measures) != 1 {
+		t.Fatal("bad number of measures:", measures)
+	}
+
+	fields := measures[0].Fields
+	if measures[0].Name != "test.rpc" || len(fields) != 3 {
+		t.Fatal("bad measure:", measures[0])
+	}
+
+	for i, name := range []string{"latency.p50", "latency.p90", "latency.p99"} {
+		if fields[i].Name != name || fields[i].Type() != stats.Gauge {
+			t.Errorf("bad field at index %d: %s", i, fields[i])
+		}
+	}
+
+	if h.FlushCalls() != 1 {
+		t.Error("the handler was not flushed")
+	}
+}
+
+func TestSummariesSize(t *testing.T) {
+	eng := stats.NewEngine("test", stats.Discard)
+	eng.Summaries = &stats.Summaries{Size: 10, Quantiles: []float64{0, 0.999}}
+
+	for i := 0; i < 100; i++ {
+		eng.Observe("value", i)
+	}
+
+	state := eng.State()
+	if len(state) != 1 {
+		t.Fatal("bad number of summaries:", state)
+	}
+
+	if s := state[0]; s.Count != 10 || s.Quantiles[0].Value != 90 || s.Quantiles[1].Value != 99 {
+		t.Errorf("bad summary: %+v", s)
+	}
+}
+
+func TestEngineStateWithoutSummaries(t *testing.T) {
+	eng := stats.NewEngine("test", stats.Discard)
+	eng.Observe("value", 1)
+
+	if state := eng.State(); state != nil {
+		t.Error("unexpected summaries:", state)
+	}
+}
+
+func TestEngineStateFilters(t *testing.T) {
+	eng := stats.NewEngine("test", stats.Discard)
+	eng.Summaries = &stats.Summaries{}
+
+	eng.Observe("http:rtt", 1, stats.T("method", "GET"))
+	eng.Observe("http:rtt", 1, stats.T("method", "POST"))
+	eng.Observe("http:size", 1, stats.T("method", "GET"))
+	eng.Observe("rpc:rtt", 1)
+
+	tests := []struct {
+		scenario string
+		filters  []stats.StateFilter
+		expected []string
+	}{
+		{
+			scenario: "no filters",
+			expected: []string{"test.http:rtt:GET", "test.http:rtt:POST", "test.http:size:GET", "test.rpc:rtt:"},
+		},
+		{
+			scenario: "prefix",
+			filters:  []stats.StateFilter{{Prefix: "test.http"}},
+			expected: []string{"test.http:rtt:GET", "test.http:rtt:POST", "test.http:size:GET"},
+		},
+		{
+			scenario: "field and tag value",
+			filters:  []stats.StateFilter{{Field: "rtt", Tags: []stats.Tag{stats.T("method", "GET")}}},
+			expected: []string{"test.http:rtt:GET"},
+		},
+		{
+			scenario: "any tag value",
+			filters:  []stats.StateFilter{{Field: "rtt", Tags: []stats.Tag{stats.T("method", "")}}},
+			expected: []string{"test.http:rtt:GET", "test.http:rtt:POST"},
+		},
+		{
+			scenario: "union of filters",
+			filters:  []stats.StateFilter{{Prefix: "test.rpc"}, {Field: "size"}},
+			expected: []string{"test.http:size:GET", "test.rpc:rtt:"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			found := []string{}
+
+			for _, s := range eng.State(test.filters...) {
+				method := ""
+				if len(s.Tags) != 0 {
+					method = s.Tags[0].Value
+				}
+				found = append(found, s.Measure+":"+s.Field+":"+method)
+			}
+
+			if !reflect.DeepEqual(found, test.expected) {
+				t.Errorf("bad summaries:\nexpected: %v\nfound:    %v", test.expected, found)
+			}
+		})
+	}
+}
+
+func TestEngineStateTypes(t *testing.T) {
+	eng := stats.NewEngine("test", stats.Discard)
+	eng.Summaries = &stats.Summaries{}
+
+	eng.Add("http:requests", 2, stats.T("method", "GET"))
+	eng.Add("http:requests", 3, stats.T("method", "GET"))
+	eng.Set("http:conns", 4)
+	eng.Set("http:conns", 1)
+	eng.Observe("http:rtt", 1, stats.T("method", "GET"))
+
+	expected := []stats.Summary{{
+		Measure: "test.http",
+		Field:   "conns",
+		Type:    stats.Gauge,
+		Count:   2,
+		Value:   1,
+	}, {
+		Measure: "test.http",
+		Field:   "requests",
+		Type:    stats.Counter,
+		Tags:    []stats.Tag{stats.T("method", "GET")},
+		Count:   2,
+		Value:   5,
+	}}
+
+	state := eng.State(stats.StateFilter{Types: []stats.FieldType{stats.Counter, stats.Gauge}})
+	if !reflect.DeepEqual(state, expected) {
+		t.Errorf("bad state:\nexpected: %#v\nfound:    %#v", expected, state)
+	}
+
+	state = eng.State(stats.StateFilter{Types: []stats.FieldType{stats.Histogram}, Tags: []stats.Tag{stats.T("method", "")}})
+	if len(state) != 1 || state[0].Field != "rtt" {
+		t.Error("bad state:", state)
+	}
+}
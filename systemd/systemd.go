@@ -0,0 +1,218 @@
+// Package systemd integrates the receivers of the stats packages with systemd,
+// so programs running as aggregators can be supervised natively on Linux
+// hosts. The package supports socket activation, and the notification of the
+// service state and watchdog pings:
+//
+//	listeners, conns, err := systemd.Sockets()
+//	...
+//	go http.Serve(listeners[0], relay.NewHandler(eng))
+//	go (&datadog.Receiver{Engine: eng}).Serve(conns...)
+//
+//	systemd.Ready()
+//	defer systemd.StartWatchdog(systemd.HandlerHealth(handler))()
+//
+// When the program was not started by systemd, the functions of the package
+// find no sockets and send no notifications.
+package systemd
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd to socket
+// activated programs.
+const listenFdsStart = 3
+
+// Sockets returns the sockets passed by systemd to the program when it was
+// started by socket activation, stream sockets are returned as listeners and
+// datagram sockets as packet connections.
+//
+// The environment variables used by systemd to pass the sockets are unset, so
+// the sockets are only returned by the first call to Sockets, and are not
+// inherited by the child processes of the program.
+func Sockets() ([]net.Listener, []net.PacketConn, error) {
+	pid, fds := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	return sockets(pid, fds, listenFdsStart)
+}
+
+func sockets(pid string, fds string, start int) ([]net.Listener, []net.PacketConn, error) {
+	if pid == "" || fds == "" {
+		return nil, nil, nil
+	}
+
+	if p, err := strconv.Atoi(pid); err != nil || p != os.Getpid() {
+		// The sockets were passed to another process.
+		return nil, nil, nil
+	}
+
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, nil, errors.New("stats/systemd: invalid LISTEN_FDS environment variable: " + fds)
+	}
+
+	var listeners []net.Listener
+	var conns []net.PacketConn
+
+	for fd := start; fd < start+n; fd++ {
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+
+		// The net package duplicates the file descriptors, so the files are
+		// closed whether they could be used or not.
+		if l, err := net.FileListener(f); err == nil {
+			listeners = append(listeners, l)
+		} else if c, err := net.FilePacketConn(f); err == nil {
+			conns = append(conns, c)
+		} else {
+			f.Close()
+			return listeners, conns, errors.New("stats/systemd: unsupported socket type of file descriptor " + strconv.Itoa(fd))
+		}
+
+		f.Close()
+	}
+
+	return listeners, conns, nil
+}
+
+// Notify sends state to the service manager, see sd_notify(3) for the list of
+// the supported states. The function returns nil and sends nothing if the
+// program was not started by systemd.
+func Notify(state string) error {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return nil
+	}
+
+	if addr[0] == '@' {
+		// Abstract unix socket.
+		addr = "\x00" + addr[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// Ready notifies the service manager that the program finished starting.
+func Ready() error {
+	return Notify("READY=1")
+}
+
+// Stopping notifies the service manager that the program is shutting down.
+func Stopping() error {
+	return Notify("STOPPING=1")
+}
+
+// WatchdogInterval returns the interval at which the service manager expects
+// watchdog pings, or zero if the watchdog is not enabled for the program.
+func WatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" {
+		if p, err := strconv.Atoi(pid); err != nil || p != os.Getpid() {
+			return 0
+		}
+	}
+
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
+
+// StartWatchdog starts a goroutine which sends watchdog pings to the service
+// manager at half the watchdog interval, as long as health returns nil. When
+// health returns an error, the ping is skipped and the error is reported as
+// the status of the service, so systemd restarts the program if it stays
+// unhealthy for longer than the watchdog interval. A nil health function
+// always reports the program as healthy.
+//
+// The returned function stops the watchdog, it does nothing if the watchdog is
+// not enabled for the program.
+func StartWatchdog(health func() error) (stop func()) {
+	interval := WatchdogInterval()
+	if interval <= 0 {
+		return func() {}
+	}
+	return startWatchdog(interval/2, health, Notify)
+}
+
+func startWatchdog(interval time.Duration, health func() error, notify func(string) error) (stop func()) {
+	done := make(chan struct{})
+	once := sync.Once{}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		healthy := true
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+
+			var err error
+			if health != nil {
+				err = health()
+			}
+
+			switch {
+			case err != nil:
+				notify("STATUS=unhealthy: " + err.Error())
+				healthy = false
+			case !healthy:
+				notify("STATUS=healthy\nWATCHDOG=1")
+				healthy = true
+			default:
+				notify("WATCHDOG=1")
+			}
+		}
+	}()
+
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// HandlerHealth returns a health function reporting the last error produced by
+// handler since the previous call, if the handler reports errors on a channel
+// returned by an Errors method (like stats.Buffer). Since the function drains
+// the error channel, the errors are not delivered to other readers.
+func HandlerHealth(handler stats.Handler) func() error {
+	h, ok := handler.(interface{ Errors() <-chan error })
+	if !ok {
+		return func() error { return nil }
+	}
+
+	errs := h.Errors()
+
+	return func() error {
+		var last error
+		for {
+			select {
+			case err, ok := <-errs:
+				if !ok {
+					return last
+				}
+				last = err
+			default:
+				return last
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+//go:build !windows
+
+package systemd
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+func TestSockets(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file descriptor is owned by the sockets function, which closes it.
+	fd, err := syscall.Dup(int(f.Fd()))
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listeners, conns, err := sockets(strconv.Itoa(os.Getpid()), "1", fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(listeners) != 1 || len(conns) != 0 {
+		t.Fatalf("bad sockets: %v %v", listeners, conns)
+	}
+	defer listeners[0].Close()
+
+	if addr := listeners[0].Addr().String(); addr != l.Addr().String() {
+		t.Errorf("bad listener address: %s", addr)
+	}
+
+	if listeners, conns, err := sockets(strconv.Itoa(os.Getpid()+1), "1", 3); err != nil || listeners != nil || conns != nil {
+		t.Errorf("sockets of another process were returned: %v %v %v", listeners, conns, err)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "systemd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "notify.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	defer os.Unsetenv("NOTIFY_SOCKET")
+	os.Setenv("NOTIFY_SOCKET", path)
+
+	if err := Ready(); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	b := make([]byte, 64)
+	n, err := conn.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b[:n]); s != "READY=1" {
+		t.Errorf("bad notification: %q", s)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+
+	os.Setenv("WATCHDOG_USEC", "2000000")
+
+	if d := WatchdogInterval(); d != 2*time.Second {
+		t.Error("bad watchdog interval:", d)
+	}
+
+	os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+
+	if d := WatchdogInterval(); d != 0 {
+		t.Error("bad watchdog interval of another process:", d)
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	states := make(chan string, 10)
+	health := make(chan error, 10)
+
+	health <- nil
+	health <- errors.New("oops")
+	health <- nil
+
+	stop := startWatchdog(time.Millisecond, func() error {
+		select {
+		case err := <-health:
+			return err
+		default:
+			return nil
+		}
+	}, func(state string) error {
+		select {
+		case states <- state:
+		default:
+		}
+		return nil
+	})
+
+	expected := []string{"WATCHDOG=1", "STATUS=unhealthy: oops", "STATUS=healthy\nWATCHDOG=1", "WATCHDOG=1"}
+
+	for _, s := range expected {
+		select {
+		case state := <-states:
+			if state != s {
+				t.Errorf("bad state: %q (expected %q)", state, s)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for the watchdog")
+		}
+	}
+
+	stop()
+	stop()
+}
+
+type errorsHandler struct{ errs chan error }
+
+func (errorsHandler) HandleMeasures(time.Time, ...stats.Measure) {}
+
+func (h errorsHandler) Errors() <-chan error { return h.errs }
+
+func TestHandlerHealth(t *testing.T) {
+	h := errorsHandler{errs: make(chan error, 2)}
+	health := HandlerHealth(h)
+
+	if err := health(); err != nil {
+		t.Error("unexpected error:", err)
+	}
+
+	h.errs <- errors.New("A")
+	h.errs <- errors.New("B")
+
+	if err := health(); err == nil || err.Error() != "B" {
+		t.Error("bad error:", err)
+	}
+
+	if err := health(); err != nil {
+		t.Error("unexpected error:", err)
+	}
+
+	if err := HandlerHealth(stats.Discard)(); err != nil {
+		t.Error("unexpected error:", err)
+	}
+}
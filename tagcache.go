@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"container/list"
+	"sync"
+)
+
+// TagSetCache is a cache of the tag sets of the measures produced by an engine,
+// which lets handlers reuse the serialized representation of the tags of
+// measures produced with the same name and tags, even when the program builds
+// the tags dynamically at each call instead of using a Handle.
+//
+// Caches are enabled by setting the TagSets field of an engine, the engines
+// derived from it with WithPrefix and WithTags share the cache. Entries are
+// keyed by a fingerprint of the measure name and tags, the least recently used
+// entries are evicted when the cache is full.
+type TagSetCache struct {
+	// Maximum number of entries in the cache. Defaults to 1024.
+	Size int
+
+	mutex   sync.Mutex
+	entries map[uint64]*list.Element
+	lru     list.List
+}
+
+type tagSetEntry struct {
+	hash uint64
+	name string
+	tags *TagSet
+}
+
+// Len returns the number of entries in the cache.
+func (c *TagSetCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
+
+// lookup returns the tag set of measures named name with tags, which must be
+// sorted, creating it if it was not in the cache.
+func (c *TagSetCache) lookup(name string, tags []Tag) *TagSet {
+	hash := fingerprint(name, tags)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem := c.entries[hash]; elem != nil {
+		if e := elem.Value.(*tagSetEntry); e.name == name && tagsEqual(e.tags.tags, tags) {
+			c.lru.MoveToFront(elem)
+			return e.tags
+		}
+		// Fingerprint collision, the entry is replaced.
+		c.lru.Remove(elem)
+		delete(c.entries, hash)
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[uint64]*list.Element)
+	}
+
+	for len(c.entries) >= c.size() {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tagSetEntry).hash)
+	}
+
+	e := &tagSetEntry{hash: hash, name: name, tags: &TagSet{tags: copyTags(tags)}}
+	c.entries[hash] = c.lru.PushFront(e)
+	return e.tags
+}
+
+func (c *TagSetCache) size() int {
+	if c.Size > 0 {
+		return c.Size
+	}
+	return 1024
+}
+
+// fingerprint computes a FNV-1a hash of a measure name and its tags.
+func fingerprint(name string, tags []Tag) uint64 {
+	h := uint64(14695981039346656037)
+
+	hash := func(s string) {
+		for i := 0; i != len(s); i++ {
+			h = (h ^ uint64(s[i])) * 1099511628211
+		}
+		h = h * 1099511628211 // separator
+	}
+
+	hash(name)
+
+	for _, t := range tags {
+		hash(t.Name)
+		hash(t.Value)
+	}
+
+	return h
+}
+
+func tagsEqual(t1 []Tag, t2 []Tag) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i := range t1 {
+		if t1[i] != t2[i] {
+			return false
+		}
+	}
+	return true
+}
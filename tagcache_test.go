@@ -0,0 +1,51 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestTagSetCache(t *testing.T) {
+	h := &statstest.Handler{}
+	c := &stats.TagSetCache{Size: 2}
+	e := stats.NewEngine("test", h, stats.T("service", "test"))
+	e.TagSets = c
+
+	e.Incr("A", stats.T("path", "/1"))
+	e.Incr("A", stats.T("path", "/1"))
+	e.Incr("A", stats.T("path", "/2"))
+	e.Incr("B", stats.T("path", "/1"))
+	e.WithTags(stats.T("path", "/1")).Incr("A")
+
+	measures := h.Measures()
+	if len(measures) != 5 {
+		t.Fatalf("bad number of measures: %v", measures)
+	}
+
+	for _, m := range measures {
+		if m.Const == nil || m.Const.Len() != len(m.Tags) {
+			t.Fatalf("bad tag set: %v", m)
+		}
+	}
+
+	if measures[0].Const != measures[1].Const {
+		t.Error("the tag set of identical measures was not reused")
+	}
+
+	if measures[0].Const == measures[2].Const || measures[0].Const == measures[3].Const {
+		t.Error("the tag set of different measures was reused")
+	}
+
+	// The entry of the first measure was evicted by the 3rd and 4th ones.
+	if measures[0].Const == measures[4].Const {
+		t.Error("the tag set was not evicted")
+	}
+
+	if n := c.Len(); n != 2 {
+		t.Errorf("bad number of cache entries: %d", n)
+	}
+}
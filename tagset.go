@@ -0,0 +1,82 @@
+package stats
+
+import "sync"
+
+// TagSet is an immutable and sorted list of tags. Tag sets are bound to
+// measures produced by metric handles to let handlers know which tags never
+// change between measures, so they can cache their serialized representation
+// instead of re-encoding them every time.
+type TagSet struct {
+	tags  []Tag
+	cache sync.Map
+}
+
+// NewTagSet constructs a tag set from a copy of tags.
+func NewTagSet(tags ...Tag) *TagSet {
+	return &TagSet{tags: SortTags(copyTags(tags))}
+}
+
+// Tags returns the list of tags in s, the program must not modify it.
+func (s *TagSet) Tags() []Tag {
+	if s == nil {
+		return nil
+	}
+	return s.tags
+}
+
+// Len returns the number of tags in s.
+func (s *TagSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.tags)
+}
+
+// Encoding returns the serialized representation of the tags in s for the
+// protocol identified by key, calling encode to produce it the first time the
+// key is seen. The key must be comparable, handlers usually use an unexported
+// type defined in their package.
+//
+// The returned byte slice is shared and must not be modified.
+func (s *TagSet) Encoding(key interface{}, encode func(b []byte, tags []Tag) []byte) []byte {
+	if b, ok := s.cache.Load(key); ok {
+		return b.([]byte)
+	}
+	b, _ := s.cache.LoadOrStore(key, encode(nil, s.tags))
+	return b.([]byte)
+}
+
+// Split partitions tags (which must be sorted and contain all the tags of s)
+// into the tags that belong to s and the others, appending the latter to
+// dynamic.
+func (s *TagSet) Split(dynamic []Tag, tags []Tag) []Tag {
+	i := 0
+
+	for _, t := range tags {
+		if i < len(s.tags) && s.tags[i] == t {
+			i++
+		} else {
+			dynamic = append(dynamic, t)
+		}
+	}
+
+	return dynamic
+}
+
+func mergeTags(dst []Tag, t1 []Tag, t2 []Tag) []Tag {
+	i1, i2 := 0, 0
+
+	for i1 < len(t1) && i2 < len(t2) {
+		if t2[i2].Name < t1[i1].Name {
+			dst = append(dst, t2[i2])
+			i2++
+		} else {
+			dst = append(dst, t1[i1])
+			i1++
+		}
+	}
+
+	dst = append(dst, t1[i1:]...)
+	dst = append(dst, t2[i2:]...)
+	return dst
+}
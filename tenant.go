@@ -0,0 +1,261 @@
+package stats
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tenants manages a set of engines producing metrics on behalf of multiple
+// tenants (customers, workspaces, ...) of a program. Each tenant gets its own
+// engine, which tags the measures with the tenant name and enforces a quota on
+// the number of measures that the tenant may produce per interval, and on the
+// number of series (combinations of tags) that it produces for each measure
+// name, so a single noisy tenant cannot overwhelm the metric collection
+// systems.
+//
+// Tenant engines forward their measures to the current handler of the base
+// engine, so handlers registered on the base engine after the tenant engines
+// were created also receive the measures of the tenants.
+//
+// Tenants values are safe to use concurrently from multiple goroutines, but
+// their fields must not be modified after the first call to Engine.
+type Tenants struct {
+	// The engine that tenant engines are derived from.
+	Base *Engine
+
+	// Name of the tag set to the tenant name on all measures, defaults to
+	// "tenant".
+	TagName string
+
+	// Maximum number of measures that each tenant may produce per interval,
+	// zero means no limit.
+	Quota int
+
+	// Per-tenant overrides of Quota.
+	Quotas map[string]int
+
+	// Duration of the quota intervals, defaults to 1s.
+	QuotaInterval time.Duration
+
+	// Maximum number of combinations of tags that each tenant may produce
+	// for each measure name, zero means no limit. The measures of a tenant
+	// that exceed the quota are collapsed the way CardinalityLimit does.
+	SeriesQuota int
+
+	// Per-tenant overrides of SeriesQuota.
+	SeriesQuotas map[string]int
+
+	mutex   sync.RWMutex
+	tenants map[string]*tenant
+}
+
+type tenant struct {
+	engine *Engine
+	quota  *quotaHandler
+	series *CardinalityLimit
+}
+
+// Engine returns the engine of the given tenant, creating it if needed.
+func (t *Tenants) Engine(name string) *Engine {
+	return t.lookup(name).engine
+}
+
+// Dropped returns the number of measures of a tenant that were dropped because
+// they exceeded the quota.
+func (t *Tenants) Dropped(name string) uint64 {
+	t.mutex.RLock()
+	x := t.tenants[name]
+	t.mutex.RUnlock()
+
+	if x == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&x.quota.dropped)
+}
+
+// SeriesDropped returns the number of measures of a tenant that were collapsed
+// because they exceeded the series quota.
+func (t *Tenants) SeriesDropped(name string) uint64 {
+	t.mutex.RLock()
+	x := t.tenants[name]
+	t.mutex.RUnlock()
+
+	if x == nil || x.series == nil {
+		return 0
+	}
+
+	x.series.mutex.Lock()
+	defer x.series.mutex.Unlock()
+
+	var dropped uint64
+	for _, c := range x.series.names {
+		dropped += c.total
+	}
+	return dropped
+}
+
+// Flush passes the number of measures collapsed by the series quotas of the
+// tenants to the handler of the base engine, then flushes the base engine.
+func (t *Tenants) Flush() {
+	base := t.base()
+	now := base.Now()
+
+	t.mutex.RLock()
+	for _, x := range t.tenants {
+		if x.series != nil {
+			if ms := x.series.measures(now, x.engine.Tags); len(ms) != 0 {
+				base.Handler.HandleMeasures(now, ms...)
+			}
+		}
+	}
+	t.mutex.RUnlock()
+
+	base.Flush()
+}
+
+// Names returns the names of the tenants that an engine was created for.
+func (t *Tenants) Names() []string {
+	t.mutex.RLock()
+	names := make([]string, 0, len(t.tenants))
+	for name := range t.tenants {
+		names = append(names, name)
+	}
+	t.mutex.RUnlock()
+	return names
+}
+
+func (t *Tenants) lookup(name string) *tenant {
+	t.mutex.RLock()
+	x := t.tenants[name]
+	t.mutex.RUnlock()
+
+	if x != nil {
+		return x
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if x = t.tenants[name]; x != nil {
+		return x
+	}
+
+	base := t.base()
+
+	tagName := t.TagName
+	if len(tagName) == 0 {
+		tagName = "tenant"
+	}
+
+	quota, ok := t.Quotas[name]
+	if !ok {
+		quota = t.Quota
+	}
+
+	interval := t.QuotaInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+
+	series, ok := t.SeriesQuotas[name]
+	if !ok {
+		series = t.SeriesQuota
+	}
+
+	x = &tenant{
+		engine: base.WithTags(T(tagName, name)),
+		quota: &quotaHandler{
+			handler:  baseHandler{base},
+			clock:    base.clock(),
+			limit:    quota,
+			interval: interval,
+		},
+	}
+
+	x.engine.Handler = x.quota.handler
+
+	if quota > 0 {
+		x.engine.Handler = x.quota
+	}
+
+	if series > 0 {
+		x.series = &CardinalityLimit{Max: series}
+		x.engine.CardinalityLimit = x.series
+	}
+
+	if t.tenants == nil {
+		t.tenants = make(map[string]*tenant)
+	}
+
+	t.tenants[name] = x
+	return x
+}
+
+func (t *Tenants) base() *Engine {
+	if t.Base != nil {
+		return t.Base
+	}
+	return DefaultEngine
+}
+
+// baseHandler forwards measures to the current handler of an engine.
+type baseHandler struct {
+	base *Engine
+}
+
+func (h baseHandler) HandleMeasures(t time.Time, measures ...Measure) {
+	h.base.Handler.HandleMeasures(t, measures...)
+}
+
+func (h baseHandler) Flush() {
+	flush(h.base.Handler)
+}
+
+// quotaHandler forwards up to limit measures per interval to handler.
+type quotaHandler struct {
+	dropped  uint64 // accessed atomically, first to be 64 bits aligned
+	handler  Handler
+	clock    ClockSource
+	limit    int
+	interval time.Duration
+
+	mutex  sync.Mutex
+	window time.Time // start of the current window
+	count  int       // number of measures accepted in the current window
+}
+
+func (q *quotaHandler) HandleMeasures(t time.Time, measures ...Measure) {
+	n := q.accept(q.clock.Now(), len(measures))
+
+	if n != 0 {
+		q.handler.HandleMeasures(t, measures[:n]...)
+	}
+
+	if n != len(measures) {
+		atomic.AddUint64(&q.dropped, uint64(len(measures)-n))
+	}
+}
+
+func (q *quotaHandler) Flush() {
+	flush(q.handler)
+}
+
+// accept returns how many of n measures fit in the quota of the window that
+// now falls in, and counts them.
+func (q *quotaHandler) accept(now time.Time, n int) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if now.Sub(q.window) >= q.interval {
+		q.window, q.count = now, 0
+	}
+
+	if remain := q.limit - q.count; n > remain {
+		n = remain
+	}
+
+	q.count += n
+	return n
+}
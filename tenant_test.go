@@ -0,0 +1,162 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestTenants(t *testing.T) {
+	h := &statstest.Handler{}
+	tenants := &stats.Tenants{
+		Base:          stats.NewEngine("test", h),
+		Quota:         2,
+		Quotas:        map[string]int{"big": 10},
+		QuotaInterval: time.Hour,
+	}
+
+	for i := 0; i != 5; i++ {
+		tenants.Engine("small").Incr("calls")
+		tenants.Engine("big").Incr("calls")
+	}
+
+	counts := map[string]int{}
+
+	for _, m := range h.Measures() {
+		if len(m.Tags) != 1 || m.Tags[0].Name != "tenant" {
+			t.Fatal("bad tags:", m.Tags)
+		}
+		counts[m.Tags[0].Value]++
+	}
+
+	if counts["small"] != 2 || counts["big"] != 5 {
+		t.Error("bad measure counts:", counts)
+	}
+
+	if n := tenants.Dropped("small"); n != 3 {
+		t.Error("bad number of dropped measures:", n)
+	}
+
+	if n := tenants.Dropped("big"); n != 0 {
+		t.Error("bad number of dropped measures:", n)
+	}
+}
+
+func TestTenantsQuotaBatch(t *testing.T) {
+	h := &statstest.Handler{}
+	tenants := &stats.Tenants{
+		Base:          stats.NewEngine("test", h),
+		Quota:         3,
+		QuotaInterval: time.Hour,
+	}
+
+	b := tenants.Engine("A").Batch()
+	b.Incr("a")
+	b.Incr("b")
+	b.Commit()
+
+	b.Incr("c")
+	b.Incr("d")
+	b.Commit()
+
+	b.Incr("e")
+	b.Commit()
+
+	if n := len(h.Measures()); n != 3 {
+		t.Error("bad number of measures:", n)
+	}
+
+	if n := tenants.Dropped("A"); n != 2 {
+		t.Error("bad number of dropped measures:", n)
+	}
+}
+
+func TestTenantsRegister(t *testing.T) {
+	h1 := &statstest.Handler{}
+	h2 := &statstest.Handler{}
+	base := stats.NewEngine("test", h1)
+	tenants := &stats.Tenants{Base: base}
+
+	eng := tenants.Engine("A")
+	base.Register(h2)
+	eng.Incr("calls")
+
+	if len(h1.Measures()) != 1 || len(h2.Measures()) != 1 {
+		t.Error("measures not passed to the handlers registered on the base engine")
+	}
+}
+
+func TestTenantsSeriesQuota(t *testing.T) {
+	h := &statstest.Handler{}
+	tenants := &stats.Tenants{
+		Base:         stats.NewEngine("test", h),
+		SeriesQuota:  2,
+		SeriesQuotas: map[string]int{"big": 10},
+	}
+
+	for _, id := range []string{"1", "2", "3", "4"} {
+		tenants.Engine("small").Incr("calls", stats.T("id", id))
+		tenants.Engine("big").Incr("calls", stats.T("id", id))
+	}
+
+	series := map[string]bool{}
+
+	for _, m := range h.Measures() {
+		series[stats.Measure{Name: m.Name, Tags: m.Tags}.String()] = true
+	}
+
+	if n := len(series); n != 7 {
+		t.Error("bad number of series:", n, series)
+	}
+
+	if n := tenants.SeriesDropped("small"); n != 2 {
+		t.Error("bad number of collapsed measures:", n)
+	}
+
+	if n := tenants.SeriesDropped("big"); n != 0 {
+		t.Error("bad number of collapsed measures:", n)
+	}
+
+	h.Clear()
+	tenants.Flush()
+
+	for _, m := range h.Measures() {
+		if m.Name == "test.calls" && len(m.Fields) == 1 && m.Fields[0].Name == "cardinality.dropped" {
+			return
+		}
+	}
+
+	t.Error("collapsed measures were not reported:", h.Measures())
+}
+
+func TestTenantsQuotaClockSource(t *testing.T) {
+	clock := newManualClock(time.Now())
+	h := &statstest.Handler{}
+	base := stats.NewEngine("test", h)
+	base.ClockSource = clock
+
+	tenants := &stats.Tenants{
+		Base:          base,
+		Quota:         1,
+		QuotaInterval: time.Minute,
+	}
+
+	eng := tenants.Engine("A")
+	eng.Incr("calls")
+	eng.Incr("calls")
+
+	clock.advance(time.Minute)
+	eng.Incr("calls")
+
+	if n := len(h.Measures()); n != 2 {
+		t.Error("bad number of measures:", n)
+	}
+
+	if n := tenants.Dropped("A"); n != 1 {
+		t.Error("bad number of dropped measures:", n)
+	}
+}
@@ -0,0 +1,53 @@
+package stats
+
+import "time"
+
+// Timer is a factory of clocks measuring the duration of the operations
+// identified by a name and a set of tags.
+//
+// Unlike clocks, timers are safe to use concurrently from multiple goroutines,
+// they are usually created once and started every time the operation that
+// they measure is executed:
+//
+//	var uploadTimer = stats.DefaultEngine.Timer("upload")
+//
+//	func upload(f *File) {
+//		c := uploadTimer.Start()
+//		defer c.Stop()
+//
+//		compress(f)
+//		c.Lap("compress")
+//		...
+//	}
+type Timer struct {
+	name string
+	tags []Tag
+	eng  *Engine
+}
+
+// Timer returns a new timer producing clocks identified by name and tags.
+func (eng *Engine) Timer(name string, tags ...Tag) *Timer {
+	return &Timer{
+		name: name,
+		tags: copyTags(tags),
+		eng:  eng,
+	}
+}
+
+// Start returns a new clock started now.
+func (t *Timer) Start() *Clock {
+	return t.StartAt(t.eng.Now())
+}
+
+// StartAt returns a new clock started at the given time.
+func (t *Timer) StartAt(start time.Time) *Clock {
+	return t.eng.ClockAt(t.name, start, t.tags...)
+}
+
+// Time calls fn and reports the duration it took with a "stamp" tag set to
+// "total".
+func (t *Timer) Time(fn func()) {
+	c := t.Start()
+	defer c.Stop()
+	fn()
+}
@@ -0,0 +1,54 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestTimer(t *testing.T) {
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", h)
+	timer := eng.Timer("upload", stats.T("f", "img.jpg"))
+
+	start := time.Now()
+	c := timer.StartAt(start)
+	c.LapAt("compress", start.Add(1*time.Second))
+	c.LapAt("grayscale", start.Add(3*time.Second))
+	c.StopAt(start.Add(4 * time.Second))
+
+	timer.Time(func() {})
+
+	found := h.Measures()
+
+	if len(found) != 4 {
+		t.Fatalf("expected 4 measures got %d", len(found))
+	}
+
+	expected := []struct {
+		stamp    string
+		duration time.Duration
+	}{
+		{"compress", 1 * time.Second},
+		{"grayscale", 2 * time.Second},
+		{"total", 4 * time.Second},
+		{"total", -1},
+	}
+
+	for i, m := range found {
+		tags := []stats.Tag{stats.T("f", "img.jpg"), stats.T("stamp", expected[i].stamp)}
+
+		if m.Name != "test.upload" || !reflect.DeepEqual(m.Tags, tags) {
+			t.Errorf("bad measure at index %d: %v", i, m)
+		}
+
+		if d := expected[i].duration; d >= 0 && m.Fields[0].Value.Duration() != d {
+			t.Errorf("bad duration at index %d: %v != %v", i, m.Fields[0].Value.Duration(), d)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package stats
+
+import "fmt"
+
+// TimeSource represents the source of the timestamps exported by handlers
+// which serialize them, like the InfluxDB client.
+type TimeSource int
+
+const (
+	// CaptureTime exports the time at which measures were produced, which is
+	// the time passed to the HandleMeasures method of handlers. The exported
+	// data carries no skew, even when the measures are buffered for a while.
+	CaptureTime TimeSource = iota
+
+	// ReceiveTime omits the timestamps so the backend stamps measures when it
+	// receives them, which happens when the buffered measures are flushed.
+	// This is useful when the clocks of the program and the backend cannot be
+	// trusted to be synchronized.
+	ReceiveTime
+)
+
+// String satisfies the fmt.Stringer interface.
+func (s TimeSource) String() string {
+	switch s {
+	case CaptureTime:
+		return "capture"
+	case ReceiveTime:
+		return "receive"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseTimeSource parses the string representation of a time source, as
+// returned by its String method.
+func ParseTimeSource(s string) (TimeSource, error) {
+	switch s {
+	case "capture":
+		return CaptureTime, nil
+	case "receive":
+		return ReceiveTime, nil
+	default:
+		return 0, fmt.Errorf("stats: invalid time source: %q", s)
+	}
+}
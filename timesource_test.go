@@ -0,0 +1,15 @@
+package stats
+
+import "testing"
+
+func TestParseTimeSource(t *testing.T) {
+	for _, s := range []TimeSource{CaptureTime, ReceiveTime} {
+		if p, err := ParseTimeSource(s.String()); err != nil || p != s {
+			t.Errorf("%s: bad time source: %v (%v)", s, p, err)
+		}
+	}
+
+	if _, err := ParseTimeSource("flush"); err == nil {
+		t.Error("no error returned for an invalid time source")
+	}
+}
@@ -0,0 +1,134 @@
+package stats
+
+import "time"
+
+// ValueTransform is the signature of functions converting the values of
+// metrics, see Transformer.
+type ValueTransform func(Value) Value
+
+// Transformer is a measure handler which applies conventions that depend on the
+// type of the metrics before forwarding them to its base handler. It is useful
+// when different backends expect different conventions, for example to report
+// durations in milliseconds to a Graphite backend, and in seconds to another:
+//
+//	stats.Register(&stats.Transformer{
+//		Handler: graphite,
+//		Values:  map[stats.FieldType]stats.ValueTransform{stats.Histogram: stats.DurationsIn(time.Millisecond)},
+//	})
+//
+// When default tags are configured for a type, the fields of this type are
+// forwarded in separate measures carrying the tags, unless the measures already
+// have tags with the same names.
+type Transformer struct {
+	// The handler that measures are forwarded to.
+	Handler Handler
+
+	// Functions converting the values of the fields of each type.
+	Values map[FieldType]ValueTransform
+
+	// Tags added to the fields of each type.
+	Tags map[FieldType][]Tag
+}
+
+// HandleMeasures satisfies the Handler interface.
+func (h *Transformer) HandleMeasures(t time.Time, measures ...Measure) {
+	passthrough := measurePool.Get().(*measuresBuffer)
+	ms := passthrough.measures[:0]
+
+	for _, m := range measures {
+		if !h.transforms(m) {
+			ms = append(ms, m)
+			continue
+		}
+		ms = h.transform(ms, m)
+	}
+
+	if len(ms) != 0 {
+		h.Handler.HandleMeasures(t, ms...)
+	}
+
+	for i := range ms {
+		ms[i] = Measure{}
+	}
+
+	passthrough.measures = ms[:0]
+	measurePool.Put(passthrough)
+}
+
+// Flush satisfies the Flusher interface.
+func (h *Transformer) Flush() {
+	flush(h.Handler)
+}
+
+// transforms returns true if any field of m must be transformed.
+func (h *Transformer) transforms(m Measure) bool {
+	for _, f := range m.Fields {
+		if h.Values[f.Type()] != nil || len(h.Tags[f.Type()]) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Transformer) transform(ms []Measure, m Measure) []Measure {
+	var fields []Field
+	var tagged [Histogram + 1][]Field
+
+	for _, f := range m.Fields {
+		ftype := f.Type()
+
+		if fn := h.Values[ftype]; fn != nil {
+			f = Field{Name: f.Name, Value: fn(f.Value)}
+			f.setType(ftype)
+		}
+
+		if ftype >= Counter && ftype <= Histogram && len(h.Tags[ftype]) != 0 {
+			tagged[ftype] = append(tagged[ftype], f)
+		} else {
+			fields = append(fields, f)
+		}
+	}
+
+	if len(fields) != 0 {
+		ms = append(ms, Measure{Name: m.Name, Fields: fields, Tags: m.Tags, Const: m.Const, SampleRate: m.SampleRate})
+	}
+
+	for ftype, fields := range tagged {
+		if len(fields) != 0 {
+			ms = append(ms, Measure{
+				Name:       m.Name,
+				Fields:     fields,
+				Tags:       mergeDefaultTags(m.Tags, h.Tags[FieldType(ftype)]),
+				SampleRate: m.SampleRate,
+			})
+		}
+	}
+
+	return ms
+}
+
+// mergeDefaultTags returns the list of tags with the defaults which have names
+// that are not in tags, sorted.
+func mergeDefaultTags(tags []Tag, defaults []Tag) []Tag {
+	merged := copyTags(tags)
+
+	for _, d := range defaults {
+		if tagIndex(tags, d.Name) < 0 {
+			merged = append(merged, d)
+		}
+	}
+
+	return SortTags(merged)
+}
+
+// DurationsIn returns a value transform which converts durations to floating
+// point numbers of unit, for example DurationsIn(time.Millisecond) converts
+// durations to milliseconds. Values which are not durations are unchanged.
+func DurationsIn(unit time.Duration) ValueTransform {
+	return func(v Value) Value {
+		if v.Type() != Duration {
+			return v
+		}
+		return float64Value(float64(v.Duration()) / float64(unit))
+	}
+}
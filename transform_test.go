@@ -0,0 +1,53 @@
+//go:build !statsoff
+
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestTransformer(t *testing.T) {
+	h := &statstest.Handler{}
+	eng := stats.NewEngine("test", &stats.Transformer{
+		Handler: h,
+		Values: map[stats.FieldType]stats.ValueTransform{
+			stats.Histogram: stats.DurationsIn(time.Millisecond),
+		},
+		Tags: map[stats.FieldType][]stats.Tag{
+			stats.Gauge: {stats.T("kind", "gauge"), stats.T("host", "default")},
+		},
+	})
+
+	eng.Observe("rtt", 1500*time.Microsecond)
+	eng.Observe("size", 10)
+	eng.Incr("count")
+	eng.Set("queue", 1, stats.T("host", "a"))
+
+	found := []string{}
+	for _, m := range h.Measures() {
+		s := m.Name
+		for _, f := range m.Fields {
+			s += " " + f.String()
+		}
+		for _, tag := range m.Tags {
+			s += " " + tag.String()
+		}
+		found = append(found, s)
+	}
+
+	expected := []string{
+		"test.rtt histogram:=1.5",
+		"test.size histogram:=10",
+		"test.count counter:=1",
+		"test.queue gauge:=1 host=a kind=gauge",
+	}
+
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("bad measures:\nexpected: %q\nfound:    %q", expected, found)
+	}
+}
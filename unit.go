@@ -0,0 +1,95 @@
+package stats
+
+import "sync"
+
+// Unit represents the unit of the values of a metric.
+type Unit string
+
+const (
+	// NoUnit is the unit of metrics whose unit is unknown or which have no
+	// units.
+	NoUnit Unit = ""
+
+	// Bytes is the unit of metrics measuring sizes of data.
+	Bytes Unit = "bytes"
+
+	// Seconds is the unit of metrics measuring durations, the durations
+	// produced by engines are expressed in seconds by most backends.
+	Seconds Unit = "seconds"
+
+	// Requests is the unit of metrics counting requests.
+	Requests Unit = "requests"
+
+	// Ratio is the unit of metrics measuring fractions between 0 and 1.
+	Ratio Unit = "ratio"
+)
+
+// UnitRegistry maps metrics to their units, so backends which support units
+// can expose them, either as metadata or by following the naming conventions
+// of their metric collection systems.
+//
+// UnitRegistry values are safe to use concurrently from multiple goroutines.
+type UnitRegistry struct {
+	mutex sync.RWMutex
+	units map[Key]Unit
+}
+
+// Set sets the unit of the metric identified by key, which is the name of a
+// measure and field separated by a colon (or the name of a measure only for
+// fields with no names), including the prefix of the engine that produces the
+// metric.
+func (r *UnitRegistry) Set(key string, unit Unit) {
+	r.set(makeKey(key), unit)
+}
+
+// Lookup returns the unit of the metric identified by the given measure and
+// field names, or NoUnit if none was set.
+func (r *UnitRegistry) Lookup(measure string, field string) Unit {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.units[Key{Measure: measure, Field: field}]
+}
+
+func (r *UnitRegistry) set(key Key, unit Unit) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if unit == NoUnit {
+		delete(r.units, key)
+		return
+	}
+
+	if r.units == nil {
+		r.units = make(map[Key]Unit)
+	}
+
+	r.units[key] = unit
+}
+
+// Units is the registry of the units of metrics used by default by backends.
+// Units are usually registered when creating handles with HandleUnit, or in
+// the init function of the packages producing the metrics.
+var Units = &UnitRegistry{}
+
+// HandleUnit returns a new metric handle like Handle, for a metric whose values
+// are expressed in unit. The unit is set in the engine's registry, or Units if
+// the engine has none.
+func (eng *Engine) HandleUnit(name string, ftype FieldType, unit Unit, tags ...Tag) *Handle {
+	h := eng.Handle(name, ftype, tags...)
+	h.unit = unit
+	eng.units().set(Key{Measure: h.name, Field: h.field}, unit)
+	return h
+}
+
+func (eng *Engine) units() *UnitRegistry {
+	if eng.Units != nil {
+		return eng.Units
+	}
+	return Units
+}
+
+// Unit returns the unit of the values of h, or NoUnit if h was not created by
+// HandleUnit.
+func (h *Handle) Unit() Unit {
+	return h.unit
+}
@@ -0,0 +1,62 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/segmentio/stats"
+	"github.com/segmentio/stats/statstest"
+)
+
+func TestHandleUnit(t *testing.T) {
+	eng := stats.NewEngine("test", &statstest.Handler{})
+
+	h := eng.HandleUnit("http:rtt", stats.Histogram, stats.Seconds)
+
+	if u := h.Unit(); u != stats.Seconds {
+		t.Error("bad handle unit:", u)
+	}
+
+	if u := stats.Units.Lookup("test.http", "rtt"); u != stats.Seconds {
+		t.Error("bad registered unit:", u)
+	}
+
+	if u := eng.Handle("http:count", stats.Counter).Unit(); u != stats.NoUnit {
+		t.Error("bad handle unit:", u)
+	}
+}
+
+func TestHandleUnitEngineRegistry(t *testing.T) {
+	r := &stats.UnitRegistry{}
+	eng := stats.NewEngine("test", &statstest.Handler{})
+	eng.Units = r
+
+	eng.WithTags(stats.T("a", "b")).HandleUnit("queue:size", stats.Gauge, stats.Bytes)
+
+	if u := r.Lookup("test.queue", "size"); u != stats.Bytes {
+		t.Error("bad registered unit:", u)
+	}
+
+	if u := stats.Units.Lookup("test.queue", "size"); u != stats.NoUnit {
+		t.Error("the unit was set in the default registry:", u)
+	}
+}
+
+func TestUnitRegistry(t *testing.T) {
+	r := &stats.UnitRegistry{}
+	r.Set("queue:size", stats.Bytes)
+	r.Set("requests", stats.Requests)
+
+	if u := r.Lookup("queue", "size"); u != stats.Bytes {
+		t.Error("bad unit:", u)
+	}
+
+	if u := r.Lookup("requests", ""); u != stats.Requests {
+		t.Error("bad unit:", u)
+	}
+
+	r.Set("queue:size", stats.NoUnit)
+
+	if u := r.Lookup("queue", "size"); u != stats.NoUnit {
+		t.Error("the unit was not removed:", u)
+	}
+}
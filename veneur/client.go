@@ -98,6 +98,7 @@ func (c *Client) HandleMeasures(time time.Time, measures ...stats.Measure) {
 	for i, _ := range measures {
 		finalMeasures[i] = measures[i].Clone()
 		finalMeasures[i].Tags = append(measures[i].Tags, c.tags...)
+		finalMeasures[i].Const = nil
 	}
 
 	c.Client.HandleMeasures(time, finalMeasures...)
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Encoder is the interface implemented by the encodings that writer handlers
+// serialize measures with.
+//
+// Encoders only have to produce the representation of measures, the handlers
+// returned by NewWriterHandler take care of buffering and writing them.
+type Encoder interface {
+	// AppendMeasures appends the encoded representation of measures taken at
+	// time t to b, and returns the extended buffer.
+	//
+	// The representation must be made of complete records, writer handlers
+	// may write the output of different calls with separate writes.
+	AppendMeasures(b []byte, t time.Time, measures ...Measure) []byte
+}
+
+// NewWriterHandler returns a handler which encodes the measures it receives
+// with enc and writes them to w. The measures are buffered and written when
+// the buffer is full or when the handler is flushed, writes to w are never
+// made concurrently.
+//
+// The buffering can be configured by setting the fields of the returned value
+// before using it. Errors returned by w are reported on the channel returned
+// by the Errors method of the handler.
+func NewWriterHandler(w io.Writer, enc Encoder) *Buffer {
+	return &Buffer{
+		BufferSize: 8192,
+		Serializer: &writerSerializer{w: w, enc: enc},
+	}
+}
+
+type writerSerializer struct {
+	mutex sync.Mutex
+	w     io.Writer
+	enc   Encoder
+}
+
+func (s *writerSerializer) AppendMeasures(b []byte, t time.Time, measures ...Measure) []byte {
+	return s.enc.AppendMeasures(b, t, measures...)
+}
+
+func (s *writerSerializer) Write(b []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.w.Write(b)
+}
+
+// LookupEncoder returns the encoder of the encoding with the given name, which
+// is useful to let users select the output format of writer handlers in the
+// configuration of programs. The supported names are:
+//
+//	json          see JSONEncoder
+//	json-pretty   JSONEncoder, indented with two spaces
+//	logfmt        see LogfmtEncoder
+//	csv           see CSVEncoder
+//	msgpack       see MsgpackEncoder
+func LookupEncoder(name string) (Encoder, error) {
+	switch name {
+	case "json":
+		return JSONEncoder{}, nil
+	case "json-pretty":
+		return JSONEncoder{Indent: "  "}, nil
+	case "logfmt":
+		return LogfmtEncoder{}, nil
+	case "csv":
+		return CSVEncoder{}, nil
+	case "msgpack":
+		return MsgpackEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("stats: unknown encoding %q", name)
+	}
+}
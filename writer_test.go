@@ -0,0 +1,150 @@
+package stats_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/segmentio/stats"
+)
+
+func TestWriterHandler(t *testing.T) {
+	now := time.Date(2017, 6, 4, 22, 12, 0, 0, time.UTC)
+	measure := stats.Measure{
+		Name: "http",
+		Fields: []stats.Field{
+			stats.MakeField("count", 1, stats.Counter),
+			stats.MakeField("rtt", 100*time.Millisecond, stats.Histogram),
+		},
+		Tags: []stats.Tag{stats.T("host", "a b"), stats.T("path", `/"x",y`)},
+	}
+
+	tests := []struct {
+		encoder stats.Encoder
+		output  string
+	}{
+		{
+			encoder: stats.JSONEncoder{},
+			output:  `{"time":"2017-06-04T22:12:00Z","name":"http","fields":[{"name":"count","type":"counter","value":1},{"name":"rtt","type":"histogram","value":0.1}],"tags":{"host":"a b","path":"/\"x\",y"}}` + "\n",
+		},
+		{
+			encoder: stats.JSONEncoder{Indent: "  "},
+			output: `{
+  "time": "2017-06-04T22:12:00Z",
+  "name": "http",
+  "fields": [
+    {
+      "name": "count",
+      "type": "counter",
+      "value": 1
+    },
+    {
+      "name": "rtt",
+      "type": "histogram",
+      "value": 0.1
+    }
+  ],
+  "tags": {
+    "host": "a b",
+    "path": "/\"x\",y"
+  }
+}
+`,
+		},
+		{
+			encoder: stats.LogfmtEncoder{},
+			output: `time=2017-06-04T22:12:00Z name=http field=count type=counter value=1 host="a b" path="/\"x\",y"` + "\n" +
+				`time=2017-06-04T22:12:00Z name=http field=rtt type=histogram value=0.1 host="a b" path="/\"x\",y"` + "\n",
+		},
+		{
+			encoder: stats.CSVEncoder{},
+			output: `2017-06-04T22:12:00Z,http,count,counter,1,"host=a b;path=/""x"",y"` + "\r\n" +
+				`2017-06-04T22:12:00Z,http,rtt,histogram,0.1,"host=a b;path=/""x"",y"` + "\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			b := &bytes.Buffer{}
+			h := stats.NewWriterHandler(b, test.encoder)
+			h.HandleMeasures(now, measure)
+
+			if b.Len() != 0 {
+				t.Error("measures were written before the handler was flushed")
+			}
+
+			h.Flush()
+
+			if s := b.String(); s != test.output {
+				t.Errorf("bad output:\n- expected: %s\n- found:    %s", test.output, s)
+			}
+		})
+	}
+}
+
+func TestLookupEncoder(t *testing.T) {
+	for _, name := range []string{"json", "json-pretty", "logfmt", "csv", "msgpack"} {
+		if enc, err := stats.LookupEncoder(name); err != nil || enc == nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if _, err := stats.LookupEncoder("xml"); err == nil {
+		t.Error("no error returned for an unknown encoding")
+	}
+}
+
+func TestJSONEncoderValid(t *testing.T) {
+	b := stats.JSONEncoder{}.AppendMeasures(nil, time.Now(), stats.Measure{
+		Name:   "\x00\xff\"",
+		Fields: []stats.Field{stats.MakeField("", true, stats.Gauge)},
+	})
+
+	var v map[string]interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Error(err, string(b))
+	}
+}
+
+func TestCSVEncoderValid(t *testing.T) {
+	b := stats.CSVEncoder{}.AppendMeasures(nil, time.Now(), stats.Measure{
+		Name:   "a,\"b\"\nc",
+		Fields: []stats.Field{stats.MakeField("value", 1.5, stats.Gauge)},
+	})
+
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 || len(records[0]) != 6 || records[0][1] != "a,\"b\"\nc" {
+		t.Errorf("bad records: %q", records)
+	}
+}
+
+func TestMsgpackEncoder(t *testing.T) {
+	now := time.Unix(1, 2)
+	b := stats.MsgpackEncoder{}.AppendMeasures(nil, now, stats.Measure{
+		Name:   "A",
+		Fields: []stats.Field{stats.MakeField("", 1, stats.Counter)},
+		Tags:   []stats.Tag{stats.T("a", "1")},
+	})
+
+	expect := []byte{
+		0x84,
+		0xa4, 't', 'i', 'm', 'e', 0xc7, 12, 0xff, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 1,
+		0xa4, 'n', 'a', 'm', 'e', 0xa1, 'A',
+		0xa6, 'f', 'i', 'e', 'l', 'd', 's', 0x91,
+		0x83,
+		0xa4, 'n', 'a', 'm', 'e', 0xa0,
+		0xa4, 't', 'y', 'p', 'e', 0xa7, 'c', 'o', 'u', 'n', 't', 'e', 'r',
+		0xa5, 'v', 'a', 'l', 'u', 'e', 0xd3, 0, 0, 0, 0, 0, 0, 0, 1,
+		0xa4, 't', 'a', 'g', 's', 0x81, 0xa1, 'a', 0xa1, '1',
+	}
+
+	if !bytes.Equal(b, expect) {
+		t.Errorf("bad output:\n- expected: %x\n- found:    %x", expect, b)
+	}
+}